@@ -31,9 +31,10 @@ import (
 )
 
 var (
-	fileStats, mode, pageSelection string
-	upw, opw, key, perm            string
-	verbose                        bool
+	fileStats, mode, pageSelection   string
+	upw, opw, key, perm, from        string
+	verbose, incremental, lazy, json bool
+	bookmarks                        bool
 
 	needStackTrace = true
 )
@@ -44,7 +45,7 @@ func init() {
 	flag.StringVar(&fileStats, "stats", "", statsUsage)
 	flag.StringVar(&fileStats, "s", "", statsUsage)
 
-	modeUsage := "validate: strict|relaxed; extract: image|font|content|page; encrypt: rc4|aes"
+	modeUsage := "validate: strict|relaxed; extract: image|font|content|page; encrypt: rc4|aes; pages insert: before|after; split: span|bookmark|page|size; merge: append|zip|zipreverse"
 	flag.StringVar(&mode, "mode", "", modeUsage)
 	flag.StringVar(&mode, "m", "", modeUsage)
 
@@ -62,6 +63,26 @@ func init() {
 	flag.BoolVar(&verbose, "verbose", false, "")
 	flag.BoolVar(&verbose, "v", false, "")
 
+	incrementalUsage := "annotations, attach, booklet, bookmarks, boxes, collect, flatten, form, nup, pagelabels, pages, properties, resize, rotate, stamp, watermark: append changes as incremental update"
+	flag.BoolVar(&incremental, "incr", false, incrementalUsage)
+	flag.BoolVar(&incremental, "i", false, incrementalUsage)
+
+	lazyUsage := "load objects on demand for processing large files with little memory"
+	flag.BoolVar(&lazy, "lazy", false, lazyUsage)
+	flag.BoolVar(&lazy, "l", false, lazyUsage)
+
+	jsonUsage := "info, bookmarks list, annotations list, form list: output as JSON"
+	flag.BoolVar(&json, "json", false, jsonUsage)
+	flag.BoolVar(&json, "j", false, jsonUsage)
+
+	fromUsage := "pages insert: a PDF file providing the pages to insert"
+	flag.StringVar(&from, "from", "", fromUsage)
+	flag.StringVar(&from, "f", "", fromUsage)
+
+	bookmarksUsage := "merge: add a bookmark for each merged file"
+	flag.BoolVar(&bookmarks, "bookmarks", false, bookmarksUsage)
+	flag.BoolVar(&bookmarks, "b", false, bookmarksUsage)
+
 	flag.StringVar(&upw, "upw", "", "user password")
 	flag.StringVar(&opw, "opw", "", "owner password")
 
@@ -76,6 +97,8 @@ func main() {
 	config := pdfcpu.NewDefaultConfiguration()
 	config.UserPW = upw
 	config.OwnerPW = opw
+	config.Incremental = incremental
+	config.LazyLoading = lazy
 
 	var cmd *api.Command
 
@@ -87,28 +110,44 @@ func main() {
 	}
 
 	for k, v := range map[string]func(config *pdfcpu.Configuration) *api.Command{
-		"validate":  prepareValidateCommand,
-		"optimize":  prepareOptimizeCommand,
-		"o":         prepareOptimizeCommand,
-		"split":     prepareSplitCommand,
-		"s":         prepareSplitCommand,
-		"merge":     prepareMergeCommand,
-		"m":         prepareMergeCommand,
-		"extract":   prepareExtractCommand,
-		"ext":       prepareExtractCommand,
-		"trim":      prepareTrimCommand,
-		"t":         prepareTrimCommand,
-		"attach":    prepareAttachmentCommand,
-		"decrypt":   prepareDecryptCommand,
-		"d":         prepareDecryptCommand,
-		"dec":       prepareDecryptCommand,
-		"encrypt":   prepareEncryptCommand,
-		"enc":       prepareEncryptCommand,
-		"changeupw": prepareChangeUserPasswordCommand,
-		"changeopw": prepareChangeOwnerPasswordCommand,
-		"perm":      preparePermissionsCommand,
-		"stamp":     prepareAddStampsCommand,
-		"watermark": prepareAddWatermarksCommand,
+		"validate":    prepareValidateCommand,
+		"optimize":    prepareOptimizeCommand,
+		"o":           prepareOptimizeCommand,
+		"split":       prepareSplitCommand,
+		"s":           prepareSplitCommand,
+		"merge":       prepareMergeCommand,
+		"m":           prepareMergeCommand,
+		"extract":     prepareExtractCommand,
+		"ext":         prepareExtractCommand,
+		"trim":        prepareTrimCommand,
+		"t":           prepareTrimCommand,
+		"attach":      prepareAttachmentCommand,
+		"decrypt":     prepareDecryptCommand,
+		"d":           prepareDecryptCommand,
+		"dec":         prepareDecryptCommand,
+		"encrypt":     prepareEncryptCommand,
+		"enc":         prepareEncryptCommand,
+		"changeupw":   prepareChangeUserPasswordCommand,
+		"changeopw":   prepareChangeOwnerPasswordCommand,
+		"perm":        preparePermissionsCommand,
+		"stamp":       prepareAddStampsCommand,
+		"watermark":   prepareAddWatermarksCommand,
+		"info":        prepareInfoCommand,
+		"rotate":      prepareRotateCommand,
+		"r":           prepareRotateCommand,
+		"pages":       preparePagesCommand,
+		"nup":         prepareNUpCommand,
+		"booklet":     prepareBookletCommand,
+		"collect":     prepareCollectCommand,
+		"import":      prepareImportImagesCommand,
+		"boxes":       prepareBoxesCommand,
+		"resize":      prepareResizeCommand,
+		"pagelabels":  preparePageLabelsCommand,
+		"bookmarks":   prepareBookmarksCommand,
+		"properties":  preparePropertiesCommand,
+		"annotations": prepareAnnotationsCommand,
+		"flatten":     prepareFlattenCommand,
+		"form":        prepareFormCommand,
 	} {
 		if command == k {
 			cmd = v(config)
@@ -149,21 +188,36 @@ func helpString(topic string) string {
 		usageShort, usageLong string
 		usagePageSelection    bool
 	}{
-		"validate":  {usageValidate, usageLongValidate, false},
-		"optimize":  {usageOptimize, usageLongOptimize, false},
-		"split":     {usageSplit, usageLongSplit, false},
-		"merge":     {usageMerge, usageLongMerge, false},
-		"extract":   {usageExtract, usageLongExtract, false},
-		"trim":      {usageTrim, usageLongTrim, true},
-		"attach":    {usageAttach, usageLongAttach, false},
-		"perm":      {usagePerm, usageLongPerm, false},
-		"encrypt":   {usageEncrypt, usageLongEncrypt, false},
-		"decrypt":   {usageDecrypt, usageLongDecrypt, false},
-		"changeupw": {usageChangeUserPW, usageLongChangeUserPW, false},
-		"changeopw": {usageChangeOwnerPW, usageLongChangeOwnerPW, false},
-		"stamp":     {usageStamp, usageLongStamp, true},
-		"watermark": {usageWatermark, usageLongWatermark, true},
-		"version":   {usageVersion, usageLongVersion, false},
+		"validate":    {usageValidate, usageLongValidate, false},
+		"optimize":    {usageOptimize, usageLongOptimize, false},
+		"split":       {usageSplit, usageLongSplit, false},
+		"merge":       {usageMerge, usageLongMerge, true},
+		"extract":     {usageExtract, usageLongExtract, false},
+		"trim":        {usageTrim, usageLongTrim, true},
+		"attach":      {usageAttach, usageLongAttach, false},
+		"perm":        {usagePerm, usageLongPerm, false},
+		"encrypt":     {usageEncrypt, usageLongEncrypt, false},
+		"decrypt":     {usageDecrypt, usageLongDecrypt, false},
+		"changeupw":   {usageChangeUserPW, usageLongChangeUserPW, false},
+		"changeopw":   {usageChangeOwnerPW, usageLongChangeOwnerPW, false},
+		"stamp":       {usageStamp, usageLongStamp, true},
+		"watermark":   {usageWatermark, usageLongWatermark, true},
+		"info":        {usageInfo, usageLongInfo, false},
+		"rotate":      {usageRotate, usageLongRotate, true},
+		"pages":       {usagePages, usageLongPages, true},
+		"nup":         {usageNUp, usageLongNUp, true},
+		"booklet":     {usageBooklet, usageLongBooklet, true},
+		"collect":     {usageCollect, usageLongCollect, false},
+		"import":      {usageImport, usageLongImport, false},
+		"boxes":       {usageBoxes, usageLongBoxes, true},
+		"resize":      {usageResize, usageLongResize, true},
+		"pagelabels":  {usagePageLabels, usageLongPageLabels, true},
+		"bookmarks":   {usageBookmarks, usageLongBookmarks, false},
+		"properties":  {usageProperties, usageLongProperties, false},
+		"annotations": {usageAnnotations, usageLongAnnotations, true},
+		"flatten":     {usageFlatten, usageLongFlatten, true},
+		"form":        {usageForm, usageLongForm, false},
+		"version":     {usageVersion, usageLongVersion, false},
 	} {
 		if topic == k {
 			if v.usagePageSelection {
@@ -237,6 +291,69 @@ func parseFlagsAndGetCommand() (command string) {
 		i = 3
 	}
 
+	// The pages command uses a subcommand and is therefore a special case => start flag processing after 3rd argument.
+	if command == "pages" {
+		if len(os.Args) == 2 {
+			fmt.Fprintln(os.Stderr, usagePages)
+			os.Exit(1)
+		}
+		i = 3
+	}
+
+	// The boxes command uses a subcommand and is therefore a special case => start flag processing after 3rd argument.
+	if command == "boxes" {
+		if len(os.Args) == 2 {
+			fmt.Fprintln(os.Stderr, usageBoxes)
+			os.Exit(1)
+		}
+		i = 3
+	}
+
+	// The pagelabels command uses a subcommand and is therefore a special case => start flag processing after 3rd argument.
+	if command == "pagelabels" {
+		if len(os.Args) == 2 {
+			fmt.Fprintln(os.Stderr, usagePageLabels)
+			os.Exit(1)
+		}
+		i = 3
+	}
+
+	// The bookmarks command uses a subcommand and is therefore a special case => start flag processing after 3rd argument.
+	if command == "bookmarks" {
+		if len(os.Args) == 2 {
+			fmt.Fprintln(os.Stderr, usageBookmarks)
+			os.Exit(1)
+		}
+		i = 3
+	}
+
+	// The properties command uses a subcommand and is therefore a special case => start flag processing after 3rd argument.
+	if command == "properties" {
+		if len(os.Args) == 2 {
+			fmt.Fprintln(os.Stderr, usageProperties)
+			os.Exit(1)
+		}
+		i = 3
+	}
+
+	// The annotations command uses a subcommand and is therefore a special case => start flag processing after 3rd argument.
+	if command == "annotations" {
+		if len(os.Args) == 2 {
+			fmt.Fprintln(os.Stderr, usageAnnotations)
+			os.Exit(1)
+		}
+		i = 3
+	}
+
+	// The form command uses a subcommand and is therefore a special case => start flag processing after 3rd argument.
+	if command == "form" {
+		if len(os.Args) == 2 {
+			fmt.Fprintln(os.Stderr, usageForm)
+			os.Exit(1)
+		}
+		i = 3
+	}
+
 	// Parse commandline flags.
 	err := flag.CommandLine.Parse(os.Args[i:])
 	if err != nil {
@@ -21,6 +21,8 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strconv"
+	"strings"
 
 	"github.com/hhrutter/pdfcpu/pkg/api"
 	"github.com/hhrutter/pdfcpu/pkg/pdfcpu"
@@ -75,9 +77,30 @@ func prepareOptimizeCommand(config *pdfcpu.Configuration) *api.Command {
 	return api.OptimizeCommand(filenameIn, filenameOut, config)
 }
 
+// parseByteSize parses a byte count with an optional unit suffix k, m or g.
+func parseByteSize(s string) (int64, error) {
+
+	s = strings.ToLower(s)
+	unit := int64(1)
+
+	for suffix, u := range map[string]int64{"k": 1 << 10, "m": 1 << 20, "g": 1 << 30} {
+		if strings.HasSuffix(s, suffix) {
+			s, unit = strings.TrimSuffix(s, suffix), u
+			break
+		}
+	}
+
+	i, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || i <= 0 {
+		return 0, fmt.Errorf("invalid size: %s", s)
+	}
+
+	return i * unit, nil
+}
+
 func prepareSplitCommand(config *pdfcpu.Configuration) *api.Command {
 
-	if len(flag.Args()) != 2 || pageSelection != "" {
+	if len(flag.Args()) < 2 || pageSelection != "" {
 		fmt.Fprintf(os.Stderr, "%s\n\n", usageSplit)
 		os.Exit(1)
 	}
@@ -87,7 +110,80 @@ func prepareSplitCommand(config *pdfcpu.Configuration) *api.Command {
 
 	dirnameOut := flag.Arg(1)
 
-	return api.SplitCommand(filenameIn, dirnameOut, config)
+	args := flag.Args()[2:]
+
+	switch mode {
+
+	case "", "span":
+		if len(args) > 1 {
+			fmt.Fprintf(os.Stderr, "%s\n\n", usageSplit)
+			os.Exit(1)
+		}
+		span := 1
+		if len(args) == 1 {
+			var err error
+			span, err = strconv.Atoi(args[0])
+			if err != nil || span < 1 {
+				log.Fatalf("split: span must be a positive integer: %s", args[0])
+			}
+		}
+		return api.SplitCommand(filenameIn, dirnameOut, span, config)
+
+	case "bookmark":
+		if len(args) > 0 {
+			fmt.Fprintf(os.Stderr, "%s\n\n", usageSplit)
+			os.Exit(1)
+		}
+		return api.SplitByBookmarksCommand(filenameIn, dirnameOut, config)
+
+	case "page":
+		if len(args) == 0 {
+			fmt.Fprintf(os.Stderr, "%s\n\n", usageSplit)
+			os.Exit(1)
+		}
+		var pageNrs []int
+		for _, arg := range args {
+			i, err := strconv.Atoi(arg)
+			if err != nil {
+				log.Fatalf("split: invalid page number: %s", arg)
+			}
+			pageNrs = append(pageNrs, i)
+		}
+		return api.SplitByPageNrsCommand(filenameIn, dirnameOut, pageNrs, config)
+
+	case "size":
+		if len(args) != 1 {
+			fmt.Fprintf(os.Stderr, "%s\n\n", usageSplit)
+			os.Exit(1)
+		}
+		maxSize, err := parseByteSize(args[0])
+		if err != nil {
+			log.Fatalf("split: %v", err)
+		}
+		return api.SplitBySizeCommand(filenameIn, dirnameOut, maxSize, config)
+
+	}
+
+	fmt.Fprintf(os.Stderr, "%s\n\n", usageSplit)
+	os.Exit(1)
+
+	return nil
+}
+
+// splitMergeArg splits a merge argument of the form inFile[:pageSelection].
+func splitMergeArg(arg string) (string, []string) {
+
+	i := strings.LastIndex(arg, ":")
+	if i < 0 || strings.HasSuffix(strings.ToLower(arg), ".pdf") {
+		return arg, nil
+	}
+
+	pageSelection, err := api.ParsePageSelection(arg[i+1:])
+	if err != nil {
+		log.Fatalf("merge: problem with page selection of %s: %v", arg[:i], err)
+	}
+
+	return arg[:i], pageSelection
 }
 
 func prepareMergeCommand(config *pdfcpu.Configuration) *api.Command {
@@ -99,17 +195,38 @@ func prepareMergeCommand(config *pdfcpu.Configuration) *api.Command {
 
 	var filenameOut string
 	filenamesIn := []string{}
+	pageSelections := [][]string{}
 	for i, arg := range flag.Args() {
 		if i == 0 {
 			filenameOut = arg
 			ensurePdfExtension(filenameOut)
 			continue
 		}
-		ensurePdfExtension(arg)
-		filenamesIn = append(filenamesIn, arg)
+		filenameIn, pageSelection := splitMergeArg(arg)
+		ensurePdfExtension(filenameIn)
+		filenamesIn = append(filenamesIn, filenameIn)
+		pageSelections = append(pageSelections, pageSelection)
+	}
+
+	switch mode {
+
+	case "", "append":
+		return api.MergeCommand(filenamesIn, pageSelections, filenameOut, bookmarks, config)
+
+	case "zip", "zipreverse":
+		if len(filenamesIn) != 2 || bookmarks {
+			fmt.Fprintf(os.Stderr, "%s\n\n", usageMerge)
+			os.Exit(1)
+		}
+		cmd := api.MergeZipCommand(filenamesIn[0], filenamesIn[1], filenameOut, mode == "zipreverse", config)
+		cmd.FilePages = pageSelections
+		return cmd
+
 	}
 
-	return api.MergeCommand(filenamesIn, filenameOut, config)
+	log.Fatalf("merge: unknown mode: %s", mode)
+
+	return nil
 }
 
 func allowedExtracMode(s string) bool {
@@ -357,6 +474,48 @@ func preparePermissionsCommand(config *pdfcpu.Configuration) *api.Command {
 
 }
 
+func prepareInfoCommand(config *pdfcpu.Configuration) *api.Command {
+
+	if len(flag.Args()) != 1 || pageSelection != "" {
+		fmt.Fprintf(os.Stderr, "%s\n\n", usageInfo)
+		os.Exit(1)
+	}
+
+	filenameIn := flag.Arg(0)
+	ensurePdfExtension(filenameIn)
+
+	return api.InfoCommand(filenameIn, json, config)
+}
+
+func prepareRotateCommand(config *pdfcpu.Configuration) *api.Command {
+
+	if len(flag.Args()) < 2 || len(flag.Args()) > 3 {
+		fmt.Fprintf(os.Stderr, "%s\n\n", usageRotate)
+		os.Exit(1)
+	}
+
+	pages, err := api.ParsePageSelection(pageSelection)
+	if err != nil {
+		log.Fatalf("rotate: problem with flag pageSelection: %v", err)
+	}
+
+	filenameIn := flag.Arg(0)
+	ensurePdfExtension(filenameIn)
+
+	rotation, err := pdfcpu.ParseRotation(flag.Arg(1))
+	if err != nil {
+		log.Fatalf("rotate: %v", err)
+	}
+
+	filenameOut := defaultFilenameOut(filenameIn)
+	if len(flag.Args()) == 3 {
+		filenameOut = flag.Arg(2)
+		ensurePdfExtension(filenameOut)
+	}
+
+	return api.RotateCommand(filenameIn, filenameOut, rotation, pages, config)
+}
+
 func prepareDecryptCommand(config *pdfcpu.Configuration) *api.Command {
 
 	if len(flag.Args()) == 0 || len(flag.Args()) > 2 || pageSelection != "" {
@@ -503,3 +662,879 @@ func prepareAddStampsCommand(config *pdfcpu.Configuration) *api.Command {
 func prepareAddWatermarksCommand(config *pdfcpu.Configuration) *api.Command {
 	return prepareWatermarksCommand(config, false)
 }
+
+func prepareInsertPagesCommand(config *pdfcpu.Configuration) *api.Command {
+
+	if len(flag.Args()) == 0 || len(flag.Args()) > 2 ||
+		!(mode == "" || mode == "before" || mode == "after") {
+		fmt.Fprintf(os.Stderr, "usage: %s\n\n", usagePagesInsert)
+		os.Exit(1)
+	}
+
+	pages, err := api.ParsePageSelection(pageSelection)
+	if err != nil {
+		log.Fatalf("pages insert: problem with flag pageSelection: %v", err)
+	}
+
+	filenameIn := flag.Arg(0)
+	ensurePdfExtension(filenameIn)
+
+	if from != "" {
+		ensurePdfExtension(from)
+	}
+
+	filenameOut := defaultFilenameOut(filenameIn)
+	if len(flag.Args()) == 2 {
+		filenameOut = flag.Arg(1)
+		ensurePdfExtension(filenameOut)
+	}
+
+	return api.InsertPagesCommand(filenameIn, filenameOut, from, mode != "after", pages, config)
+}
+
+func prepareRemovePagesCommand(config *pdfcpu.Configuration) *api.Command {
+
+	if len(flag.Args()) == 0 || len(flag.Args()) > 2 || pageSelection == "" {
+		fmt.Fprintf(os.Stderr, "usage: %s\n\n", usagePagesRemove)
+		os.Exit(1)
+	}
+
+	pages, err := api.ParsePageSelection(pageSelection)
+	if err != nil {
+		log.Fatalf("pages remove: problem with flag pageSelection: %v", err)
+	}
+
+	filenameIn := flag.Arg(0)
+	ensurePdfExtension(filenameIn)
+
+	filenameOut := defaultFilenameOut(filenameIn)
+	if len(flag.Args()) == 2 {
+		filenameOut = flag.Arg(1)
+		ensurePdfExtension(filenameOut)
+	}
+
+	return api.RemovePagesCommand(filenameIn, filenameOut, pages, config)
+}
+
+func preparePagesCommand(config *pdfcpu.Configuration) *api.Command {
+
+	if len(os.Args) == 2 {
+		fmt.Fprintln(os.Stderr, usagePages)
+		os.Exit(1)
+	}
+
+	var cmd *api.Command
+
+	subCmd := os.Args[2]
+
+	switch subCmd {
+
+	case "insert":
+		cmd = prepareInsertPagesCommand(config)
+
+	case "remove":
+		cmd = prepareRemovePagesCommand(config)
+
+	default:
+		fmt.Fprintln(os.Stderr, usagePages)
+		os.Exit(1)
+	}
+
+	return cmd
+}
+
+func prepareNUpCommand(config *pdfcpu.Configuration) *api.Command {
+
+	if len(flag.Args()) < 2 || len(flag.Args()) > 4 {
+		fmt.Fprintf(os.Stderr, "%s\n\n", usageNUp)
+		os.Exit(1)
+	}
+
+	pages, err := api.ParsePageSelection(pageSelection)
+	if err != nil {
+		log.Fatalf("nup: problem with flag pageSelection: %v", err)
+	}
+
+	args := flag.Args()
+
+	// The description is optional.
+	var description string
+	if !strings.HasSuffix(strings.ToLower(args[0]), ".pdf") {
+		description = args[0]
+		args = args[1:]
+	}
+
+	if len(args) < 2 || len(args) > 3 {
+		fmt.Fprintf(os.Stderr, "%s\n\n", usageNUp)
+		os.Exit(1)
+	}
+
+	filenameIn := args[0]
+	ensurePdfExtension(filenameIn)
+
+	n, err := pdfcpu.ParseNUpValue(args[1])
+	if err != nil {
+		log.Fatalf("nup: %v", err)
+	}
+
+	nup, err := pdfcpu.ParseNUpDetails(description, n)
+	if err != nil {
+		log.Fatalf("nup: %v", err)
+	}
+
+	filenameOut := defaultFilenameOut(filenameIn)
+	if len(args) == 3 {
+		filenameOut = args[2]
+		ensurePdfExtension(filenameOut)
+	}
+
+	return api.NUpCommand(filenameIn, filenameOut, pages, nup, config)
+}
+
+func prepareBookletCommand(config *pdfcpu.Configuration) *api.Command {
+
+	if len(flag.Args()) < 1 || len(flag.Args()) > 3 {
+		fmt.Fprintf(os.Stderr, "%s\n\n", usageBooklet)
+		os.Exit(1)
+	}
+
+	pages, err := api.ParsePageSelection(pageSelection)
+	if err != nil {
+		log.Fatalf("booklet: problem with flag pageSelection: %v", err)
+	}
+
+	args := flag.Args()
+
+	// The description is optional.
+	var description string
+	if !strings.HasSuffix(strings.ToLower(args[0]), ".pdf") {
+		description = args[0]
+		args = args[1:]
+	}
+
+	if len(args) < 1 || len(args) > 2 {
+		fmt.Fprintf(os.Stderr, "%s\n\n", usageBooklet)
+		os.Exit(1)
+	}
+
+	filenameIn := args[0]
+	ensurePdfExtension(filenameIn)
+
+	booklet, err := pdfcpu.ParseBookletDetails(description)
+	if err != nil {
+		log.Fatalf("booklet: %v", err)
+	}
+
+	filenameOut := defaultFilenameOut(filenameIn)
+	if len(args) == 2 {
+		filenameOut = args[1]
+		ensurePdfExtension(filenameOut)
+	}
+
+	return api.BookletCommand(filenameIn, filenameOut, pages, booklet, config)
+}
+
+func prepareCollectCommand(config *pdfcpu.Configuration) *api.Command {
+
+	if len(flag.Args()) < 1 || len(flag.Args()) > 2 || pageSelection == "" {
+		fmt.Fprintf(os.Stderr, "%s\n\n", usageCollect)
+		os.Exit(1)
+	}
+
+	pages, err := api.ParsePageCollection(pageSelection)
+	if err != nil {
+		log.Fatalf("collect: problem with flag pages: %v", err)
+	}
+
+	filenameIn := flag.Arg(0)
+	ensurePdfExtension(filenameIn)
+
+	filenameOut := defaultFilenameOut(filenameIn)
+	if len(flag.Args()) == 2 {
+		filenameOut = flag.Arg(1)
+		ensurePdfExtension(filenameOut)
+	}
+
+	return api.CollectCommand(filenameIn, filenameOut, pages, config)
+}
+
+func prepareImportImagesCommand(config *pdfcpu.Configuration) *api.Command {
+
+	if len(flag.Args()) < 2 {
+		fmt.Fprintf(os.Stderr, "%s\n\n", usageImport)
+		os.Exit(1)
+	}
+
+	args := flag.Args()
+
+	// The description is optional.
+	var description string
+	if !strings.HasSuffix(strings.ToLower(args[0]), ".pdf") {
+		description = args[0]
+		args = args[1:]
+	}
+
+	if len(args) < 2 {
+		fmt.Fprintf(os.Stderr, "%s\n\n", usageImport)
+		os.Exit(1)
+	}
+
+	filenameOut := args[0]
+	ensurePdfExtension(filenameOut)
+
+	imp, err := pdfcpu.ParseImportDetails(description)
+	if err != nil {
+		log.Fatalf("import: %v", err)
+	}
+
+	return api.ImportImagesCommand(args[1:], filenameOut, imp, config)
+}
+
+func prepareListBoxesCommand(config *pdfcpu.Configuration) *api.Command {
+
+	if len(flag.Args()) != 1 {
+		fmt.Fprintf(os.Stderr, "usage: %s\n\n", usageBoxesList)
+		os.Exit(1)
+	}
+
+	pages, err := api.ParsePageSelection(pageSelection)
+	if err != nil {
+		log.Fatalf("boxes list: problem with flag pageSelection: %v", err)
+	}
+
+	filenameIn := flag.Arg(0)
+	ensurePdfExtension(filenameIn)
+
+	return api.ListBoxesCommand(filenameIn, pages, config)
+}
+
+func prepareAddBoxesCommand(config *pdfcpu.Configuration) *api.Command {
+
+	if len(flag.Args()) < 2 || len(flag.Args()) > 3 {
+		fmt.Fprintf(os.Stderr, "usage: %s\n\n", usageBoxesAdd)
+		os.Exit(1)
+	}
+
+	pages, err := api.ParsePageSelection(pageSelection)
+	if err != nil {
+		log.Fatalf("boxes add: problem with flag pageSelection: %v", err)
+	}
+
+	pbs, err := pdfcpu.ParseBoxes(flag.Arg(0))
+	if err != nil {
+		log.Fatalf("boxes add: %v", err)
+	}
+
+	filenameIn := flag.Arg(1)
+	ensurePdfExtension(filenameIn)
+
+	filenameOut := defaultFilenameOut(filenameIn)
+	if len(flag.Args()) == 3 {
+		filenameOut = flag.Arg(2)
+		ensurePdfExtension(filenameOut)
+	}
+
+	return api.AddBoxesCommand(filenameIn, filenameOut, pages, pbs, config)
+}
+
+func prepareRemoveBoxesCommand(config *pdfcpu.Configuration) *api.Command {
+
+	if len(flag.Args()) < 2 || len(flag.Args()) > 3 {
+		fmt.Fprintf(os.Stderr, "usage: %s\n\n", usageBoxesRemove)
+		os.Exit(1)
+	}
+
+	pages, err := api.ParsePageSelection(pageSelection)
+	if err != nil {
+		log.Fatalf("boxes remove: problem with flag pageSelection: %v", err)
+	}
+
+	pbs, err := pdfcpu.ParseBoxNames(flag.Arg(0))
+	if err != nil {
+		log.Fatalf("boxes remove: %v", err)
+	}
+
+	filenameIn := flag.Arg(1)
+	ensurePdfExtension(filenameIn)
+
+	filenameOut := defaultFilenameOut(filenameIn)
+	if len(flag.Args()) == 3 {
+		filenameOut = flag.Arg(2)
+		ensurePdfExtension(filenameOut)
+	}
+
+	return api.RemoveBoxesCommand(filenameIn, filenameOut, pages, pbs, config)
+}
+
+func prepareCropCommand(config *pdfcpu.Configuration) *api.Command {
+
+	if len(flag.Args()) < 1 || len(flag.Args()) > 2 {
+		fmt.Fprintf(os.Stderr, "usage: %s\n\n", usageBoxesCrop)
+		os.Exit(1)
+	}
+
+	pages, err := api.ParsePageSelection(pageSelection)
+	if err != nil {
+		log.Fatalf("boxes crop: problem with flag pageSelection: %v", err)
+	}
+
+	filenameIn := flag.Arg(0)
+	ensurePdfExtension(filenameIn)
+
+	filenameOut := defaultFilenameOut(filenameIn)
+	if len(flag.Args()) == 2 {
+		filenameOut = flag.Arg(1)
+		ensurePdfExtension(filenameOut)
+	}
+
+	return api.CropCommand(filenameIn, filenameOut, pages, config)
+}
+
+func prepareBoxesCommand(config *pdfcpu.Configuration) *api.Command {
+
+	if len(os.Args) == 2 {
+		fmt.Fprintln(os.Stderr, usageBoxes)
+		os.Exit(1)
+	}
+
+	var cmd *api.Command
+
+	subCmd := os.Args[2]
+
+	switch subCmd {
+
+	case "list":
+		cmd = prepareListBoxesCommand(config)
+
+	case "add":
+		cmd = prepareAddBoxesCommand(config)
+
+	case "remove":
+		cmd = prepareRemoveBoxesCommand(config)
+
+	case "crop":
+		cmd = prepareCropCommand(config)
+
+	default:
+		fmt.Fprintln(os.Stderr, usageBoxes)
+		os.Exit(1)
+	}
+
+	return cmd
+}
+
+func prepareResizeCommand(config *pdfcpu.Configuration) *api.Command {
+
+	if len(flag.Args()) < 2 || len(flag.Args()) > 3 {
+		fmt.Fprintf(os.Stderr, "%s\n\n", usageResize)
+		os.Exit(1)
+	}
+
+	pages, err := api.ParsePageSelection(pageSelection)
+	if err != nil {
+		log.Fatalf("resize: problem with flag pageSelection: %v", err)
+	}
+
+	resize, err := pdfcpu.ParseResizeDetails(flag.Arg(0))
+	if err != nil {
+		log.Fatalf("resize: %v", err)
+	}
+
+	filenameIn := flag.Arg(1)
+	ensurePdfExtension(filenameIn)
+
+	filenameOut := defaultFilenameOut(filenameIn)
+	if len(flag.Args()) == 3 {
+		filenameOut = flag.Arg(2)
+		ensurePdfExtension(filenameOut)
+	}
+
+	return api.ResizeCommand(filenameIn, filenameOut, pages, resize, config)
+}
+
+func prepareListPageLabelsCommand(config *pdfcpu.Configuration) *api.Command {
+
+	if len(flag.Args()) != 1 {
+		fmt.Fprintf(os.Stderr, "usage: %s\n\n", usagePageLabelsList)
+		os.Exit(1)
+	}
+
+	filenameIn := flag.Arg(0)
+	ensurePdfExtension(filenameIn)
+
+	return api.ListPageLabelsCommand(filenameIn, config)
+}
+
+func prepareAddPageLabelsCommand(config *pdfcpu.Configuration) *api.Command {
+
+	if len(flag.Args()) < 2 || len(flag.Args()) > 3 {
+		fmt.Fprintf(os.Stderr, "usage: %s\n\n", usagePageLabelsAdd)
+		os.Exit(1)
+	}
+
+	pages, err := api.ParsePageSelection(pageSelection)
+	if err != nil {
+		log.Fatalf("pagelabels add: problem with flag pageSelection: %v", err)
+	}
+
+	pl, err := pdfcpu.ParsePageLabelDetails(flag.Arg(0))
+	if err != nil {
+		log.Fatalf("pagelabels add: %v", err)
+	}
+
+	filenameIn := flag.Arg(1)
+	ensurePdfExtension(filenameIn)
+
+	filenameOut := defaultFilenameOut(filenameIn)
+	if len(flag.Args()) == 3 {
+		filenameOut = flag.Arg(2)
+		ensurePdfExtension(filenameOut)
+	}
+
+	return api.AddPageLabelsCommand(filenameIn, filenameOut, pages, pl, config)
+}
+
+func prepareRemovePageLabelsCommand(config *pdfcpu.Configuration) *api.Command {
+
+	if len(flag.Args()) < 1 || len(flag.Args()) > 2 {
+		fmt.Fprintf(os.Stderr, "usage: %s\n\n", usagePageLabelsRemove)
+		os.Exit(1)
+	}
+
+	pages, err := api.ParsePageSelection(pageSelection)
+	if err != nil {
+		log.Fatalf("pagelabels remove: problem with flag pageSelection: %v", err)
+	}
+
+	filenameIn := flag.Arg(0)
+	ensurePdfExtension(filenameIn)
+
+	filenameOut := defaultFilenameOut(filenameIn)
+	if len(flag.Args()) == 2 {
+		filenameOut = flag.Arg(1)
+		ensurePdfExtension(filenameOut)
+	}
+
+	return api.RemovePageLabelsCommand(filenameIn, filenameOut, pages, config)
+}
+
+func preparePageLabelsCommand(config *pdfcpu.Configuration) *api.Command {
+
+	if len(os.Args) == 2 {
+		fmt.Fprintln(os.Stderr, usagePageLabels)
+		os.Exit(1)
+	}
+
+	var cmd *api.Command
+
+	subCmd := os.Args[2]
+
+	switch subCmd {
+
+	case "list":
+		cmd = prepareListPageLabelsCommand(config)
+
+	case "add":
+		cmd = prepareAddPageLabelsCommand(config)
+
+	case "remove":
+		cmd = prepareRemovePageLabelsCommand(config)
+
+	default:
+		fmt.Fprintln(os.Stderr, usagePageLabels)
+		os.Exit(1)
+	}
+
+	return cmd
+}
+
+func prepareListBookmarksCommand(config *pdfcpu.Configuration) *api.Command {
+
+	if len(flag.Args()) != 1 {
+		fmt.Fprintf(os.Stderr, "usage: %s\n\n", usageBookmarksList)
+		os.Exit(1)
+	}
+
+	filenameIn := flag.Arg(0)
+	ensurePdfExtension(filenameIn)
+
+	return api.ListBookmarksCommand(filenameIn, json, config)
+}
+
+func prepareExportBookmarksCommand(config *pdfcpu.Configuration) *api.Command {
+
+	if len(flag.Args()) != 2 {
+		fmt.Fprintf(os.Stderr, "usage: %s\n\n", usageBookmarksExport)
+		os.Exit(1)
+	}
+
+	filenameIn := flag.Arg(0)
+	ensurePdfExtension(filenameIn)
+
+	return api.ExportBookmarksCommand(filenameIn, flag.Arg(1), config)
+}
+
+func prepareImportBookmarksCommand(config *pdfcpu.Configuration) *api.Command {
+
+	if len(flag.Args()) < 2 || len(flag.Args()) > 3 {
+		fmt.Fprintf(os.Stderr, "usage: %s\n\n", usageBookmarksImport)
+		os.Exit(1)
+	}
+
+	filenameIn := flag.Arg(0)
+	ensurePdfExtension(filenameIn)
+
+	filenameOut := defaultFilenameOut(filenameIn)
+	if len(flag.Args()) == 3 {
+		filenameOut = flag.Arg(2)
+		ensurePdfExtension(filenameOut)
+	}
+
+	return api.ImportBookmarksCommand(filenameIn, flag.Arg(1), filenameOut, config)
+}
+
+func prepareRemoveBookmarksCommand(config *pdfcpu.Configuration) *api.Command {
+
+	if len(flag.Args()) < 1 || len(flag.Args()) > 2 {
+		fmt.Fprintf(os.Stderr, "usage: %s\n\n", usageBookmarksRemove)
+		os.Exit(1)
+	}
+
+	filenameIn := flag.Arg(0)
+	ensurePdfExtension(filenameIn)
+
+	filenameOut := defaultFilenameOut(filenameIn)
+	if len(flag.Args()) == 2 {
+		filenameOut = flag.Arg(1)
+		ensurePdfExtension(filenameOut)
+	}
+
+	return api.RemoveBookmarksCommand(filenameIn, filenameOut, config)
+}
+
+func prepareBookmarksCommand(config *pdfcpu.Configuration) *api.Command {
+
+	if len(os.Args) == 2 {
+		fmt.Fprintln(os.Stderr, usageBookmarks)
+		os.Exit(1)
+	}
+
+	var cmd *api.Command
+
+	subCmd := os.Args[2]
+
+	switch subCmd {
+
+	case "list":
+		cmd = prepareListBookmarksCommand(config)
+
+	case "export":
+		cmd = prepareExportBookmarksCommand(config)
+
+	case "import":
+		cmd = prepareImportBookmarksCommand(config)
+
+	case "remove":
+		cmd = prepareRemoveBookmarksCommand(config)
+
+	default:
+		fmt.Fprintln(os.Stderr, usageBookmarks)
+		os.Exit(1)
+	}
+
+	return cmd
+}
+
+func prepareListPropertiesCommand(config *pdfcpu.Configuration) *api.Command {
+
+	if len(flag.Args()) != 1 {
+		fmt.Fprintf(os.Stderr, "usage: %s\n\n", usagePropertiesList)
+		os.Exit(1)
+	}
+
+	filenameIn := flag.Arg(0)
+	ensurePdfExtension(filenameIn)
+
+	return api.ListPropertiesCommand(filenameIn, config)
+}
+
+func prepareAddPropertiesCommand(config *pdfcpu.Configuration) *api.Command {
+
+	if len(flag.Args()) < 2 {
+		fmt.Fprintf(os.Stderr, "usage: %s\n\n", usagePropertiesAdd)
+		os.Exit(1)
+	}
+
+	filenameIn := flag.Arg(0)
+	ensurePdfExtension(filenameIn)
+
+	properties := map[string]string{}
+
+	for _, s := range flag.Args()[1:] {
+		k, v, err := pdfcpu.ParseProperty(s)
+		if err != nil {
+			log.Fatalf("properties add: %v", err)
+		}
+		properties[k] = v
+	}
+
+	return api.AddPropertiesCommand(filenameIn, properties, config)
+}
+
+func prepareRemovePropertiesCommand(config *pdfcpu.Configuration) *api.Command {
+
+	if len(flag.Args()) < 1 {
+		fmt.Fprintf(os.Stderr, "usage: %s\n\n", usagePropertiesRemove)
+		os.Exit(1)
+	}
+
+	filenameIn := flag.Arg(0)
+	ensurePdfExtension(filenameIn)
+
+	return api.RemovePropertiesCommand(filenameIn, flag.Args()[1:], config)
+}
+
+func preparePropertiesCommand(config *pdfcpu.Configuration) *api.Command {
+
+	if len(os.Args) == 2 {
+		fmt.Fprintln(os.Stderr, usageProperties)
+		os.Exit(1)
+	}
+
+	var cmd *api.Command
+
+	subCmd := os.Args[2]
+
+	switch subCmd {
+
+	case "list":
+		cmd = prepareListPropertiesCommand(config)
+
+	case "add":
+		cmd = prepareAddPropertiesCommand(config)
+
+	case "remove":
+		cmd = prepareRemovePropertiesCommand(config)
+
+	default:
+		fmt.Fprintln(os.Stderr, usageProperties)
+		os.Exit(1)
+	}
+
+	return cmd
+}
+
+func prepareListAnnotationsCommand(config *pdfcpu.Configuration) *api.Command {
+
+	if len(flag.Args()) != 1 {
+		fmt.Fprintf(os.Stderr, "usage: %s\n\n", usageAnnotationsList)
+		os.Exit(1)
+	}
+
+	pages, err := api.ParsePageSelection(pageSelection)
+	if err != nil {
+		log.Fatalf("annotations list: problem with flag pageSelection: %v", err)
+	}
+
+	filenameIn := flag.Arg(0)
+	ensurePdfExtension(filenameIn)
+
+	return api.ListAnnotationsCommand(filenameIn, pages, json, config)
+}
+
+func prepareAddAnnotationsCommand(config *pdfcpu.Configuration) *api.Command {
+
+	if len(flag.Args()) < 2 || len(flag.Args()) > 3 {
+		fmt.Fprintf(os.Stderr, "usage: %s\n\n", usageAnnotationsAdd)
+		os.Exit(1)
+	}
+
+	filenameIn := flag.Arg(0)
+	ensurePdfExtension(filenameIn)
+
+	filenameOut := defaultFilenameOut(filenameIn)
+	if len(flag.Args()) == 3 {
+		filenameOut = flag.Arg(2)
+		ensurePdfExtension(filenameOut)
+	}
+
+	return api.AddAnnotationsCommand(filenameIn, flag.Arg(1), filenameOut, config)
+}
+
+func prepareRemoveAnnotationsCommand(config *pdfcpu.Configuration) *api.Command {
+
+	if len(flag.Args()) < 1 {
+		fmt.Fprintf(os.Stderr, "usage: %s\n\n", usageAnnotationsRemove)
+		os.Exit(1)
+	}
+
+	pages, err := api.ParsePageSelection(pageSelection)
+	if err != nil {
+		log.Fatalf("annotations remove: problem with flag pageSelection: %v", err)
+	}
+
+	filenameIn := flag.Arg(0)
+	ensurePdfExtension(filenameIn)
+
+	filenameOut := defaultFilenameOut(filenameIn)
+	ids := flag.Args()[1:]
+
+	// An optional outFile precedes the annotation types and ids.
+	if len(ids) > 0 && strings.HasSuffix(strings.ToLower(ids[0]), ".pdf") {
+		filenameOut = ids[0]
+		ids = ids[1:]
+	}
+
+	return api.RemoveAnnotationsCommand(filenameIn, filenameOut, pages, ids, config)
+}
+
+func prepareAnnotationsCommand(config *pdfcpu.Configuration) *api.Command {
+
+	if len(os.Args) == 2 {
+		fmt.Fprintln(os.Stderr, usageAnnotations)
+		os.Exit(1)
+	}
+
+	var cmd *api.Command
+
+	subCmd := os.Args[2]
+
+	switch subCmd {
+
+	case "list":
+		cmd = prepareListAnnotationsCommand(config)
+
+	case "add":
+		cmd = prepareAddAnnotationsCommand(config)
+
+	case "remove":
+		cmd = prepareRemoveAnnotationsCommand(config)
+
+	default:
+		fmt.Fprintln(os.Stderr, usageAnnotations)
+		os.Exit(1)
+	}
+
+	return cmd
+}
+
+func prepareFlattenCommand(config *pdfcpu.Configuration) *api.Command {
+
+	if len(flag.Args()) == 0 || len(flag.Args()) > 2 {
+		fmt.Fprintf(os.Stderr, "%s\n\n", usageFlatten)
+		os.Exit(1)
+	}
+
+	pages, err := api.ParsePageSelection(pageSelection)
+	if err != nil {
+		log.Fatalf("flatten: problem with flag pageSelection: %v", err)
+	}
+
+	filenameIn := flag.Arg(0)
+	ensurePdfExtension(filenameIn)
+
+	filenameOut := defaultFilenameOut(filenameIn)
+	if len(flag.Args()) == 2 {
+		filenameOut = flag.Arg(1)
+		ensurePdfExtension(filenameOut)
+	}
+
+	return api.FlattenCommand(filenameIn, filenameOut, pages, config)
+}
+
+func prepareListFormFieldsCommand(config *pdfcpu.Configuration) *api.Command {
+
+	if len(flag.Args()) != 1 {
+		fmt.Fprintf(os.Stderr, "usage: %s\n\n", usageFormList)
+		os.Exit(1)
+	}
+
+	filenameIn := flag.Arg(0)
+	ensurePdfExtension(filenameIn)
+
+	return api.ListFormFieldsCommand(filenameIn, json, config)
+}
+
+func prepareFillFormCommand(config *pdfcpu.Configuration) *api.Command {
+
+	if len(flag.Args()) < 2 || len(flag.Args()) > 3 {
+		fmt.Fprintf(os.Stderr, "usage: %s\n\n", usageFormFill)
+		os.Exit(1)
+	}
+
+	filenameIn := flag.Arg(0)
+	ensurePdfExtension(filenameIn)
+
+	filenameOut := defaultFilenameOut(filenameIn)
+	if len(flag.Args()) == 3 {
+		filenameOut = flag.Arg(2)
+		ensurePdfExtension(filenameOut)
+	}
+
+	return api.FillFormCommand(filenameIn, flag.Arg(1), filenameOut, config)
+}
+
+func prepareExportFormDataCommand(config *pdfcpu.Configuration) *api.Command {
+
+	if len(flag.Args()) != 2 {
+		fmt.Fprintf(os.Stderr, "usage: %s\n\n", usageFormExport)
+		os.Exit(1)
+	}
+
+	filenameIn := flag.Arg(0)
+	ensurePdfExtension(filenameIn)
+
+	return api.ExportFormDataCommand(filenameIn, flag.Arg(1), config)
+}
+
+func prepareImportFormDataCommand(config *pdfcpu.Configuration) *api.Command {
+
+	if len(flag.Args()) < 2 || len(flag.Args()) > 3 {
+		fmt.Fprintf(os.Stderr, "usage: %s\n\n", usageFormImport)
+		os.Exit(1)
+	}
+
+	filenameIn := flag.Arg(0)
+	ensurePdfExtension(filenameIn)
+
+	filenameOut := defaultFilenameOut(filenameIn)
+	if len(flag.Args()) == 3 {
+		filenameOut = flag.Arg(2)
+		ensurePdfExtension(filenameOut)
+	}
+
+	return api.ImportFormDataCommand(filenameIn, flag.Arg(1), filenameOut, config)
+}
+
+func prepareFormCommand(config *pdfcpu.Configuration) *api.Command {
+
+	if len(os.Args) == 2 {
+		fmt.Fprintln(os.Stderr, usageForm)
+		os.Exit(1)
+	}
+
+	var cmd *api.Command
+
+	subCmd := os.Args[2]
+
+	switch subCmd {
+
+	case "list":
+		cmd = prepareListFormFieldsCommand(config)
+
+	case "fill":
+		cmd = prepareFillFormCommand(config)
+
+	case "export":
+		cmd = prepareExportFormDataCommand(config)
+
+	case "import":
+		cmd = prepareImportFormDataCommand(config)
+
+	default:
+		fmt.Fprintln(os.Stderr, usageForm)
+		os.Exit(1)
+	}
+
+	return cmd
+}
@@ -27,7 +27,7 @@ The commands are:
 	
 	validate	validate PDF against PDF 32000-1:2008 (PDF 1.7)
 	optimize	optimize PDF by getting rid of redundant page resources
-	split		split multi-page PDF into several single-page PDFs
+	split		split PDF by page span, bookmark, page number or file size
 	merge		concatenate 2 or more PDFs
 	extract		extract images, fonts, content, pages, metadata
 	trim		create trimmed version
@@ -39,16 +39,32 @@ The commands are:
 	changeopw	change owner password
 	stamp		add stamps
 	watermark	add watermarks
+	info		print document facts
+	rotate		rotate pages
+	pages		insert, remove pages
+	nup		place multiple pages on each page
+	booklet		arrange pages for booklet printing
+	collect		arrange pages in any order including repetitions
+	import		convert or append images to PDF
+	boxes		list, add, remove page boundaries, crop pages
+	resize		scale pages to a paper size or by a factor
+	pagelabels	list, add, remove page labels
+	bookmarks	list, export, import, remove bookmarks
+	properties	list, add, remove document properties
+	annotations	list, add, remove annotations
+	flatten		draw annotations and form fields into the page content
+	form		list, fill form fields
 	version		print version
    
 	Single-letter Unix-style supported for commands and flags.
 
 Use "pdfcpu help [command]" for more information about a command.`
 
-	usageValidate     = "usage: pdfcpu validate [-verbose] [-mode strict|relaxed] [-upw userpw] [-opw ownerpw] inFile"
+	usageValidate     = "usage: pdfcpu validate [-verbose] [-lazy] [-mode strict|relaxed] [-upw userpw] [-opw ownerpw] inFile"
 	usageLongValidate = `Validate checks inFile for specification compliance.
 
 verbose ... extensive log output
+   lazy ... load objects on demand, for large files
    mode ... validation mode
     upw ... user password
     opw ... owner password
@@ -70,21 +86,53 @@ verbose ... extensive log output
  inFile ... input pdf file
 outFile ... output pdf file (default: inFile-new.pdf)`
 
-	usageSplit     = "usage: pdfcpu split [-verbose] [-upw userpw] [-opw ownerpw] inFile outDir"
-	usageLongSplit = `Split generates a set of single page PDFs for the input file in outDir.
+	usageSplit     = "usage: pdfcpu split [-verbose] [-mode span|bookmark|page|size] [-upw userpw] [-opw ownerpw] inFile outDir [span|pageNr...|maxSize]"
+	usageLongSplit = `Split generates a set of PDFs for the input file in outDir.
 
 verbose ... extensive log output
+   mode ... where to start a new file (default: span)
     upw ... user password
     opw ... owner password
  inFile ... input pdf file
- outDir ... output directory`
+ outDir ... output directory
+   span ... span mode: pages per file (default: 1)
+ pageNr ... page mode: a page starting a new file
+maxSize ... size mode: maximum file size in bytes, append k, m or g for kilo-, mega- or gigabytes
+
+The split modes:
+
+     span ... a new file every span pages, files are named after their page range
+ bookmark ... a new file at each top level bookmark, files are named after the bookmark titles
+     page ... a new file at each pageNr given, files are named after their page range
+     size ... as many pages per file as fit into maxSize bytes, files are named after their page range
+              a page exceeding maxSize on its own goes into a file by itself
 
-	usageMerge     = "usage: pdfcpu merge [-verbose] outFile inFile..."
+e.g. pdfcpu split in.pdf out 10         pdfcpu split -mode page in.pdf out 5 12
+     pdfcpu split -mode bookmark in.pdf out     pdfcpu split -mode size in.pdf out 2m`
+
+	usageMerge     = "usage: pdfcpu merge [-verbose] [-mode append|zip|zipreverse] [-bookmarks] outFile inFile[:pageSelection]..."
 	usageLongMerge = `Merge concatenates a sequence of PDFs/inFiles to outFile.
+Bookmarks, named destinations, form fields and page labels are merged too.
+Colliding destination and field names of later files get a numeric suffix.
 
-verbose ... extensive log output
-outFile	... output pdf file
-inFiles ... a list of at least 2 pdf files subject to concatenation.`
+      verbose ... extensive log output
+         mode ... how to combine the pages (default: append)
+    bookmarks ... append mode: add a bookmark for each inFile titled by its document title or file name
+      outFile ... output pdf file
+       inFile ... a list of at least 2 pdf files subject to concatenation
+pageSelection ... merge the selected pages of inFile only
+
+The merge modes:
+
+    append ... concatenate all inFiles
+       zip ... interleave the pages of 2 inFiles: page 1 of the first file, page 1 of the second file, page 2 of the first file...
+zipreverse ... like zip but take the pages of the second file back to front,
+               e.g. for merging the front and back sides of a stack of paper scanned one side at a time
+               Both zip modes keep bookmarks and form fields but drop page labels.
+
+e.g. pdfcpu merge out.pdf in1.pdf in2.pdf
+     pdfcpu merge -bookmarks out.pdf in1.pdf:1-3 in2.pdf:odd,n1
+     pdfcpu merge -mode zipreverse out.pdf fronts.pdf backs.pdf`
 
 	usageExtract     = "usage: pdfcpu extract [-verbose] -mode image|font|content|page|meta [-pages pageSelection] [-upw userpw] [-opw ownerpw] inFile outDir"
 	usageLongExtract = `Extract exports inFile's images, fonts, content or pages into outDir.
@@ -132,9 +180,9 @@ outFile ... output pdf file (default: inFile-new.pdf)`
 
 e.g. -3,5,7- or 4-7,!6 or 1-,!5 or odd,n1`
 
-	usageAttachList    = "pdfcpu attach list [-verbose] [-upw userpw] [-opw ownerpw] inFile"
-	usageAttachAdd     = "pdfcpu attach add [-verbose] [-upw userpw] [-opw ownerpw] inFile file..."
-	usageAttachRemove  = "pdfcpu attach remove [-verbose] [-upw userpw] [-opw ownerpw] inFile [file...]"
+	usageAttachList    = "pdfcpu attach list [-verbose] [-lazy] [-upw userpw] [-opw ownerpw] inFile"
+	usageAttachAdd     = "pdfcpu attach add [-verbose] [-incr] [-upw userpw] [-opw ownerpw] inFile file..."
+	usageAttachRemove  = "pdfcpu attach remove [-verbose] [-incr] [-upw userpw] [-opw ownerpw] inFile [file...]"
 	usageAttachExtract = "pdfcpu attach extract [-verbose] [-upw userpw] [-opw ownerpw] inFile outDir [file...]"
 
 	usageAttach = "usage: " + usageAttachList +
@@ -145,6 +193,8 @@ e.g. -3,5,7- or 4-7,!6 or 1-,!5 or odd,n1`
 	usageLongAttach = `Attach manages embedded file attachments.
 	
 verbose ... extensive log output
+   incr ... append changes as incremental update leaving the original bytes untouched
+   lazy ... load objects on demand, for large files
    perm ... user access permissions
     upw ... user password
     opw ... owner password
@@ -230,10 +280,11 @@ e.g. 'Draft'                                                  'logo.png'
      'Intentionally left blank, p:48'
      'Confidental, f:Courier, s:0.75, c: 0.5 0.0 0.0, r:20'`
 
-	usageStamp     = "usage: pdfcpu stamp [-verbose] -pages pageSelection description inFile [outFile]"
+	usageStamp     = "usage: pdfcpu stamp [-verbose] [-incr] -pages pageSelection description inFile [outFile]"
 	usageLongStamp = `Stamp adds stamps for selected pages. 
 
     verbose ... extensive log output
+       incr ... append changes as incremental update leaving the original bytes untouched
       pages ... page selection
 description ... font, text, color, rotation
      inFile ... input pdf file
@@ -241,10 +292,11 @@ description ... font, text, color, rotation
 
 ` + usageWMDescription
 
-	usageWatermark     = "usage: pdfcpu watermark [-verbose] -pages pageSelection description inFile [outFile]"
+	usageWatermark     = "usage: pdfcpu watermark [-verbose] [-incr] -pages pageSelection description inFile [outFile]"
 	usageLongWatermark = `Watermark adds watermarks for selected pages. 
 
     verbose ... extensive log output
+       incr ... append changes as incremental update leaving the original bytes untouched
       pages ... page selection
 description ... font, text, color, rotation
      inFile ... input pdf file
@@ -252,6 +304,451 @@ description ... font, text, color, rotation
 
 ` + usageWMDescription
 
+	usageInfo     = "usage: pdfcpu info [-verbose] [-lazy] [-json] [-upw userpw] [-opw ownerpw] inFile"
+	usageLongInfo = `Info prints page count, PDF version, page boundaries, document information,
+encryption details, permissions, attachment count and form presence of inFile.
+
+verbose ... extensive log output
+   lazy ... load objects on demand, for large files
+   json ... output as JSON
+    upw ... user password
+    opw ... owner password
+ inFile ... input pdf file`
+
+	usageRotate     = "usage: pdfcpu rotate [-verbose] [-incr] [-pages pageSelection] [-upw userpw] [-opw ownerpw] inFile rotation [outFile]"
+	usageLongRotate = `Rotate rotates selected pages clockwise.
+
+ verbose ... extensive log output
+    incr ... append changes as incremental update leaving the original bytes untouched
+   pages ... page selection (default: all pages)
+     upw ... user password
+     opw ... owner password
+  inFile ... input pdf file
+rotation ... a multiple of 90 degrees, negative values rotate counterclockwise
+ outFile ... output pdf file (default: inFile-new.pdf)`
+
+	usagePagesInsert = "pdfcpu pages insert [-verbose] [-incr] [-pages pageSelection] [-mode before|after] [-from srcFile] [-upw userpw] [-opw ownerpw] inFile [outFile]"
+	usagePagesRemove = "pdfcpu pages remove [-verbose] [-incr] -pages pageSelection [-upw userpw] [-opw ownerpw] inFile [outFile]"
+
+	usagePages = "usage: " + usagePagesInsert +
+		"\n       " + usagePagesRemove
+
+	usageLongPages = `Pages inserts blank pages or the pages of srcFile and removes pages.
+
+verbose ... extensive log output
+   incr ... append changes as incremental update leaving the original bytes untouched
+  pages ... page selection (insert default: all pages, with srcFile: first page or last page for mode after)
+   mode ... insert before (default) or after selected pages
+   from ... insert the pages of srcFile at the single page selected instead of blank pages
+    upw ... user password
+    opw ... owner password
+ inFile ... input pdf file
+outFile ... output pdf file (default: inFile-new.pdf)
+
+Blank pages get the media box of the page they are inserted next to.`
+
+	usageNUp     = "usage: pdfcpu nup [-verbose] [-incr] [-pages pageSelection] [-upw userpw] [-opw ownerpw] [description] inFile n [outFile]"
+	usageLongNUp = `NUp places n pages of inFile on each page of outFile.
+
+    verbose ... extensive log output
+       incr ... append changes as incremental update leaving the original bytes untouched
+      pages ... page selection (default: all pages)
+        upw ... user password
+        opw ... owner password
+description ... paper size, page order, margin, border
+     inFile ... input pdf file
+          n ... pages per page: 2, 3, 4, 6, 8, 9, 12, 16
+    outFile ... output pdf file (default: inFile-new.pdf)
+
+<description> is a comma separated configuration string containing:
+
+         (defaults: 'f:A4, o:rd, m:0, b:off')
+
+      f: paper size, one of A3, A4, A5, Letter, Legal, Tabloid,
+         append P or L to force portrait or landscape orientation (default: best fit)
+      o: order of pages: rd ... right, then down
+                         dr ... down, then right
+                         ld ... left, then down
+                         dl ... down, then left
+      m: margin around each page in points
+      b: border line around each page: on, off
+
+    Document outline, forms and annotations do not carry over.
+
+e.g. 'f:A4L'                    'f:Letter, o:dr, m:10, b:on'`
+
+	usageBooklet     = "usage: pdfcpu booklet [-verbose] [-incr] [-pages pageSelection] [-upw userpw] [-opw ownerpw] [description] inFile [outFile]"
+	usageLongBooklet = `Booklet arranges the pages of inFile for printing a saddle stitched booklet.
+
+    verbose ... extensive log output
+       incr ... append changes as incremental update leaving the original bytes untouched
+      pages ... page selection (default: all pages)
+        upw ... user password
+        opw ... owner password
+description ... paper size, binding edge, signature size, creep, margin, border
+     inFile ... input pdf file
+    outFile ... output pdf file (default: inFile-new.pdf)
+
+<description> is a comma separated configuration string containing:
+
+         (defaults: 'f:A4, b:left, s:0, c:0, m:0, l:off')
+
+      f: paper size of a sheet, one of A3, A4, A5, Letter, Legal, Tabloid
+      b: binding edge: left, right, top
+      s: sheets per signature, 0 for a single signature
+      c: creep, shift towards the fold per sheet in points, limited by the margin
+      m: margin around each page in points
+      l: border line around each page: on, off
+
+    Two pages go on each side of a sheet. The page count is padded with blank pages to a multiple of 4.
+    Print outFile double-sided flipping on the short edge, fold each signature and stack the signatures.
+    Document outline, forms and annotations do not carry over.
+
+e.g. 'f:A3'                     'f:Letter, b:right, s:4, c:0.5'`
+
+	usageCollect     = "usage: pdfcpu collect [-verbose] [-incr] -pages pageList [-upw userpw] [-opw ownerpw] inFile [outFile]"
+	usageLongCollect = `Collect creates a document made of the pages of inFile in the order given by pageList.
+
+verbose ... extensive log output
+   incr ... append changes as incremental update leaving the original bytes untouched
+  pages ... ordered page list
+    upw ... user password
+    opw ... owner password
+ inFile ... input pdf file
+outFile ... output pdf file (default: inFile-new.pdf)
+
+<pageList> is a comma separated list of:
+
+      #     ... a page number or "last"
+      #-#   ... a page range, a range running backwards yields its pages in reverse order
+      #-    ... all pages from # up to the last page
+
+    Pages may be given repeatedly, repetitions share resources and content with the original page.
+    Document outline and forms do not carry over.
+
+e.g. '3,1,1,5-2,last'    'last-1' reverses inFile    '1-,1-' doubles inFile`
+
+	usageImport     = "usage: pdfcpu import [-verbose] [description] outFile imageFile..."
+	usageLongImport = `Import turns JPEG, PNG and TIFF images into pages appended to outFile.
+outFile gets created if it does not exist yet.
+
+    verbose ... extensive log output
+description ... page size, resolution, margin, position
+    outFile ... output pdf file
+  imageFile ... image file, one page per image
+
+<description> is a comma separated configuration string containing:
+
+         (defaults: 'd:72, m:0, p:c')
+
+      f: paper size, one of A3, A4, A5, Letter, Legal, Tabloid,
+         append P or L to force portrait or landscape orientation (default: best fit)
+         Images get scaled to fit the paper size.
+         Without f each page takes the size of its image.
+      d: image resolution in dpi for pages taking the size of their image
+      m: margin around the image in points
+      p: position of the image on the page:
+         c ... center, tl tc tr ... top left/center/right,
+         l r ... left/right, bl bc br ... bottom left/center/right
+
+    JPEG images are embedded as is without re-encoding.
+
+e.g. 'f:A4'                     'f:LetterP, m:36, p:tc'     'd:300'`
+
+	usageBoxesList   = "pdfcpu boxes list [-verbose] [-pages pageSelection] [-upw userpw] [-opw ownerpw] inFile"
+	usageBoxesAdd    = "pdfcpu boxes add [-verbose] [-incr] [-pages pageSelection] [-upw userpw] [-opw ownerpw] description inFile [outFile]"
+	usageBoxesRemove = "pdfcpu boxes remove [-verbose] [-incr] [-pages pageSelection] [-upw userpw] [-opw ownerpw] boxes inFile [outFile]"
+	usageBoxesCrop   = "pdfcpu boxes crop [-verbose] [-incr] [-pages pageSelection] [-upw userpw] [-opw ownerpw] inFile [outFile]"
+
+	usageBoxes = "usage: " + usageBoxesList +
+		"\n       " + usageBoxesAdd +
+		"\n       " + usageBoxesRemove +
+		"\n       " + usageBoxesCrop
+
+	usageLongBoxes = `Boxes manages the page boundaries media box, crop box, bleed box, trim box and art box.
+
+    verbose ... extensive log output
+       incr ... append changes as incremental update leaving the original bytes untouched
+      pages ... page selection (default: all pages)
+        upw ... user password
+        opw ... owner password
+description ... comma separated list of boxes to set
+      boxes ... comma separated list of boxes to remove: crop, bleed, trim, art
+     inFile ... input pdf file
+    outFile ... output pdf file (default: inFile-new.pdf)
+
+list   ... print the boxes in effect, inherited or defaulted boxes are marked as such
+add    ... set boxes
+remove ... remove boxes so that they take their default
+crop   ... set the crop box to the trim box
+
+<description> is a comma separated list of box: value with box being one of media, crop, bleed, trim, art
+and value being one of
+
+      [llx lly urx ury]           ... a rectangle in user space
+      m                           ... a margin on all sides
+      mTopBottom mLeftRight       ... vertical and horizontal margins
+      mTop mRight mBottom mLeft   ... margins for each side
+
+    Lengths are points unless followed by one of the units pt, mm, in.
+    Margins are relative to the default box taking page rotation into account, negative margins enlarge a box.
+    Boxes get clipped to the media box.
+    The crop box defaults to the media box, all other boxes default to the crop box.
+
+e.g. 'trim: 10mm'     'crop: [0 0 595 842], trim: 20 10'     'media: -3mm, trim: 3mm'`
+
+	usageResize     = "usage: pdfcpu resize [-verbose] [-incr] [-pages pageSelection] [-upw userpw] [-opw ownerpw] description inFile [outFile]"
+	usageLongResize = `Resize scales the pages of inFile to a paper size or by a scale factor.
+
+    verbose ... extensive log output
+       incr ... append changes as incremental update leaving the original bytes untouched
+      pages ... page selection (default: all pages)
+        upw ... user password
+        opw ... owner password
+description ... paper size or scale factor
+     inFile ... input pdf file
+    outFile ... output pdf file (default: inFile-new.pdf)
+
+<description> is one of:
+
+      f: paper size, one of A3, A4, A5, Letter, Legal, Tabloid,
+         append P or L to force portrait or landscape orientation (default: orientation of each page)
+         The visible region of each page gets scaled uniformly to fit and centered on the paper.
+      s: scale factor applied to the visible region of each page
+
+    Annotations get moved and scaled along with the page content.
+
+e.g. 'f:A4'    'f:LetterL'    's:0.5'`
+
+	usagePageLabelsList   = "pdfcpu pagelabels list [-verbose] [-upw userpw] [-opw ownerpw] inFile"
+	usagePageLabelsAdd    = "pdfcpu pagelabels add [-verbose] [-incr] [-pages pageSelection] [-upw userpw] [-opw ownerpw] description inFile [outFile]"
+	usagePageLabelsRemove = "pdfcpu pagelabels remove [-verbose] [-incr] [-pages pageSelection] [-upw userpw] [-opw ownerpw] inFile [outFile]"
+
+	usagePageLabels = "usage: " + usagePageLabelsList +
+		"\n       " + usagePageLabelsAdd +
+		"\n       " + usagePageLabelsRemove
+
+	usageLongPageLabels = `PageLabels manages the page labels viewers display instead of page numbers.
+
+    verbose ... extensive log output
+       incr ... append changes as incremental update leaving the original bytes untouched
+      pages ... page selection (default: all pages)
+        upw ... user password
+        opw ... owner password
+description ... numbering style, prefix and start value
+     inFile ... input pdf file
+    outFile ... output pdf file (default: inFile-new.pdf)
+
+list   ... print the page label ranges
+add    ... start a page label range at each run of consecutive selected pages, following pages keep their labels
+remove ... remove the page label ranges starting at selected pages, all ranges if no pages are selected
+
+<description> is a comma separated list of the following optional entries:
+
+      s: numbering style, one of
+         D ... decimal (default)
+         R ... uppercase roman numerals
+         r ... lowercase roman numerals
+         A ... uppercase letters (A to Z, AA to ZZ, ...)
+         a ... lowercase letters (a to z, aa to zz, ...)
+         none ... labels consist of the prefix only
+      p: label prefix
+     st: value of the numeric portion for the first page of the range (default: 1)
+
+e.g. 's:r'    's:D, p:A-, st:1'    'p:Cover, s:none'`
+
+	usageBookmarksList   = "pdfcpu bookmarks list [-verbose] [-json] [-upw userpw] [-opw ownerpw] inFile"
+	usageBookmarksExport = "pdfcpu bookmarks export [-verbose] [-upw userpw] [-opw ownerpw] inFile jsonFile"
+	usageBookmarksImport = "pdfcpu bookmarks import [-verbose] [-incr] [-upw userpw] [-opw ownerpw] inFile jsonFile [outFile]"
+	usageBookmarksRemove = "pdfcpu bookmarks remove [-verbose] [-incr] [-upw userpw] [-opw ownerpw] inFile [outFile]"
+
+	usageBookmarks = "usage: " + usageBookmarksList +
+		"\n       " + usageBookmarksExport +
+		"\n       " + usageBookmarksImport +
+		"\n       " + usageBookmarksRemove
+
+	usageLongBookmarks = `Bookmarks manages the document outline.
+
+    verbose ... extensive log output
+       json ... output as JSON
+       incr ... append changes as incremental update leaving the original bytes untouched
+        upw ... user password
+        opw ... owner password
+     inFile ... input pdf file
+   jsonFile ... bookmarks as JSON
+    outFile ... output pdf file (default: inFile-new.pdf)
+
+list   ... print the bookmarks as an indented tree
+export ... write the bookmarks to jsonFile
+import ... replace the bookmarks by the bookmarks of jsonFile
+remove ... remove all bookmarks
+
+<jsonFile> holds a list of bookmarks, each bookmark being an object with the entries
+
+      title  ... required
+      page   ... page number of the destination, 0 or omitted for bookmarks leading nowhere
+      zoom   ... zoom factor, 0 or omitted for fitting the page into the window
+      color  ... title color as RGB components in the range 0..1
+      bold   ... bold title
+      italic ... italic title
+      open   ... show the kids
+      kids   ... nested bookmarks
+
+e.g. {"bookmarks": [
+        {"title": "Preface", "page": 1},
+        {"title": "Part 1", "page": 3, "bold": true, "color": [1, 0, 0], "open": true, "kids": [
+            {"title": "Chapter 1", "page": 3, "zoom": 1.5}
+        ]}
+     ]}`
+
+	usagePropertiesList   = "pdfcpu properties list [-verbose] [-upw userpw] [-opw ownerpw] inFile"
+	usagePropertiesAdd    = "pdfcpu properties add [-verbose] [-incr] [-upw userpw] [-opw ownerpw] inFile property..."
+	usagePropertiesRemove = "pdfcpu properties remove [-verbose] [-incr] [-upw userpw] [-opw ownerpw] inFile [key...]"
+
+	usageProperties = "usage: " + usagePropertiesList +
+		"\n       " + usagePropertiesAdd +
+		"\n       " + usagePropertiesRemove
+
+	usageLongProperties = `Properties manages the entries of the document info dict.
+
+   verbose ... extensive log output
+      incr ... append changes as incremental update leaving the original bytes untouched
+       upw ... user password
+       opw ... owner password
+    inFile ... input pdf file
+  property ... key = value
+       key ... property key
+
+list   ... print all properties
+add    ... set properties
+remove ... remove properties, all properties if no key is given
+
+    Standard keys are Title, Author, Subject, Keywords, Creator and Trapped, any other key is a custom property.
+    Producer, CreationDate and ModDate are maintained by pdfcpu.
+    Trapped takes one of True, False, Unknown.
+
+e.g. pdfcpu properties add test.pdf 'Title = My Title' 'Author = Me'
+     pdfcpu properties remove test.pdf Keywords`
+
+	usageAnnotationsList   = "pdfcpu annotations list [-verbose] [-pages pageSelection] [-json] [-upw userpw] [-opw ownerpw] inFile"
+	usageAnnotationsAdd    = "pdfcpu annotations add [-verbose] [-incr] [-upw userpw] [-opw ownerpw] inFile jsonFile [outFile]"
+	usageAnnotationsRemove = "pdfcpu annotations remove [-verbose] [-incr] [-pages pageSelection] [-upw userpw] [-opw ownerpw] inFile [outFile] [id...]"
+
+	usageAnnotations = "usage: " + usageAnnotationsList +
+		"\n       " + usageAnnotationsAdd +
+		"\n       " + usageAnnotationsRemove
+
+	usageLongAnnotations = `Annotations manages the annotations of pages.
+
+    verbose ... extensive log output
+      pages ... page selection (default: all pages)
+       json ... output as JSON
+       incr ... append changes as incremental update leaving the original bytes untouched
+        upw ... user password
+        opw ... owner password
+     inFile ... input pdf file
+   jsonFile ... annotations as JSON
+    outFile ... output pdf file (default: inFile-new.pdf)
+         id ... annotation type, annotation id as listed or object number
+
+list   ... print the annotations of selected pages
+add    ... add the annotations of jsonFile
+remove ... remove the annotations matching any id from selected pages, all annotations if no id is given
+
+    Popups and replies get removed along with their annotation.
+    Form field widgets are never removed.
+
+<jsonFile> holds a list of annotations, each annotation being an object with the entries
+
+      type          ... required, one of Text, Link, FreeText, Line, Square, Circle, Polygon, PolyLine,
+                        Highlight, Underline, Squiggly, StrikeOut, Stamp, Ink, FileAttachment
+      page          ... required, page number
+      rect          ... required, [llx lly urx ury] in user space
+      id            ... annotation name, unique per page
+      contents      ... text
+      author        ... author
+      color         ... RGB components in the range 0..1
+      interiorColor ... Line, Square, Circle, Polygon, PolyLine: RGB components in the range 0..1
+      icon          ... Text, Stamp, FileAttachment: icon name
+      open          ... Text: show the popup
+      uri           ... Link: target URI
+      dest          ... Link: target page number
+      points        ... Line: [x1 y1 x2 y2] (default: rect diagonal)
+                        Polygon, PolyLine: required vertex coordinates
+                        text markup: quadrilateral coordinates (default: rect)
+      inkList       ... Ink: required list of paths
+      file          ... FileAttachment: required file to embed
+
+e.g. {"annotations": [
+        {"type": "Text", "page": 1, "rect": [100, 700, 120, 720], "contents": "Check this", "author": "Me"},
+        {"type": "Link", "page": 1, "rect": [100, 600, 300, 620], "uri": "https://pdfcpu.io"},
+        {"type": "Highlight", "page": 2, "rect": [72, 500, 300, 515], "color": [1, 1, 0]}
+     ]}
+
+     pdfcpu annotations remove test.pdf Link Popup
+     pdfcpu annotations remove -pages 1 test.pdf out.pdf 27`
+
+	usageFlatten     = "usage: pdfcpu flatten [-verbose] [-incr] [-pages pageSelection] [-upw userpw] [-opw ownerpw] inFile [outFile]"
+	usageLongFlatten = `Flatten draws the annotations and form fields of inFile into the page content so they can no longer be edited.
+
+    verbose ... extensive log output
+       incr ... append changes as incremental update leaving the original bytes untouched
+      pages ... page selection (default: all pages)
+        upw ... user password
+        opw ... owner password
+     inFile ... input pdf file
+    outFile ... output pdf file (default: inFile-new.pdf)
+
+    Annotations get drawn using their normal appearance and removed along with their popups and replies.
+    Hidden annotations get removed without being drawn.
+    Annotations without appearance like most links are kept.
+    Form fields get removed once all their widgets are flattened.`
+
+	usageFormList   = "pdfcpu form list [-verbose] [-json] [-upw userpw] [-opw ownerpw] inFile"
+	usageFormFill   = "pdfcpu form fill [-verbose] [-incr] [-upw userpw] [-opw ownerpw] inFile jsonFile [outFile]"
+	usageFormExport = "pdfcpu form export [-verbose] [-upw userpw] [-opw ownerpw] inFile dataFile"
+	usageFormImport = "pdfcpu form import [-verbose] [-incr] [-upw userpw] [-opw ownerpw] inFile dataFile [outFile]"
+
+	usageForm = "usage: " + usageFormList +
+		"\n       " + usageFormFill +
+		"\n       " + usageFormExport +
+		"\n       " + usageFormImport
+
+	usageLongForm = `Form lists, fills, exports and imports the fields of an AcroForm.
+
+   verbose ... extensive log output
+      json ... output as JSON
+      incr ... append changes as incremental update leaving the original bytes untouched
+       upw ... user password
+       opw ... owner password
+    inFile ... input pdf file
+  jsonFile ... field values as JSON
+  dataFile ... field values and annotations as FDF or XFDF, identified by the extension ".fdf" or ".xfdf"
+   outFile ... output pdf file (default: inFile-new.pdf)
+
+list   ... print the fields with their fully qualified names, types, values, options and flags
+fill   ... set the values of the fields of jsonFile
+export ... write the values of the fields and the markup annotations to dataFile
+import ... set the values of the fields and add the annotations of dataFile,
+           annotations replace annotations of the same name on their page
+
+<jsonFile> holds a list of fields, each field being an object with the entries
+
+      name   ... required, fully qualified field name
+      value  ... Text: text, an empty value clears the field
+                 CheckBox, RadioButton: one of the options as listed or Off
+                 ComboBox, ListBox: one of the options as listed, any text for editable combo boxes
+      values ... ListBox: multiple options for multiple selection
+
+    The output of form list -json may be used as jsonFile, entries other than name, value and values are ignored.
+    Read only, push button and signature fields cannot be filled.
+
+e.g. {"fields": [
+        {"name": "person.name", "value": "Jane Doe"},
+        {"name": "person.married", "value": "Yes"},
+        {"name": "languages", "values": ["English", "German"]}
+     ]}`
+
 	usageVersion     = "usage: pdfcpu version"
 	usageLongVersion = "Version prints the pdfcpu version"
 )
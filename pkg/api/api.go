@@ -18,7 +18,10 @@ limitations under the License.
 package api
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
@@ -77,6 +80,8 @@ func Validate(cmd *Command) ([]string, error) {
 		return nil, err
 	}
 
+	defer ctx.Close()
+
 	dur1 := time.Since(from1).Seconds()
 
 	from2 := time.Now()
@@ -124,35 +129,63 @@ func Write(ctx *pdf.Context) error {
 	return nil
 }
 
-// singlePageFileName generates a filename for a Context and a specific page number.
-func singlePageFileName(ctx *pdf.Context, pageNr int) string {
+// pagesFileName generates a filename for the pages of a Context given as page number or page range.
+// Contexts read from an io.ReadSeeker have no file name to start with.
+func pagesFileName(ctx *pdf.Context, pages string) string {
+
+	if ctx.Read.FileName == "" {
+		return pages + ".pdf"
+	}
 
 	baseFileName := filepath.Base(ctx.Read.FileName)
 	fileName := strings.TrimSuffix(baseFileName, ".pdf")
-	return fileName + "_" + strconv.Itoa(pageNr) + ".pdf"
+	return fileName + "_" + pages + ".pdf"
+}
+
+// singlePageFileName generates a filename for a Context and a specific page number.
+func singlePageFileName(ctx *pdf.Context, pageNr int) string {
+	return pagesFileName(ctx, strconv.Itoa(pageNr))
+}
+
+// pdfFileCreator returns a FileCreator for PDF files in dirOut.
+func pdfFileCreator(dirOut string) pdf.FileCreator {
+
+	create := pdf.DirFileCreator(dirOut)
+
+	return func(fileName string) (io.Writer, error) {
+		fmt.Printf("writing %s ...\n", filepath.Join(dirOut, fileName))
+		return create(fileName)
+	}
+}
+
+// writePDF writes ctx to the file create returns for fileName.
+func writePDF(ctx *pdf.Context, fileName string, create pdf.FileCreator) error {
+
+	ctx.Write.FileName = fileName
+
+	return pdf.WriteOutputFile(create, fileName, func(w io.Writer) error {
+		return pdf.Write(ctx, w)
+	})
 }
 
-func writeSinglePagePDF(ctx *pdf.Context, pageNr int, dirOut string) error {
+func writeSinglePagePDF(ctx *pdf.Context, pageNr int, create pdf.FileCreator) error {
 
 	ctx.ResetWriteContext()
 
 	w := ctx.Write
 	w.Command = "Split"
 	w.ExtractPageNr = pageNr
-	w.DirName = dirOut + "/"
-	w.FileName = singlePageFileName(ctx, pageNr)
-	fmt.Printf("writing %s ...\n", w.DirName+w.FileName)
 
-	return pdf.WritePDFFile(ctx)
+	return writePDF(ctx, singlePageFileName(ctx, pageNr), create)
 }
 
-func writeSinglePagePDFs(ctx *pdf.Context, selectedPages pdf.IntSet, dirOut string) error {
+func writeSinglePagePDFs(ctx *pdf.Context, selectedPages pdf.IntSet, create pdf.FileCreator) error {
 
 	ensureSelectedPages(ctx, &selectedPages)
 
 	for i, v := range selectedPages {
 		if v {
-			err := writeSinglePagePDF(ctx, i, dirOut)
+			err := writeSinglePagePDF(ctx, i, create)
 			if err != nil {
 				return err
 			}
@@ -175,6 +208,7 @@ func readAndValidate(fileIn string, config *pdf.Configuration, from1 time.Time)
 	//logInfoAPI.Printf("validating %s..\n", fileIn)
 	err = validate.XRefTable(ctx.XRefTable)
 	if err != nil {
+		ctx.Close()
 		return nil, 0, 0, err
 	}
 	dur2 = time.Since(from2).Seconds()
@@ -193,6 +227,7 @@ func readValidateAndOptimize(fileIn string, config *pdf.Configuration, from1 tim
 	//fmt.Printf("optimizing %s ...\n", fileIn)
 	err = pdf.OptimizeXRefTable(ctx)
 	if err != nil {
+		ctx.Close()
 		return nil, 0, 0, 0, err
 	}
 	dur3 = time.Since(from3).Seconds()
@@ -214,6 +249,8 @@ func Optimize(cmd *Command) ([]string, error) {
 		return nil, err
 	}
 
+	defer ctx.Close()
+
 	log.Stats.Printf("XRefTable:\n%s\n", ctx)
 
 	fromWrite := time.Now()
@@ -243,7 +280,263 @@ func Optimize(cmd *Command) ([]string, error) {
 	return nil, nil
 }
 
-// Split generates a sequence of single page PDF files in dirOut creating one file for every page of inFile.
+// SplitMode determines where Split starts a new file.
+type SplitMode int
+
+// The split modes.
+const (
+	SplitSpan      SplitMode = iota // after every Span pages.
+	SplitBookmarks                  // at each top level bookmark.
+	SplitPageNrs                    // at each page of PageNrs.
+	SplitSize                       // whenever a file would exceed MaxSize bytes.
+)
+
+// pageSpan represents a sequence of pages going into one file.
+type pageSpan struct {
+	from, thru int
+	title      string // the bookmark leading to from.
+}
+
+func spansForSpan(pageCount, span int) []pageSpan {
+
+	var spans []pageSpan
+
+	for i := 1; i <= pageCount; i += span {
+		thru := i + span - 1
+		if thru > pageCount {
+			thru = pageCount
+		}
+		spans = append(spans, pageSpan{from: i, thru: thru})
+	}
+
+	return spans
+}
+
+// spansForPageNrs returns the spans resulting from starting a new file at each page of pageNrs.
+func spansForPageNrs(pageCount int, pageNrs []int) ([]pageSpan, error) {
+
+	starts := pdf.IntSet{1: true}
+
+	for _, i := range pageNrs {
+		if i < 1 || i > pageCount {
+			return nil, errors.Errorf("split: page %d out of range 1-%d", i, pageCount)
+		}
+		starts[i] = true
+	}
+
+	var spans []pageSpan
+
+	for i := 1; i <= pageCount; i++ {
+		if !starts[i] {
+			continue
+		}
+		if len(spans) > 0 {
+			spans[len(spans)-1].thru = i - 1
+		}
+		spans = append(spans, pageSpan{from: i, thru: pageCount})
+	}
+
+	return spans, nil
+}
+
+// spansForBookmarks returns the spans resulting from starting a new file at each top level bookmark.
+// Any pages preceding the first bookmark go into the first file.
+func spansForBookmarks(ctx *pdf.Context) ([]pageSpan, error) {
+
+	bms, err := pdf.TopLevelBookmarks(ctx.XRefTable)
+	if err != nil {
+		return nil, err
+	}
+
+	var spans []pageSpan
+
+	for _, bm := range bms {
+
+		// Skip bookmarks leading nowhere and bookmarks out of page order.
+		if bm.PageNr == 0 || len(spans) > 0 && bm.PageNr <= spans[len(spans)-1].from {
+			continue
+		}
+
+		if len(spans) > 0 {
+			spans[len(spans)-1].thru = bm.PageNr - 1
+		}
+
+		spans = append(spans, pageSpan{from: bm.PageNr, thru: ctx.PageCount, title: bm.Title})
+	}
+
+	if len(spans) == 0 {
+		return nil, errors.New("split: no bookmarks available")
+	}
+
+	spans[0].from = 1
+
+	return spans, nil
+}
+
+// prepareSpanWrite sets up ctx for writing the pages of span.
+func prepareSpanWrite(ctx *pdf.Context, span pageSpan) {
+
+	ctx.ResetWriteContext()
+
+	w := ctx.Write
+	w.Command = "Split"
+	w.ExtractPages = pdf.IntSet{}
+
+	for i := span.from; i <= span.thru; i++ {
+		w.ExtractPages[i] = true
+	}
+}
+
+// spanSize returns the size of a file holding the pages of span.
+func spanSize(ctx *pdf.Context, span pageSpan) (int64, error) {
+
+	prepareSpanWrite(ctx, span)
+
+	var buf bytes.Buffer
+
+	err := pdf.Write(ctx, &buf)
+	if err != nil {
+		return 0, err
+	}
+
+	return int64(buf.Len()), nil
+}
+
+// spansForSize returns the spans resulting from putting as many pages into each file as maxSize allows.
+// A page exceeding maxSize on its own goes into a file by itself.
+func spansForSize(ctx *pdf.Context, maxSize int64) ([]pageSpan, error) {
+
+	var spans []pageSpan
+
+	for from := 1; from <= ctx.PageCount; {
+
+		fits := func(thru int) (bool, error) {
+			size, err := spanSize(ctx, pageSpan{from: from, thru: thru})
+			return size <= maxSize, err
+		}
+
+		// Grow the span exponentially, then narrow down its end by bisection.
+		good, bad := from, ctx.PageCount+1
+
+		for n := 1; from+n <= ctx.PageCount; n *= 2 {
+			ok, err := fits(from + n)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				bad = from + n
+				break
+			}
+			good = from + n
+		}
+
+		for bad-good > 1 {
+			m := (good + bad) / 2
+			ok, err := fits(m)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				good = m
+			} else {
+				bad = m
+			}
+		}
+
+		spans = append(spans, pageSpan{from: from, thru: good})
+		from = good + 1
+	}
+
+	return spans, nil
+}
+
+// spanFileName returns a file name for span derived from its bookmark or its page range.
+func spanFileName(ctx *pdf.Context, span pageSpan, used map[string]bool) string {
+
+	fileName := strings.Map(func(r rune) rune {
+		if r < ' ' || strings.ContainsRune(`/\:*?"<>|`, r) {
+			return '_'
+		}
+		return r
+	}, strings.TrimSpace(span.title))
+
+	if fileName == "" {
+		pages := strconv.Itoa(span.from)
+		if span.thru > span.from {
+			pages += "-" + strconv.Itoa(span.thru)
+		}
+		fileName = strings.TrimSuffix(pagesFileName(ctx, pages), ".pdf")
+	}
+
+	// Bookmark titles need not be unique.
+	name := fileName
+	for i := 2; used[strings.ToLower(name)]; i++ {
+		name = fileName + "_" + strconv.Itoa(i)
+	}
+	used[strings.ToLower(name)] = true
+
+	return name + ".pdf"
+}
+
+func writePageSpans(ctx *pdf.Context, spans []pageSpan, create pdf.FileCreator) error {
+
+	used := map[string]bool{}
+
+	for _, span := range spans {
+
+		prepareSpanWrite(ctx, span)
+
+		err := writePDF(ctx, spanFileName(ctx, span, used), create)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func splitSpans(ctx *pdf.Context, cmd *Command) ([]pageSpan, error) {
+
+	switch cmd.SplitMode {
+
+	case SplitBookmarks:
+		return spansForBookmarks(ctx)
+
+	case SplitPageNrs:
+		return spansForPageNrs(ctx.PageCount, cmd.PageNrs)
+
+	case SplitSize:
+		if cmd.MaxSize <= 0 {
+			return nil, errors.New("split: maximum file size must be positive")
+		}
+		return spansForSize(ctx, cmd.MaxSize)
+
+	}
+
+	if cmd.Span < 1 {
+		return nil, errors.New("split: span must be positive")
+	}
+
+	return spansForSpan(ctx.PageCount, cmd.Span), nil
+}
+
+func doSplit(ctx *pdf.Context, cmd *Command, create pdf.FileCreator) error {
+
+	if cmd.SplitMode == SplitSpan && cmd.Span == 1 {
+		return writeSinglePagePDFs(ctx, nil, create)
+	}
+
+	spans, err := splitSpans(ctx, cmd)
+	if err != nil {
+		return err
+	}
+
+	return writePageSpans(ctx, spans, create)
+}
+
+// Split generates a sequence of PDF files in dirOut for the pages of inFile.
+// By default there is one file for every page, alternatively files start every Span pages,
+// at each top level bookmark, at given pages or whenever the next page would exceed a maximum file size.
 func Split(cmd *Command) ([]string, error) {
 
 	fileIn := *cmd.InFile
@@ -259,9 +552,11 @@ func Split(cmd *Command) ([]string, error) {
 		return nil, err
 	}
 
+	defer ctx.Close()
+
 	fromWrite := time.Now()
 
-	err = writeSinglePagePDFs(ctx, nil, dirOut)
+	err = doSplit(ctx, cmd, pdfFileCreator(dirOut))
 	if err != nil {
 		return nil, err
 	}
@@ -282,39 +577,129 @@ func Split(cmd *Command) ([]string, error) {
 	return nil, nil
 }
 
-// appendTo appends fileIn to ctxDest's page tree.
-func appendTo(fileIn string, ctxDest *pdf.Context) error {
+// keepPages removes all pages not selected by pageSelection.
+func keepPages(ctx *pdf.Context, pageSelection []string) error {
+
+	pages, err := pagesForPageSelection(ctx.PageCount, pageSelection)
+	if err != nil {
+		return err
+	}
+
+	if len(pages) == 0 {
+		return errors.Errorf("no pages selected for %s", ctx.Read.FileName)
+	}
+
+	pagesToRemove := pdf.IntSet{}
+	for i := 1; i <= ctx.PageCount; i++ {
+		if !pages[i] {
+			pagesToRemove[i] = true
+		}
+	}
+
+	if len(pagesToRemove) == 0 {
+		return nil
+	}
+
+	return pdf.RemovePages(ctx.XRefTable, pagesToRemove)
+}
+
+// readForMerge builds a Context for fileIn holding the pages selected by pageSelection.
+// If bookmark is true the outline of fileIn gets wrapped into a single bookmark for this file.
+func readForMerge(fileIn string, pageSelection []string, bookmark bool, config *pdf.Configuration) (*pdf.Context, error) {
+
+	ctx, _, _, err := readAndValidate(fileIn, config, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	if len(pageSelection) > 0 {
+		if err = keepPages(ctx, pageSelection); err != nil {
+			ctx.Close()
+			return nil, err
+		}
+	}
+
+	if bookmark {
+		title := strings.TrimSuffix(filepath.Base(fileIn), filepath.Ext(fileIn))
+		if err = pdf.AddDocumentBookmark(ctx.XRefTable, title); err != nil {
+			ctx.Close()
+			return nil, err
+		}
+	}
+
+	return ctx, nil
+}
+
+// MergeMode determines how Merge combines the pages of the input files.
+type MergeMode int
+
+// The merge modes.
+const (
+	MergeAppend      MergeMode = iota // concatenate all files.
+	MergeZip                          // interleave the pages of 2 files.
+	MergeZipReversed                  // interleave the pages of 2 files taking the pages of the second file back to front.
+)
+
+// appendTo appends fileIn to ctxDest's page tree or interleaves it with ctxDest's pages.
+func appendTo(fileIn string, pageSelection []string, bookmark bool, mergeMode MergeMode, ctxDest *pdf.Context) error {
 
 	log.Stats.Printf("appendTo: appending %s to %s\n", fileIn, ctxDest.Read.FileName)
 
 	// Build a Context for fileIn.
-	ctxSource, _, _, err := readAndValidate(fileIn, ctxDest.Configuration, time.Now())
+	ctxSource, err := readForMerge(fileIn, pageSelection, bookmark, ctxDest.Configuration)
 	if err != nil {
 		return err
 	}
 
+	defer ctxSource.Close()
+
 	// Merge the source context into the dest context.
 	fmt.Printf("merging in %s ...\n", fileIn)
+
+	if mergeMode != MergeAppend {
+		return pdf.ZipXRefTables(ctxSource, ctxDest, mergeMode == MergeZipReversed)
+	}
+
 	return pdf.MergeXRefTables(ctxSource, ctxDest)
 }
 
 // Merge some PDF files together and write the result to fileOut.
 // This corresponds to concatenating these files in the order specified by filesIn.
 // The first entry of filesIn serves as the destination xRefTable where all the remaining files gets merged into.
+// cmd.FilePages optionally restricts the pages taken from each file,
+// cmd.Bookmarks adds a top level bookmark for each file.
+// For the zip modes filesIn holds the 2 files whose pages get interleaved.
 func Merge(cmd *Command) ([]string, error) {
 
 	filesIn := cmd.InFiles
 	fileOut := *cmd.OutFile
 	config := cmd.Config
 
+	if cmd.MergeMode != MergeAppend && len(filesIn) != 2 {
+		return nil, errors.New("Merge: zip needs exactly 2 input files")
+	}
+
+	if len(cmd.FilePages) > len(filesIn) {
+		return nil, errors.New("Merge: more page selections than input files")
+	}
+
+	pageSelection := func(i int) []string {
+		if i < len(cmd.FilePages) {
+			return cmd.FilePages[i]
+		}
+		return nil
+	}
+
 	fmt.Printf("merging into %s: %v\n", fileOut, filesIn)
 	//logErrorAPI.Printf("Merge: filesIn: %v\n", filesIn)
 
-	ctxDest, _, _, err := readAndValidate(filesIn[0], config, time.Now())
+	ctxDest, err := readForMerge(filesIn[0], pageSelection(0), cmd.Bookmarks, config)
 	if err != nil {
 		return nil, err
 	}
 
+	defer ctxDest.Close()
+
 	if ctxDest.XRefTable.Version() < pdf.V15 {
 		v, _ := pdf.PDFVersion("1.5")
 		ctxDest.XRefTable.RootVersion = &v
@@ -322,8 +707,8 @@ func Merge(cmd *Command) ([]string, error) {
 	}
 
 	// Repeatedly merge files into fileDest's xref table.
-	for _, f := range filesIn[1:] {
-		err = appendTo(f, ctxDest)
+	for i, f := range filesIn[1:] {
+		err = appendTo(f, pageSelection(i+1), cmd.Bookmarks, cmd.MergeMode, ctxDest)
 		if err != nil {
 			return nil, err
 		}
@@ -355,6 +740,14 @@ func Merge(cmd *Command) ([]string, error) {
 	return nil, nil
 }
 
+// writeOutputFile writes b to the file create returns for fileName.
+func writeOutputFile(create pdf.FileCreator, fileName string, b []byte) error {
+	return pdf.WriteOutputFile(create, fileName, func(w io.Writer) error {
+		_, err := w.Write(b)
+		return err
+	})
+}
+
 func imageObjNrs(ctx *pdf.Context, page int) []int {
 
 	// TODO Exclude SMask image objects.
@@ -370,11 +763,11 @@ func imageObjNrs(ctx *pdf.Context, page int) []int {
 	return o
 }
 
-func imageFilenameWithoutExtension(dir, resID string, pageNr, objNr int) string {
-	return filepath.Join(dir, fmt.Sprintf("%s_%d_%d", resID, pageNr, objNr))
+func imageFilenameWithoutExtension(resID string, pageNr, objNr int) string {
+	return fmt.Sprintf("%s_%d_%d", resID, pageNr, objNr)
 }
 
-func doExtractImages(ctx *pdf.Context, selectedPages pdf.IntSet) error {
+func doExtractImages(ctx *pdf.Context, selectedPages pdf.IntSet, create pdf.FileCreator) error {
 
 	visited := pdf.IntSet{}
 
@@ -401,9 +794,9 @@ func doExtractImages(ctx *pdf.Context, selectedPages pdf.IntSet) error {
 					continue
 				}
 
-				filename := imageFilenameWithoutExtension(ctx.Write.DirName, io.ResourceNames[0], pageNr, objNr)
+				filename := imageFilenameWithoutExtension(io.ResourceNames[0], pageNr, objNr)
 
-				_, err = pdf.WriteImage(ctx.XRefTable, filename, io.ImageDict, objNr)
+				_, err = pdf.WriteImageTo(ctx.XRefTable, create, filename, io.ImageDict, objNr)
 				if err != nil {
 					return err
 				}
@@ -434,6 +827,8 @@ func ExtractImages(cmd *Command) ([]string, error) {
 		return nil, err
 	}
 
+	defer ctx.Close()
+
 	fromWrite := time.Now()
 
 	pages, err := pagesForPageSelection(ctx.PageCount, pageSelection)
@@ -443,8 +838,7 @@ func ExtractImages(cmd *Command) ([]string, error) {
 
 	ensureSelectedPages(ctx, &pages)
 
-	ctx.Write.DirName = dirOut
-	err = doExtractImages(ctx, pages)
+	err = doExtractImages(ctx, pages, pdf.DirFileCreator(dirOut))
 	if err != nil {
 		return nil, err
 	}
@@ -476,7 +870,7 @@ func fontObjNrs(ctx *pdf.Context, page int) []int {
 	return o
 }
 
-func doExtractFonts(ctx *pdf.Context, selectedPages pdf.IntSet) error {
+func doExtractFonts(ctx *pdf.Context, selectedPages pdf.IntSet, create pdf.FileCreator) error {
 
 	visited := pdf.IntSet{}
 
@@ -503,9 +897,9 @@ func doExtractFonts(ctx *pdf.Context, selectedPages pdf.IntSet) error {
 					continue
 				}
 
-				fileName := fmt.Sprintf("%s/%s_%d_%d.%s", ctx.Write.DirName, fo.ResourceNames[0], p, objNr, fo.Extension)
+				fileName := fmt.Sprintf("%s_%d_%d.%s", fo.ResourceNames[0], p, objNr, fo.Extension)
 
-				err = ioutil.WriteFile(fileName, fo.Data, os.ModePerm)
+				err = writeOutputFile(create, fileName, fo.Data)
 				if err != nil {
 					return err
 				}
@@ -536,6 +930,8 @@ func ExtractFonts(cmd *Command) ([]string, error) {
 		return nil, err
 	}
 
+	defer ctx.Close()
+
 	fromWrite := time.Now()
 
 	pages, err := pagesForPageSelection(ctx.PageCount, pageSelection)
@@ -545,8 +941,7 @@ func ExtractFonts(cmd *Command) ([]string, error) {
 
 	ensureSelectedPages(ctx, &pages)
 
-	ctx.Write.DirName = dirOut
-	err = doExtractFonts(ctx, pages)
+	err = doExtractFonts(ctx, pages, pdf.DirFileCreator(dirOut))
 	if err != nil {
 		return nil, err
 	}
@@ -582,6 +977,8 @@ func ExtractPages(cmd *Command) ([]string, error) {
 		return nil, err
 	}
 
+	defer ctx.Close()
+
 	fromWrite := time.Now()
 
 	pages, err := pagesForPageSelection(ctx.PageCount, pageSelection)
@@ -589,7 +986,7 @@ func ExtractPages(cmd *Command) ([]string, error) {
 		return nil, err
 	}
 
-	err = writeSinglePagePDFs(ctx, pages, dirOut)
+	err = writeSinglePagePDFs(ctx, pages, pdfFileCreator(dirOut))
 	if err != nil {
 		return nil, err
 	}
@@ -673,7 +1070,7 @@ func contentObjNrs(ctx *pdf.Context, page int) ([]int, error) {
 	return objNrs, nil
 }
 
-func doExtractContent(ctx *pdf.Context, selectedPages pdf.IntSet) error {
+func doExtractContent(ctx *pdf.Context, selectedPages pdf.IntSet, create pdf.FileCreator) error {
 
 	visited := pdf.IntSet{}
 
@@ -709,9 +1106,9 @@ func doExtractContent(ctx *pdf.Context, selectedPages pdf.IntSet) error {
 					continue
 				}
 
-				fileName := fmt.Sprintf("%s/%d_%d.txt", ctx.Write.DirName, p, objNr)
+				fileName := fmt.Sprintf("%d_%d.txt", p, objNr)
 
-				err = ioutil.WriteFile(fileName, b, os.ModePerm)
+				err = writeOutputFile(create, fileName, b)
 				if err != nil {
 					return err
 				}
@@ -742,6 +1139,8 @@ func ExtractContent(cmd *Command) ([]string, error) {
 		return nil, err
 	}
 
+	defer ctx.Close()
+
 	fromWrite := time.Now()
 
 	pages, err := pagesForPageSelection(ctx.PageCount, pageSelection)
@@ -751,8 +1150,7 @@ func ExtractContent(cmd *Command) ([]string, error) {
 
 	ensureSelectedPages(ctx, &pages)
 
-	ctx.Write.DirName = dirOut
-	err = doExtractContent(ctx, pages)
+	err = doExtractContent(ctx, pages, pdf.DirFileCreator(dirOut))
 	if err != nil {
 		return nil, err
 	}
@@ -771,7 +1169,7 @@ func ExtractContent(cmd *Command) ([]string, error) {
 	return nil, nil
 }
 
-func extractMetadataStream(ctx *pdf.Context, obj pdf.Object, objNr int, dt string) error {
+func extractMetadataStream(ctx *pdf.Context, obj pdf.Object, objNr int, dt string, create pdf.FileCreator) error {
 
 	indRef, _ := obj.(pdf.IndirectRef)
 	sObjNr := indRef.ObjectNumber.Value()
@@ -784,12 +1182,12 @@ func extractMetadataStream(ctx *pdf.Context, obj pdf.Object, objNr int, dt strin
 		return nil
 	}
 
-	fileName := fmt.Sprintf("%s/%d_%s.txt", ctx.Write.DirName, objNr, dt)
+	fileName := fmt.Sprintf("%d_%s.txt", objNr, dt)
 
-	return ioutil.WriteFile(fileName, b, os.ModePerm)
+	return writeOutputFile(create, fileName, b)
 }
 
-func doExtractMetadata(ctx *pdf.Context, selectedPages pdf.IntSet) error {
+func doExtractMetadata(ctx *pdf.Context, create pdf.FileCreator) error {
 
 	for k, v := range ctx.XRefTable.Table {
 		if v.Free || v.Compressed {
@@ -809,7 +1207,7 @@ func doExtractMetadata(ctx *pdf.Context, selectedPages pdf.IntSet) error {
 				dt = *d.Type()
 			}
 
-			err := extractMetadataStream(ctx, obj, k, dt)
+			err := extractMetadataStream(ctx, obj, k, dt, create)
 			if err != nil {
 				return err
 			}
@@ -826,7 +1224,7 @@ func doExtractMetadata(ctx *pdf.Context, selectedPages pdf.IntSet) error {
 				dt = *d.Type()
 			}
 
-			err := extractMetadataStream(ctx, obj, k, dt)
+			err := extractMetadataStream(ctx, obj, k, dt, create)
 			if err != nil {
 				return err
 			}
@@ -842,7 +1240,6 @@ func ExtractMetadata(cmd *Command) ([]string, error) {
 
 	fileIn := *cmd.InFile
 	dirOut := *cmd.OutDir
-	pageSelection := cmd.PageSelection
 	config := cmd.Config
 
 	fromStart := time.Now()
@@ -854,17 +1251,11 @@ func ExtractMetadata(cmd *Command) ([]string, error) {
 		return nil, err
 	}
 
-	fromWrite := time.Now()
-
-	pages, err := pagesForPageSelection(ctx.PageCount, pageSelection)
-	if err != nil {
-		return nil, err
-	}
+	defer ctx.Close()
 
-	ensureSelectedPages(ctx, &pages)
+	fromWrite := time.Now()
 
-	ctx.Write.DirName = dirOut
-	err = doExtractMetadata(ctx, pages)
+	err = doExtractMetadata(ctx, pdf.DirFileCreator(dirOut))
 	if err != nil {
 		return nil, err
 	}
@@ -902,6 +1293,8 @@ func Trim(cmd *Command) ([]string, error) {
 		return nil, err
 	}
 
+	defer ctx.Close()
+
 	fromWrite := time.Now()
 
 	pages, err := pagesForPageSelection(ctx.PageCount, pageSelection)
@@ -973,6 +1366,8 @@ func ListAttachments(fileIn string, config *pdf.Configuration) ([]string, error)
 		return nil, err
 	}
 
+	defer ctx.Close()
+
 	fromWrite := time.Now()
 
 	list, err := pdf.AttachList(ctx.XRefTable)
@@ -1004,6 +1399,8 @@ func AddAttachments(fileIn string, files []string, config *pdf.Configuration) er
 		return err
 	}
 
+	defer ctx.Close()
+
 	fmt.Printf("adding %d attachments to %s ...\n", len(files), fileIn)
 
 	from := time.Now()
@@ -1059,6 +1456,8 @@ func RemoveAttachments(fileIn string, files []string, config *pdf.Configuration)
 		return err
 	}
 
+	defer ctx.Close()
+
 	if len(files) > 0 {
 		fmt.Printf("removing %d attachments from %s ...\n", len(files), fileIn)
 	} else {
@@ -1120,6 +1519,8 @@ func ExtractAttachments(fileIn, dirOut string, files []string, config *pdf.Confi
 		return err
 	}
 
+	defer ctx.Close()
+
 	fromWrite := time.Now()
 
 	ctx.Write.DirName = dirOut
@@ -1154,6 +1555,8 @@ func ListPermissions(fileIn string, config *pdf.Configuration) ([]string, error)
 		return nil, err
 	}
 
+	defer ctx.Close()
+
 	fromList := time.Now()
 	list := pdf.Permissions(ctx)
 	durList := time.Since(fromList).Seconds()
@@ -1181,6 +1584,8 @@ func AddPermissions(fileIn string, config *pdf.Configuration) error {
 		return err
 	}
 
+	defer ctx.Close()
+
 	fmt.Printf("adding permissions to %s ...\n", fileIn)
 
 	fromWrite := time.Now()
@@ -1227,6 +1632,8 @@ func AddWatermarks(cmd *Command) ([]string, error) {
 		return nil, err
 	}
 
+	defer ctx.Close()
+
 	fmt.Printf("%sing %s ...\n", wm.OnTopString(), fileIn)
 
 	from := time.Now()
@@ -1272,3 +1679,1584 @@ func AddWatermarks(cmd *Command) ([]string, error) {
 
 	return nil, nil
 }
+
+// Info returns the essential facts of a PDF file like page count, page boundaries, document information and encryption.
+func Info(fileIn string, config *pdf.Configuration) (*pdf.PDFInfo, error) {
+
+	fromStart := time.Now()
+
+	ctx, durRead, durVal, err := readAndValidate(fileIn, config, fromStart)
+	if err != nil {
+		return nil, err
+	}
+
+	defer ctx.Close()
+
+	fromInfo := time.Now()
+
+	info, err := pdf.DocumentInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	durInfo := time.Since(fromInfo).Seconds()
+	durTotal := time.Since(fromStart).Seconds()
+
+	log.Stats.Printf("XRefTable:\n%s\n", ctx)
+	log.Stats.Println("Timing:")
+	log.Stats.Printf("read                 : %6.3fs  %4.1f%%\n", durRead, durRead/durTotal*100)
+	log.Stats.Printf("validate             : %6.3fs  %4.1f%%\n", durVal, durVal/durTotal*100)
+	log.Stats.Printf("info                 : %6.3fs  %4.1f%%\n", durInfo, durInfo/durTotal*100)
+	log.Stats.Printf("total processing time: %6.3fs\n\n", durTotal)
+
+	return info, nil
+}
+
+// Rotate rotates selected pages of fileIn clockwise by a multiple of 90 degrees and writes the result to fileOut.
+func Rotate(cmd *Command) ([]string, error) {
+
+	fileIn := *cmd.InFile
+	fileOut := *cmd.OutFile
+	pageSelection := cmd.PageSelection
+	config := cmd.Config
+
+	fromStart := time.Now()
+
+	ctx, durRead, durVal, durOpt, err := readValidateAndOptimize(fileIn, config, fromStart)
+	if err != nil {
+		return nil, err
+	}
+
+	defer ctx.Close()
+
+	fmt.Printf("rotating %s ...\n", fileIn)
+
+	from := time.Now()
+
+	pages, err := pagesForPageSelection(ctx.PageCount, pageSelection)
+	if err != nil {
+		return nil, err
+	}
+
+	ensureSelectedPages(ctx, &pages)
+
+	err = pdf.RotatePages(ctx.XRefTable, pages, cmd.Rotation)
+	if err != nil {
+		return nil, err
+	}
+
+	durRotate := time.Since(from).Seconds()
+
+	fromWrite := time.Now()
+
+	dirName, fileName := filepath.Split(fileOut)
+	ctx.Write.DirName = dirName
+	ctx.Write.FileName = fileName
+
+	err = Write(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	durWrite := time.Since(fromWrite).Seconds()
+	durTotal := time.Since(fromStart).Seconds()
+
+	log.Stats.Printf("XRefTable:\n%s\n", ctx)
+	log.Stats.Println("Timing:")
+	log.Stats.Printf("read                 : %6.3fs  %4.1f%%\n", durRead, durRead/durTotal*100)
+	log.Stats.Printf("validate             : %6.3fs  %4.1f%%\n", durVal, durVal/durTotal*100)
+	log.Stats.Printf("optimize             : %6.3fs  %4.1f%%\n", durOpt, durOpt/durTotal*100)
+	log.Stats.Printf("rotate               : %6.3fs  %4.1f%%\n", durRotate, durRotate/durTotal*100)
+	log.Stats.Printf("write                : %6.3fs  %4.1f%%\n", durWrite, durWrite/durTotal*100)
+	log.Stats.Printf("total processing time: %6.3fs\n\n", durTotal)
+	ctx.Read.LogStats(ctx.Optimized)
+	ctx.Write.LogStats()
+
+	return nil, nil
+}
+
+// ensureInsertPosition defaults to all pages for inserting blank pages
+// and to the first or last page for inserting the pages of a source file.
+func ensureInsertPosition(ctx, ctxSource *pdf.Context, selectedPages *pdf.IntSet, before bool) {
+
+	if ctxSource == nil || len(*selectedPages) > 0 {
+		ensureSelectedPages(ctx, selectedPages)
+		return
+	}
+
+	pageNr := ctx.PageCount
+	if before {
+		pageNr = 1
+	}
+
+	*selectedPages = pdf.IntSet{pageNr: true}
+}
+
+// InsertPages inserts blank pages or the pages of another PDF file before or after selected pages of fileIn and writes the result to fileOut.
+func InsertPages(cmd *Command) ([]string, error) {
+
+	fileIn := *cmd.InFile
+	fileOut := *cmd.OutFile
+	pageSelection := cmd.PageSelection
+	config := cmd.Config
+
+	fromStart := time.Now()
+
+	ctx, durRead, durVal, err := readAndValidate(fileIn, config, fromStart)
+	if err != nil {
+		return nil, err
+	}
+
+	defer ctx.Close()
+
+	var ctxSource *pdf.Context
+	if len(cmd.InFiles) > 0 {
+		ctxSource, _, _, err = readAndValidate(cmd.InFiles[0], config, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		defer ctxSource.Close()
+	}
+
+	fmt.Printf("inserting pages into %s ...\n", fileIn)
+
+	from := time.Now()
+
+	pages, err := pagesForPageSelection(ctx.PageCount, pageSelection)
+	if err != nil {
+		return nil, err
+	}
+
+	ensureInsertPosition(ctx, ctxSource, &pages, cmd.Before)
+
+	err = pdf.InsertPages(ctxSource, ctx, pages, cmd.Before)
+	if err != nil {
+		return nil, err
+	}
+
+	err = pdf.OptimizeXRefTable(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	err = validate.XRefTable(ctx.XRefTable)
+	if err != nil {
+		return nil, err
+	}
+
+	durInsert := time.Since(from).Seconds()
+
+	fromWrite := time.Now()
+
+	dirName, fileName := filepath.Split(fileOut)
+	ctx.Write.DirName = dirName
+	ctx.Write.FileName = fileName
+
+	err = Write(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	durWrite := time.Since(fromWrite).Seconds()
+	durTotal := time.Since(fromStart).Seconds()
+
+	log.Stats.Printf("XRefTable:\n%s\n", ctx)
+	log.Stats.Println("Timing:")
+	log.Stats.Printf("read                 : %6.3fs  %4.1f%%\n", durRead, durRead/durTotal*100)
+	log.Stats.Printf("validate             : %6.3fs  %4.1f%%\n", durVal, durVal/durTotal*100)
+	log.Stats.Printf("insert pages         : %6.3fs  %4.1f%%\n", durInsert, durInsert/durTotal*100)
+	log.Stats.Printf("write                : %6.3fs  %4.1f%%\n", durWrite, durWrite/durTotal*100)
+	log.Stats.Printf("total processing time: %6.3fs\n\n", durTotal)
+	ctx.Read.LogStats(ctx.Optimized)
+	ctx.Write.LogStats()
+
+	return nil, nil
+}
+
+// RemovePages removes selected pages from fileIn and writes the result to fileOut.
+func RemovePages(cmd *Command) ([]string, error) {
+
+	fileIn := *cmd.InFile
+	fileOut := *cmd.OutFile
+	pageSelection := cmd.PageSelection
+	config := cmd.Config
+
+	fromStart := time.Now()
+
+	ctx, durRead, durVal, durOpt, err := readValidateAndOptimize(fileIn, config, fromStart)
+	if err != nil {
+		return nil, err
+	}
+
+	defer ctx.Close()
+
+	fmt.Printf("removing pages from %s ...\n", fileIn)
+
+	from := time.Now()
+
+	pages, err := pagesForPageSelection(ctx.PageCount, pageSelection)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(pages) == 0 {
+		return nil, errors.New("remove pages: missing page selection")
+	}
+
+	err = pdf.RemovePages(ctx.XRefTable, pages)
+	if err != nil {
+		return nil, err
+	}
+
+	durRemove := time.Since(from).Seconds()
+
+	fromWrite := time.Now()
+
+	dirName, fileName := filepath.Split(fileOut)
+	ctx.Write.DirName = dirName
+	ctx.Write.FileName = fileName
+
+	err = Write(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	durWrite := time.Since(fromWrite).Seconds()
+	durTotal := time.Since(fromStart).Seconds()
+
+	log.Stats.Printf("XRefTable:\n%s\n", ctx)
+	log.Stats.Println("Timing:")
+	log.Stats.Printf("read                 : %6.3fs  %4.1f%%\n", durRead, durRead/durTotal*100)
+	log.Stats.Printf("validate             : %6.3fs  %4.1f%%\n", durVal, durVal/durTotal*100)
+	log.Stats.Printf("optimize             : %6.3fs  %4.1f%%\n", durOpt, durOpt/durTotal*100)
+	log.Stats.Printf("remove pages         : %6.3fs  %4.1f%%\n", durRemove, durRemove/durTotal*100)
+	log.Stats.Printf("write                : %6.3fs  %4.1f%%\n", durWrite, durWrite/durTotal*100)
+	log.Stats.Printf("total processing time: %6.3fs\n\n", durTotal)
+	ctx.Read.LogStats(ctx.Optimized)
+	ctx.Write.LogStats()
+
+	return nil, nil
+}
+
+// NUp places n selected pages of fileIn on each page of a given paper size and writes the result to fileOut.
+func NUp(cmd *Command) ([]string, error) {
+
+	fileIn := *cmd.InFile
+	fileOut := *cmd.OutFile
+	pageSelection := cmd.PageSelection
+	config := cmd.Config
+
+	fromStart := time.Now()
+
+	ctx, durRead, durVal, durOpt, err := readValidateAndOptimize(fileIn, config, fromStart)
+	if err != nil {
+		return nil, err
+	}
+
+	defer ctx.Close()
+
+	fmt.Printf("n-up %s ...\n", fileIn)
+
+	from := time.Now()
+
+	pages, err := pagesForPageSelection(ctx.PageCount, pageSelection)
+	if err != nil {
+		return nil, err
+	}
+
+	ensureSelectedPages(ctx, &pages)
+
+	err = pdf.NUpPages(ctx.XRefTable, pages, cmd.NUp)
+	if err != nil {
+		return nil, err
+	}
+
+	durNUp := time.Since(from).Seconds()
+
+	fromWrite := time.Now()
+
+	dirName, fileName := filepath.Split(fileOut)
+	ctx.Write.DirName = dirName
+	ctx.Write.FileName = fileName
+
+	err = Write(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	durWrite := time.Since(fromWrite).Seconds()
+	durTotal := time.Since(fromStart).Seconds()
+
+	log.Stats.Printf("XRefTable:\n%s\n", ctx)
+	log.Stats.Println("Timing:")
+	log.Stats.Printf("read                 : %6.3fs  %4.1f%%\n", durRead, durRead/durTotal*100)
+	log.Stats.Printf("validate             : %6.3fs  %4.1f%%\n", durVal, durVal/durTotal*100)
+	log.Stats.Printf("optimize             : %6.3fs  %4.1f%%\n", durOpt, durOpt/durTotal*100)
+	log.Stats.Printf("n-up                 : %6.3fs  %4.1f%%\n", durNUp, durNUp/durTotal*100)
+	log.Stats.Printf("write                : %6.3fs  %4.1f%%\n", durWrite, durWrite/durTotal*100)
+	log.Stats.Printf("total processing time: %6.3fs\n\n", durTotal)
+	ctx.Read.LogStats(ctx.Optimized)
+	ctx.Write.LogStats()
+
+	return nil, nil
+}
+
+// Booklet arranges the selected pages of fileIn as a booklet and writes the result to fileOut.
+func Booklet(cmd *Command) ([]string, error) {
+
+	fileIn := *cmd.InFile
+	fileOut := *cmd.OutFile
+	pageSelection := cmd.PageSelection
+	config := cmd.Config
+
+	fromStart := time.Now()
+
+	ctx, durRead, durVal, durOpt, err := readValidateAndOptimize(fileIn, config, fromStart)
+	if err != nil {
+		return nil, err
+	}
+
+	defer ctx.Close()
+
+	fmt.Printf("booklet %s ...\n", fileIn)
+
+	from := time.Now()
+
+	pages, err := pagesForPageSelection(ctx.PageCount, pageSelection)
+	if err != nil {
+		return nil, err
+	}
+
+	ensureSelectedPages(ctx, &pages)
+
+	err = pdf.BookletPages(ctx.XRefTable, pages, cmd.Booklet)
+	if err != nil {
+		return nil, err
+	}
+
+	durBooklet := time.Since(from).Seconds()
+
+	fromWrite := time.Now()
+
+	dirName, fileName := filepath.Split(fileOut)
+	ctx.Write.DirName = dirName
+	ctx.Write.FileName = fileName
+
+	err = Write(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	durWrite := time.Since(fromWrite).Seconds()
+	durTotal := time.Since(fromStart).Seconds()
+
+	log.Stats.Printf("XRefTable:\n%s\n", ctx)
+	log.Stats.Println("Timing:")
+	log.Stats.Printf("read                 : %6.3fs  %4.1f%%\n", durRead, durRead/durTotal*100)
+	log.Stats.Printf("validate             : %6.3fs  %4.1f%%\n", durVal, durVal/durTotal*100)
+	log.Stats.Printf("optimize             : %6.3fs  %4.1f%%\n", durOpt, durOpt/durTotal*100)
+	log.Stats.Printf("booklet              : %6.3fs  %4.1f%%\n", durBooklet, durBooklet/durTotal*100)
+	log.Stats.Printf("write                : %6.3fs  %4.1f%%\n", durWrite, durWrite/durTotal*100)
+	log.Stats.Printf("total processing time: %6.3fs\n\n", durTotal)
+	ctx.Read.LogStats(ctx.Optimized)
+	ctx.Write.LogStats()
+
+	return nil, nil
+}
+
+// Collect arranges the pages of fileIn in the order of an ordered page list and writes the result to fileOut.
+func Collect(cmd *Command) ([]string, error) {
+
+	fileIn := *cmd.InFile
+	fileOut := *cmd.OutFile
+	pageSelection := cmd.PageSelection
+	config := cmd.Config
+
+	fromStart := time.Now()
+
+	ctx, durRead, durVal, durOpt, err := readValidateAndOptimize(fileIn, config, fromStart)
+	if err != nil {
+		return nil, err
+	}
+
+	defer ctx.Close()
+
+	fmt.Printf("collecting %s ...\n", fileIn)
+
+	from := time.Now()
+
+	pageNrs, err := pagesForPageCollection(ctx.PageCount, pageSelection)
+	if err != nil {
+		return nil, err
+	}
+
+	err = pdf.CollectPages(ctx.XRefTable, pageNrs)
+	if err != nil {
+		return nil, err
+	}
+
+	durCollect := time.Since(from).Seconds()
+
+	fromWrite := time.Now()
+
+	dirName, fileName := filepath.Split(fileOut)
+	ctx.Write.DirName = dirName
+	ctx.Write.FileName = fileName
+
+	err = Write(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	durWrite := time.Since(fromWrite).Seconds()
+	durTotal := time.Since(fromStart).Seconds()
+
+	log.Stats.Printf("XRefTable:\n%s\n", ctx)
+	log.Stats.Println("Timing:")
+	log.Stats.Printf("read                 : %6.3fs  %4.1f%%\n", durRead, durRead/durTotal*100)
+	log.Stats.Printf("validate             : %6.3fs  %4.1f%%\n", durVal, durVal/durTotal*100)
+	log.Stats.Printf("optimize             : %6.3fs  %4.1f%%\n", durOpt, durOpt/durTotal*100)
+	log.Stats.Printf("collect              : %6.3fs  %4.1f%%\n", durCollect, durCollect/durTotal*100)
+	log.Stats.Printf("write                : %6.3fs  %4.1f%%\n", durWrite, durWrite/durTotal*100)
+	log.Stats.Printf("total processing time: %6.3fs\n\n", durTotal)
+	ctx.Read.LogStats(ctx.Optimized)
+	ctx.Write.LogStats()
+
+	return nil, nil
+}
+
+// ImportImages appends a page for each image file to fileOut creating fileOut if it does not exist yet.
+func ImportImages(cmd *Command) ([]string, error) {
+
+	fileOut := *cmd.OutFile
+	config := cmd.Config
+
+	imp := cmd.Import
+	if imp == nil {
+		imp = pdf.DefaultImport()
+	}
+
+	fromStart := time.Now()
+
+	var (
+		ctx                     *pdf.Context
+		durRead, durVal, durOpt float64
+		err                     error
+	)
+
+	if _, err = os.Stat(fileOut); err == nil {
+		ctx, durRead, durVal, durOpt, err = readValidateAndOptimize(fileOut, config, fromStart)
+	} else {
+		ctx, err = pdf.CreateContext(config)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	defer ctx.Close()
+
+	fmt.Printf("importing %d images into %s ...\n", len(cmd.InFiles), fileOut)
+
+	from := time.Now()
+
+	var imgs []io.Reader
+
+	for _, fileName := range cmd.InFiles {
+		f, err := os.Open(fileName)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		imgs = append(imgs, f)
+	}
+
+	err = pdf.ImportImages(ctx.XRefTable, imgs, imp)
+	if err != nil {
+		return nil, err
+	}
+
+	durImport := time.Since(from).Seconds()
+
+	fromWrite := time.Now()
+
+	dirName, fileName := filepath.Split(fileOut)
+	ctx.Write.DirName = dirName
+	ctx.Write.FileName = fileName
+
+	err = Write(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	durWrite := time.Since(fromWrite).Seconds()
+	durTotal := time.Since(fromStart).Seconds()
+
+	log.Stats.Printf("XRefTable:\n%s\n", ctx)
+	log.Stats.Println("Timing:")
+	log.Stats.Printf("read                 : %6.3fs  %4.1f%%\n", durRead, durRead/durTotal*100)
+	log.Stats.Printf("validate             : %6.3fs  %4.1f%%\n", durVal, durVal/durTotal*100)
+	log.Stats.Printf("optimize             : %6.3fs  %4.1f%%\n", durOpt, durOpt/durTotal*100)
+	log.Stats.Printf("import images        : %6.3fs  %4.1f%%\n", durImport, durImport/durTotal*100)
+	log.Stats.Printf("write                : %6.3fs  %4.1f%%\n", durWrite, durWrite/durTotal*100)
+	log.Stats.Printf("total processing time: %6.3fs\n\n", durTotal)
+	ctx.Read.LogStats(ctx.Optimized)
+	ctx.Write.LogStats()
+
+	return nil, nil
+}
+
+// ListBoxes returns the page boundaries in effect for the selected pages of fileIn.
+func ListBoxes(cmd *Command) ([]string, error) {
+
+	fileIn := *cmd.InFile
+	config := cmd.Config
+
+	fromStart := time.Now()
+
+	ctx, durRead, durVal, err := readAndValidate(fileIn, config, fromStart)
+	if err != nil {
+		return nil, err
+	}
+
+	defer ctx.Close()
+
+	from := time.Now()
+
+	pages, err := pagesForPageSelection(ctx.PageCount, cmd.PageSelection)
+	if err != nil {
+		return nil, err
+	}
+
+	ensureSelectedPages(ctx, &pages)
+
+	list, err := pdf.ListBoxes(ctx.XRefTable, pages)
+	if err != nil {
+		return nil, err
+	}
+
+	durList := time.Since(from).Seconds()
+	durTotal := time.Since(fromStart).Seconds()
+
+	log.Stats.Printf("XRefTable:\n%s\n", ctx)
+	log.Stats.Println("Timing:")
+	log.Stats.Printf("read                 : %6.3fs  %4.1f%%\n", durRead, durRead/durTotal*100)
+	log.Stats.Printf("validate             : %6.3fs  %4.1f%%\n", durVal, durVal/durTotal*100)
+	log.Stats.Printf("list boxes           : %6.3fs  %4.1f%%\n", durList, durList/durTotal*100)
+	log.Stats.Printf("total processing time: %6.3fs\n\n", durTotal)
+
+	return list, nil
+}
+
+// changeBoxes applies f to the selected pages of fileIn and writes the result to fileOut.
+func changeBoxes(cmd *Command, f func(xRefTable *pdf.XRefTable, selectedPages pdf.IntSet) error) ([]string, error) {
+
+	fileIn := *cmd.InFile
+	fileOut := *cmd.OutFile
+	config := cmd.Config
+
+	fromStart := time.Now()
+
+	ctx, durRead, durVal, durOpt, err := readValidateAndOptimize(fileIn, config, fromStart)
+	if err != nil {
+		return nil, err
+	}
+
+	defer ctx.Close()
+
+	fmt.Printf("changing page boundaries of %s ...\n", fileIn)
+
+	from := time.Now()
+
+	pages, err := pagesForPageSelection(ctx.PageCount, cmd.PageSelection)
+	if err != nil {
+		return nil, err
+	}
+
+	ensureSelectedPages(ctx, &pages)
+
+	err = f(ctx.XRefTable, pages)
+	if err != nil {
+		return nil, err
+	}
+
+	durBoxes := time.Since(from).Seconds()
+
+	fromWrite := time.Now()
+
+	dirName, fileName := filepath.Split(fileOut)
+	ctx.Write.DirName = dirName
+	ctx.Write.FileName = fileName
+
+	err = Write(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	durWrite := time.Since(fromWrite).Seconds()
+	durTotal := time.Since(fromStart).Seconds()
+
+	log.Stats.Printf("XRefTable:\n%s\n", ctx)
+	log.Stats.Println("Timing:")
+	log.Stats.Printf("read                 : %6.3fs  %4.1f%%\n", durRead, durRead/durTotal*100)
+	log.Stats.Printf("validate             : %6.3fs  %4.1f%%\n", durVal, durVal/durTotal*100)
+	log.Stats.Printf("optimize             : %6.3fs  %4.1f%%\n", durOpt, durOpt/durTotal*100)
+	log.Stats.Printf("boxes                : %6.3fs  %4.1f%%\n", durBoxes, durBoxes/durTotal*100)
+	log.Stats.Printf("write                : %6.3fs  %4.1f%%\n", durWrite, durWrite/durTotal*100)
+	log.Stats.Printf("total processing time: %6.3fs\n\n", durTotal)
+	ctx.Read.LogStats(ctx.Optimized)
+	ctx.Write.LogStats()
+
+	return nil, nil
+}
+
+// AddBoxes sets page boundaries for the selected pages of fileIn and writes the result to fileOut.
+func AddBoxes(cmd *Command) ([]string, error) {
+	return changeBoxes(cmd, func(xRefTable *pdf.XRefTable, selectedPages pdf.IntSet) error {
+		return pdf.AddBoxes(xRefTable, selectedPages, cmd.Boxes)
+	})
+}
+
+// RemoveBoxes removes page boundaries from the selected pages of fileIn and writes the result to fileOut.
+func RemoveBoxes(cmd *Command) ([]string, error) {
+	return changeBoxes(cmd, func(xRefTable *pdf.XRefTable, selectedPages pdf.IntSet) error {
+		return pdf.RemoveBoxes(xRefTable, selectedPages, cmd.Boxes)
+	})
+}
+
+// Crop sets the crop box of the selected pages of fileIn to their trim box and writes the result to fileOut.
+func Crop(cmd *Command) ([]string, error) {
+	return changeBoxes(cmd, pdf.CropToTrimBox)
+}
+
+// Resize scales the selected pages of fileIn to a paper size or by a scale factor and writes the result to fileOut.
+func Resize(cmd *Command) ([]string, error) {
+
+	fileIn := *cmd.InFile
+	fileOut := *cmd.OutFile
+	pageSelection := cmd.PageSelection
+	config := cmd.Config
+
+	fromStart := time.Now()
+
+	ctx, durRead, durVal, durOpt, err := readValidateAndOptimize(fileIn, config, fromStart)
+	if err != nil {
+		return nil, err
+	}
+
+	defer ctx.Close()
+
+	fmt.Printf("resizing %s ...\n", fileIn)
+
+	from := time.Now()
+
+	pages, err := pagesForPageSelection(ctx.PageCount, pageSelection)
+	if err != nil {
+		return nil, err
+	}
+
+	ensureSelectedPages(ctx, &pages)
+
+	err = pdf.ResizePages(ctx.XRefTable, pages, cmd.Resize)
+	if err != nil {
+		return nil, err
+	}
+
+	durResize := time.Since(from).Seconds()
+
+	fromWrite := time.Now()
+
+	dirName, fileName := filepath.Split(fileOut)
+	ctx.Write.DirName = dirName
+	ctx.Write.FileName = fileName
+
+	err = Write(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	durWrite := time.Since(fromWrite).Seconds()
+	durTotal := time.Since(fromStart).Seconds()
+
+	log.Stats.Printf("XRefTable:\n%s\n", ctx)
+	log.Stats.Println("Timing:")
+	log.Stats.Printf("read                 : %6.3fs  %4.1f%%\n", durRead, durRead/durTotal*100)
+	log.Stats.Printf("validate             : %6.3fs  %4.1f%%\n", durVal, durVal/durTotal*100)
+	log.Stats.Printf("optimize             : %6.3fs  %4.1f%%\n", durOpt, durOpt/durTotal*100)
+	log.Stats.Printf("resize               : %6.3fs  %4.1f%%\n", durResize, durResize/durTotal*100)
+	log.Stats.Printf("write                : %6.3fs  %4.1f%%\n", durWrite, durWrite/durTotal*100)
+	log.Stats.Printf("total processing time: %6.3fs\n\n", durTotal)
+	ctx.Read.LogStats(ctx.Optimized)
+	ctx.Write.LogStats()
+
+	return nil, nil
+}
+
+// ListPageLabels returns the page label ranges of fileIn.
+func ListPageLabels(cmd *Command) ([]string, error) {
+
+	fileIn := *cmd.InFile
+	config := cmd.Config
+
+	fromStart := time.Now()
+
+	ctx, durRead, durVal, err := readAndValidate(fileIn, config, fromStart)
+	if err != nil {
+		return nil, err
+	}
+
+	defer ctx.Close()
+
+	from := time.Now()
+
+	list, err := pdf.ListPageLabels(ctx.XRefTable)
+	if err != nil {
+		return nil, err
+	}
+
+	durList := time.Since(from).Seconds()
+	durTotal := time.Since(fromStart).Seconds()
+
+	log.Stats.Printf("XRefTable:\n%s\n", ctx)
+	log.Stats.Println("Timing:")
+	log.Stats.Printf("read                 : %6.3fs  %4.1f%%\n", durRead, durRead/durTotal*100)
+	log.Stats.Printf("validate             : %6.3fs  %4.1f%%\n", durVal, durVal/durTotal*100)
+	log.Stats.Printf("list page labels     : %6.3fs  %4.1f%%\n", durList, durList/durTotal*100)
+	log.Stats.Printf("total processing time: %6.3fs\n\n", durTotal)
+
+	return list, nil
+}
+
+// changePageLabels applies f to the selected pages of fileIn and writes the result to fileOut.
+func changePageLabels(cmd *Command, f func(xRefTable *pdf.XRefTable, selectedPages pdf.IntSet) error) ([]string, error) {
+
+	fileIn := *cmd.InFile
+	fileOut := *cmd.OutFile
+	config := cmd.Config
+
+	fromStart := time.Now()
+
+	ctx, durRead, durVal, durOpt, err := readValidateAndOptimize(fileIn, config, fromStart)
+	if err != nil {
+		return nil, err
+	}
+
+	defer ctx.Close()
+
+	fmt.Printf("changing page labels of %s ...\n", fileIn)
+
+	from := time.Now()
+
+	pages, err := pagesForPageSelection(ctx.PageCount, cmd.PageSelection)
+	if err != nil {
+		return nil, err
+	}
+
+	ensureSelectedPages(ctx, &pages)
+
+	err = f(ctx.XRefTable, pages)
+	if err != nil {
+		return nil, err
+	}
+
+	durLabels := time.Since(from).Seconds()
+
+	fromWrite := time.Now()
+
+	dirName, fileName := filepath.Split(fileOut)
+	ctx.Write.DirName = dirName
+	ctx.Write.FileName = fileName
+
+	err = Write(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	durWrite := time.Since(fromWrite).Seconds()
+	durTotal := time.Since(fromStart).Seconds()
+
+	log.Stats.Printf("XRefTable:\n%s\n", ctx)
+	log.Stats.Println("Timing:")
+	log.Stats.Printf("read                 : %6.3fs  %4.1f%%\n", durRead, durRead/durTotal*100)
+	log.Stats.Printf("validate             : %6.3fs  %4.1f%%\n", durVal, durVal/durTotal*100)
+	log.Stats.Printf("optimize             : %6.3fs  %4.1f%%\n", durOpt, durOpt/durTotal*100)
+	log.Stats.Printf("page labels          : %6.3fs  %4.1f%%\n", durLabels, durLabels/durTotal*100)
+	log.Stats.Printf("write                : %6.3fs  %4.1f%%\n", durWrite, durWrite/durTotal*100)
+	log.Stats.Printf("total processing time: %6.3fs\n\n", durTotal)
+	ctx.Read.LogStats(ctx.Optimized)
+	ctx.Write.LogStats()
+
+	return nil, nil
+}
+
+// AddPageLabels starts a page label range at the selected pages of fileIn and writes the result to fileOut.
+func AddPageLabels(cmd *Command) ([]string, error) {
+
+	pl := cmd.PageLabel
+	if pl == nil {
+		pl = pdf.DefaultPageLabel()
+	}
+
+	return changePageLabels(cmd, func(xRefTable *pdf.XRefTable, selectedPages pdf.IntSet) error {
+		return pdf.AddPageLabels(xRefTable, selectedPages, pl)
+	})
+}
+
+// RemovePageLabels removes the page label ranges starting at the selected pages of fileIn and writes the result to fileOut.
+func RemovePageLabels(cmd *Command) ([]string, error) {
+	return changePageLabels(cmd, pdf.RemovePageLabels)
+}
+
+// bookmarksJSON is the JSON representation of a document outline.
+type bookmarksJSON struct {
+	Bookmarks []pdf.Bookmark `json:"bookmarks"`
+}
+
+func marshalBookmarks(bms []pdf.Bookmark) ([]byte, error) {
+	return json.MarshalIndent(bookmarksJSON{Bookmarks: bms}, "", "  ")
+}
+
+func unmarshalBookmarks(r io.Reader) ([]pdf.Bookmark, error) {
+
+	var bj bookmarksJSON
+
+	if err := json.NewDecoder(r).Decode(&bj); err != nil {
+		return nil, errors.Wrap(err, "invalid bookmarks JSON")
+	}
+
+	return bj.Bookmarks, nil
+}
+
+// ListBookmarks returns the bookmarks of fileIn as an indented list or as JSON.
+func ListBookmarks(cmd *Command) ([]string, error) {
+
+	fileIn := *cmd.InFile
+	config := cmd.Config
+
+	fromStart := time.Now()
+
+	ctx, durRead, durVal, err := readAndValidate(fileIn, config, fromStart)
+	if err != nil {
+		return nil, err
+	}
+
+	defer ctx.Close()
+
+	from := time.Now()
+
+	var list []string
+
+	if cmd.JSON {
+		bms, err := pdf.Bookmarks(ctx.XRefTable)
+		if err != nil {
+			return nil, err
+		}
+		bb, err := marshalBookmarks(bms)
+		if err != nil {
+			return nil, err
+		}
+		list = []string{string(bb)}
+	} else {
+		if list, err = pdf.ListBookmarks(ctx.XRefTable); err != nil {
+			return nil, err
+		}
+	}
+
+	durList := time.Since(from).Seconds()
+	durTotal := time.Since(fromStart).Seconds()
+
+	log.Stats.Printf("XRefTable:\n%s\n", ctx)
+	log.Stats.Println("Timing:")
+	log.Stats.Printf("read                 : %6.3fs  %4.1f%%\n", durRead, durRead/durTotal*100)
+	log.Stats.Printf("validate             : %6.3fs  %4.1f%%\n", durVal, durVal/durTotal*100)
+	log.Stats.Printf("list bookmarks       : %6.3fs  %4.1f%%\n", durList, durList/durTotal*100)
+	log.Stats.Printf("total processing time: %6.3fs\n\n", durTotal)
+
+	return list, nil
+}
+
+// ExportBookmarks writes the bookmarks of fileIn as JSON to a file.
+func ExportBookmarks(cmd *Command) ([]string, error) {
+
+	fileIn := *cmd.InFile
+	fileOut := *cmd.JSONFile
+	config := cmd.Config
+
+	fromStart := time.Now()
+
+	ctx, durRead, durVal, err := readAndValidate(fileIn, config, fromStart)
+	if err != nil {
+		return nil, err
+	}
+
+	defer ctx.Close()
+
+	from := time.Now()
+
+	bms, err := pdf.Bookmarks(ctx.XRefTable)
+	if err != nil {
+		return nil, err
+	}
+
+	bb, err := marshalBookmarks(bms)
+	if err != nil {
+		return nil, err
+	}
+
+	fmt.Printf("writing %s ...\n", fileOut)
+
+	err = ioutil.WriteFile(fileOut, bb, os.ModePerm)
+	if err != nil {
+		return nil, err
+	}
+
+	durExport := time.Since(from).Seconds()
+	durTotal := time.Since(fromStart).Seconds()
+
+	log.Stats.Printf("XRefTable:\n%s\n", ctx)
+	log.Stats.Println("Timing:")
+	log.Stats.Printf("read                 : %6.3fs  %4.1f%%\n", durRead, durRead/durTotal*100)
+	log.Stats.Printf("validate             : %6.3fs  %4.1f%%\n", durVal, durVal/durTotal*100)
+	log.Stats.Printf("export bookmarks     : %6.3fs  %4.1f%%\n", durExport, durExport/durTotal*100)
+	log.Stats.Printf("total processing time: %6.3fs\n\n", durTotal)
+
+	return nil, nil
+}
+
+// changeBookmarks applies f to fileIn and writes the result to fileOut.
+func changeBookmarks(cmd *Command, f func(xRefTable *pdf.XRefTable) error) ([]string, error) {
+
+	fileIn := *cmd.InFile
+	fileOut := *cmd.OutFile
+	config := cmd.Config
+
+	fromStart := time.Now()
+
+	ctx, durRead, durVal, durOpt, err := readValidateAndOptimize(fileIn, config, fromStart)
+	if err != nil {
+		return nil, err
+	}
+
+	defer ctx.Close()
+
+	fmt.Printf("changing bookmarks of %s ...\n", fileIn)
+
+	from := time.Now()
+
+	err = f(ctx.XRefTable)
+	if err != nil {
+		return nil, err
+	}
+
+	durBookmarks := time.Since(from).Seconds()
+
+	fromWrite := time.Now()
+
+	dirName, fileName := filepath.Split(fileOut)
+	ctx.Write.DirName = dirName
+	ctx.Write.FileName = fileName
+
+	err = Write(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	durWrite := time.Since(fromWrite).Seconds()
+	durTotal := time.Since(fromStart).Seconds()
+
+	log.Stats.Printf("XRefTable:\n%s\n", ctx)
+	log.Stats.Println("Timing:")
+	log.Stats.Printf("read                 : %6.3fs  %4.1f%%\n", durRead, durRead/durTotal*100)
+	log.Stats.Printf("validate             : %6.3fs  %4.1f%%\n", durVal, durVal/durTotal*100)
+	log.Stats.Printf("optimize             : %6.3fs  %4.1f%%\n", durOpt, durOpt/durTotal*100)
+	log.Stats.Printf("bookmarks            : %6.3fs  %4.1f%%\n", durBookmarks, durBookmarks/durTotal*100)
+	log.Stats.Printf("write                : %6.3fs  %4.1f%%\n", durWrite, durWrite/durTotal*100)
+	log.Stats.Printf("total processing time: %6.3fs\n\n", durTotal)
+	ctx.Read.LogStats(ctx.Optimized)
+	ctx.Write.LogStats()
+
+	return nil, nil
+}
+
+// ImportBookmarks replaces the bookmarks of fileIn by the bookmarks read from a JSON file and writes the result to fileOut.
+func ImportBookmarks(cmd *Command) ([]string, error) {
+
+	f, err := os.Open(*cmd.JSONFile)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	bms, err := unmarshalBookmarks(f)
+	if err != nil {
+		return nil, err
+	}
+
+	return changeBookmarks(cmd, func(xRefTable *pdf.XRefTable) error {
+		return pdf.ReplaceBookmarks(xRefTable, bms)
+	})
+}
+
+// RemoveBookmarks removes the bookmarks of fileIn and writes the result to fileOut.
+func RemoveBookmarks(cmd *Command) ([]string, error) {
+	return changeBookmarks(cmd, pdf.RemoveBookmarks)
+}
+
+// ListProperties returns the document properties of fileIn.
+func ListProperties(cmd *Command) ([]string, error) {
+
+	fileIn := *cmd.InFile
+	config := cmd.Config
+
+	fromStart := time.Now()
+
+	ctx, durRead, durVal, err := readAndValidate(fileIn, config, fromStart)
+	if err != nil {
+		return nil, err
+	}
+
+	defer ctx.Close()
+
+	from := time.Now()
+
+	list, err := pdf.ListProperties(ctx.XRefTable)
+	if err != nil {
+		return nil, err
+	}
+
+	durList := time.Since(from).Seconds()
+	durTotal := time.Since(fromStart).Seconds()
+
+	log.Stats.Printf("XRefTable:\n%s\n", ctx)
+	log.Stats.Println("Timing:")
+	log.Stats.Printf("read                 : %6.3fs  %4.1f%%\n", durRead, durRead/durTotal*100)
+	log.Stats.Printf("validate             : %6.3fs  %4.1f%%\n", durVal, durVal/durTotal*100)
+	log.Stats.Printf("list properties      : %6.3fs  %4.1f%%\n", durList, durList/durTotal*100)
+	log.Stats.Printf("total processing time: %6.3fs\n\n", durTotal)
+
+	return list, nil
+}
+
+// changeProperties applies f to fileIn and writes the result to fileOut.
+func changeProperties(cmd *Command, f func(xRefTable *pdf.XRefTable) error) ([]string, error) {
+
+	fileIn := *cmd.InFile
+	fileOut := *cmd.OutFile
+	config := cmd.Config
+
+	fromStart := time.Now()
+
+	ctx, durRead, durVal, durOpt, err := readValidateAndOptimize(fileIn, config, fromStart)
+	if err != nil {
+		return nil, err
+	}
+
+	defer ctx.Close()
+
+	fmt.Printf("changing properties of %s ...\n", fileIn)
+
+	from := time.Now()
+
+	err = f(ctx.XRefTable)
+	if err != nil {
+		return nil, err
+	}
+
+	durProperties := time.Since(from).Seconds()
+
+	fromWrite := time.Now()
+
+	dirName, fileName := filepath.Split(fileOut)
+	ctx.Write.DirName = dirName
+	ctx.Write.FileName = fileName
+
+	err = Write(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	durWrite := time.Since(fromWrite).Seconds()
+	durTotal := time.Since(fromStart).Seconds()
+
+	log.Stats.Printf("XRefTable:\n%s\n", ctx)
+	log.Stats.Println("Timing:")
+	log.Stats.Printf("read                 : %6.3fs  %4.1f%%\n", durRead, durRead/durTotal*100)
+	log.Stats.Printf("validate             : %6.3fs  %4.1f%%\n", durVal, durVal/durTotal*100)
+	log.Stats.Printf("optimize             : %6.3fs  %4.1f%%\n", durOpt, durOpt/durTotal*100)
+	log.Stats.Printf("properties           : %6.3fs  %4.1f%%\n", durProperties, durProperties/durTotal*100)
+	log.Stats.Printf("write                : %6.3fs  %4.1f%%\n", durWrite, durWrite/durTotal*100)
+	log.Stats.Printf("total processing time: %6.3fs\n\n", durTotal)
+	ctx.Read.LogStats(ctx.Optimized)
+	ctx.Write.LogStats()
+
+	return nil, nil
+}
+
+// AddProperties sets document properties of fileIn and writes the result to fileOut.
+func AddProperties(cmd *Command) ([]string, error) {
+	return changeProperties(cmd, func(xRefTable *pdf.XRefTable) error {
+		return pdf.AddProperties(xRefTable, cmd.Properties)
+	})
+}
+
+// RemoveProperties removes document properties of fileIn and writes the result to fileOut.
+func RemoveProperties(cmd *Command) ([]string, error) {
+	return changeProperties(cmd, func(xRefTable *pdf.XRefTable) error {
+		return pdf.RemoveProperties(xRefTable, cmd.PropertyKeys)
+	})
+}
+
+// annotationsJSON is the JSON representation of a list of annotations.
+type annotationsJSON struct {
+	Annotations []pdf.Annotation `json:"annotations"`
+}
+
+func marshalAnnotations(annots []pdf.Annotation) ([]byte, error) {
+	return json.MarshalIndent(annotationsJSON{Annotations: annots}, "", "  ")
+}
+
+func unmarshalAnnotations(r io.Reader) ([]pdf.Annotation, error) {
+
+	var aj annotationsJSON
+
+	if err := json.NewDecoder(r).Decode(&aj); err != nil {
+		return nil, errors.Wrap(err, "invalid annotations JSON")
+	}
+
+	return aj.Annotations, nil
+}
+
+// ListAnnotations returns the annotations of the selected pages of fileIn as a list or as JSON.
+func ListAnnotations(cmd *Command) ([]string, error) {
+
+	fileIn := *cmd.InFile
+	config := cmd.Config
+
+	fromStart := time.Now()
+
+	ctx, durRead, durVal, err := readAndValidate(fileIn, config, fromStart)
+	if err != nil {
+		return nil, err
+	}
+
+	defer ctx.Close()
+
+	from := time.Now()
+
+	pages, err := pagesForPageSelection(ctx.PageCount, cmd.PageSelection)
+	if err != nil {
+		return nil, err
+	}
+
+	ensureSelectedPages(ctx, &pages)
+
+	var list []string
+
+	if cmd.JSON {
+		annots, err := pdf.Annotations(ctx.XRefTable, pages)
+		if err != nil {
+			return nil, err
+		}
+		bb, err := marshalAnnotations(annots)
+		if err != nil {
+			return nil, err
+		}
+		list = []string{string(bb)}
+	} else {
+		if list, err = pdf.ListAnnotations(ctx.XRefTable, pages); err != nil {
+			return nil, err
+		}
+	}
+
+	durList := time.Since(from).Seconds()
+	durTotal := time.Since(fromStart).Seconds()
+
+	log.Stats.Printf("XRefTable:\n%s\n", ctx)
+	log.Stats.Println("Timing:")
+	log.Stats.Printf("read                 : %6.3fs  %4.1f%%\n", durRead, durRead/durTotal*100)
+	log.Stats.Printf("validate             : %6.3fs  %4.1f%%\n", durVal, durVal/durTotal*100)
+	log.Stats.Printf("list annotations     : %6.3fs  %4.1f%%\n", durList, durList/durTotal*100)
+	log.Stats.Printf("total processing time: %6.3fs\n\n", durTotal)
+
+	return list, nil
+}
+
+// changeAnnotations applies f to the selected pages of fileIn and writes the result to fileOut.
+func changeAnnotations(cmd *Command, f func(xRefTable *pdf.XRefTable, selectedPages pdf.IntSet) error) ([]string, error) {
+
+	fileIn := *cmd.InFile
+	fileOut := *cmd.OutFile
+	config := cmd.Config
+
+	fromStart := time.Now()
+
+	ctx, durRead, durVal, durOpt, err := readValidateAndOptimize(fileIn, config, fromStart)
+	if err != nil {
+		return nil, err
+	}
+
+	defer ctx.Close()
+
+	fmt.Printf("changing annotations of %s ...\n", fileIn)
+
+	from := time.Now()
+
+	pages, err := pagesForPageSelection(ctx.PageCount, cmd.PageSelection)
+	if err != nil {
+		return nil, err
+	}
+
+	ensureSelectedPages(ctx, &pages)
+
+	err = f(ctx.XRefTable, pages)
+	if err != nil {
+		return nil, err
+	}
+
+	durAnnotations := time.Since(from).Seconds()
+
+	fromWrite := time.Now()
+
+	dirName, fileName := filepath.Split(fileOut)
+	ctx.Write.DirName = dirName
+	ctx.Write.FileName = fileName
+
+	err = Write(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	durWrite := time.Since(fromWrite).Seconds()
+	durTotal := time.Since(fromStart).Seconds()
+
+	log.Stats.Printf("XRefTable:\n%s\n", ctx)
+	log.Stats.Println("Timing:")
+	log.Stats.Printf("read                 : %6.3fs  %4.1f%%\n", durRead, durRead/durTotal*100)
+	log.Stats.Printf("validate             : %6.3fs  %4.1f%%\n", durVal, durVal/durTotal*100)
+	log.Stats.Printf("optimize             : %6.3fs  %4.1f%%\n", durOpt, durOpt/durTotal*100)
+	log.Stats.Printf("annotations          : %6.3fs  %4.1f%%\n", durAnnotations, durAnnotations/durTotal*100)
+	log.Stats.Printf("write                : %6.3fs  %4.1f%%\n", durWrite, durWrite/durTotal*100)
+	log.Stats.Printf("total processing time: %6.3fs\n\n", durTotal)
+	ctx.Read.LogStats(ctx.Optimized)
+	ctx.Write.LogStats()
+
+	return nil, nil
+}
+
+// AddAnnotations adds the annotations read from a JSON file to fileIn and writes the result to fileOut.
+func AddAnnotations(cmd *Command) ([]string, error) {
+
+	f, err := os.Open(*cmd.JSONFile)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	annots, err := unmarshalAnnotations(f)
+	if err != nil {
+		return nil, err
+	}
+
+	return changeAnnotations(cmd, func(xRefTable *pdf.XRefTable, selectedPages pdf.IntSet) error {
+		return pdf.AddAnnotations(xRefTable, annots)
+	})
+}
+
+// RemoveAnnotations removes annotations from the selected pages of fileIn and writes the result to fileOut.
+func RemoveAnnotations(cmd *Command) ([]string, error) {
+	return changeAnnotations(cmd, func(xRefTable *pdf.XRefTable, selectedPages pdf.IntSet) error {
+		return pdf.RemoveAnnotations(xRefTable, selectedPages, cmd.AnnotationIDs)
+	})
+}
+
+// Flatten draws the annotations of the selected pages of fileIn into the page content and writes the result to fileOut.
+// Form fields whose widgets got flattened are removed.
+func Flatten(cmd *Command) ([]string, error) {
+	return changeAnnotations(cmd, pdf.FlattenAnnotations)
+}
+
+// formJSON is the JSON representation of the fields of a form.
+type formJSON struct {
+	Fields []pdf.Field `json:"fields"`
+}
+
+func marshalFormFields(fields []pdf.Field) ([]byte, error) {
+	return json.MarshalIndent(formJSON{Fields: fields}, "", "  ")
+}
+
+func unmarshalFormFields(r io.Reader) ([]pdf.Field, error) {
+
+	var fj formJSON
+
+	if err := json.NewDecoder(r).Decode(&fj); err != nil {
+		return nil, errors.Wrap(err, "invalid form JSON")
+	}
+
+	return fj.Fields, nil
+}
+
+// ListFormFields returns the form fields of fileIn as a list or as JSON.
+func ListFormFields(cmd *Command) ([]string, error) {
+
+	fileIn := *cmd.InFile
+	config := cmd.Config
+
+	fromStart := time.Now()
+
+	ctx, durRead, durVal, err := readAndValidate(fileIn, config, fromStart)
+	if err != nil {
+		return nil, err
+	}
+
+	defer ctx.Close()
+
+	from := time.Now()
+
+	var list []string
+
+	if cmd.JSON {
+		fields, err := pdf.FormFields(ctx.XRefTable)
+		if err != nil {
+			return nil, err
+		}
+		bb, err := marshalFormFields(fields)
+		if err != nil {
+			return nil, err
+		}
+		list = []string{string(bb)}
+	} else {
+		if list, err = pdf.ListFormFields(ctx.XRefTable); err != nil {
+			return nil, err
+		}
+	}
+
+	durList := time.Since(from).Seconds()
+	durTotal := time.Since(fromStart).Seconds()
+
+	log.Stats.Printf("XRefTable:\n%s\n", ctx)
+	log.Stats.Println("Timing:")
+	log.Stats.Printf("read                 : %6.3fs  %4.1f%%\n", durRead, durRead/durTotal*100)
+	log.Stats.Printf("validate             : %6.3fs  %4.1f%%\n", durVal, durVal/durTotal*100)
+	log.Stats.Printf("list form fields     : %6.3fs  %4.1f%%\n", durList, durList/durTotal*100)
+	log.Stats.Printf("total processing time: %6.3fs\n\n", durTotal)
+
+	return list, nil
+}
+
+// FillForm sets the form field values read from a JSON file in fileIn and writes the result to fileOut.
+func FillForm(cmd *Command) ([]string, error) {
+
+	fileIn := *cmd.InFile
+	fileOut := *cmd.OutFile
+	config := cmd.Config
+
+	f, err := os.Open(*cmd.JSONFile)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	fields, err := unmarshalFormFields(f)
+	if err != nil {
+		return nil, err
+	}
+
+	fromStart := time.Now()
+
+	ctx, durRead, durVal, durOpt, err := readValidateAndOptimize(fileIn, config, fromStart)
+	if err != nil {
+		return nil, err
+	}
+
+	defer ctx.Close()
+
+	fmt.Printf("filling form of %s ...\n", fileIn)
+
+	from := time.Now()
+
+	err = pdf.FillForm(ctx.XRefTable, fields)
+	if err != nil {
+		return nil, err
+	}
+
+	durFill := time.Since(from).Seconds()
+
+	fromWrite := time.Now()
+
+	dirName, fileName := filepath.Split(fileOut)
+	ctx.Write.DirName = dirName
+	ctx.Write.FileName = fileName
+
+	err = Write(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	durWrite := time.Since(fromWrite).Seconds()
+	durTotal := time.Since(fromStart).Seconds()
+
+	log.Stats.Printf("XRefTable:\n%s\n", ctx)
+	log.Stats.Println("Timing:")
+	log.Stats.Printf("read                 : %6.3fs  %4.1f%%\n", durRead, durRead/durTotal*100)
+	log.Stats.Printf("validate             : %6.3fs  %4.1f%%\n", durVal, durVal/durTotal*100)
+	log.Stats.Printf("optimize             : %6.3fs  %4.1f%%\n", durOpt, durOpt/durTotal*100)
+	log.Stats.Printf("fill form            : %6.3fs  %4.1f%%\n", durFill, durFill/durTotal*100)
+	log.Stats.Printf("write                : %6.3fs  %4.1f%%\n", durWrite, durWrite/durTotal*100)
+	log.Stats.Printf("total processing time: %6.3fs\n\n", durTotal)
+	ctx.Read.LogStats(ctx.Optimized)
+	ctx.Write.LogStats()
+
+	return nil, nil
+}
+
+// formDataXFDF returns true for XFDF files and false for FDF files as identified by their file extension.
+func formDataXFDF(fileName string) (bool, error) {
+
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".fdf":
+		return false, nil
+	case ".xfdf":
+		return true, nil
+	}
+
+	return false, errors.Errorf("%s needs extension \".fdf\" or \".xfdf\"", fileName)
+}
+
+func readFormData(r io.Reader, xfdf bool) (*pdf.FormData, error) {
+	if xfdf {
+		return pdf.ReadXFDF(r)
+	}
+	return pdf.ReadFDF(r)
+}
+
+func writeFormData(w io.Writer, fd *pdf.FormData, xfdf bool) error {
+	if xfdf {
+		return pdf.WriteXFDF(w, fd)
+	}
+	return pdf.WriteFDF(w, fd)
+}
+
+// ExportFormData writes the form field values and annotations of fileIn as FDF or XFDF to a file.
+func ExportFormData(cmd *Command) ([]string, error) {
+
+	fileIn := *cmd.InFile
+	fileOut := *cmd.FormDataFile
+	config := cmd.Config
+
+	xfdf, err := formDataXFDF(fileOut)
+	if err != nil {
+		return nil, err
+	}
+
+	fromStart := time.Now()
+
+	ctx, durRead, durVal, err := readAndValidate(fileIn, config, fromStart)
+	if err != nil {
+		return nil, err
+	}
+
+	defer ctx.Close()
+
+	from := time.Now()
+
+	fd, err := pdf.ExportFormData(ctx.XRefTable)
+	if err != nil {
+		return nil, err
+	}
+
+	fd.File = filepath.Base(fileIn)
+
+	var b bytes.Buffer
+	if err = writeFormData(&b, fd, xfdf); err != nil {
+		return nil, err
+	}
+
+	fmt.Printf("writing %s ...\n", fileOut)
+
+	err = ioutil.WriteFile(fileOut, b.Bytes(), os.ModePerm)
+	if err != nil {
+		return nil, err
+	}
+
+	durExport := time.Since(from).Seconds()
+	durTotal := time.Since(fromStart).Seconds()
+
+	log.Stats.Printf("XRefTable:\n%s\n", ctx)
+	log.Stats.Println("Timing:")
+	log.Stats.Printf("read                 : %6.3fs  %4.1f%%\n", durRead, durRead/durTotal*100)
+	log.Stats.Printf("validate             : %6.3fs  %4.1f%%\n", durVal, durVal/durTotal*100)
+	log.Stats.Printf("export form data     : %6.3fs  %4.1f%%\n", durExport, durExport/durTotal*100)
+	log.Stats.Printf("total processing time: %6.3fs\n\n", durTotal)
+
+	return nil, nil
+}
+
+// ImportFormData sets the form field values and adds the annotations read from an FDF or XFDF file to fileIn and writes the result to fileOut.
+func ImportFormData(cmd *Command) ([]string, error) {
+
+	fileIn := *cmd.InFile
+	fileOut := *cmd.OutFile
+	config := cmd.Config
+
+	xfdf, err := formDataXFDF(*cmd.FormDataFile)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(*cmd.FormDataFile)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	fd, err := readFormData(f, xfdf)
+	if err != nil {
+		return nil, err
+	}
+
+	fromStart := time.Now()
+
+	ctx, durRead, durVal, durOpt, err := readValidateAndOptimize(fileIn, config, fromStart)
+	if err != nil {
+		return nil, err
+	}
+
+	defer ctx.Close()
+
+	fmt.Printf("importing form data into %s ...\n", fileIn)
+
+	from := time.Now()
+
+	err = pdf.ImportFormData(ctx.XRefTable, fd)
+	if err != nil {
+		return nil, err
+	}
+
+	durImport := time.Since(from).Seconds()
+
+	fromWrite := time.Now()
+
+	dirName, fileName := filepath.Split(fileOut)
+	ctx.Write.DirName = dirName
+	ctx.Write.FileName = fileName
+
+	err = Write(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	durWrite := time.Since(fromWrite).Seconds()
+	durTotal := time.Since(fromStart).Seconds()
+
+	log.Stats.Printf("XRefTable:\n%s\n", ctx)
+	log.Stats.Println("Timing:")
+	log.Stats.Printf("read                 : %6.3fs  %4.1f%%\n", durRead, durRead/durTotal*100)
+	log.Stats.Printf("validate             : %6.3fs  %4.1f%%\n", durVal, durVal/durTotal*100)
+	log.Stats.Printf("optimize             : %6.3fs  %4.1f%%\n", durOpt, durOpt/durTotal*100)
+	log.Stats.Printf("import form data     : %6.3fs  %4.1f%%\n", durImport, durImport/durTotal*100)
+	log.Stats.Printf("write                : %6.3fs  %4.1f%%\n", durWrite, durWrite/durTotal*100)
+	log.Stats.Printf("total processing time: %6.3fs\n\n", durTotal)
+	ctx.Read.LogStats(ctx.Optimized)
+	ctx.Write.LogStats()
+
+	return nil, nil
+}
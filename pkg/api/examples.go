@@ -66,7 +66,17 @@ func exampleProcessMerge() {
 	// Concatenate this sequence of PDF files:
 	filenamesIn := []string{"in1.pdf", "in2.pdf", "in3.pdf"}
 
-	_, err := Process(MergeCommand(filenamesIn, "out.pdf", pdfcpu.NewDefaultConfiguration()))
+	_, err := Process(MergeCommand(filenamesIn, nil, "out.pdf", false, pdfcpu.NewDefaultConfiguration()))
+	if err != nil {
+		return
+	}
+
+	// Concatenate the first 3 pages of in1.pdf and the odd pages of in2.pdf
+	// and add a bookmark for each file.
+	filenamesIn = []string{"in1.pdf", "in2.pdf"}
+	pageSelections := [][]string{{"-3"}, {"odd"}}
+
+	_, err = Process(MergeCommand(filenamesIn, pageSelections, "out.pdf", true, pdfcpu.NewDefaultConfiguration()))
 	if err != nil {
 		return
 	}
@@ -82,7 +92,21 @@ func exampleProcessSplit() {
 
 	// Split into single-page PDFs.
 
-	_, err := Process(SplitCommand("in.pdf", "outDir", config))
+	_, err := Process(SplitCommand("in.pdf", "outDir", 1, config))
+	if err != nil {
+		return
+	}
+
+	// Split into PDFs of 10 pages each.
+
+	_, err = Process(SplitCommand("in.pdf", "outDir", 10, config))
+	if err != nil {
+		return
+	}
+
+	// Split into one PDF per top level bookmark.
+
+	_, err = Process(SplitByBookmarksCommand("in.pdf", "outDir", config))
 	if err != nil {
 		return
 	}
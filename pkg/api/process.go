@@ -17,6 +17,8 @@ limitations under the License.
 package api
 
 import (
+	"encoding/json"
+
 	pdf "github.com/hhrutter/pdfcpu/pkg/pdfcpu"
 	"github.com/pkg/errors"
 )
@@ -34,6 +36,27 @@ type Command struct {
 	PWOld         *string            //    -         -        -      -       -      -      -       -       -      -       -        -         *          *       -     -       -
 	PWNew         *string            //    -         -        -      -       -      -      -       -       -      -       -        -         *          *       -     -       -
 	Watermark     *pdf.Watermark     //    -         -        -      -       -      -      -       -       -      -       -        -         -          -       -     -       -
+	JSON          bool               // INFO, LISTBOOKMARKS, LISTANNOTATIONS, LISTFORMFIELDS: output as JSON
+	Rotation      int                // ROTATE: degrees clockwise, a multiple of 90
+	Before        bool               // INSERTPAGES: insert before instead of after selected pages
+	NUp           *pdf.NUp           // NUP: page layout
+	Booklet       *pdf.Booklet       // BOOKLET: booklet layout
+	SplitMode     SplitMode          // SPLIT: where to start a new file
+	Span          int                // SPLIT: pages per file
+	PageNrs       []int              // SPLIT: pages starting a new file
+	MaxSize       int64              // SPLIT: maximum file size in bytes
+	FilePages     [][]string         // MERGE: page selection per input file
+	Bookmarks     bool               // MERGE: add a bookmark for each input file
+	MergeMode     MergeMode          // MERGE: how to combine the pages
+	Import        *pdf.Import        // IMPORTIMAGES: page layout for images
+	Boxes         *pdf.PageBoxes     // ADDBOXES, REMOVEBOXES: page boundaries
+	Resize        *pdf.Resize        // RESIZE: paper size or scale factor
+	PageLabel     *pdf.PageLabel     // ADDPAGELABELS: page label style, prefix and start
+	JSONFile      *string            // EXPORTBOOKMARKS, IMPORTBOOKMARKS: bookmarks as JSON, ADDANNOTATIONS: annotations as JSON, FILLFORM: field values as JSON
+	Properties    map[string]string  // ADDPROPERTIES: document properties to set
+	PropertyKeys  []string           // REMOVEPROPERTIES: document properties to remove, all if empty
+	AnnotationIDs []string           // REMOVEANNOTATIONS: annotation types or ids to remove, all if empty
+	FormDataFile  *string            // EXPORTFORMDATA, IMPORTFORMDATA: form data as FDF or XFDF
 }
 
 // Process executes a pdfcpu command.
@@ -70,6 +93,37 @@ func Process(cmd *Command) (out []string, err error) {
 		pdf.CHANGEOPW:          processEncryption,
 		pdf.LISTPERMISSIONS:    processPermissions,
 		pdf.ADDPERMISSIONS:     processPermissions,
+		pdf.INFO:               processInfo,
+		pdf.ROTATE:             Rotate,
+		pdf.INSERTPAGES:        InsertPages,
+		pdf.REMOVEPAGES:        RemovePages,
+		pdf.NUP:                NUp,
+		pdf.BOOKLET:            Booklet,
+		pdf.COLLECT:            Collect,
+		pdf.IMPORTIMAGES:       ImportImages,
+		pdf.LISTBOXES:          ListBoxes,
+		pdf.ADDBOXES:           AddBoxes,
+		pdf.REMOVEBOXES:        RemoveBoxes,
+		pdf.CROP:               Crop,
+		pdf.RESIZE:             Resize,
+		pdf.LISTPAGELABELS:     ListPageLabels,
+		pdf.ADDPAGELABELS:      AddPageLabels,
+		pdf.REMOVEPAGELABELS:   RemovePageLabels,
+		pdf.LISTBOOKMARKS:      ListBookmarks,
+		pdf.EXPORTBOOKMARKS:    ExportBookmarks,
+		pdf.IMPORTBOOKMARKS:    ImportBookmarks,
+		pdf.REMOVEBOOKMARKS:    RemoveBookmarks,
+		pdf.LISTPROPERTIES:     ListProperties,
+		pdf.ADDPROPERTIES:      AddProperties,
+		pdf.REMOVEPROPERTIES:   RemoveProperties,
+		pdf.LISTANNOTATIONS:    ListAnnotations,
+		pdf.ADDANNOTATIONS:     AddAnnotations,
+		pdf.REMOVEANNOTATIONS:  RemoveAnnotations,
+		pdf.FLATTEN:            Flatten,
+		pdf.LISTFORMFIELDS:     ListFormFields,
+		pdf.FILLFORM:           FillForm,
+		pdf.EXPORTFORMDATA:     ExportFormData,
+		pdf.IMPORTFORMDATA:     ImportFormData,
 	} {
 		if cmd.Mode == k {
 			return v(cmd)
@@ -96,23 +150,75 @@ func OptimizeCommand(pdfFileNameIn, pdfFileNameOut string, config *pdf.Configura
 		Config:  config}
 }
 
-// SplitCommand creates a new command to split a file into single page file.
-func SplitCommand(pdfFileNameIn, dirNameOut string, config *pdf.Configuration) *Command {
+// SplitCommand creates a new command to split a file into files of span pages each.
+func SplitCommand(pdfFileNameIn, dirNameOut string, span int, config *pdf.Configuration) *Command {
 	return &Command{
-		Mode:   pdf.SPLIT,
-		InFile: &pdfFileNameIn,
-		OutDir: &dirNameOut,
-		Config: config}
+		Mode:      pdf.SPLIT,
+		InFile:    &pdfFileNameIn,
+		OutDir:    &dirNameOut,
+		SplitMode: SplitSpan,
+		Span:      span,
+		Config:    config}
+}
+
+// SplitByBookmarksCommand creates a new command to split a file at its top level bookmarks.
+func SplitByBookmarksCommand(pdfFileNameIn, dirNameOut string, config *pdf.Configuration) *Command {
+	return &Command{
+		Mode:      pdf.SPLIT,
+		InFile:    &pdfFileNameIn,
+		OutDir:    &dirNameOut,
+		SplitMode: SplitBookmarks,
+		Config:    config}
+}
+
+// SplitByPageNrsCommand creates a new command to split a file before each page of pageNrs.
+func SplitByPageNrsCommand(pdfFileNameIn, dirNameOut string, pageNrs []int, config *pdf.Configuration) *Command {
+	return &Command{
+		Mode:      pdf.SPLIT,
+		InFile:    &pdfFileNameIn,
+		OutDir:    &dirNameOut,
+		SplitMode: SplitPageNrs,
+		PageNrs:   pageNrs,
+		Config:    config}
+}
+
+// SplitBySizeCommand creates a new command to split a file into files of at most maxSize bytes.
+func SplitBySizeCommand(pdfFileNameIn, dirNameOut string, maxSize int64, config *pdf.Configuration) *Command {
+	return &Command{
+		Mode:      pdf.SPLIT,
+		InFile:    &pdfFileNameIn,
+		OutDir:    &dirNameOut,
+		SplitMode: SplitSize,
+		MaxSize:   maxSize,
+		Config:    config}
 }
 
 // MergeCommand creates a new command to merge files.
-func MergeCommand(pdfFileNamesIn []string, pdfFileNameOut string, config *pdf.Configuration) *Command {
+// pageSelections optionally holds a page selection for each input file, an empty selection merges all pages.
+func MergeCommand(pdfFileNamesIn []string, pageSelections [][]string, pdfFileNameOut string, bookmarks bool, config *pdf.Configuration) *Command {
 	return &Command{
 		Mode: pdf.MERGE,
 		//InFile:  &pdfFileNameIn,
-		InFiles: pdfFileNamesIn,
-		OutFile: &pdfFileNameOut,
-		Config:  config}
+		InFiles:   pdfFileNamesIn,
+		FilePages: pageSelections,
+		OutFile:   &pdfFileNameOut,
+		Bookmarks: bookmarks,
+		Config:    config}
+}
+
+// MergeZipCommand creates a new command to merge 2 files by interleaving their pages.
+// If reverse is true the pages of the second file are taken back to front.
+func MergeZipCommand(pdfFileNameIn1, pdfFileNameIn2, pdfFileNameOut string, reverse bool, config *pdf.Configuration) *Command {
+	mode := MergeZip
+	if reverse {
+		mode = MergeZipReversed
+	}
+	return &Command{
+		Mode:      pdf.MERGE,
+		InFiles:   []string{pdfFileNameIn1, pdfFileNameIn2},
+		OutFile:   &pdfFileNameOut,
+		MergeMode: mode,
+		Config:    config}
 }
 
 // ExtractImagesCommand creates a new command to extract embedded images.
@@ -334,3 +440,338 @@ func AddWatermarksCommand(pdfFileNameIn, pdfFileNameOut string, pageSelection []
 		Watermark:     wm,
 		Config:        config}
 }
+
+// InfoCommand creates a new command to list the essential facts of a file.
+func InfoCommand(pdfFileNameIn string, asJSON bool, config *pdf.Configuration) *Command {
+	return &Command{
+		Mode:   pdf.INFO,
+		InFile: &pdfFileNameIn,
+		JSON:   asJSON,
+		Config: config}
+}
+
+func processInfo(cmd *Command) (out []string, err error) {
+
+	info, err := Info(*cmd.InFile, cmd.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	if !cmd.JSON {
+		return info.Lines(), nil
+	}
+
+	bb, err := json.MarshalIndent(info, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return []string{string(bb)}, nil
+}
+
+// RotateCommand creates a new command to rotate pages.
+func RotateCommand(pdfFileNameIn, pdfFileNameOut string, rotation int, pageSelection []string, config *pdf.Configuration) *Command {
+	return &Command{
+		Mode:          pdf.ROTATE,
+		InFile:        &pdfFileNameIn,
+		OutFile:       &pdfFileNameOut,
+		PageSelection: pageSelection,
+		Rotation:      rotation,
+		Config:        config}
+}
+
+// InsertPagesCommand creates a new command to insert blank pages or, if pdfFileNameSource is not empty,
+// the pages of another file before or after selected pages.
+func InsertPagesCommand(pdfFileNameIn, pdfFileNameOut, pdfFileNameSource string, before bool, pageSelection []string, config *pdf.Configuration) *Command {
+
+	var fileNamesIn []string
+	if pdfFileNameSource != "" {
+		fileNamesIn = []string{pdfFileNameSource}
+	}
+
+	return &Command{
+		Mode:          pdf.INSERTPAGES,
+		InFile:        &pdfFileNameIn,
+		InFiles:       fileNamesIn,
+		OutFile:       &pdfFileNameOut,
+		PageSelection: pageSelection,
+		Before:        before,
+		Config:        config}
+}
+
+// RemovePagesCommand creates a new command to remove selected pages.
+func RemovePagesCommand(pdfFileNameIn, pdfFileNameOut string, pageSelection []string, config *pdf.Configuration) *Command {
+	return &Command{
+		Mode:          pdf.REMOVEPAGES,
+		InFile:        &pdfFileNameIn,
+		OutFile:       &pdfFileNameOut,
+		PageSelection: pageSelection,
+		Config:        config}
+}
+
+// NUpCommand creates a new command to place n pages on each output page.
+func NUpCommand(pdfFileNameIn, pdfFileNameOut string, pageSelection []string, nup *pdf.NUp, config *pdf.Configuration) *Command {
+	return &Command{
+		Mode:          pdf.NUP,
+		InFile:        &pdfFileNameIn,
+		OutFile:       &pdfFileNameOut,
+		PageSelection: pageSelection,
+		NUp:           nup,
+		Config:        config}
+}
+
+// BookletCommand creates a new command to arrange selected pages as a booklet.
+func BookletCommand(pdfFileNameIn, pdfFileNameOut string, pageSelection []string, booklet *pdf.Booklet, config *pdf.Configuration) *Command {
+	return &Command{
+		Mode:          pdf.BOOKLET,
+		InFile:        &pdfFileNameIn,
+		OutFile:       &pdfFileNameOut,
+		PageSelection: pageSelection,
+		Booklet:       booklet,
+		Config:        config}
+}
+
+// CollectCommand creates a new command to arrange pages in the order of an ordered page list.
+func CollectCommand(pdfFileNameIn, pdfFileNameOut string, pageCollection []string, config *pdf.Configuration) *Command {
+	return &Command{
+		Mode:          pdf.COLLECT,
+		InFile:        &pdfFileNameIn,
+		OutFile:       &pdfFileNameOut,
+		PageSelection: pageCollection,
+		Config:        config}
+}
+
+// ImportImagesCommand creates a new command to append a page for each image file to a PDF file.
+func ImportImagesCommand(imageFileNames []string, pdfFileNameOut string, imp *pdf.Import, config *pdf.Configuration) *Command {
+	return &Command{
+		Mode:    pdf.IMPORTIMAGES,
+		InFiles: imageFileNames,
+		OutFile: &pdfFileNameOut,
+		Import:  imp,
+		Config:  config}
+}
+
+// ListBoxesCommand creates a new command to list the page boundaries of selected pages.
+func ListBoxesCommand(pdfFileNameIn string, pageSelection []string, config *pdf.Configuration) *Command {
+	return &Command{
+		Mode:          pdf.LISTBOXES,
+		InFile:        &pdfFileNameIn,
+		PageSelection: pageSelection,
+		Config:        config}
+}
+
+// AddBoxesCommand creates a new command to set page boundaries for selected pages.
+func AddBoxesCommand(pdfFileNameIn, pdfFileNameOut string, pageSelection []string, pbs *pdf.PageBoxes, config *pdf.Configuration) *Command {
+	return &Command{
+		Mode:          pdf.ADDBOXES,
+		InFile:        &pdfFileNameIn,
+		OutFile:       &pdfFileNameOut,
+		PageSelection: pageSelection,
+		Boxes:         pbs,
+		Config:        config}
+}
+
+// RemoveBoxesCommand creates a new command to remove page boundaries from selected pages.
+func RemoveBoxesCommand(pdfFileNameIn, pdfFileNameOut string, pageSelection []string, pbs *pdf.PageBoxes, config *pdf.Configuration) *Command {
+	return &Command{
+		Mode:          pdf.REMOVEBOXES,
+		InFile:        &pdfFileNameIn,
+		OutFile:       &pdfFileNameOut,
+		PageSelection: pageSelection,
+		Boxes:         pbs,
+		Config:        config}
+}
+
+// CropCommand creates a new command to crop selected pages to their trim box.
+func CropCommand(pdfFileNameIn, pdfFileNameOut string, pageSelection []string, config *pdf.Configuration) *Command {
+	return &Command{
+		Mode:          pdf.CROP,
+		InFile:        &pdfFileNameIn,
+		OutFile:       &pdfFileNameOut,
+		PageSelection: pageSelection,
+		Config:        config}
+}
+
+// ResizeCommand creates a new command to scale selected pages to a paper size or by a scale factor.
+func ResizeCommand(pdfFileNameIn, pdfFileNameOut string, pageSelection []string, resize *pdf.Resize, config *pdf.Configuration) *Command {
+	return &Command{
+		Mode:          pdf.RESIZE,
+		InFile:        &pdfFileNameIn,
+		OutFile:       &pdfFileNameOut,
+		PageSelection: pageSelection,
+		Resize:        resize,
+		Config:        config}
+}
+
+// ListPageLabelsCommand creates a new command to list the page label ranges of a file.
+func ListPageLabelsCommand(pdfFileNameIn string, config *pdf.Configuration) *Command {
+	return &Command{
+		Mode:   pdf.LISTPAGELABELS,
+		InFile: &pdfFileNameIn,
+		Config: config}
+}
+
+// AddPageLabelsCommand creates a new command to start a page label range at selected pages.
+func AddPageLabelsCommand(pdfFileNameIn, pdfFileNameOut string, pageSelection []string, pl *pdf.PageLabel, config *pdf.Configuration) *Command {
+	return &Command{
+		Mode:          pdf.ADDPAGELABELS,
+		InFile:        &pdfFileNameIn,
+		OutFile:       &pdfFileNameOut,
+		PageSelection: pageSelection,
+		PageLabel:     pl,
+		Config:        config}
+}
+
+// RemovePageLabelsCommand creates a new command to remove the page label ranges starting at selected pages.
+func RemovePageLabelsCommand(pdfFileNameIn, pdfFileNameOut string, pageSelection []string, config *pdf.Configuration) *Command {
+	return &Command{
+		Mode:          pdf.REMOVEPAGELABELS,
+		InFile:        &pdfFileNameIn,
+		OutFile:       &pdfFileNameOut,
+		PageSelection: pageSelection,
+		Config:        config}
+}
+
+// ListBookmarksCommand creates a new command to list the bookmarks of a file.
+func ListBookmarksCommand(pdfFileNameIn string, json bool, config *pdf.Configuration) *Command {
+	return &Command{
+		Mode:   pdf.LISTBOOKMARKS,
+		InFile: &pdfFileNameIn,
+		JSON:   json,
+		Config: config}
+}
+
+// ExportBookmarksCommand creates a new command to export the bookmarks of a file as JSON.
+func ExportBookmarksCommand(pdfFileNameIn, jsonFileNameOut string, config *pdf.Configuration) *Command {
+	return &Command{
+		Mode:     pdf.EXPORTBOOKMARKS,
+		InFile:   &pdfFileNameIn,
+		JSONFile: &jsonFileNameOut,
+		Config:   config}
+}
+
+// ImportBookmarksCommand creates a new command to replace the bookmarks of a file by the bookmarks of a JSON file.
+func ImportBookmarksCommand(pdfFileNameIn, jsonFileNameIn, pdfFileNameOut string, config *pdf.Configuration) *Command {
+	return &Command{
+		Mode:     pdf.IMPORTBOOKMARKS,
+		InFile:   &pdfFileNameIn,
+		JSONFile: &jsonFileNameIn,
+		OutFile:  &pdfFileNameOut,
+		Config:   config}
+}
+
+// RemoveBookmarksCommand creates a new command to remove the bookmarks of a file.
+func RemoveBookmarksCommand(pdfFileNameIn, pdfFileNameOut string, config *pdf.Configuration) *Command {
+	return &Command{
+		Mode:    pdf.REMOVEBOOKMARKS,
+		InFile:  &pdfFileNameIn,
+		OutFile: &pdfFileNameOut,
+		Config:  config}
+}
+
+// ListPropertiesCommand creates a new command to list the document properties of a file.
+func ListPropertiesCommand(pdfFileNameIn string, config *pdf.Configuration) *Command {
+	return &Command{
+		Mode:   pdf.LISTPROPERTIES,
+		InFile: &pdfFileNameIn,
+		Config: config}
+}
+
+// AddPropertiesCommand creates a new command to set document properties of a file.
+func AddPropertiesCommand(pdfFileNameIn string, properties map[string]string, config *pdf.Configuration) *Command {
+	return &Command{
+		Mode:       pdf.ADDPROPERTIES,
+		InFile:     &pdfFileNameIn,
+		OutFile:    &pdfFileNameIn,
+		Properties: properties,
+		Config:     config}
+}
+
+// RemovePropertiesCommand creates a new command to remove document properties of a file.
+func RemovePropertiesCommand(pdfFileNameIn string, keys []string, config *pdf.Configuration) *Command {
+	return &Command{
+		Mode:         pdf.REMOVEPROPERTIES,
+		InFile:       &pdfFileNameIn,
+		OutFile:      &pdfFileNameIn,
+		PropertyKeys: keys,
+		Config:       config}
+}
+
+// ListAnnotationsCommand creates a new command to list the annotations of selected pages of a file.
+func ListAnnotationsCommand(pdfFileNameIn string, pageSelection []string, json bool, config *pdf.Configuration) *Command {
+	return &Command{
+		Mode:          pdf.LISTANNOTATIONS,
+		InFile:        &pdfFileNameIn,
+		PageSelection: pageSelection,
+		JSON:          json,
+		Config:        config}
+}
+
+// AddAnnotationsCommand creates a new command to add the annotations of a JSON file to a file.
+func AddAnnotationsCommand(pdfFileNameIn, jsonFileNameIn, pdfFileNameOut string, config *pdf.Configuration) *Command {
+	return &Command{
+		Mode:     pdf.ADDANNOTATIONS,
+		InFile:   &pdfFileNameIn,
+		JSONFile: &jsonFileNameIn,
+		OutFile:  &pdfFileNameOut,
+		Config:   config}
+}
+
+// RemoveAnnotationsCommand creates a new command to remove annotations from selected pages of a file.
+func RemoveAnnotationsCommand(pdfFileNameIn, pdfFileNameOut string, pageSelection []string, ids []string, config *pdf.Configuration) *Command {
+	return &Command{
+		Mode:          pdf.REMOVEANNOTATIONS,
+		InFile:        &pdfFileNameIn,
+		OutFile:       &pdfFileNameOut,
+		PageSelection: pageSelection,
+		AnnotationIDs: ids,
+		Config:        config}
+}
+
+// FlattenCommand creates a new command to draw the annotations of selected pages into the page content.
+func FlattenCommand(pdfFileNameIn, pdfFileNameOut string, pageSelection []string, config *pdf.Configuration) *Command {
+	return &Command{
+		Mode:          pdf.FLATTEN,
+		InFile:        &pdfFileNameIn,
+		OutFile:       &pdfFileNameOut,
+		PageSelection: pageSelection,
+		Config:        config}
+}
+
+// ListFormFieldsCommand creates a new command to list the form fields of a file.
+func ListFormFieldsCommand(pdfFileNameIn string, json bool, config *pdf.Configuration) *Command {
+	return &Command{
+		Mode:   pdf.LISTFORMFIELDS,
+		InFile: &pdfFileNameIn,
+		JSON:   json,
+		Config: config}
+}
+
+// FillFormCommand creates a new command to fill the form fields of a file with the values of a JSON file.
+func FillFormCommand(pdfFileNameIn, jsonFileNameIn, pdfFileNameOut string, config *pdf.Configuration) *Command {
+	return &Command{
+		Mode:     pdf.FILLFORM,
+		InFile:   &pdfFileNameIn,
+		JSONFile: &jsonFileNameIn,
+		OutFile:  &pdfFileNameOut,
+		Config:   config}
+}
+
+// ExportFormDataCommand creates a new command to export the form field values and annotations of a file as FDF or XFDF.
+func ExportFormDataCommand(pdfFileNameIn, formDataFileNameOut string, config *pdf.Configuration) *Command {
+	return &Command{
+		Mode:         pdf.EXPORTFORMDATA,
+		InFile:       &pdfFileNameIn,
+		FormDataFile: &formDataFileNameOut,
+		Config:       config}
+}
+
+// ImportFormDataCommand creates a new command to import the form field values and annotations of an FDF or XFDF file.
+func ImportFormDataCommand(pdfFileNameIn, formDataFileNameIn, pdfFileNameOut string, config *pdf.Configuration) *Command {
+	return &Command{
+		Mode:         pdf.IMPORTFORMDATA,
+		InFile:       &pdfFileNameIn,
+		FormDataFile: &formDataFileNameIn,
+		OutFile:      &pdfFileNameOut,
+		Config:       config}
+}
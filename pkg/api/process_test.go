@@ -18,15 +18,20 @@ package api
 
 import (
 	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
 	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/hhrutter/pdfcpu/pkg/pdfcpu"
 	"github.com/hhrutter/pdfcpu/pkg/pdfcpu/validate"
+	"github.com/hhrutter/pdfcpu/pkg/xmp"
 )
 
 var inDir, outDir string
@@ -247,12 +252,75 @@ func TestOptimizeCommandWithCRLF(t *testing.T) {
 // Split a test PDF file up into single page PDFs.
 func TestSplitCommand(t *testing.T) {
 
-	_, err := Process(SplitCommand("testdata/Acroforms2.pdf", outDir, pdfcpu.NewDefaultConfiguration()))
+	_, err := Process(SplitCommand("testdata/Acroforms2.pdf", outDir, 1, pdfcpu.NewDefaultConfiguration()))
 	if err != nil {
 		t.Fatalf("TestSplitCommand: %v\n", err)
 	}
 }
 
+// Split a test PDF file in all available modes.
+func TestSplitModesCommand(t *testing.T) {
+
+	inFile := filepath.Join(inDir, "gobook.0.pdf")
+	config := pdfcpu.NewDefaultConfiguration()
+
+	for _, tt := range []struct {
+		name      string
+		cmd       func(dirOut string) *Command
+		fileCount int
+		fileName  string
+	}{
+		{"span", func(dirOut string) *Command { return SplitCommand(inFile, dirOut, 50, config) }, 4, "gobook.0_151-165.pdf"},
+		{"bookmark", func(dirOut string) *Command { return SplitByBookmarksCommand(inFile, dirOut, config) }, 14, "14 Next Steps.pdf"},
+		{"page", func(dirOut string) *Command { return SplitByPageNrsCommand(inFile, dirOut, []int{12, 5}, config) }, 3, "gobook.0_5-11.pdf"},
+		{"size", func(dirOut string) *Command { return SplitBySizeCommand(inFile, dirOut, 2700*1024, config) }, 2, "gobook.0_118-165.pdf"},
+	} {
+
+		dirOut, err := ioutil.TempDir(outDir, tt.name)
+		if err != nil {
+			t.Fatalf("TestSplitModesCommand(%s): %v\n", tt.name, err)
+		}
+
+		_, err = Process(tt.cmd(dirOut))
+		if err != nil {
+			t.Fatalf("TestSplitModesCommand(%s): %v\n", tt.name, err)
+		}
+
+		files, err := ioutil.ReadDir(dirOut)
+		if err != nil {
+			t.Fatalf("TestSplitModesCommand(%s): %v\n", tt.name, err)
+		}
+
+		if len(files) != tt.fileCount {
+			t.Fatalf("TestSplitModesCommand(%s): want %d files, got %d\n", tt.name, tt.fileCount, len(files))
+		}
+
+		fileName := filepath.Join(dirOut, tt.fileName)
+		if _, err = os.Stat(fileName); err != nil {
+			t.Fatalf("TestSplitModesCommand(%s): %v\n", tt.name, err)
+		}
+
+		if _, err = Process(ValidateCommand(fileName, config)); err != nil {
+			t.Fatalf("TestSplitModesCommand(%s): %v\n", tt.name, err)
+		}
+
+		if tt.name != "size" {
+			continue
+		}
+
+		for _, fi := range files {
+			if fi.Size() > 2700*1024 {
+				t.Fatalf("TestSplitModesCommand(%s): %s exceeds maximum size: %d\n", tt.name, fi.Name(), fi.Size())
+			}
+		}
+	}
+
+	_, err := Process(SplitByBookmarksCommand(filepath.Join(inDir, "CenterOfWhy.pdf"), outDir, config))
+	if err == nil {
+		t.Fatalf("TestSplitModesCommand: splitting a file without bookmarks should fail\n")
+	}
+}
+
 // Merge all PDFs in testdir into out/test.pdf.
 func TestMergeCommand(t *testing.T) {
 
@@ -270,14 +338,179 @@ func TestMergeCommand(t *testing.T) {
 	}
 
 	outFile := filepath.Join(outDir, "test.pdf")
-	_, err = Process(MergeCommand(inFiles, outFile, pdfcpu.NewDefaultConfiguration()))
+	_, err = Process(MergeCommand(inFiles, nil, outFile, false, pdfcpu.NewDefaultConfiguration()))
 	if err != nil {
 		t.Fatalf("TestMergeCommand: %v\n", err)
 	}
 
 }
 
+func TestMergeCommandWithBookmarks(t *testing.T) {
+
+	msg := "TestMergeCommandWithBookmarks"
+
+	inFiles := []string{filepath.Join(inDir, "gobook.0.pdf"), filepath.Join(inDir, "CenterOfWhy.pdf")}
+	pageSelections := [][]string{{"1-10"}, {"odd"}}
+	outFile := filepath.Join(outDir, "mergeBookmarks.pdf")
+
+	_, err := Process(MergeCommand(inFiles, pageSelections, outFile, true, pdfcpu.NewDefaultConfiguration()))
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	ctx, _, _, err := readAndValidate(outFile, pdfcpu.NewDefaultConfiguration(), time.Now())
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	if ctx.PageCount != 23 {
+		t.Fatalf("%s: pageCount should be 23 but is %d\n", msg, ctx.PageCount)
+	}
+
+	bms, err := pdfcpu.TopLevelBookmarks(ctx.XRefTable)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	if len(bms) != 2 || bms[0].PageNr != 1 || bms[1].PageNr != 11 {
+		t.Fatalf("%s: want one bookmark per file leading to pages 1 and 11, got %v\n", msg, bms)
+	}
+
+	// gobook.0.pdf has no document title.
+	if bms[0].Title != "gobook.0" {
+		t.Fatalf("%s: bookmark should be titled by the file name, got %s\n", msg, bms[0].Title)
+	}
+
+	if !strings.HasPrefix(bms[1].Title, "The Center of") {
+		t.Fatalf("%s: bookmark should be titled by the document title, got %s\n", msg, bms[1].Title)
+	}
+}
+
 // Trim test PDF file so that only the first two pages are rendered.
+func TestMergeZipCommand(t *testing.T) {
+
+	msg := "TestMergeZipCommand"
+
+	// Scanning a stack of 3 sheets yields the front sides and the back sides in reverse order.
+	// The back sides are told apart by their rotation.
+	fronts := filepath.Join(outDir, "fronts.pdf")
+	backs := filepath.Join(outDir, "backs.pdf")
+	config := pdfcpu.NewDefaultConfiguration()
+
+	for _, cmd := range []*Command{
+		TrimCommand(filepath.Join(inDir, "CenterOfWhy.pdf"), fronts, []string{"1-3"}, config),
+		TrimCommand(filepath.Join(inDir, "CenterOfWhy.pdf"), backs, []string{"1-3"}, config),
+		RotateCommand(backs, backs, 90, []string{"1"}, config),
+		RotateCommand(backs, backs, 180, []string{"2"}, config),
+		RotateCommand(backs, backs, 270, []string{"3"}, config),
+	} {
+		if _, err := Process(cmd); err != nil {
+			t.Fatalf("%s: %v\n", msg, err)
+		}
+	}
+
+	for _, tt := range []struct {
+		reverse   bool
+		rotations []int
+	}{
+		{false, []int{0, 90, 0, 180, 0, 270}},
+		{true, []int{0, 270, 0, 180, 0, 90}},
+	} {
+
+		outFile := filepath.Join(outDir, "zip.pdf")
+
+		_, err := Process(MergeZipCommand(fronts, backs, outFile, tt.reverse, pdfcpu.NewDefaultConfiguration()))
+		if err != nil {
+			t.Fatalf("%s: %v\n", msg, err)
+		}
+
+		ctx, _, _, err := readAndValidate(outFile, pdfcpu.NewDefaultConfiguration(), time.Now())
+		if err != nil {
+			t.Fatalf("%s: %v\n", msg, err)
+		}
+
+		if ctx.PageCount != 6 {
+			t.Fatalf("%s: pageCount should be 6 but is %d\n", msg, ctx.PageCount)
+		}
+
+		for i, want := range tt.rotations {
+			pageDict, _, err := ctx.PageDict(i + 1)
+			if err != nil {
+				t.Fatalf("%s: %v\n", msg, err)
+			}
+			got := 0
+			if r := pageDict.IntEntry("Rotate"); r != nil {
+				got = *r
+			}
+			if got != want {
+				t.Fatalf("%s reverse=%t: page %d should be rotated by %d but is rotated by %d\n", msg, tt.reverse, i+1, want, got)
+			}
+		}
+	}
+
+	// Outlines and forms survive zipping.
+	form := filepath.Join(outDir, "zipForm.pdf")
+	bookmarked := filepath.Join(outDir, "zipBookmarks.pdf")
+
+	xRefTable, err := pdfcpu.CreateAcroFormDemoXRef()
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	if err = pdfcpu.CreatePDF(xRefTable, outDir+"/", "zipForm.pdf"); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	inFiles := []string{filepath.Join(inDir, "gobook.0.pdf"), filepath.Join(inDir, "CenterOfWhy.pdf")}
+	if _, err = Process(MergeCommand(inFiles, [][]string{{"1-2"}, {"1"}}, bookmarked, true, config)); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	config = pdfcpu.NewDefaultConfiguration()
+	config.ValidationMode = pdfcpu.ValidationRelaxed
+
+	outFile := filepath.Join(outDir, "zipped.pdf")
+	if _, err = Process(MergeZipCommand(form, bookmarked, outFile, false, config)); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	config = pdfcpu.NewDefaultConfiguration()
+	config.ValidationMode = pdfcpu.ValidationRelaxed
+
+	ctx, _, _, err := readAndValidate(outFile, config, time.Now())
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	if ctx.PageCount != 4 {
+		t.Fatalf("%s: pageCount should be 4 but is %d\n", msg, ctx.PageCount)
+	}
+
+	rootDict, err := ctx.Catalog()
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	if _, found := rootDict.Find("AcroForm"); !found {
+		t.Fatalf("%s: missing form\n", msg)
+	}
+
+	bms, err := pdfcpu.TopLevelBookmarks(ctx.XRefTable)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	if len(bms) != 2 || bms[0].PageNr != 2 || bms[1].PageNr != 4 {
+		t.Fatalf("%s: want bookmarks leading to pages 2 and 4, got %v\n", msg, bms)
+	}
+
+	cmd := MergeZipCommand(fronts, backs, filepath.Join(outDir, "zip.pdf"), false, config)
+	cmd.InFiles = append(cmd.InFiles, fronts)
+	if _, err := Process(cmd); err == nil {
+		t.Fatalf("%s: zipping 3 files should fail\n", msg)
+	}
+}
+
 func TestTrimCommand(t *testing.T) {
 
 	inFile := filepath.Join(inDir, "pike-stanford.pdf")
@@ -880,7 +1113,7 @@ func encryptDecrypt(fileName string, config *pdfcpu.Configuration, t *testing.T)
 	config = pdfcpu.NewDefaultConfiguration()
 	config.UserPW = "upw"
 	config.OwnerPW = "opwWrong"
-	_, err = Process(SplitCommand(outFile, outDir, config))
+	_, err = Process(SplitCommand(outFile, outDir, 1, config))
 	if err == nil {
 		t.Fatalf("TestEncryptDecrypt - split %s using wrong ownerPW should fail: \n", outFile)
 	}
@@ -901,7 +1134,7 @@ func encryptDecrypt(fileName string, config *pdfcpu.Configuration, t *testing.T)
 	config = pdfcpu.NewDefaultConfiguration()
 	config.UserPW = "upw"
 	config.OwnerPW = "opwWrong"
-	_, err = Process(SplitCommand(outFile, outDir, config))
+	_, err = Process(SplitCommand(outFile, outDir, 1, config))
 	if err != nil {
 		t.Fatalf("TestEncryptDecrypt - split %s using wrong ownerPW: %v\n", outFile, err)
 	}
@@ -1249,3 +1482,1307 @@ func TestAcroformDemoPDF(t *testing.T) {
 	}
 
 }
+
+func TestInfoCommand(t *testing.T) {
+
+	for _, asJSON := range []bool{false, true} {
+		cmd := InfoCommand(filepath.Join(inDir, "5116.DCT_Filter.pdf"), asJSON, pdfcpu.NewDefaultConfiguration())
+		if _, err := Process(cmd); err != nil {
+			t.Fatalf("TestInfoCommand: %v\n", err)
+		}
+	}
+}
+
+func TestRotateCommand(t *testing.T) {
+
+	inFile := filepath.Join(inDir, "pike-stanford.pdf")
+	outFile := filepath.Join(outDir, "test.pdf")
+
+	_, err := Process(RotateCommand(inFile, outFile, 90, []string{"odd"}, pdfcpu.NewDefaultConfiguration()))
+	if err != nil {
+		t.Fatalf("TestRotateCommand: %v\n", err)
+	}
+
+}
+
+func TestPagesCommand(t *testing.T) {
+
+	inFile := filepath.Join(inDir, "pike-stanford.pdf")
+	outFile := filepath.Join(outDir, "test.pdf")
+
+	_, err := Process(InsertPagesCommand(inFile, outFile, "", false, []string{"odd"}, pdfcpu.NewDefaultConfiguration()))
+	if err != nil {
+		t.Fatalf("TestPagesCommand insert: %v\n", err)
+	}
+
+	_, err = Process(InsertPagesCommand(outFile, outFile, filepath.Join(inDir, "CenterOfWhy.pdf"), true, []string{"1"}, pdfcpu.NewDefaultConfiguration()))
+	if err != nil {
+		t.Fatalf("TestPagesCommand insert from file: %v\n", err)
+	}
+
+	// Without a page selection the pages of the source file get appended.
+	_, err = Process(InsertPagesCommand(outFile, outFile, filepath.Join(inDir, "CenterOfWhy.pdf"), false, nil, pdfcpu.NewDefaultConfiguration()))
+	if err != nil {
+		t.Fatalf("TestPagesCommand insert from file w/o page selection: %v\n", err)
+	}
+
+	_, err = Process(RemovePagesCommand(outFile, outFile, []string{"even"}, pdfcpu.NewDefaultConfiguration()))
+	if err != nil {
+		t.Fatalf("TestPagesCommand remove: %v\n", err)
+	}
+
+}
+
+func TestNUpCommand(t *testing.T) {
+
+	inFile := filepath.Join(inDir, "Acroforms2.pdf")
+	outFile := filepath.Join(outDir, "test.pdf")
+
+	nup, err := pdfcpu.ParseNUpDetails("f:LetterL, o:dr", 4)
+	if err != nil {
+		t.Fatalf("TestNUpCommand: %v\n", err)
+	}
+
+	_, err = Process(NUpCommand(inFile, outFile, nil, nup, pdfcpu.NewDefaultConfiguration()))
+	if err != nil {
+		t.Fatalf("TestNUpCommand: %v\n", err)
+	}
+
+	// A4 cells of 16-up are 148 points wide.
+	if _, err = pdfcpu.ParseNUpDetails("m:70", 16); err != nil {
+		t.Fatalf("TestNUpCommand: %v\n", err)
+	}
+
+	if _, err = pdfcpu.ParseNUpDetails("m:80", 16); err == nil {
+		t.Fatalf("TestNUpCommand: want error for margin exceeding the cell size\n")
+	}
+
+}
+
+func TestBookletCommand(t *testing.T) {
+
+	inFile := filepath.Join(inDir, "Acroforms2.pdf")
+	outFile := filepath.Join(outDir, "test.pdf")
+
+	booklet, err := pdfcpu.ParseBookletDetails("f:Letter, b:top, c:1, m:10")
+	if err != nil {
+		t.Fatalf("TestBookletCommand: %v\n", err)
+	}
+
+	_, err = Process(BookletCommand(inFile, outFile, nil, booklet, pdfcpu.NewDefaultConfiguration()))
+	if err != nil {
+		t.Fatalf("TestBookletCommand: %v\n", err)
+	}
+
+	// Creep must not move the pages of the innermost sheet across the fold.
+	if _, err = pdfcpu.ParseBookletDetails("s:4, c:5, m:10"); err == nil {
+		t.Fatalf("TestBookletCommand: creep exceeding the margin should fail\n")
+	}
+
+	if booklet, err = pdfcpu.ParseBookletDetails("c:5, m:10"); err != nil {
+		t.Fatalf("TestBookletCommand: %v\n", err)
+	}
+
+	// A single signature of 7 sheets.
+	inFile = filepath.Join(inDir, "CenterOfWhy.pdf")
+
+	_, err = Process(BookletCommand(inFile, outFile, nil, booklet, pdfcpu.NewDefaultConfiguration()))
+	if err == nil {
+		t.Fatalf("TestBookletCommand: creep exceeding the margin should fail\n")
+	}
+
+}
+
+func TestCollectCommand(t *testing.T) {
+
+	inFile := filepath.Join(inDir, "Acroforms2.pdf")
+	outFile := filepath.Join(outDir, "test.pdf")
+
+	pages, err := ParsePageCollection("last-1,1")
+	if err != nil {
+		t.Fatalf("TestCollectCommand: %v\n", err)
+	}
+
+	_, err = Process(CollectCommand(inFile, outFile, pages, pdfcpu.NewDefaultConfiguration()))
+	if err != nil {
+		t.Fatalf("TestCollectCommand: %v\n", err)
+	}
+
+}
+
+func TestImportImagesCommand(t *testing.T) {
+
+	msg := "TestImportImagesCommand"
+
+	// Create a landscape JPEG.
+	img := image.NewRGBA(image.Rect(0, 0, 200, 100))
+	for x := 0; x < 200; x++ {
+		for y := 0; y < 100; y++ {
+			img.Set(x, y, color.RGBA{uint8(x), uint8(y), 128, 255})
+		}
+	}
+
+	jpgFile := filepath.Join(outDir, "import.jpg")
+
+	f, err := os.Create(jpgFile)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	if err = jpeg.Encode(f, img, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	f.Close()
+
+	imgFiles := []string{
+		"../../resources/demo.png",
+		"../../resources/pdfchip3.png",
+		"../pdfcpu/testdata/video-001.tiff",
+		jpgFile,
+	}
+
+	outFile := filepath.Join(outDir, "import.pdf")
+
+	imp, err := pdfcpu.ParseImportDetails("f:A4, m:20, p:tc")
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	// Create a new file.
+	_, err = Process(ImportImagesCommand(imgFiles, outFile, imp, pdfcpu.NewDefaultConfiguration()))
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	ctx, _, _, err := readAndValidate(outFile, pdfcpu.NewDefaultConfiguration(), time.Now())
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	if ctx.PageCount != 4 {
+		t.Fatalf("%s: pageCount should be 4 but is %d\n", msg, ctx.PageCount)
+	}
+
+	// The JPEG page is A4 landscape and embeds the JPEG as is.
+	pageDict, _, err := ctx.PageDict(4)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	if mb := pageDict.ArrayEntry("MediaBox"); mb == nil || ctx.DereferenceNumber((*mb)[2]) != 842 {
+		t.Fatalf("%s: want A4 landscape media box, got %v\n", msg, mb)
+	}
+
+	buf, err := ioutil.ReadFile(jpgFile)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	resDict, err := ctx.DereferenceDict((*pageDict)["Resources"])
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	xObjDict, err := ctx.DereferenceDict((*resDict)["XObject"])
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	sd, err := ctx.DereferenceStreamDict((*xObjDict)["Im0"])
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	if f := sd.NameEntry("Filter"); f == nil || *f != "DCTDecode" || len(sd.Raw) != len(buf) {
+		t.Fatalf("%s: JPEG should be embedded as is\n", msg)
+	}
+
+	// Append to the existing file using the image size.
+	_, err = Process(ImportImagesCommand(imgFiles[:1], outFile, nil, pdfcpu.NewDefaultConfiguration()))
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	ctx, _, _, err = readAndValidate(outFile, pdfcpu.NewDefaultConfiguration(), time.Now())
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	if ctx.PageCount != 5 {
+		t.Fatalf("%s: pageCount should be 5 but is %d\n", msg, ctx.PageCount)
+	}
+}
+
+func TestBoxesCommand(t *testing.T) {
+
+	msg := "TestBoxesCommand"
+
+	inFile := filepath.Join(inDir, "gobook.0.pdf")
+	outFile := filepath.Join(outDir, "boxes.pdf")
+	config := pdfcpu.NewDefaultConfiguration()
+
+	pageBox := func(pageNr int, k string) string {
+		ctx, _, _, err := readAndValidate(outFile, pdfcpu.NewDefaultConfiguration(), time.Now())
+		if err != nil {
+			t.Fatalf("%s: %v\n", msg, err)
+		}
+		d, _, err := ctx.PageDict(pageNr)
+		if err != nil {
+			t.Fatalf("%s: %v\n", msg, err)
+		}
+		a := d.ArrayEntry(k)
+		if a == nil {
+			return ""
+		}
+		var ss []string
+		for _, o := range *a {
+			ss = append(ss, fmt.Sprintf("%.0f", ctx.DereferenceNumber(o)))
+		}
+		return strings.Join(ss, " ")
+	}
+
+	// Margins are given as displayed, page 2 is displayed rotated by 90 degrees.
+	_, err := Process(RotateCommand(inFile, outFile, 90, []string{"2"}, config))
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	pbs, err := pdfcpu.ParseBoxes("crop: [0 0 400 600], trim: 10 20 30 40")
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	_, err = Process(AddBoxesCommand(outFile, outFile, []string{"1-2"}, pbs, pdfcpu.NewDefaultConfiguration()))
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	if s := pageBox(1, "TrimBox"); s != "40 30 380 590" {
+		t.Fatalf("%s: page 1: want trim box 40 30 380 590, got %s\n", msg, s)
+	}
+
+	if s := pageBox(2, "TrimBox"); s != "10 40 370 580" {
+		t.Fatalf("%s: page 2: want trim box 10 40 370 580, got %s\n", msg, s)
+	}
+
+	list, err := Process(ListBoxesCommand(outFile, []string{"1"}, pdfcpu.NewDefaultConfiguration()))
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	if len(list) != 6 || !strings.HasSuffix(list[3], "(default)") {
+		t.Fatalf("%s: want 5 boxes with a default bleed box, got %v\n", msg, list)
+	}
+
+	_, err = Process(CropCommand(outFile, outFile, []string{"1"}, pdfcpu.NewDefaultConfiguration()))
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	if s := pageBox(1, "CropBox"); s != "40 30 380 590" {
+		t.Fatalf("%s: want crop box 40 30 380 590, got %s\n", msg, s)
+	}
+
+	pbs, err = pdfcpu.ParseBoxNames("trim")
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	_, err = Process(RemoveBoxesCommand(outFile, outFile, nil, pbs, pdfcpu.NewDefaultConfiguration()))
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	if s := pageBox(2, "TrimBox"); s != "" {
+		t.Fatalf("%s: trim box should be removed, got %s\n", msg, s)
+	}
+
+	// The media box is mandatory.
+	pbs, err = pdfcpu.ParseBoxNames("media")
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	_, err = Process(RemoveBoxesCommand(outFile, outFile, nil, pbs, pdfcpu.NewDefaultConfiguration()))
+	if err == nil {
+		t.Fatalf("%s: removing the media box should fail\n", msg)
+	}
+
+	if _, err = pdfcpu.ParseBoxes("trim: 1 2 3"); err == nil {
+		t.Fatalf("%s: 3 margins should fail\n", msg)
+	}
+}
+
+func TestResizeCommand(t *testing.T) {
+
+	msg := "TestResizeCommand"
+
+	config := pdfcpu.NewDefaultConfiguration()
+
+	rect := func(ctx *pdfcpu.Context, o pdfcpu.Object) string {
+		a, err := ctx.DereferenceArray(o)
+		if err != nil || a == nil {
+			t.Fatalf("%s: missing rectangle: %v\n", msg, err)
+		}
+		var ss []string
+		for _, o := range *a {
+			ss = append(ss, fmt.Sprintf("%.2f", ctx.DereferenceNumber(o)))
+		}
+		return strings.Join(ss, " ")
+	}
+
+	firstAnnotRect := func(ctx *pdfcpu.Context, pageDict *pdfcpu.Dict) string {
+		o, _ := pageDict.Find("Annots")
+		annots, err := ctx.DereferenceArray(o)
+		if err != nil || annots == nil || len(*annots) == 0 {
+			t.Fatalf("%s: missing annotations: %v\n", msg, err)
+		}
+		d, err := ctx.DereferenceDict((*annots)[0])
+		if err != nil || d == nil {
+			t.Fatalf("%s: corrupt annotation: %v\n", msg, err)
+		}
+		o, _ = d.Find("Rect")
+		return rect(ctx, o)
+	}
+
+	// Scale a page with annotations by 0.5.
+	inFile := filepath.Join(inDir, "annotTest.pdf")
+	outFile := filepath.Join(outDir, "resize.pdf")
+
+	ctx, _, _, err := readAndValidate(inFile, config, time.Now())
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	pageDict, _, err := ctx.PageDict(1)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	var want []string
+	for _, s := range strings.Fields(firstAnnotRect(ctx, pageDict)) {
+		var f float64
+		fmt.Sscanf(s, "%f", &f)
+		want = append(want, fmt.Sprintf("%.2f", f/2))
+	}
+
+	r, err := pdfcpu.ParseResizeDetails("s:0.5")
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	_, err = Process(ResizeCommand(inFile, outFile, nil, r, pdfcpu.NewDefaultConfiguration()))
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	if ctx, _, _, err = readAndValidate(outFile, pdfcpu.NewDefaultConfiguration(), time.Now()); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	if pageDict, _, err = ctx.PageDict(1); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	if s := rect(ctx, (*pageDict)["MediaBox"]); s != "0.00 0.00 297.72 420.96" {
+		t.Fatalf("%s: want media box 0 0 297.72 420.96, got %s\n", msg, s)
+	}
+
+	if s := firstAnnotRect(ctx, pageDict); s != strings.Join(want, " ") {
+		t.Fatalf("%s: want annotation rect %s, got %s\n", msg, strings.Join(want, " "), s)
+	}
+
+	// Fit all pages to Letter.
+	inFile = filepath.Join(inDir, "gobook.0.pdf")
+
+	if r, err = pdfcpu.ParseResizeDetails("f:Letter"); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	_, err = Process(ResizeCommand(inFile, outFile, nil, r, pdfcpu.NewDefaultConfiguration()))
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	if ctx, _, _, err = readAndValidate(outFile, pdfcpu.NewDefaultConfiguration(), time.Now()); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	for _, pageNr := range []int{1, ctx.PageCount} {
+		if pageDict, _, err = ctx.PageDict(pageNr); err != nil {
+			t.Fatalf("%s: %v\n", msg, err)
+		}
+		if s := rect(ctx, (*pageDict)["MediaBox"]); s != "0.00 0.00 612.00 792.00" {
+			t.Fatalf("%s: page %d: want media box 0 0 612 792, got %s\n", msg, pageNr, s)
+		}
+	}
+
+	// Content outside the crop box stays hidden.
+	if ctx, _, _, err = readAndValidate(inFile, pdfcpu.NewDefaultConfiguration(), time.Now()); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	if pageDict, _, err = ctx.PageDict(1); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	pageDict.Update("CropBox", pdfcpu.NewRectangle(50, 50, 400, 500))
+
+	if err = pdfcpu.ResizePages(ctx.XRefTable, pdfcpu.IntSet{1: true}, r); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	contents, err := ctx.DereferenceArray((*pageDict)["Contents"])
+	if err != nil || contents == nil {
+		t.Fatalf("%s: missing page content: %v\n", msg, err)
+	}
+
+	sd, err := ctx.DereferenceStreamDict((*contents)[0])
+	if err != nil || sd == nil {
+		t.Fatalf("%s: missing page content: %v\n", msg, err)
+	}
+
+	if clip := "50.00000 50.00000 350.00000 450.00000 re W n"; !strings.Contains(string(sd.Content), clip) {
+		t.Fatalf("%s: want clip %s, got %s\n", msg, clip, sd.Content)
+	}
+
+	if s := rect(ctx, (*pageDict)["CropBox"]); s != "0.00 0.00 612.00 792.00" {
+		t.Fatalf("%s: want crop box 0 0 612 792, got %s\n", msg, s)
+	}
+
+	if _, err = pdfcpu.ParseResizeDetails("f:A4, s:2"); err == nil {
+		t.Fatalf("%s: paper size and scale factor should be exclusive\n", msg)
+	}
+}
+
+func TestPageLabelsCommand(t *testing.T) {
+
+	msg := "TestPageLabelsCommand"
+
+	inFile := filepath.Join(inDir, "gobook.0.pdf")
+	outFile := filepath.Join(outDir, "pageLabels.pdf")
+
+	listPageLabels := func() []string {
+		list, err := Process(ListPageLabelsCommand(outFile, pdfcpu.NewDefaultConfiguration()))
+		if err != nil {
+			t.Fatalf("%s: %v\n", msg, err)
+		}
+		return list
+	}
+
+	checkLabels := func(want ...string) {
+		list := listPageLabels()
+		if len(list) != len(want) {
+			t.Fatalf("%s: want %d page label ranges, got %v\n", msg, len(want), list)
+		}
+		for i, s := range want {
+			if !strings.HasPrefix(list[i], s) {
+				t.Fatalf("%s: want page label range %s, got %s\n", msg, s, list[i])
+			}
+		}
+	}
+
+	pl, err := pdfcpu.ParsePageLabelDetails("s:r")
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	_, err = Process(AddPageLabelsCommand(inFile, outFile, []string{"1-3"}, pl, pdfcpu.NewDefaultConfiguration()))
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	// The pages following the roman numbered range keep their labels.
+	checkLabels(`pages 1-3: style=roman lowercase, prefix="", start=1 -> "i" ... "iii"`, `pages 4-`)
+
+	pl, err = pdfcpu.ParsePageLabelDetails("p:A-, st:10")
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	_, err = Process(AddPageLabelsCommand(outFile, outFile, []string{"4"}, pl, pdfcpu.NewDefaultConfiguration()))
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	checkLabels(`pages 1-3`, `page 4: style=decimal, prefix="A-", start=10 -> "A-10"`, `pages 5-`)
+
+	if list := listPageLabels(); !strings.Contains(list[2], `start=5 -> "5"`) {
+		t.Fatalf("%s: want page 5 labeled 5, got %s\n", msg, list[2])
+	}
+
+	_, err = Process(RemovePageLabelsCommand(outFile, outFile, []string{"4"}, pdfcpu.NewDefaultConfiguration()))
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	checkLabels(`pages 1-4: style=roman lowercase, prefix="", start=1 -> "i" ... "iv"`, `pages 5-`)
+
+	_, err = Process(RemovePageLabelsCommand(outFile, outFile, nil, pdfcpu.NewDefaultConfiguration()))
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	checkLabels()
+}
+
+func TestBookmarksCommand(t *testing.T) {
+
+	msg := "TestBookmarksCommand"
+
+	inFile := filepath.Join(inDir, "gobook.0.pdf")
+	outFile := filepath.Join(outDir, "bookmarks.pdf")
+	jsonFile := filepath.Join(outDir, "bookmarks.json")
+	exportFile := filepath.Join(outDir, "bookmarksExport.json")
+
+	bookmarks := `{"bookmarks": [
+		{"title": "Preface", "page": 1},
+		{"title": "Part 1", "page": 3, "bold": true, "color": [1, 0, 0], "open": true, "kids": [
+			{"title": "Chapter 1", "page": 3, "zoom": 1.5},
+			{"title": "Chapter 2", "page": 10, "italic": true, "kids": [
+				{"title": "Section 2.1", "page": 11}
+			]}
+		]},
+		{"title": "Index"}
+	]}`
+
+	if err := ioutil.WriteFile(jsonFile, []byte(bookmarks), os.ModePerm); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	_, err := Process(ImportBookmarksCommand(inFile, jsonFile, outFile, pdfcpu.NewDefaultConfiguration()))
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	list, err := Process(ListBookmarksCommand(outFile, false, pdfcpu.NewDefaultConfiguration()))
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	want := []string{"Preface (page 1)", "Part 1 (page 3)", "  Chapter 1 (page 3)", "  Chapter 2 (page 10)", "    Section 2.1 (page 11)", "Index"}
+	if strings.Join(list, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("%s: want bookmarks %v, got %v\n", msg, want, list)
+	}
+
+	_, err = Process(ExportBookmarksCommand(outFile, exportFile, pdfcpu.NewDefaultConfiguration()))
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	f, err := os.Open(exportFile)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	defer f.Close()
+
+	bms, err := unmarshalBookmarks(f)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	part := bms[1]
+	if !part.Bold || !part.Open || len(part.Color) != 3 || part.Color[0] != 1 || part.Kids[0].Zoom != 1.5 || !part.Kids[1].Italic || part.Kids[1].Open {
+		t.Fatalf("%s: bookmark attributes got lost: %+v\n", msg, part)
+	}
+
+	// The outline shows the top level items, the kids of the open item and nothing below the closed item.
+	ctx, _, _, err := readAndValidate(outFile, pdfcpu.NewDefaultConfiguration(), time.Now())
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	rootDict, err := ctx.Catalog()
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	o, _ := rootDict.Find("Outlines")
+	outlinesDict, err := ctx.DereferenceDict(o)
+	if err != nil || outlinesDict == nil {
+		t.Fatalf("%s: missing outline dict %v\n", msg, err)
+	}
+
+	if c := outlinesDict.IntEntry("Count"); c == nil || *c != 5 {
+		t.Fatalf("%s: want 5 visible outline items, got %v\n", msg, c)
+	}
+
+	_, err = Process(RemoveBookmarksCommand(outFile, outFile, pdfcpu.NewDefaultConfiguration()))
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	list, err = Process(ListBookmarksCommand(outFile, true, pdfcpu.NewDefaultConfiguration()))
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	if len(list) != 1 || !strings.Contains(list[0], `"bookmarks": null`) {
+		t.Fatalf("%s: want no bookmarks, got %v\n", msg, list)
+	}
+}
+
+func TestRemovePagesPrunesDestinations(t *testing.T) {
+
+	msg := "TestRemovePagesPrunesDestinations"
+
+	inFile := filepath.Join(inDir, "gobook.0.pdf")
+	outFile := filepath.Join(outDir, "prune.pdf")
+	jsonFile := filepath.Join(outDir, "prune.json")
+
+	bookmarks := `{"bookmarks": [
+		{"title": "Preface", "page": 1},
+		{"title": "Part 1", "page": 3, "open": true, "kids": [
+			{"title": "Chapter 1", "page": 3},
+			{"title": "Chapter 2", "page": 10, "kids": [
+				{"title": "Section 2.1", "page": 11}
+			]}
+		]}
+	]}`
+
+	if err := ioutil.WriteFile(jsonFile, []byte(bookmarks), os.ModePerm); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	if _, err := Process(ImportBookmarksCommand(inFile, jsonFile, outFile, pdfcpu.NewDefaultConfiguration())); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	annots := `{"annotations": [
+		{"type": "Link", "page": 2, "rect": [100, 560, 300, 580], "dest": 5},
+		{"type": "Link", "page": 2, "rect": [100, 520, 300, 540], "dest": 11}
+	]}`
+
+	if err := ioutil.WriteFile(jsonFile, []byte(annots), os.ModePerm); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	if _, err := Process(AddAnnotationsCommand(outFile, jsonFile, outFile, pdfcpu.NewDefaultConfiguration())); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	if _, err := Process(RemovePagesCommand(outFile, outFile, []string{"3-10"}, pdfcpu.NewDefaultConfiguration())); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	if _, err := Process(ValidateCommand(outFile, pdfcpu.NewDefaultConfiguration())); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	// Outline items leading to removed pages are gone unless they have kids.
+	list, err := Process(ListBookmarksCommand(outFile, false, pdfcpu.NewDefaultConfiguration()))
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	want := []string{"Preface (page 1)", "Part 1", "  Chapter 2", "    Section 2.1 (page 3)"}
+	if strings.Join(list, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("%s: want bookmarks %v, got %v\n", msg, want, list)
+	}
+
+	// Only the link to the former page 11 survives.
+	n := 0
+	for _, a := range annotationsForFile(t, msg, outFile) {
+		if a.Type == "Link" && a.URI == "" {
+			if a.Dest == 0 {
+				t.Fatalf("%s: dangling link %s\n", msg, a)
+			}
+			if a.PageNr == 2 {
+				n++
+			}
+		}
+	}
+
+	if n != 1 {
+		t.Fatalf("%s: want 1 link on page 2, got %d\n", msg, n)
+	}
+}
+
+func TestPropertiesCommand(t *testing.T) {
+
+	msg := "TestPropertiesCommand"
+
+	inFile := filepath.Join(inDir, "gobook.0.pdf")
+	outFile := filepath.Join(outDir, "properties.pdf")
+
+	encConfig := func() *pdfcpu.Configuration {
+		config := pdfcpu.NewDefaultConfiguration()
+		config.UserPW = "upw"
+		config.OwnerPW = "opw"
+		return config
+	}
+
+	_, err := Process(EncryptCommand(inFile, outFile, encConfig()))
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	props := map[string]string{"Title": "Grüße aus 東京", "Author": "PlainAuthorName", "Reviewer": "Jane Doe"}
+
+	_, err = Process(AddPropertiesCommand(outFile, props, encConfig()))
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	// String values get encrypted along with all other strings.
+	bb, err := ioutil.ReadFile(outFile)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	if strings.Contains(string(bb), "PlainAuthorName") {
+		t.Fatalf("%s: property values should be encrypted\n", msg)
+	}
+
+	list, err := Process(ListPropertiesCommand(outFile, encConfig()))
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	for _, s := range []string{"Author = PlainAuthorName", "Reviewer = Jane Doe", "Title = Grüße aus 東京"} {
+		if !pdfcpu.MemberOf(s, list) {
+			t.Fatalf("%s: missing property %s in %v\n", msg, s, list)
+		}
+	}
+
+	_, err = Process(AddPropertiesCommand(outFile, map[string]string{"Producer": "me"}, encConfig()))
+	if err == nil {
+		t.Fatalf("%s: Producer should be maintained by pdfcpu\n", msg)
+	}
+
+	_, err = Process(RemovePropertiesCommand(outFile, []string{"Reviewer"}, encConfig()))
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	list, err = Process(ListPropertiesCommand(outFile, encConfig()))
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	if pdfcpu.MemberOf("Reviewer = Jane Doe", list) || !pdfcpu.MemberOf("Author = PlainAuthorName", list) {
+		t.Fatalf("%s: want Reviewer removed, got %v\n", msg, list)
+	}
+
+	_, err = Process(RemovePropertiesCommand(outFile, nil, encConfig()))
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	list, err = Process(ListPropertiesCommand(outFile, encConfig()))
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	for _, s := range list {
+		if !strings.HasPrefix(s, "Producer") && !strings.HasPrefix(s, "CreationDate") && !strings.HasPrefix(s, "ModDate") {
+			t.Fatalf("%s: want only properties maintained by pdfcpu, got %v\n", msg, list)
+		}
+	}
+}
+
+func metadataForFile(t *testing.T, msg, fileName string) *xmp.Metadata {
+
+	f, err := os.Open(fileName)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	defer f.Close()
+
+	md, err := MetadataReader(f, nil)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	if md == nil {
+		t.Fatalf("%s: missing metadata in %s\n", msg, fileName)
+	}
+
+	return md
+}
+
+func TestMetadata(t *testing.T) {
+
+	msg := "TestMetadata"
+
+	inFile := filepath.Join(inDir, "gobook.0.pdf")
+	outFile := filepath.Join(outDir, "metadata.pdf")
+
+	f, err := os.Open(inFile)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	defer f.Close()
+
+	w, err := os.Create(outFile)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	md := &xmp.Metadata{}
+	md.DC.Title = "Grüße"
+	md.DC.Creator = []string{"Jane", "John"}
+	md.PDFAID.Part, md.PDFAID.Conformance = 2, "B"
+
+	err = SetMetadataReader(f, w, md, nil)
+	w.Close()
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	_, err = Process(ValidateCommand(outFile, pdfcpu.NewDefaultConfiguration()))
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	md = metadataForFile(t, msg, outFile)
+
+	if md.DC.Title != "Grüße" || len(md.DC.Creator) != 2 || md.PDFAID.Part != 2 {
+		t.Fatalf("%s: got %+v\n", msg, md)
+	}
+
+	// pdfcpu maintains the producer and dates of the document info dict.
+	if md.PDF.Producer != pdfcpu.PDFCPULongVersion || md.XMP.ModifyDate == "" {
+		t.Fatalf("%s: want metadata in sync with info dict, got %+v\n", msg, md)
+	}
+
+	_, err = Process(AddPropertiesCommand(outFile, map[string]string{"Title": "Changed", "Keywords": "a b"}, pdfcpu.NewDefaultConfiguration()))
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	md = metadataForFile(t, msg, outFile)
+
+	if md.DC.Title != "Changed" || md.PDF.Keywords != "a b" || len(md.DC.Creator) != 2 {
+		t.Fatalf("%s: want metadata in sync with added properties, got %+v\n", msg, md)
+	}
+
+	_, err = Process(RemovePropertiesCommand(outFile, []string{"Title"}, pdfcpu.NewDefaultConfiguration()))
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	md = metadataForFile(t, msg, outFile)
+
+	if md.DC.Title != "" || md.PDFAID.Conformance != "B" {
+		t.Fatalf("%s: want metadata in sync with removed properties, got %+v\n", msg, md)
+	}
+}
+
+func TestMetadataWithoutInfoDict(t *testing.T) {
+
+	msg := "TestMetadataWithoutInfoDict"
+
+	inFile := filepath.Join(inDir, "gobook.0.pdf")
+	outFile := filepath.Join(outDir, "metadataRotated.pdf")
+
+	for _, strip := range []func(ctx *pdfcpu.Context) error{
+
+		// XMP metadata along with a document info dict lacking the title.
+		func(ctx *pdfcpu.Context) error {
+			d, err := ctx.DereferenceDict(*ctx.Info)
+			if err == nil {
+				d.Delete("Title")
+			}
+			return err
+		},
+
+		// XMP metadata without document info dict.
+		func(ctx *pdfcpu.Context) error {
+			ctx.Info = nil
+			return nil
+		},
+	} {
+
+		f, err := os.Open(inFile)
+		if err != nil {
+			t.Fatalf("%s: %v\n", msg, err)
+		}
+
+		ctx, err := readAndValidateContext(f, pdfcpu.NewDefaultConfiguration())
+		f.Close()
+		if err != nil {
+			t.Fatalf("%s: %v\n", msg, err)
+		}
+
+		md := &xmp.Metadata{}
+		md.DC.Title = "Grüße"
+		md.DC.Creator = []string{"Jane", "John"}
+
+		if err = pdfcpu.SetMetadata(ctx.XRefTable, md); err != nil {
+			t.Fatalf("%s: %v\n", msg, err)
+		}
+
+		if err = strip(ctx); err != nil {
+			t.Fatalf("%s: %v\n", msg, err)
+		}
+
+		if err = pdfcpu.RotatePages(ctx.XRefTable, pdfcpu.IntSet{1: true}, 90); err != nil {
+			t.Fatalf("%s: %v\n", msg, err)
+		}
+
+		w, err := os.Create(outFile)
+		if err != nil {
+			t.Fatalf("%s: %v\n", msg, err)
+		}
+
+		err = WriteContext(ctx, w)
+		w.Close()
+		if err != nil {
+			t.Fatalf("%s: %v\n", msg, err)
+		}
+
+		md = metadataForFile(t, msg, outFile)
+
+		if md.DC.Title != "Grüße" || len(md.DC.Creator) != 2 {
+			t.Fatalf("%s: want title and creators to survive, got %+v\n", msg, md.DC)
+		}
+	}
+
+	// A document info dict created on write gets seeded from the XMP metadata.
+	list, err := Process(ListPropertiesCommand(outFile, pdfcpu.NewDefaultConfiguration()))
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	if !pdfcpu.MemberOf("Title = Grüße", list) || !pdfcpu.MemberOf("Author = Jane, John", list) {
+		t.Fatalf("%s: want info dict in sync with metadata, got %v\n", msg, list)
+	}
+}
+
+func annotationsForFile(t *testing.T, msg, fileName string) []pdfcpu.Annotation {
+
+	t.Helper()
+
+	f, err := os.Open(fileName)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	defer f.Close()
+
+	annots, err := AnnotationsReader(f, nil, nil)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	return annots
+}
+
+func TestAnnotationsCommand(t *testing.T) {
+
+	msg := "TestAnnotationsCommand"
+
+	inFile := filepath.Join(inDir, "gobook.0.pdf")
+	jsonFile := filepath.Join(outDir, "annotations.json")
+	outFile := filepath.Join(outDir, "annotations.pdf")
+
+	json := `{"annotations": [
+		{"type": "Text", "page": 1, "rect": [100, 700, 120, 720], "id": "note", "contents": "Grüße", "open": true},
+		{"type": "Link", "page": 1, "rect": [100, 600, 300, 620], "uri": "https://pdfcpu.io/a(b\\c)(d"},
+		{"type": "Link", "page": 2, "rect": [100, 560, 300, 580], "dest": 1},
+		{"type": "Square", "page": 1, "rect": [100, 300, 300, 350], "interiorColor": [0, 0, 1]},
+		{"type": "Highlight", "page": 2, "rect": [72, 150, 300, 165], "color": [1, 1, 0]},
+		{"type": "Ink", "page": 1, "rect": [400, 600, 550, 650], "inkList": [[400, 600, 550, 650]]},
+		{"type": "FileAttachment", "page": 1, "rect": [400, 500, 420, 520], "file": "` + filepath.ToSlash(filepath.Join(inDir, "test.wav")) + `"}
+	]}`
+
+	if err := ioutil.WriteFile(jsonFile, []byte(json), 0644); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	n := len(annotationsForFile(t, msg, inFile))
+
+	if _, err := Process(AddAnnotationsCommand(inFile, jsonFile, outFile, pdfcpu.NewDefaultConfiguration())); err != nil {
+		t.Fatalf("%s add: %v\n", msg, err)
+	}
+
+	if _, err := Process(ValidateCommand(outFile, pdfcpu.NewDefaultConfiguration())); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	annots := annotationsForFile(t, msg, outFile)
+	if len(annots) != n+7 {
+		t.Fatalf("%s: want %d annotations, got %d\n", msg, n+7, len(annots))
+	}
+
+	found := false
+	for _, a := range annots {
+		if a.ID == "note" {
+			found = a.Type == "Text" && a.PageNr == 1 && a.Contents == "Grüße"
+		}
+	}
+	if !found {
+		t.Fatalf("%s: missing annotation note\n", msg)
+	}
+
+	// URIs may contain characters needing escapes.
+	found = false
+	for _, a := range annots {
+		if a.Type == "Link" && a.URI != "" {
+			found = a.URI == `https://pdfcpu.io/a(b\c)(d`
+		}
+	}
+	if !found {
+		t.Fatalf("%s: missing link to https://pdfcpu.io/a(b\\c)(d\n", msg)
+	}
+
+	// Annotation ids need to be unique per page.
+	if _, err := Process(AddAnnotationsCommand(outFile, jsonFile, outFile, pdfcpu.NewDefaultConfiguration())); err == nil {
+		t.Fatalf("%s: want error for duplicate annotation id\n", msg)
+	}
+
+	if _, err := Process(RemoveAnnotationsCommand(outFile, outFile, nil, []string{"Link", "note"}, pdfcpu.NewDefaultConfiguration())); err != nil {
+		t.Fatalf("%s remove: %v\n", msg, err)
+	}
+
+	if _, err := Process(ValidateCommand(outFile, pdfcpu.NewDefaultConfiguration())); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	annots = annotationsForFile(t, msg, outFile)
+	if len(annots) >= n+4 {
+		t.Fatalf("%s: want less than %d annotations, got %d\n", msg, n+4, len(annots))
+	}
+
+	for _, a := range annots {
+		if a.Type == "Link" || a.ID == "note" {
+			t.Fatalf("%s: want %s removed\n", msg, a)
+		}
+	}
+
+	// Removing a markup annotation also removes its popup.
+	inFile = filepath.Join(inDir, "annotTest.pdf")
+
+	if _, err := Process(RemoveAnnotationsCommand(inFile, outFile, nil, []string{"9"}, pdfcpu.NewDefaultConfiguration())); err != nil {
+		t.Fatalf("%s remove: %v\n", msg, err)
+	}
+
+	for _, a := range annotationsForFile(t, msg, outFile) {
+		if a.ID == "9" || a.ID == "28" {
+			t.Fatalf("%s: want %s removed\n", msg, a)
+		}
+	}
+}
+
+func TestFlattenCommand(t *testing.T) {
+
+	msg := "TestFlattenCommand"
+
+	xRefTable, err := pdfcpu.CreateAcroFormDemoXRef()
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	err = pdfcpu.CreatePDF(xRefTable, outDir+"/", "acroFormFlatten.pdf")
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	inFile := filepath.Join(outDir, "acroFormFlatten.pdf")
+	outFile := filepath.Join(outDir, "flattened.pdf")
+
+	config := pdfcpu.NewDefaultConfiguration()
+	config.ValidationMode = pdfcpu.ValidationRelaxed
+
+	if _, err = Process(FlattenCommand(inFile, outFile, nil, config)); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	config = pdfcpu.NewDefaultConfiguration()
+	config.ValidationMode = pdfcpu.ValidationRelaxed
+
+	ctx, _, _, err := readAndValidate(outFile, config, time.Now())
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	rootDict, err := ctx.Catalog()
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	if _, found := rootDict.Find("AcroForm"); found {
+		t.Fatalf("%s: want AcroForm removed\n", msg)
+	}
+
+	for _, a := range annotationsForFile(t, msg, outFile) {
+		if a.Type == "Widget" {
+			t.Fatalf("%s: want %s flattened\n", msg, a)
+		}
+	}
+
+	// Annotations without appearance remain.
+	inFile = filepath.Join(inDir, "annotTest.pdf")
+
+	if _, err = Process(FlattenCommand(inFile, outFile, nil, pdfcpu.NewDefaultConfiguration())); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	if _, err = Process(ValidateCommand(outFile, pdfcpu.NewDefaultConfiguration())); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	for _, a := range annotationsForFile(t, msg, outFile) {
+		if a.Type != "Text" && a.Type != "Popup" {
+			t.Fatalf("%s: want %s flattened\n", msg, a)
+		}
+	}
+}
+
+func TestFormCommand(t *testing.T) {
+
+	msg := "TestFormCommand"
+
+	xRefTable, err := pdfcpu.CreateAcroFormDemoXRef()
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	err = pdfcpu.CreatePDF(xRefTable, outDir+"/", "acroFormFill.pdf")
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	inFile := filepath.Join(outDir, "acroFormFill.pdf")
+	jsonFile := filepath.Join(outDir, "form.json")
+	outFile := filepath.Join(outDir, "filled.pdf")
+
+	json := `{"fields": [{"name": "inputField", "value": "Grüße"}, {"name": "CheckBox", "value": "Off"}]}`
+
+	if err := ioutil.WriteFile(jsonFile, []byte(json), 0644); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	config := pdfcpu.NewDefaultConfiguration()
+	config.ValidationMode = pdfcpu.ValidationRelaxed
+
+	if _, err = Process(FillFormCommand(inFile, jsonFile, outFile, config)); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	config = pdfcpu.NewDefaultConfiguration()
+	config.ValidationMode = pdfcpu.ValidationRelaxed
+
+	f, err := os.Open(outFile)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+	defer f.Close()
+
+	fields, err := FormFieldsReader(f, config)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	values := map[string]string{}
+	for _, f := range fields {
+		values[f.Name] = f.Value
+	}
+
+	if values["inputField"] != "Grüße" || values["CheckBox"] != "Off" {
+		t.Fatalf("%s: got %v\n", msg, fields)
+	}
+}
+
+func TestFormDataCommand(t *testing.T) {
+
+	msg := "TestFormDataCommand"
+
+	xRefTable, err := pdfcpu.CreateAcroFormDemoXRef()
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	err = pdfcpu.CreatePDF(xRefTable, outDir+"/", "acroFormData.pdf")
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	inFile := filepath.Join(outDir, "acroFormData.pdf")
+	jsonFile := filepath.Join(outDir, "formData.json")
+	filledFile := filepath.Join(outDir, "formDataFilled.pdf")
+	annotatedFile := filepath.Join(outDir, "formDataAnnotated.pdf")
+
+	json := `{"fields": [{"name": "inputField", "value": "Grüße"}, {"name": "CheckBox", "value": "Off"}]}`
+	if err := ioutil.WriteFile(jsonFile, []byte(json), 0644); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	config := pdfcpu.NewDefaultConfiguration()
+	config.ValidationMode = pdfcpu.ValidationRelaxed
+
+	if _, err = Process(FillFormCommand(inFile, jsonFile, filledFile, config)); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	json = `{"annotations": [{"id": "note", "type": "Text", "page": 1, "rect": [10, 10, 30, 30], "contents": "Checked"}]}`
+	if err := ioutil.WriteFile(jsonFile, []byte(json), 0644); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	if _, err = Process(AddAnnotationsCommand(filledFile, jsonFile, annotatedFile, config)); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	for _, ext := range []string{".fdf", ".xfdf"} {
+
+		dataFile := filepath.Join(outDir, "formData"+ext)
+		outFile := filepath.Join(outDir, "formDataImported"+ext[1:]+".pdf")
+
+		if _, err = Process(ExportFormDataCommand(annotatedFile, dataFile, config)); err != nil {
+			t.Fatalf("%s %s: export: %v\n", msg, ext, err)
+		}
+
+		// Importing into the filled file replaces the note.
+		for _, f := range []string{inFile, annotatedFile} {
+
+			if _, err = Process(ImportFormDataCommand(f, dataFile, outFile, config)); err != nil {
+				t.Fatalf("%s %s: import: %v\n", msg, ext, err)
+			}
+
+			rs, err := os.Open(outFile)
+			if err != nil {
+				t.Fatalf("%s: %v\n", msg, err)
+			}
+
+			fields, err := FormFieldsReader(rs, config)
+			if err != nil {
+				t.Fatalf("%s: %v\n", msg, err)
+			}
+
+			values := map[string]string{}
+			for _, f := range fields {
+				values[f.Name] = f.Value
+			}
+
+			if values["inputField"] != "Grüße" || values["CheckBox"] != "Off" {
+				t.Fatalf("%s %s: got %v\n", msg, ext, fields)
+			}
+
+			if _, err = rs.Seek(0, io.SeekStart); err != nil {
+				t.Fatalf("%s: %v\n", msg, err)
+			}
+
+			annots, err := AnnotationsReader(rs, nil, config)
+			if err != nil {
+				t.Fatalf("%s: %v\n", msg, err)
+			}
+			rs.Close()
+
+			n := 0
+			for _, a := range annots {
+				if a.ID == "note" && a.Contents == "Checked" {
+					n++
+				}
+			}
+
+			if n != 1 {
+				t.Fatalf("%s %s: want 1 note, got %d: %v\n", msg, ext, n, annots)
+			}
+		}
+	}
+
+	if _, err = Process(ExportFormDataCommand(inFile, filepath.Join(outDir, "formData.xml"), config)); err == nil {
+		t.Fatalf("%s: want error for unsupported extension\n", msg)
+	}
+}
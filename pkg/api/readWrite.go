@@ -0,0 +1,1082 @@
+/*
+Copyright 2018 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"io"
+
+	pdf "github.com/hhrutter/pdfcpu/pkg/pdfcpu"
+	"github.com/hhrutter/pdfcpu/pkg/pdfcpu/validate"
+	"github.com/hhrutter/pdfcpu/pkg/xmp"
+	"github.com/pkg/errors"
+)
+
+// The functions in this file operate on an io.ReadSeeker as input and an io.Writer as output
+// and therefore do not need any file system access except for attachments being added.
+
+// ReadContext reads in a PDF from rs and builds an internal structure holding its cross reference table aka the Context.
+func ReadContext(rs io.ReadSeeker, config *pdf.Configuration) (*pdf.Context, error) {
+
+	ctx, err := pdf.Read(rs, config)
+	if err != nil {
+		return nil, errors.Wrap(err, "Read failed.")
+	}
+
+	return ctx, nil
+}
+
+// WriteContext generates a PDF for a given Context and writes it to w.
+func WriteContext(ctx *pdf.Context, w io.Writer) error {
+
+	err := pdf.Write(ctx, w)
+	if err != nil {
+		return errors.Wrap(err, "Write failed.")
+	}
+
+	if ctx.StatsFileName != "" {
+		err = pdf.AppendStatsFile(ctx)
+		if err != nil {
+			return errors.Wrap(err, "Write stats failed.")
+		}
+	}
+
+	return nil
+}
+
+func ensureConfiguration(config *pdf.Configuration, mode pdf.CommandMode) *pdf.Configuration {
+
+	if config == nil {
+		config = pdf.NewDefaultConfiguration()
+	}
+
+	config.Mode = mode
+
+	return config
+}
+
+func readAndValidateContext(rs io.ReadSeeker, config *pdf.Configuration) (*pdf.Context, error) {
+
+	ctx, err := ReadContext(rs, config)
+	if err != nil {
+		return nil, err
+	}
+
+	err = validate.XRefTable(ctx.XRefTable)
+	if err != nil {
+		return nil, err
+	}
+
+	return ctx, nil
+}
+
+func readValidateAndOptimizeContext(rs io.ReadSeeker, config *pdf.Configuration) (*pdf.Context, error) {
+
+	ctx, err := readAndValidateContext(rs, config)
+	if err != nil {
+		return nil, err
+	}
+
+	err = pdf.OptimizeXRefTable(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return ctx, nil
+}
+
+// ValidateReader validates a PDF read from rs against ISO-32000-1:2008.
+func ValidateReader(rs io.ReadSeeker, config *pdf.Configuration) error {
+
+	config = ensureConfiguration(config, pdf.VALIDATE)
+
+	ctx, err := ReadContext(rs, config)
+	if err != nil {
+		return err
+	}
+
+	err = validate.XRefTable(ctx.XRefTable)
+	if err != nil {
+		return errors.Wrap(err, "validation error (try -mode=relaxed)")
+	}
+
+	return nil
+}
+
+func optimizeReader(rs io.ReadSeeker, w io.Writer, config *pdf.Configuration) error {
+
+	ctx, err := readValidateAndOptimizeContext(rs, config)
+	if err != nil {
+		return err
+	}
+
+	return WriteContext(ctx, w)
+}
+
+// OptimizeReader reads a PDF from rs, does validation, optimization and writes the result to w.
+func OptimizeReader(rs io.ReadSeeker, w io.Writer, config *pdf.Configuration) error {
+	return optimizeReader(rs, w, ensureConfiguration(config, pdf.OPTIMIZE))
+}
+
+// MergeReaders merges a sequence of PDFs read from rss and writes the result to w.
+// The first PDF serves as the destination xRefTable where all the remaining PDFs get merged into.
+func MergeReaders(rss []io.ReadSeeker, w io.Writer, config *pdf.Configuration) error {
+
+	if len(rss) == 0 {
+		return errors.New("MergeReaders: missing input")
+	}
+
+	config = ensureConfiguration(config, pdf.MERGE)
+
+	ctxDest, err := readAndValidateContext(rss[0], config)
+	if err != nil {
+		return err
+	}
+
+	if ctxDest.XRefTable.Version() < pdf.V15 {
+		v, _ := pdf.PDFVersion("1.5")
+		ctxDest.XRefTable.RootVersion = &v
+	}
+
+	for _, rs := range rss[1:] {
+
+		ctxSource, err := readAndValidateContext(rs, config)
+		if err != nil {
+			return err
+		}
+
+		err = pdf.MergeXRefTables(ctxSource, ctxDest)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = pdf.OptimizeXRefTable(ctxDest)
+	if err != nil {
+		return err
+	}
+
+	err = validate.XRefTable(ctxDest.XRefTable)
+	if err != nil {
+		return err
+	}
+
+	ctxDest.Write.Command = "Merge"
+
+	return WriteContext(ctxDest, w)
+}
+
+// TrimReader reads a PDF from rs and writes a version containing all pages selected to w.
+func TrimReader(rs io.ReadSeeker, w io.Writer, pageSelection []string, config *pdf.Configuration) error {
+
+	config = ensureConfiguration(config, pdf.TRIM)
+
+	ctx, err := readValidateAndOptimizeContext(rs, config)
+	if err != nil {
+		return err
+	}
+
+	pages, err := pagesForPageSelection(ctx.PageCount, pageSelection)
+	if err != nil {
+		return err
+	}
+
+	ctx.Write.Command = "Trim"
+	ctx.Write.ExtractPages = pages
+
+	return WriteContext(ctx, w)
+}
+
+func splitReader(rs io.ReadSeeker, create pdf.FileCreator, cmd *Command) error {
+
+	ctx, err := readValidateAndOptimizeContext(rs, ensureConfiguration(cmd.Config, pdf.SPLIT))
+	if err != nil {
+		return err
+	}
+
+	return doSplit(ctx, cmd, create)
+}
+
+// SplitReader reads a PDF from rs and writes a PDF for every span pages to the files create returns.
+// The files are named after the pages they hold, e.g. 1-3.pdf.
+func SplitReader(rs io.ReadSeeker, create pdf.FileCreator, span int, config *pdf.Configuration) error {
+	return splitReader(rs, create, &Command{SplitMode: SplitSpan, Span: span, Config: config})
+}
+
+// SplitByBookmarksReader reads a PDF from rs and writes a PDF for each top level bookmark to the files create returns.
+// The files are named after the bookmarks.
+func SplitByBookmarksReader(rs io.ReadSeeker, create pdf.FileCreator, config *pdf.Configuration) error {
+	return splitReader(rs, create, &Command{SplitMode: SplitBookmarks, Config: config})
+}
+
+// SplitByPageNrsReader reads a PDF from rs and writes a PDF starting at each page of pageNrs to the files create returns.
+func SplitByPageNrsReader(rs io.ReadSeeker, create pdf.FileCreator, pageNrs []int, config *pdf.Configuration) error {
+	return splitReader(rs, create, &Command{SplitMode: SplitPageNrs, PageNrs: pageNrs, Config: config})
+}
+
+// SplitBySizeReader reads a PDF from rs and writes PDFs of at most maxSize bytes to the files create returns.
+func SplitBySizeReader(rs io.ReadSeeker, create pdf.FileCreator, maxSize int64, config *pdf.Configuration) error {
+	return splitReader(rs, create, &Command{SplitMode: SplitSize, MaxSize: maxSize, Config: config})
+}
+
+// AddWatermarksReader reads a PDF from rs, adds watermarks to all pages selected and writes the result to w.
+func AddWatermarksReader(rs io.ReadSeeker, w io.Writer, pageSelection []string, wm *pdf.Watermark, config *pdf.Configuration) error {
+
+	config = ensureConfiguration(config, pdf.ADDWATERMARKS)
+
+	ctx, err := readValidateAndOptimizeContext(rs, config)
+	if err != nil {
+		return err
+	}
+
+	pages, err := pagesForPageSelection(ctx.PageCount, pageSelection)
+	if err != nil {
+		return err
+	}
+
+	ensureSelectedPages(ctx, &pages)
+
+	err = pdf.AddWatermarks(ctx.XRefTable, pages, wm)
+	if err != nil {
+		return err
+	}
+
+	return WriteContext(ctx, w)
+}
+
+// EncryptReader reads a PDF from rs, encrypts it and writes the result to w.
+func EncryptReader(rs io.ReadSeeker, w io.Writer, config *pdf.Configuration) error {
+	return optimizeReader(rs, w, ensureConfiguration(config, pdf.ENCRYPT))
+}
+
+// DecryptReader reads an encrypted PDF from rs, decrypts it and writes the result to w.
+func DecryptReader(rs io.ReadSeeker, w io.Writer, config *pdf.Configuration) error {
+	return optimizeReader(rs, w, ensureConfiguration(config, pdf.DECRYPT))
+}
+
+// ChangeUserPasswordReader reads a PDF from rs, changes the user password and writes the result to w.
+func ChangeUserPasswordReader(rs io.ReadSeeker, w io.Writer, pwOld, pwNew string, config *pdf.Configuration) error {
+
+	config = ensureConfiguration(config, pdf.CHANGEUPW)
+	config.UserPW = pwOld
+	config.UserPWNew = &pwNew
+
+	return optimizeReader(rs, w, config)
+}
+
+// ChangeOwnerPasswordReader reads a PDF from rs, changes the owner password and writes the result to w.
+func ChangeOwnerPasswordReader(rs io.ReadSeeker, w io.Writer, pwOld, pwNew string, config *pdf.Configuration) error {
+
+	config = ensureConfiguration(config, pdf.CHANGEOPW)
+	config.OwnerPW = pwOld
+	config.OwnerPWNew = &pwNew
+
+	return optimizeReader(rs, w, config)
+}
+
+// ListPermissionsReader returns a list of user access permissions of a PDF read from rs.
+func ListPermissionsReader(rs io.ReadSeeker, config *pdf.Configuration) ([]string, error) {
+
+	config = ensureConfiguration(config, pdf.LISTPERMISSIONS)
+
+	ctx, err := readValidateAndOptimizeContext(rs, config)
+	if err != nil {
+		return nil, err
+	}
+
+	return pdf.Permissions(ctx), nil
+}
+
+// AddPermissionsReader reads a PDF from rs, sets the user access permissions and writes the result to w.
+func AddPermissionsReader(rs io.ReadSeeker, w io.Writer, config *pdf.Configuration) error {
+	return optimizeReader(rs, w, ensureConfiguration(config, pdf.ADDPERMISSIONS))
+}
+
+// ListAttachmentsReader returns a list of embedded file attachments of a PDF read from rs.
+func ListAttachmentsReader(rs io.ReadSeeker, config *pdf.Configuration) ([]string, error) {
+
+	config = ensureConfiguration(config, pdf.LISTATTACHMENTS)
+
+	ctx, err := readValidateAndOptimizeContext(rs, config)
+	if err != nil {
+		return nil, err
+	}
+
+	return pdf.AttachList(ctx.XRefTable)
+}
+
+// InfoReader returns the essential facts of a PDF read from rs.
+func InfoReader(rs io.ReadSeeker, config *pdf.Configuration) (*pdf.PDFInfo, error) {
+
+	config = ensureConfiguration(config, pdf.INFO)
+
+	ctx, err := readAndValidateContext(rs, config)
+	if err != nil {
+		return nil, err
+	}
+
+	return pdf.DocumentInfo(ctx)
+}
+
+// AddAttachmentsReader reads a PDF from rs, embeds files and writes the result to w.
+func AddAttachmentsReader(rs io.ReadSeeker, w io.Writer, files []string, config *pdf.Configuration) error {
+
+	config = ensureConfiguration(config, pdf.ADDATTACHMENTS)
+
+	ctx, err := readValidateAndOptimizeContext(rs, config)
+	if err != nil {
+		return err
+	}
+
+	if _, err = pdf.AttachAdd(ctx.XRefTable, stringSet(files)); err != nil {
+		return err
+	}
+
+	return WriteContext(ctx, w)
+}
+
+// RemoveAttachmentsReader reads a PDF from rs, deletes embedded files and writes the result to w.
+func RemoveAttachmentsReader(rs io.ReadSeeker, w io.Writer, files []string, config *pdf.Configuration) error {
+
+	config = ensureConfiguration(config, pdf.REMOVEATTACHMENTS)
+
+	ctx, err := readValidateAndOptimizeContext(rs, config)
+	if err != nil {
+		return err
+	}
+
+	if _, err = pdf.AttachRemove(ctx.XRefTable, stringSet(files)); err != nil {
+		return err
+	}
+
+	return WriteContext(ctx, w)
+}
+
+// ExtractAttachmentsReader reads a PDF from rs and writes embedded files to the files create returns for them.
+// If no files are specified all embedded files get extracted.
+func ExtractAttachmentsReader(rs io.ReadSeeker, create pdf.FileCreator, files []string, config *pdf.Configuration) error {
+
+	config = ensureConfiguration(config, pdf.EXTRACTATTACHMENTS)
+
+	ctx, err := readValidateAndOptimizeContext(rs, config)
+	if err != nil {
+		return err
+	}
+
+	return pdf.AttachExtractTo(ctx, stringSet(files), create)
+}
+
+func extractReader(rs io.ReadSeeker, pageSelection []string, config *pdf.Configuration, mode pdf.CommandMode,
+	extract func(ctx *pdf.Context, selectedPages pdf.IntSet) error) error {
+
+	ctx, err := readValidateAndOptimizeContext(rs, ensureConfiguration(config, mode))
+	if err != nil {
+		return err
+	}
+
+	pages, err := pagesForPageSelection(ctx.PageCount, pageSelection)
+	if err != nil {
+		return err
+	}
+
+	ensureSelectedPages(ctx, &pages)
+
+	return extract(ctx, pages)
+}
+
+// ExtractImagesReader reads a PDF from rs and writes the images of all pages selected to the files create returns.
+func ExtractImagesReader(rs io.ReadSeeker, create pdf.FileCreator, pageSelection []string, config *pdf.Configuration) error {
+	return extractReader(rs, pageSelection, config, pdf.EXTRACTIMAGES, func(ctx *pdf.Context, selectedPages pdf.IntSet) error {
+		return doExtractImages(ctx, selectedPages, create)
+	})
+}
+
+// ExtractFontsReader reads a PDF from rs and writes the embedded fonts of all pages selected to the files create returns.
+func ExtractFontsReader(rs io.ReadSeeker, create pdf.FileCreator, pageSelection []string, config *pdf.Configuration) error {
+	return extractReader(rs, pageSelection, config, pdf.EXTRACTFONTS, func(ctx *pdf.Context, selectedPages pdf.IntSet) error {
+		return doExtractFonts(ctx, selectedPages, create)
+	})
+}
+
+// ExtractPagesReader reads a PDF from rs and writes a single page PDF for each page selected to the files create returns.
+// The files are named after their page numbers, e.g. 1.pdf.
+func ExtractPagesReader(rs io.ReadSeeker, create pdf.FileCreator, pageSelection []string, config *pdf.Configuration) error {
+	return extractReader(rs, pageSelection, config, pdf.EXTRACTPAGES, func(ctx *pdf.Context, selectedPages pdf.IntSet) error {
+		return writeSinglePagePDFs(ctx, selectedPages, create)
+	})
+}
+
+// ExtractContentReader reads a PDF from rs and writes the content streams of all pages selected to the files create returns.
+func ExtractContentReader(rs io.ReadSeeker, create pdf.FileCreator, pageSelection []string, config *pdf.Configuration) error {
+	return extractReader(rs, pageSelection, config, pdf.EXTRACTCONTENT, func(ctx *pdf.Context, selectedPages pdf.IntSet) error {
+		return doExtractContent(ctx, selectedPages, create)
+	})
+}
+
+// ExtractMetadataReader reads a PDF from rs and writes all metadata streams to the files create returns.
+func ExtractMetadataReader(rs io.ReadSeeker, create pdf.FileCreator, config *pdf.Configuration) error {
+
+	config = ensureConfiguration(config, pdf.EXTRACTMETADATA)
+
+	ctx, err := readValidateAndOptimizeContext(rs, config)
+	if err != nil {
+		return err
+	}
+
+	return doExtractMetadata(ctx, create)
+}
+
+// RotateReader reads a PDF from rs, rotates all pages selected clockwise by a multiple of 90 degrees and writes the result to w.
+func RotateReader(rs io.ReadSeeker, w io.Writer, pageSelection []string, rotation int, config *pdf.Configuration) error {
+
+	config = ensureConfiguration(config, pdf.ROTATE)
+
+	ctx, err := readValidateAndOptimizeContext(rs, config)
+	if err != nil {
+		return err
+	}
+
+	pages, err := pagesForPageSelection(ctx.PageCount, pageSelection)
+	if err != nil {
+		return err
+	}
+
+	ensureSelectedPages(ctx, &pages)
+
+	err = pdf.RotatePages(ctx.XRefTable, pages, rotation)
+	if err != nil {
+		return err
+	}
+
+	return WriteContext(ctx, w)
+}
+
+// InsertPagesReader reads a PDF from rs, inserts blank pages or, if rsSource is not nil, the pages of a PDF read from rsSource
+// before or after the pages selected and writes the result to w.
+func InsertPagesReader(rs, rsSource io.ReadSeeker, w io.Writer, pageSelection []string, before bool, config *pdf.Configuration) error {
+
+	config = ensureConfiguration(config, pdf.INSERTPAGES)
+
+	ctx, err := readAndValidateContext(rs, config)
+	if err != nil {
+		return err
+	}
+
+	var ctxSource *pdf.Context
+	if rsSource != nil {
+		if ctxSource, err = readAndValidateContext(rsSource, config); err != nil {
+			return err
+		}
+	}
+
+	pages, err := pagesForPageSelection(ctx.PageCount, pageSelection)
+	if err != nil {
+		return err
+	}
+
+	ensureInsertPosition(ctx, ctxSource, &pages, before)
+
+	if err = pdf.InsertPages(ctxSource, ctx, pages, before); err != nil {
+		return err
+	}
+
+	if err = pdf.OptimizeXRefTable(ctx); err != nil {
+		return err
+	}
+
+	if err = validate.XRefTable(ctx.XRefTable); err != nil {
+		return err
+	}
+
+	return WriteContext(ctx, w)
+}
+
+// RemovePagesReader reads a PDF from rs, removes all pages selected and writes the result to w.
+func RemovePagesReader(rs io.ReadSeeker, w io.Writer, pageSelection []string, config *pdf.Configuration) error {
+
+	config = ensureConfiguration(config, pdf.REMOVEPAGES)
+
+	ctx, err := readValidateAndOptimizeContext(rs, config)
+	if err != nil {
+		return err
+	}
+
+	pages, err := pagesForPageSelection(ctx.PageCount, pageSelection)
+	if err != nil {
+		return err
+	}
+
+	if len(pages) == 0 {
+		return errors.New("RemovePagesReader: missing page selection")
+	}
+
+	if err = pdf.RemovePages(ctx.XRefTable, pages); err != nil {
+		return err
+	}
+
+	return WriteContext(ctx, w)
+}
+
+// NUpReader reads a PDF from rs, places n of the pages selected on each page of a given paper size and writes the result to w.
+func NUpReader(rs io.ReadSeeker, w io.Writer, pageSelection []string, nup *pdf.NUp, config *pdf.Configuration) error {
+
+	config = ensureConfiguration(config, pdf.NUP)
+
+	ctx, err := readValidateAndOptimizeContext(rs, config)
+	if err != nil {
+		return err
+	}
+
+	pages, err := pagesForPageSelection(ctx.PageCount, pageSelection)
+	if err != nil {
+		return err
+	}
+
+	ensureSelectedPages(ctx, &pages)
+
+	if err = pdf.NUpPages(ctx.XRefTable, pages, nup); err != nil {
+		return err
+	}
+
+	return WriteContext(ctx, w)
+}
+
+// BookletReader reads a PDF from rs, arranges the pages selected as a booklet and writes the result to w.
+func BookletReader(rs io.ReadSeeker, w io.Writer, pageSelection []string, booklet *pdf.Booklet, config *pdf.Configuration) error {
+
+	config = ensureConfiguration(config, pdf.BOOKLET)
+
+	ctx, err := readValidateAndOptimizeContext(rs, config)
+	if err != nil {
+		return err
+	}
+
+	pages, err := pagesForPageSelection(ctx.PageCount, pageSelection)
+	if err != nil {
+		return err
+	}
+
+	ensureSelectedPages(ctx, &pages)
+
+	if err = pdf.BookletPages(ctx.XRefTable, pages, booklet); err != nil {
+		return err
+	}
+
+	return WriteContext(ctx, w)
+}
+
+// CollectReader reads a PDF from rs, arranges its pages in the order of an ordered page list and writes the result to w.
+func CollectReader(rs io.ReadSeeker, w io.Writer, pageCollection []string, config *pdf.Configuration) error {
+
+	config = ensureConfiguration(config, pdf.COLLECT)
+
+	ctx, err := readValidateAndOptimizeContext(rs, config)
+	if err != nil {
+		return err
+	}
+
+	pageNrs, err := pagesForPageCollection(ctx.PageCount, pageCollection)
+	if err != nil {
+		return err
+	}
+
+	if err = pdf.CollectPages(ctx.XRefTable, pageNrs); err != nil {
+		return err
+	}
+
+	return WriteContext(ctx, w)
+}
+
+// ImportImagesReader appends a page for each image read from imgs to the PDF read from rs and writes the result to w.
+// If rs is nil a new PDF gets created.
+func ImportImagesReader(rs io.ReadSeeker, w io.Writer, imgs []io.Reader, imp *pdf.Import, config *pdf.Configuration) error {
+
+	config = ensureConfiguration(config, pdf.IMPORTIMAGES)
+
+	if imp == nil {
+		imp = pdf.DefaultImport()
+	}
+
+	var (
+		ctx *pdf.Context
+		err error
+	)
+
+	if rs != nil {
+		ctx, err = readValidateAndOptimizeContext(rs, config)
+	} else {
+		ctx, err = pdf.CreateContext(config)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err = pdf.ImportImages(ctx.XRefTable, imgs, imp); err != nil {
+		return err
+	}
+
+	return WriteContext(ctx, w)
+}
+
+func changeBoxesReader(rs io.ReadSeeker, w io.Writer, pageSelection []string, config *pdf.Configuration, mode pdf.CommandMode,
+	f func(xRefTable *pdf.XRefTable, selectedPages pdf.IntSet) error) error {
+
+	config = ensureConfiguration(config, mode)
+
+	ctx, err := readValidateAndOptimizeContext(rs, config)
+	if err != nil {
+		return err
+	}
+
+	pages, err := pagesForPageSelection(ctx.PageCount, pageSelection)
+	if err != nil {
+		return err
+	}
+
+	ensureSelectedPages(ctx, &pages)
+
+	if err = f(ctx.XRefTable, pages); err != nil {
+		return err
+	}
+
+	return WriteContext(ctx, w)
+}
+
+// AddBoxesReader reads a PDF from rs, sets page boundaries for the pages selected and writes the result to w.
+func AddBoxesReader(rs io.ReadSeeker, w io.Writer, pageSelection []string, pbs *pdf.PageBoxes, config *pdf.Configuration) error {
+	return changeBoxesReader(rs, w, pageSelection, config, pdf.ADDBOXES, func(xRefTable *pdf.XRefTable, selectedPages pdf.IntSet) error {
+		return pdf.AddBoxes(xRefTable, selectedPages, pbs)
+	})
+}
+
+// RemoveBoxesReader reads a PDF from rs, removes page boundaries from the pages selected and writes the result to w.
+func RemoveBoxesReader(rs io.ReadSeeker, w io.Writer, pageSelection []string, pbs *pdf.PageBoxes, config *pdf.Configuration) error {
+	return changeBoxesReader(rs, w, pageSelection, config, pdf.REMOVEBOXES, func(xRefTable *pdf.XRefTable, selectedPages pdf.IntSet) error {
+		return pdf.RemoveBoxes(xRefTable, selectedPages, pbs)
+	})
+}
+
+// CropReader reads a PDF from rs, crops the pages selected to their trim box and writes the result to w.
+func CropReader(rs io.ReadSeeker, w io.Writer, pageSelection []string, config *pdf.Configuration) error {
+	return changeBoxesReader(rs, w, pageSelection, config, pdf.CROP, pdf.CropToTrimBox)
+}
+
+// ResizeReader reads a PDF from rs, scales the pages selected to a paper size or by a scale factor and writes the result to w.
+func ResizeReader(rs io.ReadSeeker, w io.Writer, pageSelection []string, resize *pdf.Resize, config *pdf.Configuration) error {
+
+	config = ensureConfiguration(config, pdf.RESIZE)
+
+	ctx, err := readValidateAndOptimizeContext(rs, config)
+	if err != nil {
+		return err
+	}
+
+	pages, err := pagesForPageSelection(ctx.PageCount, pageSelection)
+	if err != nil {
+		return err
+	}
+
+	ensureSelectedPages(ctx, &pages)
+
+	if err = pdf.ResizePages(ctx.XRefTable, pages, resize); err != nil {
+		return err
+	}
+
+	return WriteContext(ctx, w)
+}
+
+// ListPageLabelsReader returns a list of the page label ranges of a PDF read from rs.
+func ListPageLabelsReader(rs io.ReadSeeker, config *pdf.Configuration) ([]string, error) {
+
+	config = ensureConfiguration(config, pdf.LISTPAGELABELS)
+
+	ctx, err := readValidateAndOptimizeContext(rs, config)
+	if err != nil {
+		return nil, err
+	}
+
+	return pdf.ListPageLabels(ctx.XRefTable)
+}
+
+func changePageLabelsReader(rs io.ReadSeeker, w io.Writer, pageSelection []string, config *pdf.Configuration, mode pdf.CommandMode,
+	f func(xRefTable *pdf.XRefTable, selectedPages pdf.IntSet) error) error {
+
+	config = ensureConfiguration(config, mode)
+
+	ctx, err := readValidateAndOptimizeContext(rs, config)
+	if err != nil {
+		return err
+	}
+
+	pages, err := pagesForPageSelection(ctx.PageCount, pageSelection)
+	if err != nil {
+		return err
+	}
+
+	ensureSelectedPages(ctx, &pages)
+
+	if err = f(ctx.XRefTable, pages); err != nil {
+		return err
+	}
+
+	return WriteContext(ctx, w)
+}
+
+// AddPageLabelsReader reads a PDF from rs, starts a page label range at the pages selected and writes the result to w.
+func AddPageLabelsReader(rs io.ReadSeeker, w io.Writer, pageSelection []string, pl *pdf.PageLabel, config *pdf.Configuration) error {
+
+	if pl == nil {
+		pl = pdf.DefaultPageLabel()
+	}
+
+	return changePageLabelsReader(rs, w, pageSelection, config, pdf.ADDPAGELABELS, func(xRefTable *pdf.XRefTable, selectedPages pdf.IntSet) error {
+		return pdf.AddPageLabels(xRefTable, selectedPages, pl)
+	})
+}
+
+// RemovePageLabelsReader reads a PDF from rs, removes the page label ranges starting at the pages selected and writes the result to w.
+func RemovePageLabelsReader(rs io.ReadSeeker, w io.Writer, pageSelection []string, config *pdf.Configuration) error {
+	return changePageLabelsReader(rs, w, pageSelection, config, pdf.REMOVEPAGELABELS, pdf.RemovePageLabels)
+}
+
+// BookmarksReader returns the bookmarks of a PDF read from rs.
+func BookmarksReader(rs io.ReadSeeker, config *pdf.Configuration) ([]pdf.Bookmark, error) {
+
+	config = ensureConfiguration(config, pdf.LISTBOOKMARKS)
+
+	ctx, err := readValidateAndOptimizeContext(rs, config)
+	if err != nil {
+		return nil, err
+	}
+
+	return pdf.Bookmarks(ctx.XRefTable)
+}
+
+// ExportBookmarksReader writes the bookmarks of a PDF read from rs as JSON to w.
+func ExportBookmarksReader(rs io.ReadSeeker, w io.Writer, config *pdf.Configuration) error {
+
+	config = ensureConfiguration(config, pdf.EXPORTBOOKMARKS)
+
+	ctx, err := readValidateAndOptimizeContext(rs, config)
+	if err != nil {
+		return err
+	}
+
+	bms, err := pdf.Bookmarks(ctx.XRefTable)
+	if err != nil {
+		return err
+	}
+
+	bb, err := marshalBookmarks(bms)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(bb)
+
+	return err
+}
+
+// ImportBookmarksReader reads a PDF from rs, replaces its bookmarks by the bookmarks read as JSON from r and writes the result to w.
+func ImportBookmarksReader(rs io.ReadSeeker, r io.Reader, w io.Writer, config *pdf.Configuration) error {
+
+	bms, err := unmarshalBookmarks(r)
+	if err != nil {
+		return err
+	}
+
+	config = ensureConfiguration(config, pdf.IMPORTBOOKMARKS)
+
+	ctx, err := readValidateAndOptimizeContext(rs, config)
+	if err != nil {
+		return err
+	}
+
+	if err = pdf.ReplaceBookmarks(ctx.XRefTable, bms); err != nil {
+		return err
+	}
+
+	return WriteContext(ctx, w)
+}
+
+// RemoveBookmarksReader reads a PDF from rs, removes its bookmarks and writes the result to w.
+func RemoveBookmarksReader(rs io.ReadSeeker, w io.Writer, config *pdf.Configuration) error {
+
+	config = ensureConfiguration(config, pdf.REMOVEBOOKMARKS)
+
+	ctx, err := readValidateAndOptimizeContext(rs, config)
+	if err != nil {
+		return err
+	}
+
+	if err = pdf.RemoveBookmarks(ctx.XRefTable); err != nil {
+		return err
+	}
+
+	return WriteContext(ctx, w)
+}
+
+// PropertiesReader returns the document properties of a PDF read from rs.
+func PropertiesReader(rs io.ReadSeeker, config *pdf.Configuration) (map[string]string, error) {
+
+	config = ensureConfiguration(config, pdf.LISTPROPERTIES)
+
+	ctx, err := readValidateAndOptimizeContext(rs, config)
+	if err != nil {
+		return nil, err
+	}
+
+	return pdf.Properties(ctx.XRefTable)
+}
+
+// AddPropertiesReader reads a PDF from rs, sets document properties and writes the result to w.
+func AddPropertiesReader(rs io.ReadSeeker, w io.Writer, properties map[string]string, config *pdf.Configuration) error {
+
+	config = ensureConfiguration(config, pdf.ADDPROPERTIES)
+
+	ctx, err := readValidateAndOptimizeContext(rs, config)
+	if err != nil {
+		return err
+	}
+
+	if err = pdf.AddProperties(ctx.XRefTable, properties); err != nil {
+		return err
+	}
+
+	return WriteContext(ctx, w)
+}
+
+// RemovePropertiesReader reads a PDF from rs, removes document properties and writes the result to w.
+// If keys is empty all properties not maintained by pdfcpu get removed.
+func RemovePropertiesReader(rs io.ReadSeeker, w io.Writer, keys []string, config *pdf.Configuration) error {
+
+	config = ensureConfiguration(config, pdf.REMOVEPROPERTIES)
+
+	ctx, err := readValidateAndOptimizeContext(rs, config)
+	if err != nil {
+		return err
+	}
+
+	if err = pdf.RemoveProperties(ctx.XRefTable, keys); err != nil {
+		return err
+	}
+
+	return WriteContext(ctx, w)
+}
+
+// MetadataReader returns the XMP metadata of a PDF read from rs or nil if there is none.
+func MetadataReader(rs io.ReadSeeker, config *pdf.Configuration) (*xmp.Metadata, error) {
+
+	config = ensureConfiguration(config, pdf.LISTPROPERTIES)
+
+	ctx, err := readValidateAndOptimizeContext(rs, config)
+	if err != nil {
+		return nil, err
+	}
+
+	return pdf.Metadata(ctx.XRefTable)
+}
+
+// SetMetadataReader reads a PDF from rs, sets its XMP metadata along with the corresponding document properties and writes the result to w.
+func SetMetadataReader(rs io.ReadSeeker, w io.Writer, md *xmp.Metadata, config *pdf.Configuration) error {
+
+	config = ensureConfiguration(config, pdf.ADDPROPERTIES)
+
+	ctx, err := readValidateAndOptimizeContext(rs, config)
+	if err != nil {
+		return err
+	}
+
+	if err = pdf.SetMetadata(ctx.XRefTable, md); err != nil {
+		return err
+	}
+
+	return WriteContext(ctx, w)
+}
+
+// AnnotationsReader returns the annotations of the selected pages of a PDF read from rs.
+func AnnotationsReader(rs io.ReadSeeker, pageSelection []string, config *pdf.Configuration) ([]pdf.Annotation, error) {
+
+	config = ensureConfiguration(config, pdf.LISTANNOTATIONS)
+
+	ctx, err := readValidateAndOptimizeContext(rs, config)
+	if err != nil {
+		return nil, err
+	}
+
+	pages, err := pagesForPageSelection(ctx.PageCount, pageSelection)
+	if err != nil {
+		return nil, err
+	}
+
+	ensureSelectedPages(ctx, &pages)
+
+	return pdf.Annotations(ctx.XRefTable, pages)
+}
+
+// AddAnnotationsReader reads a PDF from rs, adds the annotations read as JSON from r and writes the result to w.
+func AddAnnotationsReader(rs io.ReadSeeker, r io.Reader, w io.Writer, config *pdf.Configuration) error {
+
+	annots, err := unmarshalAnnotations(r)
+	if err != nil {
+		return err
+	}
+
+	config = ensureConfiguration(config, pdf.ADDANNOTATIONS)
+
+	ctx, err := readValidateAndOptimizeContext(rs, config)
+	if err != nil {
+		return err
+	}
+
+	if err = pdf.AddAnnotations(ctx.XRefTable, annots); err != nil {
+		return err
+	}
+
+	return WriteContext(ctx, w)
+}
+
+// RemoveAnnotationsReader reads a PDF from rs, removes annotations from the pages selected and writes the result to w.
+// ids may contain annotation types, annotation names or object numbers. If ids is empty all annotations except form field widgets get removed.
+func RemoveAnnotationsReader(rs io.ReadSeeker, w io.Writer, pageSelection []string, ids []string, config *pdf.Configuration) error {
+
+	config = ensureConfiguration(config, pdf.REMOVEANNOTATIONS)
+
+	ctx, err := readValidateAndOptimizeContext(rs, config)
+	if err != nil {
+		return err
+	}
+
+	pages, err := pagesForPageSelection(ctx.PageCount, pageSelection)
+	if err != nil {
+		return err
+	}
+
+	ensureSelectedPages(ctx, &pages)
+
+	if err = pdf.RemoveAnnotations(ctx.XRefTable, pages, ids); err != nil {
+		return err
+	}
+
+	return WriteContext(ctx, w)
+}
+
+// FlattenReader reads a PDF from rs, draws the annotations of the pages selected into the page content and writes the result to w.
+// Form fields whose widgets got flattened are removed.
+func FlattenReader(rs io.ReadSeeker, w io.Writer, pageSelection []string, config *pdf.Configuration) error {
+
+	config = ensureConfiguration(config, pdf.FLATTEN)
+
+	ctx, err := readValidateAndOptimizeContext(rs, config)
+	if err != nil {
+		return err
+	}
+
+	pages, err := pagesForPageSelection(ctx.PageCount, pageSelection)
+	if err != nil {
+		return err
+	}
+
+	ensureSelectedPages(ctx, &pages)
+
+	if err = pdf.FlattenAnnotations(ctx.XRefTable, pages); err != nil {
+		return err
+	}
+
+	return WriteContext(ctx, w)
+}
+
+// FormFieldsReader returns the form fields of a PDF read from rs.
+func FormFieldsReader(rs io.ReadSeeker, config *pdf.Configuration) ([]pdf.Field, error) {
+
+	config = ensureConfiguration(config, pdf.LISTFORMFIELDS)
+
+	ctx, err := readValidateAndOptimizeContext(rs, config)
+	if err != nil {
+		return nil, err
+	}
+
+	return pdf.FormFields(ctx.XRefTable)
+}
+
+// FillFormReader reads a PDF from rs, sets the form field values read as JSON from r and writes the result to w.
+func FillFormReader(rs io.ReadSeeker, r io.Reader, w io.Writer, config *pdf.Configuration) error {
+
+	fields, err := unmarshalFormFields(r)
+	if err != nil {
+		return err
+	}
+
+	return FillFormFieldsReader(rs, w, fields, config)
+}
+
+// FillFormFieldsReader reads a PDF from rs, sets the form field values of fields and writes the result to w.
+func FillFormFieldsReader(rs io.ReadSeeker, w io.Writer, fields []pdf.Field, config *pdf.Configuration) error {
+
+	config = ensureConfiguration(config, pdf.FILLFORM)
+
+	ctx, err := readValidateAndOptimizeContext(rs, config)
+	if err != nil {
+		return err
+	}
+
+	if err = pdf.FillForm(ctx.XRefTable, fields); err != nil {
+		return err
+	}
+
+	return WriteContext(ctx, w)
+}
+
+// ExportFormDataReader writes the form field values and annotations of a PDF read from rs as FDF or XFDF to w.
+func ExportFormDataReader(rs io.ReadSeeker, w io.Writer, xfdf bool, config *pdf.Configuration) error {
+
+	config = ensureConfiguration(config, pdf.EXPORTFORMDATA)
+
+	ctx, err := readValidateAndOptimizeContext(rs, config)
+	if err != nil {
+		return err
+	}
+
+	fd, err := pdf.ExportFormData(ctx.XRefTable)
+	if err != nil {
+		return err
+	}
+
+	return writeFormData(w, fd, xfdf)
+}
+
+// ImportFormDataReader reads a PDF from rs, sets the form field values and adds the annotations read as FDF or XFDF from r
+// and writes the result to w.
+func ImportFormDataReader(rs io.ReadSeeker, r io.Reader, w io.Writer, xfdf bool, config *pdf.Configuration) error {
+
+	fd, err := readFormData(r, xfdf)
+	if err != nil {
+		return err
+	}
+
+	config = ensureConfiguration(config, pdf.IMPORTFORMDATA)
+
+	ctx, err := readValidateAndOptimizeContext(rs, config)
+	if err != nil {
+		return err
+	}
+
+	if err = pdf.ImportFormData(ctx.XRefTable, fd); err != nil {
+		return err
+	}
+
+	return WriteContext(ctx, w)
+}
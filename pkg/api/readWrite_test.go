@@ -0,0 +1,864 @@
+/*
+Copyright 2018 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hhrutter/pdfcpu/pkg/pdfcpu"
+)
+
+func readerForFile(fileName string, t *testing.T) *bytes.Reader {
+
+	buf, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		t.Fatalf("readerForFile: %v\n", err)
+	}
+
+	return bytes.NewReader(buf)
+}
+
+func TestReadWriteContext(t *testing.T) {
+
+	rs := readerForFile(filepath.Join(inDir, "CenterOfWhy.pdf"), t)
+
+	ctx, err := ReadContext(rs, pdfcpu.NewDefaultConfiguration())
+	if err != nil {
+		t.Fatalf("TestReadWriteContext: %v\n", err)
+	}
+
+	var buf bytes.Buffer
+	if err = WriteContext(ctx, &buf); err != nil {
+		t.Fatalf("TestReadWriteContext: %v\n", err)
+	}
+
+	if ctx.Write.FileSize != int64(buf.Len()) {
+		t.Fatalf("TestReadWriteContext: fileSize should be %d but is %d\n", buf.Len(), ctx.Write.FileSize)
+	}
+
+	if err = ValidateReader(bytes.NewReader(buf.Bytes()), nil); err != nil {
+		t.Fatalf("TestReadWriteContext: %v\n", err)
+	}
+}
+
+func TestOptimizeReader(t *testing.T) {
+
+	rs := readerForFile(filepath.Join(inDir, "Acroforms2.pdf"), t)
+
+	var buf bytes.Buffer
+	if err := OptimizeReader(rs, &buf, nil); err != nil {
+		t.Fatalf("TestOptimizeReader: %v\n", err)
+	}
+
+	if err := ValidateReader(bytes.NewReader(buf.Bytes()), nil); err != nil {
+		t.Fatalf("TestOptimizeReader: %v\n", err)
+	}
+}
+
+func TestMergeReaders(t *testing.T) {
+
+	rss := []io.ReadSeeker{
+		readerForFile(filepath.Join(inDir, "Acroforms2.pdf"), t),
+		readerForFile(filepath.Join(inDir, "CenterOfWhy.pdf"), t),
+	}
+
+	var buf bytes.Buffer
+	if err := MergeReaders(rss, &buf, nil); err != nil {
+		t.Fatalf("TestMergeReaders: %v\n", err)
+	}
+
+	ctx, err := readAndValidateContext(bytes.NewReader(buf.Bytes()), pdfcpu.NewDefaultConfiguration())
+	if err != nil {
+		t.Fatalf("TestMergeReaders: %v\n", err)
+	}
+
+	if ctx.PageCount != 28 {
+		t.Fatalf("TestMergeReaders: pageCount should be %d but is %d\n", 28, ctx.PageCount)
+	}
+}
+
+func TestTrimReader(t *testing.T) {
+
+	rs := readerForFile(filepath.Join(inDir, "pike-stanford.pdf"), t)
+
+	var buf bytes.Buffer
+	if err := TrimReader(rs, &buf, []string{"-2"}, nil); err != nil {
+		t.Fatalf("TestTrimReader: %v\n", err)
+	}
+
+	if err := ValidateReader(bytes.NewReader(buf.Bytes()), nil); err != nil {
+		t.Fatalf("TestTrimReader: %v\n", err)
+	}
+}
+
+func TestEncryptDecryptReader(t *testing.T) {
+
+	rs := readerForFile(filepath.Join(inDir, "5116.DCT_Filter.pdf"), t)
+
+	config := pdfcpu.NewDefaultConfiguration()
+	config.UserPW = "upw"
+	config.OwnerPW = "opw"
+
+	var encrypted bytes.Buffer
+	if err := EncryptReader(rs, &encrypted, config); err != nil {
+		t.Fatalf("TestEncryptDecryptReader: %v\n", err)
+	}
+
+	config = pdfcpu.NewDefaultConfiguration()
+	config.UserPW = "upw"
+	config.OwnerPW = "opw"
+
+	var decrypted bytes.Buffer
+	if err := DecryptReader(bytes.NewReader(encrypted.Bytes()), &decrypted, config); err != nil {
+		t.Fatalf("TestEncryptDecryptReader: %v\n", err)
+	}
+
+	if err := ValidateReader(bytes.NewReader(decrypted.Bytes()), nil); err != nil {
+		t.Fatalf("TestEncryptDecryptReader: %v\n", err)
+	}
+}
+
+func testIncrementalUpdate(fileName string, t *testing.T) {
+
+	original, err := ioutil.ReadFile(filepath.Join(inDir, fileName))
+	if err != nil {
+		t.Fatalf("testIncrementalUpdate(%s): %v\n", fileName, err)
+	}
+
+	config := pdfcpu.NewDefaultConfiguration()
+	config.Incremental = true
+
+	var buf bytes.Buffer
+	err = AddAttachmentsReader(bytes.NewReader(original), &buf, []string{filepath.Join(inDir, "test.wav")}, config)
+	if err != nil {
+		t.Fatalf("testIncrementalUpdate(%s): %v\n", fileName, err)
+	}
+
+	if !bytes.HasPrefix(buf.Bytes(), original) {
+		t.Fatalf("testIncrementalUpdate(%s): original bytes modified\n", fileName)
+	}
+
+	list, err := ListAttachmentsReader(bytes.NewReader(buf.Bytes()), nil)
+	if err != nil {
+		t.Fatalf("testIncrementalUpdate(%s): %v\n", fileName, err)
+	}
+
+	if len(list) != 1 {
+		t.Fatalf("testIncrementalUpdate(%s): want 1 attachment, got %d\n", fileName, len(list))
+	}
+}
+
+func TestIncrementalUpdate(t *testing.T) {
+	for _, fileName := range []string{
+		"Acroforms2.pdf",                    // xref table
+		"TheGoProgrammingLanguageCh1_1.pdf", // xref stream
+	} {
+		testIncrementalUpdate(fileName, t)
+	}
+}
+
+func testLazyLoading(fileName string, t *testing.T) {
+
+	want, err := readAndValidateContext(readerForFile(fileName, t), pdfcpu.NewDefaultConfiguration())
+	if err != nil {
+		t.Fatalf("testLazyLoading(%s): %v\n", fileName, err)
+	}
+
+	// Force the release of stream content.
+	config := pdfcpu.NewDefaultConfiguration()
+	config.LazyLoading = true
+	config.StreamCacheSize = 1
+
+	ctx, err := readValidateAndOptimizeContext(readerForFile(fileName, t), config)
+	if err != nil {
+		t.Fatalf("testLazyLoading(%s): %v\n", fileName, err)
+	}
+
+	if ctx.PageCount != want.PageCount {
+		t.Fatalf("testLazyLoading(%s): pageCount should be %d but is %d\n", fileName, want.PageCount, ctx.PageCount)
+	}
+
+	var buf bytes.Buffer
+	if err = WriteContext(ctx, &buf); err != nil {
+		t.Fatalf("testLazyLoading(%s): %v\n", fileName, err)
+	}
+
+	if err = ValidateReader(bytes.NewReader(buf.Bytes()), nil); err != nil {
+		t.Fatalf("testLazyLoading(%s): %v\n", fileName, err)
+	}
+}
+
+func TestLazyLoading(t *testing.T) {
+	for _, fileName := range []string{
+		"5116.DCT_Filter.pdf",
+		"Acroforms2.pdf",
+		"TheGoProgrammingLanguageCh1_1.pdf",
+		"pike-stanford.pdf",
+	} {
+		testLazyLoading(filepath.Join(inDir, fileName), t)
+	}
+}
+
+func TestLazyClose(t *testing.T) {
+
+	config := pdfcpu.NewDefaultConfiguration()
+	config.LazyLoading = true
+
+	ctx, _, _, err := readAndValidate(filepath.Join(inDir, "pike-stanford.pdf"), config, time.Now())
+	if err != nil {
+		t.Fatalf("TestLazyClose: %v\n", err)
+	}
+
+	if err = ctx.Close(); err != nil {
+		t.Fatalf("TestLazyClose: %v\n", err)
+	}
+
+	// The file opened for lazy loading is released.
+	if _, err = ctx.Read.RS.Seek(0, io.SeekStart); err == nil {
+		t.Fatalf("TestLazyClose: file still open\n")
+	}
+
+	if err = ctx.Close(); err != nil {
+		t.Fatalf("TestLazyClose: closing twice: %v\n", err)
+	}
+}
+
+func TestLazyIncrementalUpdate(t *testing.T) {
+
+	original, err := ioutil.ReadFile(filepath.Join(inDir, "pike-stanford.pdf"))
+	if err != nil {
+		t.Fatalf("TestLazyIncrementalUpdate: %v\n", err)
+	}
+
+	config := pdfcpu.NewDefaultConfiguration()
+	config.Incremental = true
+	config.LazyLoading = true
+	config.StreamCacheSize = 1
+
+	var buf bytes.Buffer
+	err = AddAttachmentsReader(bytes.NewReader(original), &buf, []string{filepath.Join(inDir, "test.wav")}, config)
+	if err != nil {
+		t.Fatalf("TestLazyIncrementalUpdate: %v\n", err)
+	}
+
+	if !bytes.HasPrefix(buf.Bytes(), original) {
+		t.Fatalf("TestLazyIncrementalUpdate: original bytes modified\n")
+	}
+
+	list, err := ListAttachmentsReader(bytes.NewReader(buf.Bytes()), nil)
+	if err != nil {
+		t.Fatalf("TestLazyIncrementalUpdate: %v\n", err)
+	}
+
+	if len(list) != 1 {
+		t.Fatalf("TestLazyIncrementalUpdate: want 1 attachment, got %d\n", len(list))
+	}
+}
+
+func TestInfoReader(t *testing.T) {
+
+	info, err := InfoReader(readerForFile(filepath.Join(inDir, "CenterOfWhy.pdf"), t), nil)
+	if err != nil {
+		t.Fatalf("TestInfoReader: %v\n", err)
+	}
+
+	if info.PageCount != 25 || len(info.Pages) != 25 {
+		t.Fatalf("TestInfoReader: pageCount should be %d but is %d (%d pages)\n", 25, info.PageCount, len(info.Pages))
+	}
+
+	for _, p := range info.Pages {
+		if p.MediaBox == nil || p.MediaBox.Width() <= 0 || p.MediaBox.Height() <= 0 {
+			t.Fatalf("TestInfoReader: page %d: invalid media box %v\n", p.Number, p.MediaBox)
+		}
+	}
+
+	if info.Encrypted {
+		t.Fatalf("TestInfoReader: unexpected encryption\n")
+	}
+
+	// Page boundaries are given as [llx lly urx ury].
+	bb, err := json.Marshal(info.Pages[0])
+	if err != nil {
+		t.Fatalf("TestInfoReader: %v\n", err)
+	}
+
+	var p map[string]interface{}
+	if err = json.Unmarshal(bb, &p); err != nil {
+		t.Fatalf("TestInfoReader: %v\n", err)
+	}
+
+	if a, ok := p["mediaBox"].([]interface{}); !ok || len(a) != 4 {
+		t.Fatalf("TestInfoReader: want media box as array, got %s\n", bb)
+	}
+
+	info.Pages[0].MediaBox = nil
+	if s := strings.Join(info.Lines(), "\n"); !strings.Contains(s, "Page 1 MediaBox: missing") {
+		t.Fatalf("TestInfoReader: want missing media box, got\n%s\n", s)
+	}
+}
+
+func pageRotation(ctx *pdfcpu.Context, pageNr int, t *testing.T) int {
+
+	info, err := pdfcpu.DocumentInfo(ctx)
+	if err != nil {
+		t.Fatalf("pageRotation: %v\n", err)
+	}
+
+	return info.Pages[pageNr-1].Rotate
+}
+
+func TestRotateReader(t *testing.T) {
+
+	var buf bytes.Buffer
+	err := RotateReader(readerForFile(filepath.Join(inDir, "CenterOfWhy.pdf"), t), &buf, []string{"1-2"}, -90, nil)
+	if err != nil {
+		t.Fatalf("TestRotateReader: %v\n", err)
+	}
+
+	ctx, err := readAndValidateContext(bytes.NewReader(buf.Bytes()), pdfcpu.NewDefaultConfiguration())
+	if err != nil {
+		t.Fatalf("TestRotateReader: %v\n", err)
+	}
+
+	for pageNr, want := range map[int]int{1: 270, 2: 270, 3: 0} {
+		if got := pageRotation(ctx, pageNr, t); got != want {
+			t.Fatalf("TestRotateReader: page %d: want rotation %d, got %d\n", pageNr, want, got)
+		}
+	}
+}
+
+func TestRotateInheritedRotation(t *testing.T) {
+
+	ctx, err := readAndValidateContext(readerForFile(filepath.Join(inDir, "pike-stanford.pdf"), t), pdfcpu.NewDefaultConfiguration())
+	if err != nil {
+		t.Fatalf("TestRotateInheritedRotation: %v\n", err)
+	}
+
+	// pike-stanford.pdf has no page level rotation.
+	// Let all pages inherit a rotation from the page tree root.
+	root, err := ctx.Pages()
+	if err != nil {
+		t.Fatalf("TestRotateInheritedRotation: %v\n", err)
+	}
+
+	rootDict, err := ctx.DereferenceDict(*root)
+	if err != nil {
+		t.Fatalf("TestRotateInheritedRotation: %v\n", err)
+	}
+
+	rootDict.Update("Rotate", pdfcpu.Integer(90))
+
+	err = pdfcpu.RotatePages(ctx.XRefTable, pdfcpu.IntSet{1: true}, 90)
+	if err != nil {
+		t.Fatalf("TestRotateInheritedRotation: %v\n", err)
+	}
+
+	for pageNr, want := range map[int]int{1: 180, 2: 90} {
+		if got := pageRotation(ctx, pageNr, t); got != want {
+			t.Fatalf("TestRotateInheritedRotation: page %d: want rotation %d, got %d\n", pageNr, want, got)
+		}
+	}
+}
+
+func pageMediaBox(ctx *pdfcpu.Context, pageNr int, t *testing.T) string {
+
+	info, err := pdfcpu.DocumentInfo(ctx)
+	if err != nil {
+		t.Fatalf("pageMediaBox: %v\n", err)
+	}
+
+	return info.Pages[pageNr-1].MediaBox.String()
+}
+
+func TestInsertBlankPagesReader(t *testing.T) {
+
+	fileName := filepath.Join(inDir, "CenterOfWhy.pdf")
+
+	orig, err := readAndValidateContext(readerForFile(fileName, t), pdfcpu.NewDefaultConfiguration())
+	if err != nil {
+		t.Fatalf("TestInsertBlankPagesReader: %v\n", err)
+	}
+
+	var buf bytes.Buffer
+	err = InsertPagesReader(readerForFile(fileName, t), nil, &buf, []string{"2", "5"}, false, nil)
+	if err != nil {
+		t.Fatalf("TestInsertBlankPagesReader: %v\n", err)
+	}
+
+	ctx, err := readAndValidateContext(bytes.NewReader(buf.Bytes()), pdfcpu.NewDefaultConfiguration())
+	if err != nil {
+		t.Fatalf("TestInsertBlankPagesReader: %v\n", err)
+	}
+
+	if ctx.PageCount != orig.PageCount+2 {
+		t.Fatalf("TestInsertBlankPagesReader: pageCount should be %d but is %d\n", orig.PageCount+2, ctx.PageCount)
+	}
+
+	// Blank pages follow pages 2 and 5 and share their media boxes.
+	for blankPageNr, pageNr := range map[int]int{3: 2, 7: 5} {
+		if got, want := pageMediaBox(ctx, blankPageNr, t), pageMediaBox(orig, pageNr, t); got != want {
+			t.Fatalf("TestInsertBlankPagesReader: page %d: want media box %s, got %s\n", blankPageNr, want, got)
+		}
+	}
+}
+
+func TestInsertPagesReader(t *testing.T) {
+
+	var buf bytes.Buffer
+	err := InsertPagesReader(
+		readerForFile(filepath.Join(inDir, "CenterOfWhy.pdf"), t),
+		readerForFile(filepath.Join(inDir, "Acroforms2.pdf"), t),
+		&buf, []string{"2"}, true, nil)
+	if err != nil {
+		t.Fatalf("TestInsertPagesReader: %v\n", err)
+	}
+
+	ctx, err := readAndValidateContext(bytes.NewReader(buf.Bytes()), pdfcpu.NewDefaultConfiguration())
+	if err != nil {
+		t.Fatalf("TestInsertPagesReader: %v\n", err)
+	}
+
+	if ctx.PageCount != 28 {
+		t.Fatalf("TestInsertPagesReader: pageCount should be %d but is %d\n", 28, ctx.PageCount)
+	}
+}
+
+func TestRemovePagesReader(t *testing.T) {
+
+	var buf bytes.Buffer
+	err := RemovePagesReader(readerForFile(filepath.Join(inDir, "CenterOfWhy.pdf"), t), &buf, []string{"1", "3-5"}, nil)
+	if err != nil {
+		t.Fatalf("TestRemovePagesReader: %v\n", err)
+	}
+
+	ctx, err := readAndValidateContext(bytes.NewReader(buf.Bytes()), pdfcpu.NewDefaultConfiguration())
+	if err != nil {
+		t.Fatalf("TestRemovePagesReader: %v\n", err)
+	}
+
+	if ctx.PageCount != 21 {
+		t.Fatalf("TestRemovePagesReader: pageCount should be %d but is %d\n", 21, ctx.PageCount)
+	}
+
+	err = RemovePagesReader(readerForFile(filepath.Join(inDir, "CenterOfWhy.pdf"), t), &buf, []string{"1-"}, nil)
+	if err == nil {
+		t.Fatalf("TestRemovePagesReader: removing all pages should fail\n")
+	}
+}
+
+func TestNUpReader(t *testing.T) {
+
+	for _, tt := range []struct {
+		n, pageCount int
+		landscape    bool
+	}{
+		{2, 13, true},
+		{4, 7, false},
+		{6, 5, true},
+		{9, 3, false},
+	} {
+
+		nup, err := pdfcpu.ParseNUpDetails("m:10, b:on", tt.n)
+		if err != nil {
+			t.Fatalf("TestNUpReader(%d): %v\n", tt.n, err)
+		}
+
+		var buf bytes.Buffer
+		err = NUpReader(readerForFile(filepath.Join(inDir, "CenterOfWhy.pdf"), t), &buf, nil, nup, nil)
+		if err != nil {
+			t.Fatalf("TestNUpReader(%d): %v\n", tt.n, err)
+		}
+
+		info, err := InfoReader(bytes.NewReader(buf.Bytes()), nil)
+		if err != nil {
+			t.Fatalf("TestNUpReader(%d): %v\n", tt.n, err)
+		}
+
+		if info.PageCount != tt.pageCount {
+			t.Fatalf("TestNUpReader(%d): pageCount should be %d but is %d\n", tt.n, tt.pageCount, info.PageCount)
+		}
+
+		mb := info.Pages[0].MediaBox
+		if landscape := mb.Width() > mb.Height(); landscape != tt.landscape {
+			t.Fatalf("TestNUpReader(%d): unexpected paper orientation: %s\n", tt.n, mb)
+		}
+	}
+}
+
+func TestParseNUpDetails(t *testing.T) {
+
+	for _, s := range []string{"", "f:A4", "f:LetterL", "f:A3P, o:dl, m:5, b:off"} {
+		if _, err := pdfcpu.ParseNUpDetails(s, 4); err != nil {
+			t.Fatalf("TestParseNUpDetails(%s): %v\n", s, err)
+		}
+	}
+
+	for _, s := range []string{"f:A7", "o:up", "m:-1", "b:maybe", "x:1", "f"} {
+		if _, err := pdfcpu.ParseNUpDetails(s, 4); err == nil {
+			t.Fatalf("TestParseNUpDetails(%s): should fail\n", s)
+		}
+	}
+
+	if _, err := pdfcpu.ParseNUpDetails("", 5); err == nil {
+		t.Fatalf("TestParseNUpDetails: 5-up should fail\n")
+	}
+}
+
+func TestBookletReader(t *testing.T) {
+
+	// 25 pages padded to 28 give 14 sheet sides regardless of the signature size.
+	for _, tt := range []struct {
+		description string
+		landscape   bool
+	}{
+		{"", true},
+		{"b:right, c:0.5, m:5", true},
+		{"b:top, m:10, l:on", false},
+		{"f:Letter, s:2", true},
+	} {
+
+		booklet, err := pdfcpu.ParseBookletDetails(tt.description)
+		if err != nil {
+			t.Fatalf("TestBookletReader(%s): %v\n", tt.description, err)
+		}
+
+		var buf bytes.Buffer
+		err = BookletReader(readerForFile(filepath.Join(inDir, "CenterOfWhy.pdf"), t), &buf, nil, booklet, nil)
+		if err != nil {
+			t.Fatalf("TestBookletReader(%s): %v\n", tt.description, err)
+		}
+
+		info, err := InfoReader(bytes.NewReader(buf.Bytes()), nil)
+		if err != nil {
+			t.Fatalf("TestBookletReader(%s): %v\n", tt.description, err)
+		}
+
+		if info.PageCount != 14 {
+			t.Fatalf("TestBookletReader(%s): pageCount should be 14 but is %d\n", tt.description, info.PageCount)
+		}
+
+		mb := info.Pages[0].MediaBox
+		if landscape := mb.Width() > mb.Height(); landscape != tt.landscape {
+			t.Fatalf("TestBookletReader(%s): unexpected paper orientation: %s\n", tt.description, mb)
+		}
+	}
+}
+
+func TestParseBookletDetails(t *testing.T) {
+
+	for _, s := range []string{"", "f:A3", "b:top", "f:Letter, b:right, s:4, c:0.5, m:5, l:on"} {
+		if _, err := pdfcpu.ParseBookletDetails(s); err != nil {
+			t.Fatalf("TestParseBookletDetails(%s): %v\n", s, err)
+		}
+	}
+
+	for _, s := range []string{"f:A4L", "b:bottom", "s:-1", "c:x", "m:-1", "l:maybe", "x:1", "f"} {
+		if _, err := pdfcpu.ParseBookletDetails(s); err == nil {
+			t.Fatalf("TestParseBookletDetails(%s): should fail\n", s)
+		}
+	}
+}
+
+func TestCollectReader(t *testing.T) {
+
+	fileName := filepath.Join(inDir, "CenterOfWhy.pdf")
+
+	var buf bytes.Buffer
+	err := CollectReader(readerForFile(fileName, t), &buf, []string{"3", "1", "1", "5-2", "last"}, nil)
+	if err != nil {
+		t.Fatalf("TestCollectReader: %v\n", err)
+	}
+
+	ctx, err := readAndValidateContext(bytes.NewReader(buf.Bytes()), pdfcpu.NewDefaultConfiguration())
+	if err != nil {
+		t.Fatalf("TestCollectReader: %v\n", err)
+	}
+
+	if ctx.PageCount != 8 {
+		t.Fatalf("TestCollectReader: pageCount should be 8 but is %d\n", ctx.PageCount)
+	}
+
+	// Pages 2 and 3 are both page 1 of the original.
+	pageIndRef2, _, err := ctx.PageIndRef(2)
+	if err != nil {
+		t.Fatalf("TestCollectReader: %v\n", err)
+	}
+
+	pageIndRef3, _, err := ctx.PageIndRef(3)
+	if err != nil {
+		t.Fatalf("TestCollectReader: %v\n", err)
+	}
+
+	if pageIndRef2.ObjectNumber == pageIndRef3.ObjectNumber {
+		t.Fatalf("TestCollectReader: repeated page should be a separate page object\n")
+	}
+
+	pageDict2, _, err := ctx.PageDict(2)
+	if err != nil {
+		t.Fatalf("TestCollectReader: %v\n", err)
+	}
+
+	pageDict3, _, err := ctx.PageDict(3)
+	if err != nil {
+		t.Fatalf("TestCollectReader: %v\n", err)
+	}
+
+	c2, _ := pageDict2.Find("Contents")
+	c3, _ := pageDict3.Find("Contents")
+	if c2 == nil || c2.String() != c3.String() {
+		t.Fatalf("TestCollectReader: repeated page should share content: %v %v\n", c2, c3)
+	}
+
+	err = CollectReader(readerForFile(fileName, t), &buf, []string{"26"}, nil)
+	if err == nil {
+		t.Fatalf("TestCollectReader: collecting an unknown page should fail\n")
+	}
+}
+
+func TestMergeReadersCatalog(t *testing.T) {
+
+	msg := "TestMergeReadersCatalog"
+
+	// Merging a document with itself provokes collisions for all named destinations.
+	fileName := filepath.Join(inDir, "gobook.0.pdf")
+
+	rss := []io.ReadSeeker{readerForFile(fileName, t), readerForFile(fileName, t)}
+
+	var buf bytes.Buffer
+	if err := MergeReaders(rss, &buf, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	ctx, err := readAndValidateContext(bytes.NewReader(buf.Bytes()), pdfcpu.NewDefaultConfiguration())
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	bms, err := pdfcpu.TopLevelBookmarks(ctx.XRefTable)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	if len(bms) != 28 {
+		t.Fatalf("%s: want 28 bookmarks, got %d\n", msg, len(bms))
+	}
+
+	// The bookmarks of the second copy lead to its own pages.
+	for i, bm := range bms[:14] {
+		if bms[14+i].Title != bm.Title || bms[14+i].PageNr != bm.PageNr+165 {
+			t.Fatalf("%s: bookmark %v should lead to page %d\n", msg, bms[14+i], bm.PageNr+165)
+		}
+	}
+
+	// Form fields of the second copy get renamed.
+	xRefTable, err := pdfcpu.CreateAcroFormDemoXRef()
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	err = pdfcpu.CreatePDF(xRefTable, outDir+"/", "acroFormMerge.pdf")
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	fileName = filepath.Join(outDir, "acroFormMerge.pdf")
+
+	rss = []io.ReadSeeker{readerForFile(fileName, t), readerForFile(fileName, t)}
+
+	buf.Reset()
+	if err := MergeReaders(rss, &buf, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	config := pdfcpu.NewDefaultConfiguration()
+	config.ValidationMode = pdfcpu.ValidationRelaxed
+
+	ctx, err = readAndValidateContext(bytes.NewReader(buf.Bytes()), config)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	rootDict, err := ctx.Catalog()
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	o, _ := rootDict.Find("AcroForm")
+	formDict, err := ctx.DereferenceDict(o)
+	if err != nil || formDict == nil {
+		t.Fatalf("%s: missing AcroForm %v\n", msg, err)
+	}
+
+	fields := formDict.ArrayEntry("Fields")
+	if fields == nil || len(*fields) != 10 {
+		t.Fatalf("%s: want 10 fields, got %v\n", msg, fields)
+	}
+
+	names := map[string]bool{}
+	for _, o := range *fields {
+		d, err := ctx.DereferenceDict(o)
+		if err != nil || d == nil {
+			t.Fatalf("%s: corrupt field %v\n", msg, err)
+		}
+		o, _ := d.Find("T")
+		name, err := ctx.DereferenceText(o)
+		if err != nil {
+			t.Fatalf("%s: %v\n", msg, err)
+		}
+		if names[name] {
+			t.Fatalf("%s: duplicate field name %s\n", msg, name)
+		}
+		names[name] = true
+	}
+}
+
+// memFiles collects the files written by a command producing several files.
+type memFiles map[string]*bytes.Buffer
+
+func (m memFiles) create(fileName string) (io.Writer, error) {
+	b := &bytes.Buffer{}
+	m[fileName] = b
+	return b, nil
+}
+
+func TestSplitReader(t *testing.T) {
+
+	msg := "TestSplitReader"
+	inFile := filepath.Join(inDir, "gobook.0.pdf")
+
+	for _, tt := range []struct {
+		name      string
+		split     func(rs io.ReadSeeker, create pdfcpu.FileCreator) error
+		fileCount int
+		fileName  string
+	}{
+		{"span", func(rs io.ReadSeeker, create pdfcpu.FileCreator) error { return SplitReader(rs, create, 50, nil) }, 4, "151-165.pdf"},
+		{"bookmark", func(rs io.ReadSeeker, create pdfcpu.FileCreator) error {
+			return SplitByBookmarksReader(rs, create, nil)
+		}, 14, "14 Next Steps.pdf"},
+		{"page", func(rs io.ReadSeeker, create pdfcpu.FileCreator) error {
+			return SplitByPageNrsReader(rs, create, []int{12, 5}, nil)
+		}, 3, "5-11.pdf"},
+		{"size", func(rs io.ReadSeeker, create pdfcpu.FileCreator) error {
+			return SplitBySizeReader(rs, create, 2700*1024, nil)
+		}, 2, "118-165.pdf"},
+	} {
+
+		files := memFiles{}
+
+		if err := tt.split(readerForFile(inFile, t), files.create); err != nil {
+			t.Fatalf("%s(%s): %v\n", msg, tt.name, err)
+		}
+
+		if len(files) != tt.fileCount {
+			t.Fatalf("%s(%s): want %d files, got %d\n", msg, tt.name, tt.fileCount, len(files))
+		}
+
+		b, ok := files[tt.fileName]
+		if !ok {
+			t.Fatalf("%s(%s): missing %s\n", msg, tt.name, tt.fileName)
+		}
+
+		if err := ValidateReader(bytes.NewReader(b.Bytes()), nil); err != nil {
+			t.Fatalf("%s(%s): %v\n", msg, tt.name, err)
+		}
+	}
+}
+
+func TestExtractReaders(t *testing.T) {
+
+	msg := "TestExtractReaders"
+
+	for _, tt := range []struct {
+		name     string
+		fileName string
+		extract  func(rs io.ReadSeeker, create pdfcpu.FileCreator) error
+	}{
+		{"images", "testImage.pdf", func(rs io.ReadSeeker, create pdfcpu.FileCreator) error {
+			return ExtractImagesReader(rs, create, nil, nil)
+		}},
+		{"fonts", "go.pdf", func(rs io.ReadSeeker, create pdfcpu.FileCreator) error {
+			return ExtractFontsReader(rs, create, []string{"1-3"}, nil)
+		}},
+		{"pages", "TheGoProgrammingLanguageCh1.pdf", func(rs io.ReadSeeker, create pdfcpu.FileCreator) error {
+			return ExtractPagesReader(rs, create, []string{"1-2"}, nil)
+		}},
+		{"content", "5116.DCT_Filter.pdf", func(rs io.ReadSeeker, create pdfcpu.FileCreator) error {
+			return ExtractContentReader(rs, create, nil, nil)
+		}},
+		{"metadata", "TheGoProgrammingLanguageCh1.pdf", func(rs io.ReadSeeker, create pdfcpu.FileCreator) error {
+			return ExtractMetadataReader(rs, create, nil)
+		}},
+	} {
+
+		files := memFiles{}
+
+		if err := tt.extract(readerForFile(filepath.Join(inDir, tt.fileName), t), files.create); err != nil {
+			t.Fatalf("%s(%s): %v\n", msg, tt.name, err)
+		}
+
+		if len(files) == 0 {
+			t.Fatalf("%s(%s): nothing extracted\n", msg, tt.name)
+		}
+
+		for fileName, b := range files {
+			if b.Len() == 0 {
+				t.Fatalf("%s(%s): %s is empty\n", msg, tt.name, fileName)
+			}
+		}
+
+		if tt.name == "pages" {
+			if _, ok := files["1.pdf"]; !ok || len(files) != 2 {
+				t.Fatalf("%s(%s): want 1.pdf and 2.pdf, got %d files\n", msg, tt.name, len(files))
+			}
+		}
+	}
+}
+
+func TestExtractAttachmentsReader(t *testing.T) {
+
+	msg := "TestExtractAttachmentsReader"
+
+	var buf bytes.Buffer
+	err := AddAttachmentsReader(readerForFile(filepath.Join(inDir, "go.pdf"), t), &buf, []string{filepath.Join(inDir, "test.wav")}, nil)
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	want, err := ioutil.ReadFile(filepath.Join(inDir, "test.wav"))
+	if err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	files := memFiles{}
+
+	if err = ExtractAttachmentsReader(bytes.NewReader(buf.Bytes()), files.create, nil, nil); err != nil {
+		t.Fatalf("%s: %v\n", msg, err)
+	}
+
+	b, ok := files["test.wav"]
+	if !ok || !bytes.Equal(b.Bytes(), want) {
+		t.Fatalf("%s: want test.wav extracted, got %d files\n", msg, len(files))
+	}
+}
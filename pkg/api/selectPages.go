@@ -28,7 +28,8 @@ import (
 )
 
 var (
-	selectedPagesRegExp *regexp.Regexp
+	selectedPagesRegExp  *regexp.Regexp
+	pageCollectionRegExp *regexp.Regexp
 )
 
 func setupRegExpForPageSelection() *regexp.Regexp {
@@ -44,9 +45,23 @@ func setupRegExpForPageSelection() *regexp.Regexp {
 	return re
 }
 
+func setupRegExpForPageCollection() *regexp.Regexp {
+
+	p := "(\\d+|\\Qlast\\E)"
+
+	e := p + "(-" + p + "?)?"
+
+	exp := "^" + e + "(," + e + ")*$"
+
+	re, _ := regexp.Compile(exp)
+
+	return re
+}
+
 func init() {
 
 	selectedPagesRegExp = setupRegExpForPageSelection()
+	pageCollectionRegExp = setupRegExpForPageCollection()
 }
 
 // ParsePageSelection ensures a correct page selection expression.
@@ -296,3 +311,79 @@ func ensureSelectedPages(ctx *pdf.Context, selectedPages *pdf.IntSet) {
 
 	*selectedPages = m
 }
+
+// ParsePageCollection ensures a correct ordered page list expression.
+func ParsePageCollection(s string) ([]string, error) {
+
+	if s == "" {
+		return nil, nil
+	}
+
+	// Ensure valid comma separated expression of: { # | #-# | #- }* where # is a page number or "last".
+	//
+	// Pages are collected strictly in the given order:
+	// "3,1,1,5-2,last" yields pages 3,1,1,5,4,3,2 followed by the last page.
+	// A range running backwards yields its pages in reverse order.
+	// "#-" is short for "#-last".
+
+	if !pageCollectionRegExp.MatchString(s) {
+		return nil, errors.Errorf("-pages \"%s\" => syntax error\n", s)
+	}
+
+	return strings.Split(s, ","), nil
+}
+
+func collectedPage(s string, pageCount int) (int, error) {
+
+	if s == "last" || s == "" {
+		return pageCount, nil
+	}
+
+	i, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, err
+	}
+
+	if i < 1 || i > pageCount {
+		return 0, errors.Errorf("page %d out of range 1-%d", i, pageCount)
+	}
+
+	return i, nil
+}
+
+// pagesForPageCollection returns the page numbers of an ordered page list including repetitions.
+func pagesForPageCollection(pageCount int, pageCollection []string) ([]int, error) {
+
+	var pageNrs []int
+
+	for _, v := range pageCollection {
+
+		pr := strings.Split(v, "-")
+
+		from, err := collectedPage(pr[0], pageCount)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(pr) == 1 {
+			pageNrs = append(pageNrs, from)
+			continue
+		}
+
+		thru, err := collectedPage(pr[1], pageCount)
+		if err != nil {
+			return nil, err
+		}
+
+		step := 1
+		if thru < from {
+			step = -1
+		}
+
+		for i := from; i != thru+step; i += step {
+			pageNrs = append(pageNrs, i)
+		}
+	}
+
+	return pageNrs, nil
+}
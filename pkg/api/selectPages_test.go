@@ -17,6 +17,7 @@ limitations under the License.
 package api
 
 import (
+	"fmt"
 	"regexp"
 	"testing"
 
@@ -133,3 +134,43 @@ func TestPageSelection(t *testing.T) {
 	doTestPageSelection("4-", pageCount, "00011", t)
 	doTestPageSelection("5-", pageCount, "00001", t)
 }
+
+func doTestPageCollection(s string, pageCount int, want []int, t *testing.T) {
+
+	pageCollection, err := ParsePageCollection(s)
+	if err != nil {
+		t.Fatalf("TestPageCollection(%s) %v\n", s, err)
+	}
+
+	pageNrs, err := pagesForPageCollection(pageCount, pageCollection)
+	if err != nil {
+		t.Fatalf("TestPageCollection(%s) %v\n", s, err)
+	}
+
+	if fmt.Sprint(pageNrs) != fmt.Sprint(want) {
+		t.Fatalf("TestPageCollection(%s) expected:%v got:%v\n", s, want, pageNrs)
+	}
+}
+
+func TestPageCollection(t *testing.T) {
+
+	pageCount := 5
+
+	doTestPageCollection("3,1,1,5-2,last", pageCount, []int{3, 1, 1, 5, 4, 3, 2, 5}, t)
+	doTestPageCollection("last-1", pageCount, []int{5, 4, 3, 2, 1}, t)
+	doTestPageCollection("4-", pageCount, []int{4, 5}, t)
+	doTestPageCollection("1-,1-2", pageCount, []int{1, 2, 3, 4, 5, 1, 2}, t)
+	doTestPageCollection("2-2", pageCount, []int{2}, t)
+
+	for _, s := range []string{"even", "!3", "-3", "1,,2", "first"} {
+		if _, err := ParsePageCollection(s); err == nil {
+			t.Fatalf("TestPageCollection(%s) should fail\n", s)
+		}
+	}
+
+	for _, s := range []string{"0", "6", "3-6"} {
+		if _, err := pagesForPageCollection(pageCount, []string{s}); err == nil {
+			t.Fatalf("TestPageCollection(%s) should fail\n", s)
+		}
+	}
+}
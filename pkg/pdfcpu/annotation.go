@@ -0,0 +1,906 @@
+/*
+Copyright 2018 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pdfcpu
+
+import (
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hhrutter/pdfcpu/pkg/log"
+	"github.com/pkg/errors"
+)
+
+// Annotation represents an annotation of a page.
+type Annotation struct {
+	ID            string      `json:"id,omitempty"` // the annotation name or else the object number.
+	Type          string      `json:"type"`         // the annotation subtype.
+	PageNr        int         `json:"page"`
+	Rect          []float64   `json:"rect"` // llx lly urx ury
+	Contents      string      `json:"contents,omitempty"`
+	Author        string      `json:"author,omitempty"`
+	Color         []float64   `json:"color,omitempty"`         // RGB components in the range 0..1
+	InteriorColor []float64   `json:"interiorColor,omitempty"` // Line, Square, Circle, Polygon, PolyLine
+	Icon          string      `json:"icon,omitempty"`          // Text, Stamp, FileAttachment
+	Open          bool        `json:"open,omitempty"`          // Text
+	URI           string      `json:"uri,omitempty"`           // Link
+	Dest          int         `json:"dest,omitempty"`          // Link: the page number of the destination.
+	Points        []float64   `json:"points,omitempty"`        // Line, Polygon, PolyLine and text markup annotations.
+	InkList       [][]float64 `json:"inkList,omitempty"`       // Ink
+	File          string      `json:"file,omitempty"`          // FileAttachment: the file to be embedded.
+}
+
+// annotation flags
+const (
+	annotPrint = 4
+)
+
+// The annotation types supported for adding annotations along with their default icons.
+var annotationTypes = map[string]string{
+	"Text":           "Note",
+	"Link":           "",
+	"FreeText":       "",
+	"Line":           "",
+	"Square":         "",
+	"Circle":         "",
+	"Polygon":        "",
+	"PolyLine":       "",
+	"Highlight":      "",
+	"Underline":      "",
+	"Squiggly":       "",
+	"StrikeOut":      "",
+	"Stamp":          "Draft",
+	"Ink":            "",
+	"FileAttachment": "PushPin",
+}
+
+// The annotation entries holding the points of an annotation.
+var annotationPoints = map[string]string{
+	"Line":      "L",
+	"Polygon":   "Vertices",
+	"PolyLine":  "Vertices",
+	"Highlight": "QuadPoints",
+	"Underline": "QuadPoints",
+	"Squiggly":  "QuadPoints",
+	"StrikeOut": "QuadPoints",
+}
+
+func (a Annotation) String() string {
+
+	s := fmt.Sprintf("page %d: %s", a.PageNr, a.Type)
+
+	if a.ID != "" {
+		s += " id=" + a.ID
+	}
+
+	if len(a.Rect) == 4 {
+		s += fmt.Sprintf(" rect=[%.2f %.2f %.2f %.2f]", a.Rect[0], a.Rect[1], a.Rect[2], a.Rect[3])
+	}
+
+	if a.Author != "" {
+		s += fmt.Sprintf(" author=%q", a.Author)
+	}
+
+	if a.Contents != "" {
+		s += fmt.Sprintf(" contents=%q", a.Contents)
+	}
+
+	return s
+}
+
+// numbers returns the numbers of the array o.
+func (xRefTable *XRefTable) numbers(o Object) ([]float64, error) {
+
+	a, err := xRefTable.DereferenceArray(o)
+	if err != nil || a == nil {
+		return nil, err
+	}
+
+	var ff []float64
+	for _, o := range *a {
+		ff = append(ff, xRefTable.DereferenceNumber(o))
+	}
+
+	return ff, nil
+}
+
+// annotationText returns the text string entry key of d.
+func (xRefTable *XRefTable) annotationText(d *Dict, key string) (string, error) {
+
+	o, found := d.Find(key)
+	if !found {
+		return "", nil
+	}
+
+	return xRefTable.DereferenceText(o)
+}
+
+// annotationLink fills in the URI or the destination of a link annotation.
+func (xRefTable *XRefTable) annotationLink(d *Dict, a *Annotation, pageNrs map[int]int) error {
+
+	if o, found := d.Find("A"); found {
+
+		action, err := xRefTable.DereferenceDict(o)
+		if err != nil || action == nil {
+			return err
+		}
+
+		if s := action.NameEntry("S"); s != nil && *s == "URI" {
+			a.URI, err = xRefTable.annotationText(action, "URI")
+			return err
+		}
+
+		if s := action.NameEntry("S"); s == nil || *s != "GoTo" {
+			return nil
+		}
+
+		d = action
+		if o, found := d.Find("D"); found {
+			d = &Dict{"Dest": o}
+		}
+	}
+
+	o, found := d.Find("Dest")
+	if !found {
+		return nil
+	}
+
+	dest, err := xRefTable.explicitDestination(o)
+	if err != nil {
+		return err
+	}
+
+	if len(dest) > 0 {
+		if indRef, ok := dest[0].(IndirectRef); ok {
+			a.Dest = pageNrs[indRef.ObjectNumber.Value()]
+		}
+	}
+
+	return nil
+}
+
+// annotationFile returns the file name of the file attached to a file attachment annotation.
+func (xRefTable *XRefTable) annotationFile(d *Dict) (string, error) {
+
+	o, found := d.Find("FS")
+	if !found {
+		return "", nil
+	}
+
+	o, err := xRefTable.Dereference(o)
+	if err != nil {
+		return "", err
+	}
+
+	fs, ok := o.(Dict)
+	if !ok {
+		return xRefTable.DereferenceText(o)
+	}
+
+	for _, k := range []string{"UF", "F"} {
+		if o, found := fs.Find(k); found {
+			return xRefTable.DereferenceText(o)
+		}
+	}
+
+	return "", nil
+}
+
+// annotation returns the annotation for an annotation dict of page pageNr.
+func (xRefTable *XRefTable) annotation(d *Dict, objNr, pageNr int, pageNrs map[int]int) (*Annotation, error) {
+
+	a := &Annotation{PageNr: pageNr}
+
+	if st := d.Subtype(); st != nil {
+		a.Type = *st
+	}
+
+	var err error
+
+	if a.ID, err = xRefTable.annotationText(d, "NM"); err != nil {
+		return nil, err
+	}
+
+	if a.ID == "" && objNr > 0 {
+		a.ID = strconv.Itoa(objNr)
+	}
+
+	if o, found := d.Find("Rect"); found {
+		if a.Rect, err = xRefTable.numbers(o); err != nil {
+			return nil, err
+		}
+	}
+
+	if a.Contents, err = xRefTable.annotationText(d, "Contents"); err != nil {
+		return nil, err
+	}
+
+	if a.Author, err = xRefTable.annotationText(d, "T"); err != nil {
+		return nil, err
+	}
+
+	for k, p := range map[string]*[]float64{"C": &a.Color, "IC": &a.InteriorColor} {
+		if o, found := d.Find(k); found {
+			if *p, err = xRefTable.numbers(o); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if k, ok := annotationPoints[a.Type]; ok {
+		if o, found := d.Find(k); found {
+			if a.Points, err = xRefTable.numbers(o); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	switch a.Type {
+
+	case "Text", "Stamp", "FileAttachment":
+		if n := d.NameEntry("Name"); n != nil {
+			a.Icon = *n
+		}
+		if open := d.BooleanEntry("Open"); open != nil {
+			a.Open = *open
+		}
+		if a.Type == "FileAttachment" {
+			if a.File, err = xRefTable.annotationFile(d); err != nil {
+				return nil, err
+			}
+		}
+
+	case "Link":
+		if err = xRefTable.annotationLink(d, a, pageNrs); err != nil {
+			return nil, err
+		}
+
+	case "Ink":
+		if o, found := d.Find("InkList"); found {
+			inkList, err := xRefTable.DereferenceArray(o)
+			if err != nil {
+				return nil, err
+			}
+			if inkList != nil {
+				for _, o := range *inkList {
+					path, err := xRefTable.numbers(o)
+					if err != nil {
+						return nil, err
+					}
+					a.InkList = append(a.InkList, path)
+				}
+			}
+		}
+	}
+
+	return a, nil
+}
+
+// pageAnnotations returns the annotations array of a page dict.
+func (xRefTable *XRefTable) pageAnnotations(pageDict *Dict) (Array, error) {
+
+	o, found := pageDict.Find("Annots")
+	if !found {
+		return nil, nil
+	}
+
+	annots, err := xRefTable.DereferenceArray(o)
+	if err != nil || annots == nil {
+		return nil, err
+	}
+
+	return *annots, nil
+}
+
+// annotationDict resolves an annotations array entry and returns its object number, 0 for direct dicts.
+func (xRefTable *XRefTable) annotationDict(o Object) (*Dict, int, error) {
+
+	objNr := 0
+	if indRef, ok := o.(IndirectRef); ok {
+		objNr = indRef.ObjectNumber.Value()
+	}
+
+	d, err := xRefTable.DereferenceDict(o)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return d, objNr, nil
+}
+
+// Annotations returns the annotations of the selected pages.
+func Annotations(xRefTable *XRefTable, selectedPages IntSet) ([]Annotation, error) {
+
+	pageNrs, err := xRefTable.pageNumbers()
+	if err != nil {
+		return nil, err
+	}
+
+	var annots []Annotation
+
+	for _, pageNr := range selectedPageNumbers(selectedPages) {
+
+		pageDict, _, err := xRefTable.PageDict(pageNr)
+		if err != nil {
+			return nil, err
+		}
+
+		if pageDict == nil {
+			return nil, errors.Errorf("Annotations: missing page %d", pageNr)
+		}
+
+		arr, err := xRefTable.pageAnnotations(pageDict)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, o := range arr {
+
+			d, objNr, err := xRefTable.annotationDict(o)
+			if err != nil {
+				return nil, err
+			}
+
+			if d == nil {
+				continue
+			}
+
+			a, err := xRefTable.annotation(d, objNr, pageNr, pageNrs)
+			if err != nil {
+				return nil, err
+			}
+
+			annots = append(annots, *a)
+		}
+	}
+
+	return annots, nil
+}
+
+// ListAnnotations returns a list of the annotations of the selected pages.
+func ListAnnotations(xRefTable *XRefTable, selectedPages IntSet) ([]string, error) {
+
+	log.Debug.Println("ListAnnotations begin")
+
+	annots, err := Annotations(xRefTable, selectedPages)
+	if err != nil {
+		return nil, err
+	}
+
+	var list []string
+	for _, a := range annots {
+		list = append(list, a.String())
+	}
+
+	log.Debug.Println("ListAnnotations end")
+
+	return list, nil
+}
+
+// colorArray returns a PDF array for the RGB color c.
+func colorArray(c []float64) (Array, error) {
+
+	if len(c) != 3 {
+		return nil, errors.New("color needs 3 components")
+	}
+
+	for _, f := range c {
+		if f < 0 || f > 1 {
+			return nil, errors.New("color components need to be in the range 0..1")
+		}
+	}
+
+	return NewNumberArray(c...), nil
+}
+
+// pointsArray returns a PDF array for the coordinates of the points of a.
+// Text markup annotations default to the quadrilateral of their rectangle and lines to the diagonal.
+func (a Annotation) pointsArray() (Array, error) {
+
+	r := a.Rect
+	pp := a.Points
+
+	if pp == nil {
+		switch a.Type {
+		case "Line":
+			pp = []float64{r[0], r[1], r[2], r[3]}
+		case "Polygon", "PolyLine":
+			return nil, errors.Errorf("%s annotation: missing points", a.Type)
+		default:
+			pp = []float64{r[0], r[3], r[2], r[3], r[0], r[1], r[2], r[1]}
+		}
+	}
+
+	n := 2
+	switch a.Type {
+	case "Line":
+		n = 4
+	case "Highlight", "Underline", "Squiggly", "StrikeOut":
+		n = 8
+	}
+
+	if len(pp) == 0 || len(pp)%n > 0 || a.Type == "Line" && len(pp) != 4 {
+		return nil, errors.Errorf("%s annotation: invalid number of point coordinates: %d", a.Type, len(pp))
+	}
+
+	return NewNumberArray(pp...), nil
+}
+
+// embedAnnotationFile embeds fileName and returns a reference to its file specification.
+func (xRefTable *XRefTable) embedAnnotationFile(fileName string) (*IndirectRef, error) {
+
+	sd, err := xRefTable.NewEmbeddedFileStreamDict(fileName)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = encodeStream(sd); err != nil {
+		return nil, err
+	}
+
+	indRef, err := xRefTable.IndRefForNewObject(*sd)
+	if err != nil {
+		return nil, err
+	}
+
+	fileSpecDict, err := xRefTable.NewFileSpecDict(path.Base(fileName), *indRef)
+	if err != nil {
+		return nil, err
+	}
+
+	return xRefTable.IndRefForNewObject(*fileSpecDict)
+}
+
+// annotationReferences adds the entries of a referring to other objects to d.
+func (xRefTable *XRefTable) annotationReferences(d Dict, a Annotation) error {
+
+	switch a.Type {
+
+	case "Link":
+		if a.URI != "" || a.Dest == 0 {
+			return nil
+		}
+		pageIndRef, _, err := xRefTable.PageIndRef(a.Dest)
+		if err != nil {
+			return err
+		}
+		if pageIndRef == nil {
+			return errors.Errorf("Link annotation: missing page %d", a.Dest)
+		}
+		d.Insert("Dest", Array{*pageIndRef, Name("Fit")})
+
+	case "FileAttachment":
+		indRef, err := xRefTable.embedAnnotationFile(a.File)
+		if err != nil {
+			return err
+		}
+		d.Insert("FS", *indRef)
+	}
+
+	return nil
+}
+
+// annotationTypeDetails adds the entries specific to the type of a to d
+// except for entries referring to other objects.
+func annotationTypeDetails(d Dict, a Annotation) error {
+
+	switch a.Type {
+
+	case "Link":
+		if a.URI == "" && a.Dest == 0 {
+			return errors.New("Link annotation: missing uri or dest")
+		}
+		if a.URI != "" {
+			// URIs are 7-bit ASCII strings, see 12.6.4.7 URI Actions.
+			uri, err := Escape(a.URI)
+			if err != nil {
+				return err
+			}
+			d.Insert("A", Dict{"Type": Name("Action"), "S": Name("URI"), "URI": StringLiteral(*uri)})
+		}
+		d.Insert("Border", NewIntegerArray(0, 0, 0))
+
+	case "FreeText":
+		d.Insert("DA", StringLiteral("/Helv 12 Tf 0 g"))
+
+	case "Ink":
+		if len(a.InkList) == 0 {
+			return errors.New("Ink annotation: missing inkList")
+		}
+		inkList := Array{}
+		for _, path := range a.InkList {
+			if len(path) == 0 || len(path)%2 > 0 {
+				return errors.Errorf("Ink annotation: invalid number of point coordinates: %d", len(path))
+			}
+			inkList = append(inkList, NewNumberArray(path...))
+		}
+		d.Insert("InkList", inkList)
+
+	case "FileAttachment":
+		if a.File == "" {
+			return errors.New("FileAttachment annotation: missing file")
+		}
+	}
+
+	if k, ok := annotationPoints[a.Type]; ok {
+		arr, err := a.pointsArray()
+		if err != nil {
+			return err
+		}
+		d.Insert(k, arr)
+	}
+
+	if icon := annotationTypes[a.Type]; icon != "" {
+		if a.Icon != "" {
+			icon = a.Icon
+		}
+		d.Insert("Name", Name(icon))
+	}
+
+	if a.Type == "Text" && a.Open {
+		d.Insert("Open", Boolean(true))
+	}
+
+	if a.InteriorColor != nil {
+		switch a.Type {
+		case "Line", "Square", "Circle", "Polygon", "PolyLine":
+			c, err := colorArray(a.InteriorColor)
+			if err != nil {
+				return errors.Wrapf(err, "%s annotation: interiorColor", a.Type)
+			}
+			d.Insert("IC", c)
+		}
+	}
+
+	return nil
+}
+
+// newAnnotationDict creates the annotation dict for a without entries referring to other objects.
+func newAnnotationDict(a Annotation) (Dict, error) {
+
+	if len(a.Rect) != 4 {
+		return nil, errors.Errorf("%s annotation: rect needs 4 coordinates", a.Type)
+	}
+
+	now := StringLiteral(DateString(time.Now()))
+
+	d := Dict{
+		"Type":    Name("Annot"),
+		"Subtype": Name(a.Type),
+		"Rect":    NewRectangle(a.Rect[0], a.Rect[1], a.Rect[2], a.Rect[3]),
+		"F":       Integer(annotPrint),
+		"M":       now,
+	}
+
+	for k, v := range map[string]string{"NM": a.ID, "Contents": a.Contents} {
+		if v == "" {
+			continue
+		}
+		o, err := textObject(v)
+		if err != nil {
+			return nil, err
+		}
+		d.Insert(k, o)
+	}
+
+	// Links are the only annotations in this set which are no markup annotations.
+	if a.Type != "Link" {
+		d.Insert("CreationDate", now)
+		if a.Author != "" {
+			o, err := textObject(a.Author)
+			if err != nil {
+				return nil, err
+			}
+			d.Insert("T", o)
+		}
+	}
+
+	if a.Color != nil {
+		c, err := colorArray(a.Color)
+		if err != nil {
+			return nil, errors.Wrapf(err, "%s annotation: color", a.Type)
+		}
+		d.Insert("C", c)
+	}
+
+	if err := annotationTypeDetails(d, a); err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
+
+// annotationIDs returns the annotation names in use on a page.
+func (xRefTable *XRefTable) annotationIDs(annots Array) (map[string]bool, error) {
+
+	m := map[string]bool{}
+
+	for _, o := range annots {
+
+		d, _, err := xRefTable.annotationDict(o)
+		if err != nil {
+			return nil, err
+		}
+
+		if d == nil {
+			continue
+		}
+
+		nm, err := xRefTable.annotationText(d, "NM")
+		if err != nil {
+			return nil, err
+		}
+
+		if nm != "" {
+			m[nm] = true
+		}
+	}
+
+	return m, nil
+}
+
+// addAnnotation adds the annotation a to its page.
+func (xRefTable *XRefTable) addAnnotation(a Annotation) error {
+
+	if _, ok := annotationTypes[a.Type]; !ok {
+		return errors.Errorf("addAnnotation: unsupported annotation type %q", a.Type)
+	}
+
+	if a.PageNr < 1 || a.PageNr > xRefTable.PageCount {
+		return errors.Errorf("addAnnotation: %s annotation: page %d out of range", a.Type, a.PageNr)
+	}
+
+	pageIndRef, _, err := xRefTable.PageIndRef(a.PageNr)
+	if err != nil {
+		return err
+	}
+
+	pageDict, err := xRefTable.DereferenceDict(*pageIndRef)
+	if err != nil {
+		return err
+	}
+
+	if pageDict == nil {
+		return errors.Errorf("addAnnotation: missing page %d", a.PageNr)
+	}
+
+	annots, err := xRefTable.pageAnnotations(pageDict)
+	if err != nil {
+		return err
+	}
+
+	// Annotation names need to be unique within a page.
+	if a.ID != "" {
+		ids, err := xRefTable.annotationIDs(annots)
+		if err != nil {
+			return err
+		}
+		if ids[a.ID] {
+			return errors.Errorf("addAnnotation: page %d: duplicate annotation id %q", a.PageNr, a.ID)
+		}
+	}
+
+	d, err := newAnnotationDict(a)
+	if err != nil {
+		return err
+	}
+
+	d.Insert("P", *pageIndRef)
+
+	if err = xRefTable.annotationReferences(d, a); err != nil {
+		return err
+	}
+
+	indRef, err := xRefTable.IndRefForNewObject(d)
+	if err != nil {
+		return err
+	}
+
+	pageDict.Update("Annots", append(annots, *indRef))
+
+	return nil
+}
+
+// AddAnnotations adds annotations to the pages given by their page numbers.
+func AddAnnotations(xRefTable *XRefTable, annots []Annotation) error {
+
+	log.Debug.Println("AddAnnotations begin")
+
+	for _, a := range annots {
+		if err := xRefTable.addAnnotation(a); err != nil {
+			return err
+		}
+	}
+
+	log.Debug.Println("AddAnnotations end")
+
+	return nil
+}
+
+// annotationMatches returns true if the annotation d is selected by one of ids.
+// An id is either an annotation type, an annotation name or an object number.
+func (xRefTable *XRefTable) annotationMatches(d *Dict, objNr int, ids []string) (bool, error) {
+
+	if len(ids) == 0 {
+		return true, nil
+	}
+
+	if st := d.Subtype(); st != nil && MemberOf(*st, ids) {
+		return true, nil
+	}
+
+	if objNr > 0 && MemberOf(strconv.Itoa(objNr), ids) {
+		return true, nil
+	}
+
+	nm, err := xRefTable.annotationText(d, "NM")
+	if err != nil {
+		return false, err
+	}
+
+	return nm != "" && MemberOf(nm, ids), nil
+}
+
+// referencedObjNr returns the object number referenced by entry key of d or 0.
+func referencedObjNr(d *Dict, key string) int {
+
+	indRef := d.IndirectRefEntry(key)
+	if indRef == nil {
+		return 0
+	}
+
+	return indRef.ObjectNumber.Value()
+}
+
+// removePageAnnotations removes the annotations of a page selected by match
+// along with their popups and replies.
+func (xRefTable *XRefTable) removePageAnnotations(pageDict *Dict, match func(d *Dict, objNr int) (bool, error)) (int, error) {
+
+	annots, err := xRefTable.pageAnnotations(pageDict)
+	if err != nil || len(annots) == 0 {
+		return 0, err
+	}
+
+	type entry struct {
+		o     Object
+		d     *Dict
+		objNr int
+	}
+
+	var entries []entry
+
+	removed := IntSet{} // object numbers of removed annotations.
+	popups := IntSet{}  // object numbers of the popups of removed annotations.
+	remove := []bool{}  // per entry.
+
+	markRemoved := func(d *Dict, objNr int) {
+		if objNr > 0 {
+			removed[objNr] = true
+		}
+		if popup := referencedObjNr(d, "Popup"); popup > 0 {
+			popups[popup] = true
+		}
+	}
+
+	for _, o := range annots {
+
+		d, objNr, err := xRefTable.annotationDict(o)
+		if err != nil {
+			return 0, err
+		}
+
+		ok := false
+
+		if d != nil {
+			if ok, err = match(d, objNr); err != nil {
+				return 0, err
+			}
+		}
+
+		if ok {
+			markRemoved(d, objNr)
+		}
+
+		entries = append(entries, entry{o, d, objNr})
+		remove = append(remove, ok)
+	}
+
+	// Popups and replies go along with their parent annotation.
+	for changed := true; changed; {
+
+		changed = false
+
+		for i, e := range entries {
+
+			if remove[i] || e.d == nil {
+				continue
+			}
+
+			if popups[e.objNr] || removed[referencedObjNr(e.d, "Parent")] || removed[referencedObjNr(e.d, "IRT")] {
+				remove[i] = true
+				markRemoved(e.d, e.objNr)
+				changed = true
+			}
+		}
+	}
+
+	var arr Array
+	c := 0
+
+	for i, e := range entries {
+
+		if remove[i] {
+			c++
+			continue
+		}
+
+		// The popup of a remaining annotation may have been removed explicitly.
+		if e.d != nil && removed[referencedObjNr(e.d, "Popup")] {
+			e.d.Delete("Popup")
+		}
+
+		arr = append(arr, e.o)
+	}
+
+	if len(arr) == 0 {
+		pageDict.Delete("Annots")
+	} else {
+		pageDict.Update("Annots", arr)
+	}
+
+	return c, nil
+}
+
+// RemoveAnnotations removes the annotations selected by ids from the selected pages.
+// An id is either an annotation type, an annotation name or an object number.
+// If ids is empty all annotations except form field widgets get removed.
+// Popups and replies of a removed annotation get removed as well.
+func RemoveAnnotations(xRefTable *XRefTable, selectedPages IntSet, ids []string) error {
+
+	log.Debug.Printf("RemoveAnnotations begin: %s\n", strings.Join(ids, ","))
+
+	for _, pageNr := range selectedPageNumbers(selectedPages) {
+
+		pageDict, _, err := xRefTable.PageDict(pageNr)
+		if err != nil {
+			return err
+		}
+
+		if pageDict == nil {
+			return errors.Errorf("RemoveAnnotations: missing page %d", pageNr)
+		}
+
+		c, err := xRefTable.removePageAnnotations(pageDict, func(d *Dict, objNr int) (bool, error) {
+			// Widgets belong to form fields.
+			if st := d.Subtype(); st != nil && *st == "Widget" {
+				return false, nil
+			}
+			return xRefTable.annotationMatches(d, objNr, ids)
+		})
+		if err != nil {
+			return err
+		}
+
+		log.Debug.Printf("RemoveAnnotations: page %d: removed %d annotations\n", pageNr, c)
+	}
+
+	log.Debug.Println("RemoveAnnotations end")
+
+	return nil
+}
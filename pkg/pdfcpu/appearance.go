@@ -0,0 +1,578 @@
+/*
+Copyright 2018 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pdfcpu
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/hhrutter/pdfcpu/pkg/fonts/metrics"
+	"github.com/hhrutter/pdfcpu/pkg/log"
+)
+
+// field flags (bit positions) affecting the appearance of text fields.
+const (
+	fieldMultiline = 13
+	fieldPassword  = 14
+	fieldComb      = 25
+)
+
+// Distance between the widget border and its content.
+const appearancePadding = 2.0
+
+// Default font size for auto sized multi line text and list boxes.
+const appearanceFontSize = 12.0
+
+// WinAnsiEncoding codes of runes outside Latin-1, see D.2 Latin Character Set and Encodings.
+var winAnsiCodes = map[rune]byte{
+	'€': 0x80, '‚': 0x82, 'ƒ': 0x83, '„': 0x84, '…': 0x85, '†': 0x86, '‡': 0x87, 'ˆ': 0x88,
+	'‰': 0x89, 'Š': 0x8A, '‹': 0x8B, 'Œ': 0x8C, 'Ž': 0x8E, '‘': 0x91, '’': 0x92, '“': 0x93,
+	'”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97, '˜': 0x98, '™': 0x99, 'š': 0x9A, '›': 0x9B,
+	'œ': 0x9C, 'ž': 0x9E, 'Ÿ': 0x9F,
+}
+
+// winAnsiString returns s as a PDF string literal encoded in WinAnsiEncoding.
+// Runes not covered by WinAnsiEncoding are replaced by '?'.
+func winAnsiString(s string) string {
+
+	var b bytes.Buffer
+
+	for _, r := range s {
+		switch {
+		case r < 0x80 || r >= 0xA0 && r <= 0xFF:
+			b.WriteByte(byte(r))
+		case winAnsiCodes[r] > 0:
+			b.WriteByte(winAnsiCodes[r])
+		default:
+			b.WriteByte('?')
+		}
+	}
+
+	s1, _ := Escape(b.String())
+
+	return "(" + *s1 + ")"
+}
+
+// metricsFontName returns the standard font providing the metrics for a base font.
+func metricsFontName(baseFont string) string {
+
+	// Subset fonts are prefixed by a tag like ABCDEF+.
+	if i := strings.Index(baseFont, "+"); i == 6 {
+		baseFont = baseFont[i+1:]
+	}
+
+	switch {
+	case strings.HasPrefix(baseFont, "Times"):
+		return "Times-Roman"
+	case strings.HasPrefix(baseFont, "Cour"):
+		return "Courier"
+	}
+
+	return "Helvetica"
+}
+
+// charWidth returns the width of r in glyph space units.
+// The metrics are keyed by StandardEncoding so non ASCII runes get the average width.
+func charWidth(fontName string, r rune) float64 {
+
+	if r >= 0x80 {
+		return float64(metrics.CharWidth(fontName, -1))
+	}
+
+	return float64(metrics.CharWidth(fontName, int(r)))
+}
+
+func textWidth(s, fontName string, fontSize float64) float64 {
+
+	var w float64
+	for _, r := range s {
+		w += charWidth(fontName, r)
+	}
+
+	return w * fontSize / 1000
+}
+
+// colorOperator returns the operator setting the color of a color array of a widget's appearance characteristics.
+func (xRefTable *XRefTable) colorOperator(o Object, stroke bool) string {
+
+	a, err := xRefTable.DereferenceArray(o)
+	if err != nil || a == nil {
+		return ""
+	}
+
+	ops := map[int]string{1: "g", 3: "rg", 4: "k"}
+
+	op, ok := ops[len(*a)]
+	if !ok {
+		return ""
+	}
+
+	if stroke {
+		op = strings.ToUpper(op)
+	}
+
+	var ss []string
+	for _, o := range *a {
+		ss = append(ss, fmt.Sprintf("%.3f", xRefTable.DereferenceNumber(o)))
+	}
+
+	return strings.Join(ss, " ") + " " + op
+}
+
+// defaultAppearance represents the parts of a DA string needed for rendering variable text.
+type defaultAppearance struct {
+	fontID   string  // font resource name.
+	fontSize float64 // 0 for auto size.
+	color    string  // color operator.
+}
+
+// parseDefaultAppearance parses a default appearance string like "/Helv 12 Tf 0 g".
+func parseDefaultAppearance(s string) defaultAppearance {
+
+	da := defaultAppearance{fontID: "Helv", color: "0 g"}
+
+	var operands []string
+
+	for _, t := range strings.Fields(s) {
+
+		if _, err := strconv.ParseFloat(t, 64); err == nil || strings.HasPrefix(t, "/") {
+			operands = append(operands, t)
+			continue
+		}
+
+		n := len(operands)
+
+		switch {
+		case t == "Tf" && n >= 2:
+			da.fontID = strings.TrimPrefix(operands[n-2], "/")
+			da.fontSize, _ = strconv.ParseFloat(operands[n-1], 64)
+		case t == "g" && n >= 1:
+			da.color = strings.Join(operands[n-1:], " ") + " g"
+		case t == "rg" && n >= 3:
+			da.color = strings.Join(operands[n-3:], " ") + " rg"
+		case t == "k" && n >= 4:
+			da.color = strings.Join(operands[n-4:], " ") + " k"
+		}
+
+		operands = nil
+	}
+
+	return da
+}
+
+// inheritedFieldEntry returns the value of an inheritable entry of a field dict or its ancestors.
+func (xRefTable *XRefTable) inheritedFieldEntry(d *Dict, key string) (Object, error) {
+
+	visited := IntSet{}
+
+	for d != nil {
+
+		if o, found := d.Find(key); found {
+			return xRefTable.Dereference(o)
+		}
+
+		parent := d.IndirectRefEntry("Parent")
+		if parent == nil || visited[parent.ObjectNumber.Value()] {
+			break
+		}
+		visited[parent.ObjectNumber.Value()] = true
+
+		var err error
+		if d, err = xRefTable.DereferenceDict(*parent); err != nil {
+			return nil, err
+		}
+	}
+
+	return nil, nil
+}
+
+// appearanceContext caches the fonts of generated appearance streams.
+type appearanceContext struct {
+	form  *Dict
+	fonts map[string]IndirectRef // by metrics font name.
+}
+
+// fieldFont returns the metrics font name for the font resource fontID of the default resources
+// of a field or the AcroForm and a standard font using WinAnsiEncoding in its place.
+func (xRefTable *XRefTable) fieldFont(ac *appearanceContext, d *Dict, fontID string) (string, *IndirectRef, error) {
+
+	fontName := "Helvetica"
+
+	// Some writers attach default resources to fields.
+	o, err := xRefTable.inheritedFieldEntry(d, "DR")
+	if err != nil {
+		return "", nil, err
+	}
+	if o == nil {
+		o, _ = ac.form.Find("DR")
+	}
+
+	dr, err := xRefTable.DereferenceDict(o)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if dr != nil {
+		if o, found := dr.Find("Font"); found {
+			fonts, err := xRefTable.DereferenceDict(o)
+			if err != nil {
+				return "", nil, err
+			}
+			if fonts != nil {
+				if o, found := fonts.Find(fontID); found {
+					d, err := xRefTable.DereferenceDict(o)
+					if err != nil {
+						return "", nil, err
+					}
+					if d != nil {
+						if baseFont := d.NameEntry("BaseFont"); baseFont != nil {
+							fontName = metricsFontName(*baseFont)
+						}
+					}
+				}
+			}
+		}
+	}
+
+	if indRef, ok := ac.fonts[fontName]; ok {
+		return fontName, &indRef, nil
+	}
+
+	fontDict := NewDict()
+	fontDict.InsertName("Type", "Font")
+	fontDict.InsertName("Subtype", "Type1")
+	fontDict.InsertName("BaseFont", fontName)
+	fontDict.InsertName("Encoding", "WinAnsiEncoding")
+
+	indRef, err := xRefTable.IndRefForNewObject(fontDict)
+	if err != nil {
+		return "", nil, err
+	}
+
+	ac.fonts[fontName] = *indRef
+
+	return fontName, indRef, nil
+}
+
+// textLayout positions lines of text within a widget.
+type textLayout struct {
+	fontName string
+	fontSize float64
+	w, h     float64
+	q        int // quadding: 0 left, 1 centered, 2 right.
+}
+
+// lineHeight returns the height of a line of text based on the font bounding box.
+func (l textLayout) lineHeight() float64 {
+	bb := metrics.FontBoundingBox(l.fontName)
+	return (bb.UR.Y - bb.LL.Y) * l.fontSize / 1000
+}
+
+// descent returns the distance between the bottom of a line and its baseline.
+func (l textLayout) descent() float64 {
+	return -metrics.FontBoundingBox(l.fontName).LL.Y * l.fontSize / 1000
+}
+
+func (l textLayout) x(s string) float64 {
+
+	switch l.q {
+	case 1:
+		return (l.w - textWidth(s, l.fontName, l.fontSize)) / 2
+	case 2:
+		return l.w - appearancePadding - textWidth(s, l.fontName, l.fontSize)
+	}
+
+	return appearancePadding
+}
+
+// wrap breaks s into lines fitting the width of the layout.
+func (l textLayout) wrap(s string) []string {
+
+	maxWidth := l.w - 2*appearancePadding
+
+	var lines []string
+
+	for _, para := range strings.Split(strings.NewReplacer("\r\n", "\n", "\r", "\n").Replace(s), "\n") {
+
+		line := ""
+
+		for _, word := range strings.Split(para, " ") {
+
+			candidate := word
+			if line != "" {
+				candidate = line + " " + word
+			}
+
+			if textWidth(candidate, l.fontName, l.fontSize) <= maxWidth {
+				line = candidate
+				continue
+			}
+
+			if line != "" {
+				lines = append(lines, line)
+			}
+
+			// Break words exceeding the width.
+			line = ""
+			for _, r := range word {
+				if line != "" && textWidth(line+string(r), l.fontName, l.fontSize) > maxWidth {
+					lines = append(lines, line)
+					line = ""
+				}
+				line += string(r)
+			}
+		}
+
+		lines = append(lines, line)
+	}
+
+	return lines
+}
+
+// autoFontSize returns the font size for auto sized single line text fitting into the layout.
+func (l textLayout) autoFontSize(s string) float64 {
+
+	l.fontSize = 1
+	size := (l.h - 2*appearancePadding) / l.lineHeight()
+
+	if w := textWidth(s, l.fontName, 1); w > 0 {
+		size = math.Min(size, (l.w-2*appearancePadding)/w)
+	}
+
+	return math.Max(size, 1)
+}
+
+// textContent renders the value of a text field or combo box.
+func (l textLayout) textContent(b *bytes.Buffer, s string, ff int, maxLen int) {
+
+	if flagSet(ff, fieldPassword) {
+		s = strings.Repeat("*", len([]rune(s)))
+	}
+
+	switch {
+
+	case flagSet(ff, fieldComb) && maxLen > 0:
+		// Center each character within its cell.
+		cw := l.w / float64(maxLen)
+		y := (l.h-l.lineHeight())/2 + l.descent()
+		for i, r := range []rune(s) {
+			x := float64(i)*cw + (cw-textWidth(string(r), l.fontName, l.fontSize))/2
+			fmt.Fprintf(b, "1 0 0 1 %.2f %.2f Tm %s Tj\n", x, y, winAnsiString(string(r)))
+		}
+
+	case flagSet(ff, fieldMultiline):
+		lh := l.lineHeight()
+		for i, line := range l.wrap(s) {
+			y := l.h - appearancePadding - float64(i+1)*lh + l.descent()
+			fmt.Fprintf(b, "1 0 0 1 %.2f %.2f Tm %s Tj\n", l.x(line), y, winAnsiString(line))
+		}
+
+	default:
+		y := (l.h-l.lineHeight())/2 + l.descent()
+		fmt.Fprintf(b, "1 0 0 1 %.2f %.2f Tm %s Tj\n", l.x(s), y, winAnsiString(s))
+	}
+}
+
+// listContent renders the options of a list box starting at the top index highlighting selected options.
+func (l textLayout) listContent(b *bytes.Buffer, color string, options []string, selected []bool, top int) {
+
+	lh := l.lineHeight()
+
+	for i := top; i < len(options); i++ {
+
+		y := l.h - appearancePadding - float64(i-top+1)*lh
+		if y+lh < 0 {
+			break
+		}
+
+		if selected[i] {
+			fmt.Fprintf(b, "0.600 0.757 0.855 rg %.2f %.2f %.2f %.2f re f\n", appearancePadding/2, y, l.w-appearancePadding, lh)
+		}
+
+		fmt.Fprintf(b, "BT %s /F0 %.2f Tf 1 0 0 1 %.2f %.2f Tm %s Tj ET\n",
+			color, l.fontSize, l.x(options[i]), y+l.descent(), winAnsiString(options[i]))
+	}
+}
+
+// widgetDecoration renders the background and border of a widget as specified by its appearance characteristics.
+func (xRefTable *XRefTable) widgetDecoration(b *bytes.Buffer, w *Dict, width, height float64) {
+
+	mk := w.DictEntry("MK")
+	if mk == nil {
+		return
+	}
+
+	if o, found := mk.Find("BG"); found {
+		if op := xRefTable.colorOperator(o, false); op != "" {
+			fmt.Fprintf(b, "%s 0 0 %.2f %.2f re f\n", op, width, height)
+		}
+	}
+
+	o, found := mk.Find("BC")
+	if !found {
+		return
+	}
+
+	op := xRefTable.colorOperator(o, true)
+	if op == "" {
+		return
+	}
+
+	bw := 1.0
+	if bs := w.DictEntry("BS"); bs != nil {
+		if o, found := bs.Find("W"); found {
+			bw = xRefTable.DereferenceNumber(o)
+		}
+	}
+
+	if bw > 0 {
+		fmt.Fprintf(b, "%s %.2f w %.2f %.2f %.2f %.2f re S\n", op, bw, bw/2, bw/2, width-bw, height-bw)
+	}
+}
+
+// generateAppearance creates the normal appearance streams for the widgets of a text field, combo box or list box
+// using the default appearance string, the quadding and the flags of the field.
+func (xRefTable *XRefTable) generateAppearance(ac *appearanceContext, f *formField, values []string) error {
+
+	o, err := xRefTable.inheritedFieldEntry(f.d, "Q")
+	if err != nil {
+		return err
+	}
+	if o == nil {
+		o, _ = ac.form.Find("Q")
+	}
+	q, _ := o.(Integer)
+
+	maxLen := 0
+	if o, err = xRefTable.inheritedFieldEntry(f.d, "MaxLen"); err != nil {
+		return err
+	}
+	if i, ok := o.(Integer); ok {
+		maxLen = i.Value()
+	}
+
+	var options, displays []string
+	if f.Type == "ComboBox" || f.Type == "ListBox" {
+		if options, displays, err = xRefTable.choiceOptions(f.d); err != nil {
+			return err
+		}
+	}
+
+	for _, w := range f.widgets {
+
+		// The default appearance may be overridden per widget.
+		o, found := w.Find("DA")
+		if !found {
+			if o, err = xRefTable.inheritedFieldEntry(f.d, "DA"); err != nil {
+				return err
+			}
+			if o == nil {
+				o, _ = ac.form.Find("DA")
+			}
+		}
+
+		s, _ := xRefTable.propertyString(o)
+		da := parseDefaultAppearance(s)
+
+		fontName, font, err := xRefTable.fieldFont(ac, f.d, da.fontID)
+		if err != nil {
+			return err
+		}
+
+		a := w.ArrayEntry("Rect")
+		if a == nil || len(*a) != 4 {
+			continue
+		}
+		r := rect(xRefTable, *a)
+
+		l := textLayout{fontName: fontName, fontSize: da.fontSize, w: r.Width(), h: r.Height(), q: q.Value()}
+
+		var b bytes.Buffer
+
+		xRefTable.widgetDecoration(&b, w, l.w, l.h)
+
+		fmt.Fprintf(&b, "/Tx BMC\nq %.2f %.2f %.2f %.2f re W n\n", appearancePadding/2, appearancePadding/2, l.w-appearancePadding, l.h-appearancePadding)
+
+		if f.Type == "ListBox" {
+
+			if l.fontSize == 0 {
+				l.fontSize = appearanceFontSize
+			}
+
+			selected := make([]bool, len(options))
+			for i, opt := range options {
+				selected[i] = MemberOf(opt, values)
+			}
+
+			top := 0
+			if i := f.d.IntEntry("TI"); i != nil && *i > 0 && *i < len(options) {
+				top = *i
+			}
+
+			l.listContent(&b, da.color, displays, selected, top)
+
+		} else {
+
+			s := strings.Join(values, " ")
+
+			// Combo boxes show the display text of the selected option.
+			for i, opt := range options {
+				if len(values) == 1 && opt == values[0] {
+					s = displays[i]
+				}
+			}
+
+			if l.fontSize == 0 {
+				l.fontSize = appearanceFontSize
+				if !flagSet(f.ff, fieldMultiline) {
+					l.fontSize = math.Min(l.autoFontSize(s), appearanceFontSize)
+				}
+			}
+
+			fmt.Fprintf(&b, "BT\n%s /F0 %.2f Tf\n", da.color, l.fontSize)
+			l.textContent(&b, s, f.ff, maxLen)
+			b.WriteString("ET\n")
+		}
+
+		b.WriteString("Q\nEMC\n")
+
+		sd := &StreamDict{Dict: NewDict(), Content: b.Bytes()}
+		sd.InsertName("Type", "XObject")
+		sd.InsertName("Subtype", "Form")
+		sd.Insert("BBox", NewRectangle(0, 0, l.w, l.h))
+		sd.Insert("Resources", Dict(map[string]Object{"Font": Dict(map[string]Object{"F0": *font})}))
+
+		if err = encodeStream(sd); err != nil {
+			return err
+		}
+
+		indRef, err := xRefTable.IndRefForNewObject(*sd)
+		if err != nil {
+			return err
+		}
+
+		// Rollover and down appearances would show stale values.
+		w.Update("AP", Dict(map[string]Object{"N": *indRef}))
+	}
+
+	log.Debug.Printf("generateAppearance: %s\n", f.Name)
+
+	return nil
+}
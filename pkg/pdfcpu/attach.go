@@ -17,8 +17,7 @@ limitations under the License.
 package pdfcpu
 
 import (
-	"io/ioutil"
-	"os"
+	"io"
 	"path/filepath"
 
 	"github.com/hhrutter/pdfcpu/pkg/filter"
@@ -86,29 +85,28 @@ func decodedFileSpecStreamDict(xRefTable *XRefTable, fileName string, o Object)
 	return sd, nil
 }
 
-func extractAttachedFiles(ctx *Context, files StringSet) error {
+func extractAttachedFiles(ctx *Context, files StringSet, create FileCreator) error {
 
 	writeFile := func(xRefTable *XRefTable, fileName string, o Object) error {
 
-		path := ctx.Write.DirName + "/" + fileName
-
-		log.Debug.Printf("writeFile begin: %s\n", path)
+		log.Debug.Printf("writeFile begin: %s\n", fileName)
 
 		sd, err := decodedFileSpecStreamDict(xRefTable, fileName, o)
 		if err != nil {
 			return err
 		}
 
-		log.Info.Printf("writing %s\n", path)
+		log.Info.Printf("writing %s\n", fileName)
 
-		// TODO Refactor into returning only stream object numbers for files to be extracted.
-		// No writing to file in library!
-		err = ioutil.WriteFile(path, sd.Content, os.ModePerm)
+		err = WriteOutputFile(create, fileName, func(w io.Writer) error {
+			_, err := w.Write(sd.Content)
+			return err
+		})
 		if err != nil {
 			return err
 		}
 
-		log.Debug.Printf("writeFile end: %s \n", path)
+		log.Debug.Printf("writeFile end: %s \n", fileName)
 
 		return nil
 	}
@@ -275,9 +273,15 @@ func AttachList(xRefTable *XRefTable) (list []string, err error) {
 	return list, nil
 }
 
-// AttachExtract exports specified embedded files.
+// AttachExtract exports specified embedded files into ctx.Write.DirName.
+// If no files specified extract all embedded files.
+func AttachExtract(ctx *Context, files StringSet) error {
+	return AttachExtractTo(ctx, files, DirFileCreator(ctx.Write.DirName))
+}
+
+// AttachExtractTo exports specified embedded files into the files create returns for them.
 // If no files specified extract all embedded files.
-func AttachExtract(ctx *Context, files StringSet) (err error) {
+func AttachExtractTo(ctx *Context, files StringSet, create FileCreator) (err error) {
 
 	log.Debug.Println("Extract begin")
 
@@ -292,7 +296,7 @@ func AttachExtract(ctx *Context, files StringSet) (err error) {
 		return errors.Errorf("no attachments available.")
 	}
 
-	err = extractAttachedFiles(ctx, files)
+	err = extractAttachedFiles(ctx, files, create)
 	if err != nil {
 		return err
 	}
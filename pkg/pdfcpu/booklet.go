@@ -0,0 +1,298 @@
+/*
+Copyright 2018 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pdfcpu
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hhrutter/pdfcpu/pkg/log"
+	"github.com/hhrutter/pdfcpu/pkg/types"
+	"github.com/pkg/errors"
+)
+
+// A booklet is made of signatures, stacks of sheets folded in the middle and stitched along the fold.
+// Each sheet carries 2 pages on each side.
+// The pages are arranged for printing double-sided flipping on the short edge.
+
+// binding edge
+const (
+	bindingLeft = iota
+	bindingRight
+	bindingTop
+)
+
+// Booklet represents the command details for the command "Booklet".
+type Booklet struct {
+	paperSize string    // name of the paper size.
+	paperDim  types.Dim // paper dimensions in portrait orientation.
+	binding   int       // binding edge: left, right or top.
+	sheets    int       // sheets per signature, 0 for a single signature.
+	creep     float64   // shift towards the fold per sheet in points.
+	margin    float64   // space around each page in points.
+	border    bool      // draw a border line around each page.
+}
+
+func (b Booklet) String() string {
+	return fmt.Sprintf("booklet on %s, binding=%d, sheets=%d, creep=%.2f, margin=%.0f, border=%t",
+		b.paperSize, b.binding, b.sheets, b.creep, b.margin, b.border)
+}
+
+// DefaultBooklet returns the default configuration for a left bound booklet made of a single signature of A4 sheets.
+func DefaultBooklet() *Booklet {
+	return &Booklet{
+		paperSize: "A4",
+		paperDim:  paperSizes["A4"],
+	}
+}
+
+func parseBookletError() error {
+	return errors.New("Invalid booklet description string. Please consult pdfcpu help booklet!\n")
+}
+
+func parseBookletPaperSize(v string, b *Booklet) error {
+
+	dim, orientation, err := parsePaperSize(v)
+	if err != nil {
+		return err
+	}
+
+	if orientation != orientationAuto {
+		return errors.New("booklet: the paper orientation follows from the binding edge")
+	}
+
+	b.paperSize, b.paperDim = v, dim
+
+	return nil
+}
+
+func parseBookletBinding(v string, b *Booklet) error {
+
+	switch v {
+	case "left":
+		b.binding = bindingLeft
+	case "right":
+		b.binding = bindingRight
+	case "top":
+		b.binding = bindingTop
+	default:
+		return errors.New("Valid bindings: left, right, top")
+	}
+
+	return nil
+}
+
+func parseBookletSheets(v string, b *Booklet) error {
+
+	i, err := strconv.Atoi(v)
+	if err != nil || i < 0 {
+		return errors.New("sheets per signature must be a positive integer")
+	}
+
+	b.sheets = i
+
+	return nil
+}
+
+func parseBookletCreep(v string, b *Booklet) error {
+
+	c, err := strconv.ParseFloat(v, 64)
+	if err != nil || c < 0 {
+		return errors.New("creep must be a positive number of points")
+	}
+
+	b.creep = c
+
+	return nil
+}
+
+// ParseBookletDetails parses a Booklet command string into an internal structure.
+func ParseBookletDetails(s string) (*Booklet, error) {
+
+	b := DefaultBooklet()
+
+	if s == "" {
+		return b, nil
+	}
+
+	for _, s := range strings.Split(s, ",") {
+
+		ss1 := strings.Split(s, ":")
+		if len(ss1) != 2 {
+			return nil, parseBookletError()
+		}
+
+		k := strings.TrimSpace(ss1[0])
+		v := strings.TrimSpace(ss1[1])
+
+		var err error
+
+		switch k {
+		case "f": // paper size
+			err = parseBookletPaperSize(v, b)
+
+		case "b": // binding edge
+			err = parseBookletBinding(v, b)
+
+		case "s": // sheets per signature
+			err = parseBookletSheets(v, b)
+
+		case "c": // creep
+			err = parseBookletCreep(v, b)
+
+		case "m": // margin
+			b.margin, err = parseMargin(v)
+
+		case "l": // border lines
+			b.border, err = parseBorder(v)
+
+		default:
+			err = parseBookletError()
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if b.sheets > 0 {
+		if err := b.validateCreep(b.sheets); err != nil {
+			return nil, err
+		}
+	}
+
+	return b, nil
+}
+
+// validateCreep ensures the cells of the innermost of sheets sheets stay clear of the fold.
+func (b *Booklet) validateCreep(sheets int) error {
+
+	if creep := float64(sheets-1) * b.creep; creep > b.margin {
+		return errors.Errorf("creep %.2f of the innermost sheet exceeds margin %.0f, increase the margin or use fewer sheets per signature", creep, b.margin)
+	}
+
+	return nil
+}
+
+// sheetDim returns the dimensions of a sheet: landscape for side binding, portrait for top binding.
+func (b *Booklet) sheetDim() types.Dim {
+
+	if b.binding == bindingTop {
+		return b.paperDim
+	}
+
+	return types.Dim{Width: b.paperDim.Height, Height: b.paperDim.Width}
+}
+
+// cells returns the regions for the 2 pages on a sheet side shifted by creep towards the fold.
+// For side binding the cells are ordered left, right, for top binding top, bottom.
+func (b *Booklet) cells(creep float64) []types.Rectangle {
+
+	dim := b.sheetDim()
+	m := b.margin
+
+	if b.binding == bindingTop {
+		h := dim.Height / 2
+		return []types.Rectangle{
+			types.NewRectangle(m, h+m-creep, dim.Width-m, dim.Height-m-creep),
+			types.NewRectangle(m, m+creep, dim.Width-m, h-m+creep),
+		}
+	}
+
+	w := dim.Width / 2
+	return []types.Rectangle{
+		types.NewRectangle(m+creep, m, w-m+creep, dim.Height-m),
+		types.NewRectangle(w+m-creep, m, dim.Width-m-creep, dim.Height-m),
+	}
+}
+
+// signatureSheets returns the sheet sides of a signature holding pageNrs, a multiple of 4 pages.
+func (b *Booklet) signatureSheets(pageNrs []int) []sheet {
+
+	var sheets []sheet
+
+	n := len(pageNrs)
+
+	// Sheet k counts from the outside, its pages are 1-based within the signature.
+	page := func(i int) int { return pageNrs[i-1] }
+
+	for k := 0; k < n/4; k++ {
+
+		cells := b.cells(float64(k) * b.creep)
+
+		// The outer side of the sheet holds the pages 1+2k and n-2k.
+		front := []int{page(n - 2*k), page(1 + 2*k)}
+
+		// The inner side holds the pages 2+2k and n-1-2k.
+		back := []int{page(2 + 2*k), page(n - 1 - 2*k)}
+
+		if b.binding == bindingRight {
+			front[0], front[1] = front[1], front[0]
+			back[0], back[1] = back[1], back[0]
+		}
+
+		sheets = append(sheets, sheet{pageNrs: front, cells: cells}, sheet{pageNrs: back, cells: cells})
+	}
+
+	return sheets
+}
+
+// BookletPages replaces the page tree by the sheet sides of a booklet made of the selected pages.
+func BookletPages(xRefTable *XRefTable, selectedPages IntSet, b *Booklet) error {
+
+	log.Debug.Printf("BookletPages begin: %s\n", b)
+
+	pageNrs := selectedPageNumbers(selectedPages)
+
+	if len(pageNrs) == 0 {
+		return errors.New("BookletPages: no pages selected")
+	}
+
+	// Pad with blank pages to a multiple of 4.
+	for len(pageNrs)%4 > 0 {
+		pageNrs = append(pageNrs, 0)
+	}
+
+	pagesPerSignature := len(pageNrs)
+	if b.sheets > 0 && 4*b.sheets < pagesPerSignature {
+		pagesPerSignature = 4 * b.sheets
+	}
+
+	if err := b.validateCreep(pagesPerSignature / 4); err != nil {
+		return err
+	}
+
+	var sheets []sheet
+
+	for i := 0; i < len(pageNrs); i += pagesPerSignature {
+		j := i + pagesPerSignature
+		if j > len(pageNrs) {
+			j = len(pageNrs)
+		}
+		sheets = append(sheets, b.signatureSheets(pageNrs[i:j])...)
+	}
+
+	err := replacePageTree(xRefTable, sheets, b.sheetDim(), b.border)
+	if err != nil {
+		return err
+	}
+
+	log.Debug.Println("BookletPages end")
+
+	return nil
+}
@@ -0,0 +1,843 @@
+/*
+Copyright 2018 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pdfcpu
+
+import (
+	"fmt"
+
+	"github.com/hhrutter/pdfcpu/pkg/log"
+	"github.com/pkg/errors"
+)
+
+// Bookmark represents an outline item.
+type Bookmark struct {
+	Title  string     `json:"title"`
+	PageNr int        `json:"page,omitempty"`  // 0 if the outline item does not lead to a page of this document.
+	Zoom   float64    `json:"zoom,omitempty"`  // 0 for fitting the page into the window.
+	Color  []float64  `json:"color,omitempty"` // RGB components in the range 0..1
+	Bold   bool       `json:"bold,omitempty"`
+	Italic bool       `json:"italic,omitempty"`
+	Open   bool       `json:"open,omitempty"` // true if the kids are visible.
+	Kids   []Bookmark `json:"kids,omitempty"`
+}
+
+// outline item flags
+const (
+	outlineItemItalic = 1
+	outlineItemBold   = 2
+)
+
+// collectPageNumbers records the page number for each page dict object number of the page tree rooted at indRef.
+func (xRefTable *XRefTable) collectPageNumbers(indRef IndirectRef, p *int, m map[int]int) error {
+
+	dict, err := xRefTable.DereferenceDict(indRef)
+	if err != nil {
+		return err
+	}
+
+	if dict == nil || dict.Type() == nil {
+		return errors.Errorf("collectPageNumbers: corrupt page tree node: obj#%d", indRef.ObjectNumber)
+	}
+
+	if *dict.Type() == "Page" {
+		*p++
+		m[indRef.ObjectNumber.Value()] = *p
+		return nil
+	}
+
+	kids := dict.ArrayEntry("Kids")
+	if kids == nil {
+		return errors.Errorf("collectPageNumbers: corrupt page tree node: obj#%d", indRef.ObjectNumber)
+	}
+
+	for _, o := range *kids {
+
+		ir, ok := o.(IndirectRef)
+		if !ok {
+			continue
+		}
+
+		err = xRefTable.collectPageNumbers(ir, p, m)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// pageNumbers returns the page numbers of all pages keyed by the object number of their page dict.
+func (xRefTable *XRefTable) pageNumbers() (map[int]int, error) {
+
+	root, err := xRefTable.Pages()
+	if err != nil {
+		return nil, err
+	}
+
+	m := map[int]int{}
+	p := 0
+
+	err = xRefTable.collectPageNumbers(*root, &p, m)
+	if err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// namedDestination returns the destination for a name or string used as a named destination.
+func (xRefTable *XRefTable) namedDestination(o Object) (Object, error) {
+
+	switch o := o.(type) {
+
+	case Name:
+		// PDF 1.1 named destinations live in the root dict.
+		rootDict, err := xRefTable.Catalog()
+		if err != nil {
+			return nil, err
+		}
+		d, found := rootDict.Find("Dests")
+		if !found {
+			return nil, nil
+		}
+		destsDict, err := xRefTable.DereferenceDict(d)
+		if err != nil || destsDict == nil {
+			return nil, err
+		}
+		dest, _ := destsDict.Find(o.Value())
+		return dest, nil
+
+	case StringLiteral:
+		return xRefTable.destinationForKey(o.Value()), nil
+
+	case HexLiteral:
+		return xRefTable.destinationForKey(o.Value()), nil
+
+	}
+
+	return nil, nil
+}
+
+func (xRefTable *XRefTable) destinationForKey(k string) Object {
+
+	tree := xRefTable.Names["Dests"]
+	if tree == nil {
+		return nil
+	}
+
+	dest, _ := tree.Value(k)
+
+	return dest
+}
+
+// explicitDestination resolves dest into an explicit destination array.
+func (xRefTable *XRefTable) explicitDestination(dest Object) (Array, error) {
+
+	dest, err := xRefTable.Dereference(dest)
+	if err != nil || dest == nil {
+		return nil, err
+	}
+
+	switch d := dest.(type) {
+
+	case Array:
+		return d, nil
+
+	case Dict:
+		// A named destination may also be a dict holding the destination in "D".
+		o, _ := d.Find("D")
+		return xRefTable.explicitDestination(o)
+
+	}
+
+	named, err := xRefTable.namedDestination(dest)
+	if err != nil || named == nil {
+		return nil, err
+	}
+
+	return xRefTable.explicitDestination(named)
+}
+
+// outlineItemDestination returns the explicit destination of an outline item or nil.
+func (xRefTable *XRefTable) outlineItemDestination(dict *Dict) (Array, error) {
+
+	if dest, found := dict.Find("Dest"); found {
+		return xRefTable.explicitDestination(dest)
+	}
+
+	o, found := dict.Find("A")
+	if !found {
+		return nil, nil
+	}
+
+	actionDict, err := xRefTable.DereferenceDict(o)
+	if err != nil || actionDict == nil {
+		return nil, err
+	}
+
+	if s := actionDict.NameEntry("S"); s == nil || *s != "GoTo" {
+		return nil, nil
+	}
+
+	dest, _ := actionDict.Find("D")
+
+	return xRefTable.explicitDestination(dest)
+}
+
+// bookmark returns the bookmark for an outline item dict without its kids.
+func (xRefTable *XRefTable) bookmark(dict *Dict, pageNrs map[int]int) (*Bookmark, error) {
+
+	o, _ := dict.Find("Title")
+	title, err := xRefTable.DereferenceText(o)
+	if err != nil {
+		return nil, err
+	}
+
+	bm := &Bookmark{Title: title}
+
+	dest, err := xRefTable.outlineItemDestination(dict)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(dest) > 0 {
+
+		// Destinations into other documents start with an integer page number instead.
+		if indRef, ok := dest[0].(IndirectRef); ok {
+			bm.PageNr = pageNrs[indRef.ObjectNumber.Value()]
+		}
+
+		// [page /XYZ left top zoom]
+		if len(dest) == 5 {
+			if n, ok := dest[1].(Name); ok && n.Value() == "XYZ" {
+				bm.Zoom = xRefTable.DereferenceNumber(dest[4])
+			}
+		}
+	}
+
+	if o, found := dict.Find("C"); found {
+		a, err := xRefTable.DereferenceArray(o)
+		if err != nil {
+			return nil, err
+		}
+		if a != nil && len(*a) == 3 {
+			for _, o := range *a {
+				bm.Color = append(bm.Color, xRefTable.DereferenceNumber(o))
+			}
+		}
+	}
+
+	if o, found := dict.Find("F"); found {
+		f, err := xRefTable.DereferenceInteger(o)
+		if err != nil {
+			return nil, err
+		}
+		if f != nil {
+			bm.Italic = f.Value()&outlineItemItalic > 0
+			bm.Bold = f.Value()&outlineItemBold > 0
+		}
+	}
+
+	// A positive count means the outline item is open.
+	if o, found := dict.Find("Count"); found {
+		c, err := xRefTable.DereferenceInteger(o)
+		if err != nil {
+			return nil, err
+		}
+		bm.Open = c != nil && c.Value() > 0
+	}
+
+	return bm, nil
+}
+
+// outlineItems returns the bookmarks for the list of outline items starting at first.
+// Kids get included if deep is true.
+func (xRefTable *XRefTable) outlineItems(first *IndirectRef, pageNrs map[int]int, visited IntSet, deep bool) ([]Bookmark, error) {
+
+	var (
+		bms  []Bookmark
+		dict *Dict
+		err  error
+	)
+
+	for indRef := first; indRef != nil; indRef = dict.IndirectRefEntry("Next") {
+
+		if visited[indRef.ObjectNumber.Value()] {
+			return nil, errors.Errorf("outlineItems: circular outline item list at obj#%d", indRef.ObjectNumber)
+		}
+		visited[indRef.ObjectNumber.Value()] = true
+
+		dict, err = xRefTable.DereferenceDict(*indRef)
+		if err != nil {
+			return nil, err
+		}
+
+		if dict == nil {
+			return nil, errors.Errorf("outlineItems: corrupt outline item obj#%d", indRef.ObjectNumber)
+		}
+
+		bm, err := xRefTable.bookmark(dict, pageNrs)
+		if err != nil {
+			return nil, err
+		}
+
+		log.Debug.Printf("outlineItems: %s -> page %d\n", bm.Title, bm.PageNr)
+
+		if first := dict.IndirectRefEntry("First"); deep && first != nil {
+			if bm.Kids, err = xRefTable.outlineItems(first, pageNrs, visited, deep); err != nil {
+				return nil, err
+			}
+		}
+
+		bms = append(bms, *bm)
+	}
+
+	return bms, nil
+}
+
+// outline returns the bookmarks of the document outline.
+// Kids get included if deep is true.
+func (xRefTable *XRefTable) outline(deep bool) ([]Bookmark, error) {
+
+	rootDict, err := xRefTable.Catalog()
+	if err != nil {
+		return nil, err
+	}
+
+	o, found := rootDict.Find("Outlines")
+	if !found {
+		return nil, nil
+	}
+
+	outlinesDict, err := xRefTable.DereferenceDict(o)
+	if err != nil || outlinesDict == nil {
+		return nil, err
+	}
+
+	pageNrs, err := xRefTable.pageNumbers()
+	if err != nil {
+		return nil, err
+	}
+
+	return xRefTable.outlineItems(outlinesDict.IndirectRefEntry("First"), pageNrs, IntSet{}, deep)
+}
+
+// TopLevelBookmarks returns the top level items of the document outline.
+func TopLevelBookmarks(xRefTable *XRefTable) ([]Bookmark, error) {
+
+	log.Debug.Println("TopLevelBookmarks begin")
+
+	bms, err := xRefTable.outline(false)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Debug.Println("TopLevelBookmarks end")
+
+	return bms, nil
+}
+
+// Bookmarks returns the document outline.
+func Bookmarks(xRefTable *XRefTable) ([]Bookmark, error) {
+
+	log.Debug.Println("Bookmarks begin")
+
+	bms, err := xRefTable.outline(true)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Debug.Println("Bookmarks end")
+
+	return bms, nil
+}
+
+// documentTitle returns the title of the document info dict.
+func (xRefTable *XRefTable) documentTitle() (string, error) {
+
+	if xRefTable.Info == nil {
+		return "", nil
+	}
+
+	dict, err := xRefTable.DereferenceDict(*xRefTable.Info)
+	if err != nil || dict == nil {
+		return "", err
+	}
+
+	o, found := dict.Find("Title")
+	if !found {
+		return "", nil
+	}
+
+	return xRefTable.DereferenceText(o)
+}
+
+// AddDocumentBookmark makes a single top level outline item leading to the first page the parent of the existing outline.
+// The item is titled by the document title falling back to title.
+func AddDocumentBookmark(xRefTable *XRefTable, title string) error {
+
+	log.Debug.Println("AddDocumentBookmark begin")
+
+	rootDict, err := xRefTable.Catalog()
+	if err != nil {
+		return err
+	}
+
+	s, err := xRefTable.documentTitle()
+	if err != nil {
+		return err
+	}
+
+	if s != "" {
+		title = s
+	}
+
+	t, err := textObject(title)
+	if err != nil {
+		return err
+	}
+
+	pageIndRef, _, err := xRefTable.PageIndRef(1)
+	if err != nil {
+		return err
+	}
+
+	if pageIndRef == nil {
+		return errors.New("AddDocumentBookmark: missing first page")
+	}
+
+	var outlinesDict *Dict
+
+	indRef := rootDict.IndirectRefEntry("Outlines")
+
+	if indRef == nil {
+		d := Dict{"Type": Name("Outlines")}
+		if indRef, err = xRefTable.IndRefForNewObject(d); err != nil {
+			return err
+		}
+		rootDict.Update("Outlines", *indRef)
+		outlinesDict = &d
+	} else {
+		if outlinesDict, err = xRefTable.DereferenceDict(*indRef); err != nil {
+			return err
+		}
+		if outlinesDict == nil {
+			return errors.New("AddDocumentBookmark: corrupt outline dict")
+		}
+	}
+
+	item := Dict{
+		"Title":  t,
+		"Parent": *indRef,
+		"Dest":   Array{*pageIndRef, Name("Fit")},
+	}
+
+	itemIndRef, err := xRefTable.IndRefForNewObject(item)
+	if err != nil {
+		return err
+	}
+
+	first := outlinesDict.IndirectRefEntry("First")
+	last := outlinesDict.IndirectRefEntry("Last")
+
+	if first != nil && last != nil {
+
+		var dict *Dict
+		visited := IntSet{}
+		n := 0
+
+		for ir := first; ir != nil; ir = dict.IndirectRefEntry("Next") {
+
+			if visited[ir.ObjectNumber.Value()] {
+				return errors.Errorf("AddDocumentBookmark: circular outline item list at obj#%d", ir.ObjectNumber)
+			}
+			visited[ir.ObjectNumber.Value()] = true
+
+			if dict, err = xRefTable.DereferenceDict(*ir); err != nil {
+				return err
+			}
+
+			if dict == nil {
+				return errors.Errorf("AddDocumentBookmark: corrupt outline item obj#%d", ir.ObjectNumber)
+			}
+
+			dict.Update("Parent", *itemIndRef)
+			n++
+		}
+
+		// Count holds the number of visible outline items.
+		if c := outlinesDict.IntEntry("Count"); c != nil && *c > n {
+			n = *c
+		}
+
+		// The former outline hides below the closed document item.
+		item.Insert("First", *first)
+		item.Insert("Last", *last)
+		item.Insert("Count", Integer(-n))
+	}
+
+	outlinesDict.Update("First", *itemIndRef)
+	outlinesDict.Update("Last", *itemIndRef)
+	outlinesDict.Update("Count", Integer(1))
+
+	log.Debug.Println("AddDocumentBookmark end")
+
+	return nil
+}
+
+func bookmarkLines(list []string, bms []Bookmark, indent string) []string {
+
+	for _, bm := range bms {
+
+		s := indent + bm.Title
+		if bm.PageNr > 0 {
+			s += fmt.Sprintf(" (page %d)", bm.PageNr)
+		}
+
+		list = append(list, s)
+		list = bookmarkLines(list, bm.Kids, indent+"  ")
+	}
+
+	return list
+}
+
+// ListBookmarks returns the document outline as an indented list of titles and page numbers.
+func ListBookmarks(xRefTable *XRefTable) ([]string, error) {
+
+	bms, err := Bookmarks(xRefTable)
+	if err != nil {
+		return nil, err
+	}
+
+	return bookmarkLines(nil, bms, ""), nil
+}
+
+// visibleCount returns the number of visible outline items of bms at all levels.
+func visibleCount(bms []Bookmark) int {
+
+	c := len(bms)
+
+	for _, bm := range bms {
+		if bm.Open {
+			c += visibleCount(bm.Kids)
+		}
+	}
+
+	return c
+}
+
+// outlineItemDict creates the outline item dict for bm without any links to other outline items.
+func (xRefTable *XRefTable) outlineItemDict(bm Bookmark, parent IndirectRef) (Dict, error) {
+
+	if bm.Title == "" {
+		return nil, errors.New("outlineItemDict: missing bookmark title")
+	}
+
+	t, err := textObject(bm.Title)
+	if err != nil {
+		return nil, err
+	}
+
+	d := Dict{"Title": t, "Parent": parent}
+
+	if bm.PageNr < 0 || bm.PageNr > xRefTable.PageCount {
+		return nil, errors.Errorf("outlineItemDict: bookmark %q: page %d out of range", bm.Title, bm.PageNr)
+	}
+
+	if bm.PageNr > 0 {
+
+		pageIndRef, _, err := xRefTable.PageIndRef(bm.PageNr)
+		if err != nil {
+			return nil, err
+		}
+
+		if pageIndRef == nil {
+			return nil, errors.Errorf("outlineItemDict: missing page %d", bm.PageNr)
+		}
+
+		dest := Array{*pageIndRef, Name("Fit")}
+		if bm.Zoom > 0 {
+			dest = Array{*pageIndRef, Name("XYZ"), nil, nil, Float(bm.Zoom)}
+		}
+
+		d.Insert("Dest", dest)
+	}
+
+	if bm.Color != nil {
+
+		if len(bm.Color) != 3 {
+			return nil, errors.Errorf("outlineItemDict: bookmark %q: color needs 3 components", bm.Title)
+		}
+
+		c := Array{}
+		for _, f := range bm.Color {
+			if f < 0 || f > 1 {
+				return nil, errors.Errorf("outlineItemDict: bookmark %q: color components need to be in the range 0..1", bm.Title)
+			}
+			c = append(c, Float(f))
+		}
+
+		d.Insert("C", c)
+	}
+
+	f := 0
+	if bm.Italic {
+		f |= outlineItemItalic
+	}
+	if bm.Bold {
+		f |= outlineItemBold
+	}
+	if f > 0 {
+		d.Insert("F", Integer(f))
+	}
+
+	return d, nil
+}
+
+// createOutlineItems creates a linked list of outline items for bms as kids of parent.
+func (xRefTable *XRefTable) createOutlineItems(parent IndirectRef, bms []Bookmark) (first, last *IndirectRef, err error) {
+
+	var prev Dict
+
+	for _, bm := range bms {
+
+		d, err := xRefTable.outlineItemDict(bm, parent)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		indRef, err := xRefTable.IndRefForNewObject(d)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if len(bm.Kids) > 0 {
+
+			f, l, err := xRefTable.createOutlineItems(*indRef, bm.Kids)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			// A negative count hides the kids of a closed outline item.
+			c := visibleCount(bm.Kids)
+			if !bm.Open {
+				c = -c
+			}
+
+			d.Insert("First", *f)
+			d.Insert("Last", *l)
+			d.Insert("Count", Integer(c))
+		}
+
+		if prev != nil {
+			prev.Insert("Next", *indRef)
+			d.Insert("Prev", *last)
+		}
+
+		if first == nil {
+			first = indRef
+		}
+
+		prev, last = d, indRef
+	}
+
+	return first, last, nil
+}
+
+// ReplaceBookmarks replaces the document outline by bms.
+func ReplaceBookmarks(xRefTable *XRefTable, bms []Bookmark) error {
+
+	log.Debug.Println("ReplaceBookmarks begin")
+
+	rootDict, err := xRefTable.Catalog()
+	if err != nil {
+		return err
+	}
+
+	rootDict.Delete("Outlines")
+
+	if len(bms) == 0 {
+		return nil
+	}
+
+	outlinesDict := Dict{"Type": Name("Outlines")}
+
+	indRef, err := xRefTable.IndRefForNewObject(outlinesDict)
+	if err != nil {
+		return err
+	}
+
+	first, last, err := xRefTable.createOutlineItems(*indRef, bms)
+	if err != nil {
+		return err
+	}
+
+	outlinesDict.Insert("First", *first)
+	outlinesDict.Insert("Last", *last)
+	outlinesDict.Insert("Count", Integer(visibleCount(bms)))
+
+	rootDict.Insert("Outlines", *indRef)
+
+	log.Debug.Println("ReplaceBookmarks end")
+
+	return nil
+}
+
+// RemoveBookmarks removes the document outline.
+func RemoveBookmarks(xRefTable *XRefTable) error {
+
+	log.Debug.Println("RemoveBookmarks begin")
+
+	rootDict, err := xRefTable.Catalog()
+	if err != nil {
+		return err
+	}
+
+	rootDict.Delete("Outlines")
+
+	// There is no outline to show on opening the document anymore.
+	if pm := rootDict.NameEntry("PageMode"); pm != nil && *pm == "UseOutlines" {
+		rootDict.Delete("PageMode")
+	}
+
+	log.Debug.Println("RemoveBookmarks end")
+
+	return nil
+}
+
+// pruneOutlineItems unlinks the outline items leading to removed pages from the kids of parent
+// and returns the number of visible descendants of parent.
+// Outline items with kids stay and lose their destination instead.
+func (xRefTable *XRefTable) pruneOutlineItems(parent *Dict, removed, visited IntSet) (int, error) {
+
+	var (
+		first, last *IndirectRef
+		prev        *Dict
+	)
+
+	c := 0
+
+	for indRef := parent.IndirectRefEntry("First"); indRef != nil; {
+
+		if visited[indRef.ObjectNumber.Value()] {
+			return 0, errors.Errorf("pruneOutlineItems: circular outline item list at obj#%d", indRef.ObjectNumber)
+		}
+		visited[indRef.ObjectNumber.Value()] = true
+
+		dict, err := xRefTable.DereferenceDict(*indRef)
+		if err != nil {
+			return 0, err
+		}
+
+		if dict == nil {
+			return 0, errors.Errorf("pruneOutlineItems: corrupt outline item obj#%d", indRef.ObjectNumber)
+		}
+
+		next := dict.IndirectRefEntry("Next")
+
+		kidCount, err := xRefTable.pruneOutlineItems(dict, removed, visited)
+		if err != nil {
+			return 0, err
+		}
+
+		dest, err := xRefTable.outlineItemDestination(dict)
+		if err != nil {
+			return 0, err
+		}
+
+		if removedPageDestination(dest, removed) {
+			if dict.IndirectRefEntry("First") == nil {
+				log.Debug.Printf("pruneOutlineItems: removing obj#%d\n", indRef.ObjectNumber)
+				indRef = next
+				continue
+			}
+			dict.Delete("Dest")
+			dict.Delete("A")
+		}
+
+		dict.Delete("Prev")
+		dict.Delete("Next")
+
+		if prev != nil {
+			prev.Insert("Next", *indRef)
+			dict.Insert("Prev", *last)
+		}
+
+		if first == nil {
+			first = indRef
+		}
+
+		prev, last = dict, indRef
+		c++
+
+		if dict.IndirectRefEntry("First") != nil {
+			// A negative count hides the kids of a closed outline item.
+			if count := dict.IntEntry("Count"); count != nil && *count > 0 {
+				dict.Update("Count", Integer(kidCount))
+				c += kidCount
+			} else {
+				dict.Update("Count", Integer(-kidCount))
+			}
+		}
+
+		indRef = next
+	}
+
+	if first == nil {
+		parent.Delete("First")
+		parent.Delete("Last")
+		parent.Delete("Count")
+		return 0, nil
+	}
+
+	parent.Update("First", *first)
+	parent.Update("Last", *last)
+
+	return c, nil
+}
+
+// pruneOutline removes the outline items leading to removed pages.
+func (xRefTable *XRefTable) pruneOutline(removed IntSet) error {
+
+	rootDict, err := xRefTable.Catalog()
+	if err != nil {
+		return err
+	}
+
+	o, found := rootDict.Find("Outlines")
+	if !found {
+		return nil
+	}
+
+	outlinesDict, err := xRefTable.DereferenceDict(o)
+	if err != nil || outlinesDict == nil {
+		return err
+	}
+
+	c, err := xRefTable.pruneOutlineItems(outlinesDict, removed, IntSet{})
+	if err != nil {
+		return err
+	}
+
+	if outlinesDict.IndirectRefEntry("First") == nil {
+		return RemoveBookmarks(xRefTable)
+	}
+
+	outlinesDict.Update("Count", Integer(c))
+
+	return nil
+}
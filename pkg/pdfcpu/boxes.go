@@ -0,0 +1,476 @@
+/*
+Copyright 2018 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pdfcpu
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/hhrutter/pdfcpu/pkg/log"
+	"github.com/hhrutter/pdfcpu/pkg/types"
+	"github.com/pkg/errors"
+)
+
+// Page boundaries in the order they depend on each other.
+// The crop box defaults to the media box, all other boxes default to the crop box.
+var boxNames = []string{"MediaBox", "CropBox", "BleedBox", "TrimBox", "ArtBox"}
+
+var boxKeys = map[string]string{
+	"media": "MediaBox",
+	"crop":  "CropBox",
+	"bleed": "BleedBox",
+	"trim":  "TrimBox",
+	"art":   "ArtBox",
+}
+
+// Units for margins.
+var units = map[string]float64{
+	"pt": 1,
+	"mm": 72 / 25.4,
+	"in": 72,
+}
+
+// boxSpec represents a page boundary given as a rectangle in user space
+// or as margins relative to its default box.
+type boxSpec struct {
+	rect    *types.Rectangle
+	margins [4]float64 // top, right, bottom, left as displayed taking page rotation into account.
+}
+
+func (pb boxSpec) String() string {
+	if pb.rect != nil {
+		return fmt.Sprintf("[%.2f %.2f %.2f %.2f]", pb.rect.LL.X, pb.rect.LL.Y, pb.rect.UR.X, pb.rect.UR.Y)
+	}
+	return fmt.Sprintf("margins %.2f %.2f %.2f %.2f", pb.margins[0], pb.margins[1], pb.margins[2], pb.margins[3])
+}
+
+// PageBoxes represents the command details for the commands "AddBoxes" and "RemoveBoxes".
+type PageBoxes struct {
+	boxes map[string]*boxSpec // keyed by box name, nil values for "RemoveBoxes".
+}
+
+func (pbs PageBoxes) String() string {
+
+	var ss []string
+
+	for _, k := range boxNames {
+		pb, found := pbs.boxes[k]
+		if !found {
+			continue
+		}
+		if pb == nil {
+			ss = append(ss, k)
+			continue
+		}
+		ss = append(ss, fmt.Sprintf("%s: %s", k, pb))
+	}
+
+	return strings.Join(ss, ", ")
+}
+
+func parseBoxName(s string) (string, error) {
+
+	k, ok := boxKeys[strings.ToLower(strings.TrimSpace(s))]
+	if !ok {
+		return "", errors.Errorf("Valid boxes: media, crop, bleed, trim, art: %s", s)
+	}
+
+	return k, nil
+}
+
+// parseLength returns the length in points for s, optionally followed by one of the units pt, mm, in.
+func parseLength(s string) (float64, error) {
+
+	f := 1.0
+
+	for u, v := range units {
+		if strings.HasSuffix(s, u) {
+			s, f = strings.TrimSpace(strings.TrimSuffix(s, u)), v
+			break
+		}
+	}
+
+	l, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, errors.Errorf("invalid length: %s", s)
+	}
+
+	return l * f, nil
+}
+
+// parseBoxRect parses a rectangle in user space given as [llx lly urx ury].
+func parseBoxRect(s string) (*boxSpec, error) {
+
+	ss := strings.Fields(strings.TrimSuffix(strings.TrimPrefix(s, "["), "]"))
+	if len(ss) != 4 {
+		return nil, errors.Errorf("a box rectangle needs 4 coordinates: %s", s)
+	}
+
+	var c [4]float64
+
+	for i, s := range ss {
+		f, err := parseLength(s)
+		if err != nil {
+			return nil, err
+		}
+		c[i] = f
+	}
+
+	r := types.NewRectangle(math.Min(c[0], c[2]), math.Min(c[1], c[3]), math.Max(c[0], c[2]), math.Max(c[1], c[3]))
+
+	if r.Width() == 0 || r.Height() == 0 {
+		return nil, errors.Errorf("empty box rectangle: %s", s)
+	}
+
+	return &boxSpec{rect: &r}, nil
+}
+
+// parseBoxMargins parses 1, 2 or 4 margins with the usual meaning of top, right, bottom, left.
+func parseBoxMargins(s string) (*boxSpec, error) {
+
+	ss := strings.Fields(s)
+
+	var m []float64
+
+	for _, s := range ss {
+		f, err := parseLength(s)
+		if err != nil {
+			return nil, err
+		}
+		m = append(m, f)
+	}
+
+	pb := &boxSpec{}
+
+	switch len(m) {
+	case 1:
+		pb.margins = [4]float64{m[0], m[0], m[0], m[0]}
+	case 2:
+		pb.margins = [4]float64{m[0], m[1], m[0], m[1]}
+	case 4:
+		pb.margins = [4]float64{m[0], m[1], m[2], m[3]}
+	default:
+		return nil, errors.Errorf("margins need 1, 2 or 4 values: %s", s)
+	}
+
+	return pb, nil
+}
+
+// ParseBoxes parses a comma separated list of box descriptions like "trim: 10mm, bleed: [0 0 595 842]".
+func ParseBoxes(s string) (*PageBoxes, error) {
+
+	pbs := &PageBoxes{boxes: map[string]*boxSpec{}}
+
+	for _, s := range strings.Split(s, ",") {
+
+		ss := strings.SplitN(s, ":", 2)
+		if len(ss) != 2 {
+			return nil, errors.New("Invalid box description string. Please consult pdfcpu help boxes!\n")
+		}
+
+		k, err := parseBoxName(ss[0])
+		if err != nil {
+			return nil, err
+		}
+
+		v := strings.TrimSpace(ss[1])
+
+		var pb *boxSpec
+		if strings.HasPrefix(v, "[") {
+			pb, err = parseBoxRect(v)
+		} else {
+			pb, err = parseBoxMargins(v)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		pbs.boxes[k] = pb
+	}
+
+	return pbs, nil
+}
+
+// ParseBoxNames parses a comma separated list of box names like "crop, trim".
+func ParseBoxNames(s string) (*PageBoxes, error) {
+
+	pbs := &PageBoxes{boxes: map[string]*boxSpec{}}
+
+	for _, s := range strings.Split(s, ",") {
+
+		k, err := parseBoxName(s)
+		if err != nil {
+			return nil, err
+		}
+
+		pbs.boxes[k] = nil
+	}
+
+	return pbs, nil
+}
+
+// effectiveBoxes returns the page dict of page pageNr along with the boxes in effect and its rotation.
+// origin records the boxes inherited or defaulted.
+func effectiveBoxes(xRefTable *XRefTable, pageNr int) (*Dict, map[string]types.Rectangle, map[string]string, int, error) {
+
+	d, inhPAttrs, err := xRefTable.PageDict(pageNr)
+	if err != nil {
+		return nil, nil, nil, 0, err
+	}
+
+	if d == nil {
+		return nil, nil, nil, 0, errors.Errorf("effectiveBoxes: unknown page %d", pageNr)
+	}
+
+	if inhPAttrs.mediaBox == nil {
+		return nil, nil, nil, 0, errors.Errorf("effectiveBoxes: missing media box for page %d", pageNr)
+	}
+
+	boxes := map[string]types.Rectangle{}
+	origin := map[string]string{}
+
+	r, err := boxRect(xRefTable, inhPAttrs.mediaBox)
+	if err != nil {
+		return nil, nil, nil, 0, err
+	}
+
+	boxes["MediaBox"] = *r
+	if _, found := d.Find("MediaBox"); !found {
+		origin["MediaBox"] = "inherited"
+	}
+
+	boxes["CropBox"] = boxes["MediaBox"]
+	origin["CropBox"] = "default"
+
+	if r, err = boxRect(xRefTable, inhPAttrs.cropBox); err != nil {
+		return nil, nil, nil, 0, err
+	}
+
+	if r != nil {
+		boxes["CropBox"] = *r
+		delete(origin, "CropBox")
+		if _, found := d.Find("CropBox"); !found {
+			origin["CropBox"] = "inherited"
+		}
+	}
+
+	// Bleed, trim and art box are not inheritable.
+	for _, k := range boxNames[2:] {
+
+		if r, err = pageBox(xRefTable, d, k); err != nil {
+			return nil, nil, nil, 0, err
+		}
+
+		if r == nil {
+			boxes[k] = boxes["CropBox"]
+			origin[k] = "default"
+			continue
+		}
+
+		boxes[k] = *r
+	}
+
+	return d, boxes, origin, normalizedRotation(int(inhPAttrs.rotate)), nil
+}
+
+// defaultBox returns the name of the box k defaults to.
+func defaultBox(k string) string {
+
+	switch k {
+	case "MediaBox":
+		return "MediaBox"
+	case "CropBox":
+		return "MediaBox"
+	}
+
+	return "CropBox"
+}
+
+// applyMargins insets r by margins given as displayed for a page rotated by rot degrees clockwise.
+// Negative margins enlarge r.
+func applyMargins(r types.Rectangle, margins [4]float64, rot int) types.Rectangle {
+
+	// Map the margins top, right, bottom, left as displayed into user space.
+	var m [4]float64
+	for i := range m {
+		m[i] = margins[(i+rot/90)%4]
+	}
+
+	return types.NewRectangle(r.LL.X+m[3], r.LL.Y+m[2], r.UR.X-m[1], r.UR.Y-m[0])
+}
+
+// intersection returns the intersection of r1 and r2, which is empty if they do not overlap.
+func intersection(r1, r2 types.Rectangle) types.Rectangle {
+	return types.NewRectangle(
+		math.Max(r1.LL.X, r2.LL.X), math.Max(r1.LL.Y, r2.LL.Y),
+		math.Min(r1.UR.X, r2.UR.X), math.Min(r1.UR.Y, r2.UR.Y))
+}
+
+// ListBoxes returns the page boundaries in effect for all selected pages.
+func ListBoxes(xRefTable *XRefTable, selectedPages IntSet) ([]string, error) {
+
+	log.Debug.Println("ListBoxes begin")
+
+	var list []string
+
+	for _, pageNr := range selectedPageNumbers(selectedPages) {
+
+		_, boxes, origin, rot, err := effectiveBoxes(xRefTable, pageNr)
+		if err != nil {
+			return nil, err
+		}
+
+		list = append(list, fmt.Sprintf("page %d: rotate=%d", pageNr, rot))
+
+		for _, k := range boxNames {
+			r := boxes[k]
+			s := fmt.Sprintf("  %-8s [%.2f %.2f %.2f %.2f] %.2f x %.2f pt", k, r.LL.X, r.LL.Y, r.UR.X, r.UR.Y, r.Width(), r.Height())
+			if o, ok := origin[k]; ok {
+				s += " (" + o + ")"
+			}
+			list = append(list, s)
+		}
+	}
+
+	log.Debug.Println("ListBoxes end")
+
+	return list, nil
+}
+
+// AddBoxes sets the page boundaries described by pbs for all selected pages.
+func AddBoxes(xRefTable *XRefTable, selectedPages IntSet, pbs *PageBoxes) error {
+
+	log.Debug.Printf("AddBoxes begin: %s\n", pbs)
+
+	for _, pageNr := range selectedPageNumbers(selectedPages) {
+
+		// Boxes get set in order so that margins refer to the updated default box.
+		for _, k := range boxNames {
+
+			pb, found := pbs.boxes[k]
+			if !found || pb == nil {
+				continue
+			}
+
+			d, boxes, _, rot, err := effectiveBoxes(xRefTable, pageNr)
+			if err != nil {
+				return err
+			}
+
+			var r types.Rectangle
+			if pb.rect != nil {
+				r = *pb.rect
+			} else {
+				r = applyMargins(boxes[defaultBox(k)], pb.margins, rot)
+			}
+
+			// All other boxes take effect within the media box only.
+			if k != "MediaBox" {
+				r = intersection(r, boxes["MediaBox"])
+			}
+
+			if r.Width() <= 0 || r.Height() <= 0 {
+				return errors.Errorf("AddBoxes: page %d: %s would be empty", pageNr, k)
+			}
+
+			log.Debug.Printf("AddBoxes: page %d: %s=%s\n", pageNr, k, r)
+
+			d.Update(k, NewRectangle(r.LL.X, r.LL.Y, r.UR.X, r.UR.Y))
+		}
+	}
+
+	log.Debug.Println("AddBoxes end")
+
+	return nil
+}
+
+// RemoveBoxes removes the page boundaries named in pbs from all selected pages so that they take their default.
+// The media box is mandatory and cannot be removed.
+func RemoveBoxes(xRefTable *XRefTable, selectedPages IntSet, pbs *PageBoxes) error {
+
+	log.Debug.Printf("RemoveBoxes begin: %s\n", pbs)
+
+	if _, found := pbs.boxes["MediaBox"]; found {
+		return errors.New("RemoveBoxes: the media box cannot be removed")
+	}
+
+	for _, pageNr := range selectedPageNumbers(selectedPages) {
+
+		for _, k := range boxNames[1:] {
+
+			if _, found := pbs.boxes[k]; !found {
+				continue
+			}
+
+			d, _, err := xRefTable.PageDict(pageNr)
+			if err != nil {
+				return err
+			}
+
+			if d == nil {
+				return errors.Errorf("RemoveBoxes: unknown page %d", pageNr)
+			}
+
+			d.Delete(k)
+
+			if k != "CropBox" {
+				continue
+			}
+
+			// A crop box inherited from an ancestor page tree node gets neutralized by the media box.
+			_, inhPAttrs, err := xRefTable.PageDict(pageNr)
+			if err != nil {
+				return err
+			}
+
+			if inhPAttrs.cropBox != nil && inhPAttrs.mediaBox != nil {
+				r := rect(xRefTable, *inhPAttrs.mediaBox)
+				d.Insert("CropBox", NewRectangle(r.LL.X, r.LL.Y, r.UR.X, r.UR.Y))
+			}
+		}
+	}
+
+	log.Debug.Println("RemoveBoxes end")
+
+	return nil
+}
+
+// CropToTrimBox sets the crop box of all selected pages to their trim box.
+func CropToTrimBox(xRefTable *XRefTable, selectedPages IntSet) error {
+
+	log.Debug.Println("CropToTrimBox begin")
+
+	for _, pageNr := range selectedPageNumbers(selectedPages) {
+
+		d, boxes, _, _, err := effectiveBoxes(xRefTable, pageNr)
+		if err != nil {
+			return err
+		}
+
+		r := boxes["TrimBox"]
+
+		log.Debug.Printf("CropToTrimBox: page %d: %s\n", pageNr, r)
+
+		d.Update("CropBox", NewRectangle(r.LL.X, r.LL.Y, r.UR.X, r.UR.Y))
+	}
+
+	log.Debug.Println("CropToTrimBox end")
+
+	return nil
+}
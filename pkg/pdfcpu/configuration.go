@@ -27,6 +27,9 @@ const (
 	// StatsFileNameDefault is the standard stats filename.
 	StatsFileNameDefault = "stats.csv"
 
+	// StreamCacheSizeDefault is the standard amount of stream content in bytes held in memory when lazy loading.
+	StreamCacheSizeDefault = 64 << 20
+
 	// PermissionsAll enables all user access permission bits.
 	PermissionsAll int16 = -1 // 0xFFFF
 
@@ -62,6 +65,37 @@ const (
 	CHANGEOPW
 	STAMP
 	ADDWATERMARKS
+	INFO
+	ROTATE
+	INSERTPAGES
+	REMOVEPAGES
+	NUP
+	BOOKLET
+	COLLECT
+	IMPORTIMAGES
+	LISTBOXES
+	ADDBOXES
+	REMOVEBOXES
+	CROP
+	RESIZE
+	LISTPAGELABELS
+	ADDPAGELABELS
+	REMOVEPAGELABELS
+	LISTBOOKMARKS
+	EXPORTBOOKMARKS
+	IMPORTBOOKMARKS
+	REMOVEBOOKMARKS
+	LISTPROPERTIES
+	ADDPROPERTIES
+	REMOVEPROPERTIES
+	LISTANNOTATIONS
+	ADDANNOTATIONS
+	REMOVEANNOTATIONS
+	FLATTEN
+	LISTFORMFIELDS
+	FILLFORM
+	EXPORTFORMDATA
+	IMPORTFORMDATA
 )
 
 // Configuration of a Context.
@@ -88,6 +122,19 @@ type Configuration struct {
 	// Switches between xRefSection (<=V1.4) and objectStream/xRefStream (>=V1.5) writing.
 	WriteXRefStream bool
 
+	// Enables incremental updates: Only new and modified objects and a new xref section
+	// get appended to the original PDF which stays untouched.
+	// Needs to be set before reading.
+	Incremental bool
+
+	// Enables lazy loading: Objects and stream content get loaded on first access
+	// instead of reading in the whole file upfront.
+	// Needs to be set before reading, the resulting Context needs to be closed after use.
+	LazyLoading bool
+
+	// The max amount of stream content in bytes held in memory when lazy loading.
+	StreamCacheSize int64
+
 	// Turns on stats collection.
 	CollectStats bool
 
@@ -130,6 +177,7 @@ func NewDefaultConfiguration() *Configuration {
 		WriteObjectStream:     true,
 		WriteXRefStream:       true,
 		CollectStats:          true,
+		StreamCacheSize:       StreamCacheSizeDefault,
 		EncryptUsingAES:       true,
 		EncryptUsing128BitKey: true,
 		UserAccessPermissions: PermissionsNone,
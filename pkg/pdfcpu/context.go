@@ -19,7 +19,7 @@ package pdfcpu
 import (
 	"bufio"
 	"fmt"
-	"os"
+	"io"
 	"sort"
 	"strings"
 
@@ -35,14 +35,14 @@ type Context struct {
 	Write    *WriteContext
 }
 
-// NewContext initializes a new Context.
-func NewContext(fileName string, file *os.File, config *Configuration) (*Context, error) {
+// NewContext initializes a new Context for reading from rs.
+func NewContext(rs io.ReadSeeker, config *Configuration) (*Context, error) {
 
 	if config == nil {
 		config = NewDefaultConfiguration()
 	}
 
-	fileInfo, err := file.Stat()
+	fileSize, err := rs.Seek(0, io.SeekEnd)
 	if err != nil {
 		return nil, err
 	}
@@ -50,7 +50,7 @@ func NewContext(fileName string, file *os.File, config *Configuration) (*Context
 	ctx := &Context{
 		config,
 		newXRefTable(config.ValidationMode),
-		newReadContext(fileName, file, fileInfo.Size()),
+		newReadContext(rs, fileSize),
 		newOptimizationContext(),
 		NewWriteContext(config.Eol),
 	}
@@ -156,10 +156,12 @@ func (ctx *Context) String() string {
 type ReadContext struct {
 
 	// The PDF-File which gets processed.
-	FileName string
-	File     *os.File
+	FileName string        // optional, empty when reading from an io.ReadSeeker.
+	RS       io.ReadSeeker // the source of the PDF.
 	FileSize int64
 
+	OffsetLastXRefSection int64 // the offset of the last xref section aka startxref.
+
 	BinaryTotalSize     int64 // total stream data
 	BinaryImageSize     int64 // total image stream data
 	BinaryFontSize      int64 // total font stream data (fontfiles)
@@ -174,12 +176,15 @@ type ReadContext struct {
 
 	UsingXRefStreams bool   // File is using xref streams.
 	XRefStreams      IntSet // All object numbers of any xref streams found.
+
+	fingerprints map[int]fingerprint // The state of all objects read, needed for incremental updates.
+
+	file io.Closer // The file kept open by ReadPDFFile for lazy loading.
 }
 
-func newReadContext(fileName string, file *os.File, fileSize int64) *ReadContext {
+func newReadContext(rs io.ReadSeeker, fileSize int64) *ReadContext {
 	return &ReadContext{
-		FileName:      fileName,
-		File:          file,
+		RS:            rs,
 		FileSize:      fileSize,
 		ObjectStreams: IntSet{},
 		XRefStreams:   IntSet{},
@@ -590,11 +595,11 @@ func (wc *WriteContext) HasWriteOffset(objNumber int) bool {
 	return found
 }
 
-// ReducedFeatureSet returns true for Split,Trim,Merge,ExtractPages.
+// ReducedFeatureSet returns true for Split,Trim,ExtractPages.
 // Don't confuse with pdfcpu commands, these are internal triggers.
 func (wc *WriteContext) ReducedFeatureSet() bool {
 	switch wc.Command {
-	case "Split", "Trim", "Merge":
+	case "Split", "Trim":
 		return true
 	}
 	return false
@@ -65,6 +65,37 @@ var (
 		LISTPERMISSIONS:    {0, 0},
 		ADDPERMISSIONS:     {0, 0},
 		ADDWATERMARKS:      {1, 0},
+		INFO:               {0, 0},
+		ROTATE:             {0, 1},
+		INSERTPAGES:        {0, 1},
+		REMOVEPAGES:        {0, 1},
+		NUP:                {0, 1},
+		BOOKLET:            {0, 1},
+		COLLECT:            {0, 1},
+		IMPORTIMAGES:       {0, 1},
+		LISTBOXES:          {0, 0},
+		ADDBOXES:           {0, 1},
+		REMOVEBOXES:        {0, 1},
+		CROP:               {0, 1},
+		RESIZE:             {0, 1},
+		LISTPAGELABELS:     {0, 0},
+		ADDPAGELABELS:      {0, 1},
+		REMOVEPAGELABELS:   {0, 1},
+		LISTBOOKMARKS:      {0, 0},
+		EXPORTBOOKMARKS:    {0, 0},
+		IMPORTBOOKMARKS:    {0, 1},
+		REMOVEBOOKMARKS:    {0, 1},
+		LISTPROPERTIES:     {0, 0},
+		ADDPROPERTIES:      {0, 1},
+		REMOVEPROPERTIES:   {0, 1},
+		LISTANNOTATIONS:    {0, 0},
+		ADDANNOTATIONS:     {0, 1},
+		REMOVEANNOTATIONS:  {0, 1},
+		FLATTEN:            {0, 1},
+		LISTFORMFIELDS:     {0, 0},
+		FILLFORM:           {0, 1},
+		EXPORTFORMDATA:     {0, 0},
+		IMPORTFORMDATA:     {0, 1},
 	}
 )
 
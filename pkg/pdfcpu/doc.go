@@ -7,7 +7,7 @@ The available commands are:
 
 	validate	validate PDF against PDF 32000-1:2008 (PDF 1.7)
 	optimize	optimize PDF by getting rid of redundant page resources
-	split		split multi-page PDF into several single-page PDFs
+	split		split PDF by page span, bookmark, page number or file size
 	merge		concatenate 2 or more PDFs
 	extract		extract images, fonts, content, pages or metadata
 	trim		create trimmed version
@@ -0,0 +1,513 @@
+/*
+Copyright 2018 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pdfcpu
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/hhrutter/pdfcpu/pkg/log"
+	"github.com/pkg/errors"
+)
+
+// FormData represents the field values and annotations of a PDF
+// as exchanged by FDF and XFDF files, see 12.7.8 Forms Data Format.
+type FormData struct {
+	File        string       // the PDF file the form data belongs to.
+	Fields      []Field      // fields by fully qualified name along with their values.
+	Annotations []Annotation // markup annotations.
+}
+
+// fieldNode represents a node of the field tree of form data.
+type fieldNode struct {
+	name  string // partial field name.
+	field *Field // terminal fields only.
+	kids  []*fieldNode
+}
+
+// fieldTree arranges fields by their fully qualified names.
+func fieldTree(fields []Field) []*fieldNode {
+
+	root := &fieldNode{}
+
+	for i := range fields {
+
+		n := root
+
+		for _, name := range strings.Split(fields[i].Name, ".") {
+			var kid *fieldNode
+			for _, k := range n.kids {
+				if k.name == name {
+					kid = k
+					break
+				}
+			}
+			if kid == nil {
+				kid = &fieldNode{name: name}
+				n.kids = append(n.kids, kid)
+			}
+			n = kid
+		}
+
+		n.field = &fields[i]
+	}
+
+	return root.kids
+}
+
+// fieldValues returns the values of f, nil for fields without value.
+func fieldValues(f *Field) []string {
+
+	if len(f.Values) > 0 {
+		return f.Values
+	}
+
+	if f.Value != "" {
+		return []string{f.Value}
+	}
+
+	return nil
+}
+
+// newField returns the field called name holding values.
+func newField(name string, values []string) Field {
+
+	f := Field{Name: name}
+
+	if len(values) == 1 {
+		f.Value = values[0]
+	} else {
+		f.Values = values
+	}
+
+	return f
+}
+
+// exportedAnnotation returns true for annotations exchanged as form data.
+// Links and file attachments refer to objects not being part of form data.
+func exportedAnnotation(a Annotation) bool {
+	_, ok := annotationTypes[a.Type]
+	return ok && a.Type != "Link" && a.Type != "FileAttachment"
+}
+
+// ExportFormData returns the values of the fillable form fields and the markup annotations.
+func ExportFormData(xRefTable *XRefTable) (*FormData, error) {
+
+	log.Debug.Println("ExportFormData begin")
+
+	fd := &FormData{}
+
+	fields, err := FormFields(xRefTable)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, f := range fields {
+		switch f.Type {
+		case "CheckBox", "RadioButton":
+			// Buttons show up as off for values other than their on states.
+			if !MemberOf(f.Value, f.Options) {
+				f.Value = "Off"
+			}
+			fallthrough
+		case "Text", "ComboBox", "ListBox":
+			fd.Fields = append(fd.Fields, Field{Name: f.Name, Type: f.Type, Value: f.Value, Values: f.Values})
+		}
+	}
+
+	for pageNr := 1; pageNr <= xRefTable.PageCount; pageNr++ {
+
+		pageDict, _, err := xRefTable.PageDict(pageNr)
+		if err != nil {
+			return nil, err
+		}
+
+		if pageDict == nil {
+			return nil, errors.Errorf("ExportFormData: missing page %d", pageNr)
+		}
+
+		arr, err := xRefTable.pageAnnotations(pageDict)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, o := range arr {
+
+			d, err := xRefTable.DereferenceDict(o)
+			if err != nil {
+				return nil, err
+			}
+
+			if d == nil {
+				continue
+			}
+
+			// Only annotation names identify annotations across files.
+			a, err := xRefTable.annotation(d, 0, pageNr, nil)
+			if err != nil {
+				return nil, err
+			}
+
+			if exportedAnnotation(*a) {
+				fd.Annotations = append(fd.Annotations, *a)
+			}
+		}
+	}
+
+	log.Debug.Println("ExportFormData end")
+
+	return fd, nil
+}
+
+// importAnnotation adds a to its page replacing an annotation of the same name.
+func (xRefTable *XRefTable) importAnnotation(a Annotation) error {
+
+	if a.ID != "" && a.PageNr >= 1 && a.PageNr <= xRefTable.PageCount {
+
+		pageDict, _, err := xRefTable.PageDict(a.PageNr)
+		if err != nil {
+			return err
+		}
+
+		if pageDict != nil {
+			_, err = xRefTable.removePageAnnotations(pageDict, func(d *Dict, objNr int) (bool, error) {
+				nm, err := xRefTable.annotationText(d, "NM")
+				return nm == a.ID, err
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return xRefTable.addAnnotation(a)
+}
+
+// ImportFormData sets the field values of fd and adds its annotations.
+// Annotations replace annotations of the same name on their page.
+func ImportFormData(xRefTable *XRefTable, fd *FormData) error {
+
+	log.Debug.Println("ImportFormData begin")
+
+	if len(fd.Fields) > 0 {
+		if err := FillForm(xRefTable, fd.Fields); err != nil {
+			return err
+		}
+	}
+
+	for _, a := range fd.Annotations {
+		if err := xRefTable.importAnnotation(a); err != nil {
+			return err
+		}
+	}
+
+	log.Debug.Println("ImportFormData end")
+
+	return nil
+}
+
+// fdfFieldsArray returns the FDF field dicts for the field tree nodes.
+func fdfFieldsArray(nodes []*fieldNode) (Array, error) {
+
+	arr := Array{}
+
+	for _, n := range nodes {
+
+		t, err := textObject(n.name)
+		if err != nil {
+			return nil, err
+		}
+
+		d := Dict{"T": t}
+
+		if n.field != nil {
+			if vv := fieldValues(n.field); vv != nil {
+				var a Array
+				for _, v := range vv {
+					// Button values are appearance state names.
+					if n.field.Type == "CheckBox" || n.field.Type == "RadioButton" {
+						a = append(a, Name(v))
+						continue
+					}
+					o, err := textObject(v)
+					if err != nil {
+						return nil, err
+					}
+					a = append(a, o)
+				}
+				if len(a) == 1 {
+					d.Insert("V", a[0])
+				} else {
+					d.Insert("V", a)
+				}
+			}
+		}
+
+		if len(n.kids) > 0 {
+			kids, err := fdfFieldsArray(n.kids)
+			if err != nil {
+				return nil, err
+			}
+			d.Insert("Kids", kids)
+		}
+
+		arr = append(arr, d)
+	}
+
+	return arr, nil
+}
+
+// WriteFDF writes fd as FDF file to w.
+func WriteFDF(w io.Writer, fd *FormData) error {
+
+	fields, err := fdfFieldsArray(fieldTree(fd.Fields))
+	if err != nil {
+		return err
+	}
+
+	fdf := Dict{"Fields": fields}
+
+	if fd.File != "" {
+		f, err := textObject(fd.File)
+		if err != nil {
+			return err
+		}
+		fdf.Insert("F", f)
+	}
+
+	if len(fd.Annotations) > 0 {
+		annots := Array{}
+		for _, a := range fd.Annotations {
+			d, err := newAnnotationDict(a)
+			if err != nil {
+				return err
+			}
+			// FDF annotations identify their page by its zero based index.
+			d.Insert("Page", Integer(a.PageNr-1))
+			annots = append(annots, d)
+		}
+		fdf.Insert("Annots", annots)
+	}
+
+	root := Dict{"FDF": fdf}
+
+	_, err = fmt.Fprintf(w, "%%FDF-1.2\n%%\xe2\xe3\xcf\xd3\n1 0 obj\n%s\nendobj\ntrailer\n<</Root 1 0 R>>\n%%%%EOF\n", root.PDFString())
+
+	return err
+}
+
+var fdfObjectHeader = regexp.MustCompile(`(?m)^\s*(\d+)\s+(\d+)\s+obj\b`)
+
+// fdfFields adds the fields of the FDF field array o having a value to ff.
+func (xRefTable *XRefTable) fdfFields(o Object, prefix string, ff *[]Field) error {
+
+	arr, err := xRefTable.DereferenceArray(o)
+	if err != nil || arr == nil {
+		return err
+	}
+
+	for _, o := range *arr {
+
+		d, err := xRefTable.DereferenceDict(o)
+		if err != nil {
+			return err
+		}
+
+		if d == nil {
+			continue
+		}
+
+		name := prefix
+		if o, found := d.Find("T"); found {
+			t, err := xRefTable.DereferenceText(o)
+			if err != nil {
+				return err
+			}
+			if name != "" {
+				name += "."
+			}
+			name += t
+		}
+
+		if o, found := d.Find("V"); found {
+
+			o, err := xRefTable.Dereference(o)
+			if err != nil {
+				return err
+			}
+
+			var values []string
+
+			if a, ok := o.(Array); ok {
+				for _, o := range a {
+					s, err := xRefTable.propertyString(o)
+					if err != nil {
+						return err
+					}
+					values = append(values, s)
+				}
+			} else {
+				s, err := xRefTable.propertyString(o)
+				if err != nil {
+					return err
+				}
+				values = []string{s}
+			}
+
+			*ff = append(*ff, newField(name, values))
+		}
+
+		if o, found := d.Find("Kids"); found {
+			if err = xRefTable.fdfFields(o, name, ff); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// fdfAnnotations returns the annotations of the FDF annotation array o.
+func (xRefTable *XRefTable) fdfAnnotations(o Object) ([]Annotation, error) {
+
+	arr, err := xRefTable.DereferenceArray(o)
+	if err != nil || arr == nil {
+		return nil, err
+	}
+
+	var annots []Annotation
+
+	for _, o := range *arr {
+
+		d, err := xRefTable.DereferenceDict(o)
+		if err != nil {
+			return nil, err
+		}
+
+		if d == nil {
+			continue
+		}
+
+		// Popups go along with their parent annotation.
+		if st := d.Subtype(); st != nil && *st == "Popup" {
+			continue
+		}
+
+		pageNr := 1
+		if i := d.IntEntry("Page"); i != nil {
+			pageNr = *i + 1
+		}
+
+		a, err := xRefTable.annotation(d, 0, pageNr, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		annots = append(annots, *a)
+	}
+
+	return annots, nil
+}
+
+// ReadFDF reads form data from an FDF file.
+func ReadFDF(r io.Reader) (*FormData, error) {
+
+	bb, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	s := string(bb)
+
+	if !strings.HasPrefix(s, "%FDF-") {
+		return nil, errors.New("ReadFDF: missing FDF header")
+	}
+
+	xRefTable := newXRefTable(ValidationRelaxed)
+
+	for _, m := range fdfObjectHeader.FindAllStringSubmatchIndex(s, -1) {
+
+		objNr, _ := strconv.Atoi(s[m[2]:m[3]])
+		genNr, _ := strconv.Atoi(s[m[4]:m[5]])
+
+		l := s[m[1]:]
+		o, err := parseObject(&l)
+		if err != nil {
+			return nil, errors.Wrapf(err, "ReadFDF: object %d", objNr)
+		}
+
+		xRefTable.Table[objNr] = &XRefTableEntry{Generation: &genNr, Object: o}
+	}
+
+	i := strings.LastIndex(s, "trailer")
+	if i < 0 {
+		return nil, errors.New("ReadFDF: missing trailer")
+	}
+
+	l := s[i+len("trailer"):]
+	o, err := parseObject(&l)
+	if err != nil {
+		return nil, errors.Wrap(err, "ReadFDF: trailer")
+	}
+
+	trailer, ok := o.(Dict)
+	if !ok {
+		return nil, errors.New("ReadFDF: corrupt trailer")
+	}
+
+	rootDict, err := xRefTable.DereferenceDict(trailer["Root"])
+	if err != nil || rootDict == nil {
+		return nil, errors.New("ReadFDF: missing catalog")
+	}
+
+	o, _ = rootDict.Find("FDF")
+
+	fdf, err := xRefTable.DereferenceDict(o)
+	if err != nil || fdf == nil {
+		return nil, errors.New("ReadFDF: missing FDF dict")
+	}
+
+	fd := &FormData{}
+
+	if o, found := fdf.Find("F"); found {
+		// A file specification is either a string or a dict.
+		if d, ok := o.(Dict); ok {
+			o = d["F"]
+		}
+		if fd.File, err = xRefTable.propertyString(o); err != nil {
+			return nil, err
+		}
+	}
+
+	if o, found := fdf.Find("Fields"); found {
+		if err = xRefTable.fdfFields(o, "", &fd.Fields); err != nil {
+			return nil, err
+		}
+	}
+
+	if o, found := fdf.Find("Annots"); found {
+		if fd.Annotations, err = xRefTable.fdfAnnotations(o); err != nil {
+			return nil, err
+		}
+	}
+
+	return fd, nil
+}
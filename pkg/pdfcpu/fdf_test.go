@@ -0,0 +1,182 @@
+/*
+Copyright 2018 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pdfcpu
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func testFormData() *FormData {
+	return &FormData{
+		File: `form (draft)\v2.pdf`,
+		Fields: []Field{
+			{Name: "person.name", Value: "Jane Doe"},
+			{Name: "person.city", Value: "Zürich"},
+			{Name: "married", Value: "Yes"},
+			{Name: "languages", Values: []string{"de", "en"}},
+		},
+		Annotations: []Annotation{
+			{ID: "note", Type: "Text", PageNr: 1, Rect: []float64{10, 10, 30, 30}, Contents: "Check this", Author: "Jane", Color: []float64{1, 0, 0}, Icon: "Comment", Open: true},
+			{Type: "Line", PageNr: 2, Rect: []float64{0, 0, 100, 100}, Points: []float64{0, 0, 100, 100.5}},
+			{Type: "Highlight", PageNr: 1, Rect: []float64{0, 0, 50, 10}, Points: []float64{0, 10, 50, 10, 0, 0, 50, 0}},
+			{Type: "Ink", PageNr: 1, Rect: []float64{0, 0, 50, 50}, InkList: [][]float64{{0, 0, 10, 10, 20, 0}}},
+		},
+	}
+}
+
+func TestFormDataRoundTrip(t *testing.T) {
+
+	for _, tt := range []struct {
+		format string
+		write  func(*bytes.Buffer, *FormData) error
+		read   func(*bytes.Buffer) (*FormData, error)
+	}{
+		{"FDF",
+			func(b *bytes.Buffer, fd *FormData) error { return WriteFDF(b, fd) },
+			func(b *bytes.Buffer) (*FormData, error) { return ReadFDF(b) }},
+		{"XFDF",
+			func(b *bytes.Buffer, fd *FormData) error { return WriteXFDF(b, fd) },
+			func(b *bytes.Buffer) (*FormData, error) { return ReadXFDF(b) }},
+	} {
+		want := testFormData()
+
+		var b bytes.Buffer
+		if err := tt.write(&b, want); err != nil {
+			t.Fatalf("%s: %v\n", tt.format, err)
+		}
+
+		got, err := tt.read(&b)
+		if err != nil {
+			t.Fatalf("%s: %v\n", tt.format, err)
+		}
+
+		if got.File != want.File {
+			t.Errorf("%s: file: want %q, got %q\n", tt.format, want.File, got.File)
+		}
+
+		if !reflect.DeepEqual(got.Fields, want.Fields) {
+			t.Errorf("%s: fields: want %+v, got %+v\n", tt.format, want.Fields, got.Fields)
+		}
+
+		if !reflect.DeepEqual(got.Annotations, want.Annotations) {
+			t.Errorf("%s: annotations: want %+v, got %+v\n", tt.format, want.Annotations, got.Annotations)
+		}
+	}
+}
+
+func TestReadFDF(t *testing.T) {
+
+	// Fields and annotations as indirect objects, a popup and a file specification dict.
+	s := `%FDF-1.2
+1 0 obj
+<</FDF <</F <</Type /Filespec /F (order.pdf)>> /Fields [2 0 R] /Annots [4 0 R 5 0 R]>>>>
+endobj
+2 0 obj
+<</T (order) /Kids [3 0 R <</T (express) /V /Yes>>]>>
+endobj
+3 0 obj
+<</T (item) /V <FEFF00540065006500201F75>>>
+endobj
+4 0 obj
+<</Type /Annot /Subtype /Square /Page 1 /Rect [1 2 3 4] /NM (box) /Popup 5 0 R>>
+endobj
+5 0 obj
+<</Type /Annot /Subtype /Popup /Page 1 /Rect [1 2 3 4]>>
+endobj
+trailer
+<</Root 1 0 R>>
+%%EOF
+`
+	fd, err := ReadFDF(strings.NewReader(s))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if fd.File != "order.pdf" {
+		t.Errorf("want file order.pdf, got %q\n", fd.File)
+	}
+
+	want := []Field{{Name: "order.item", Value: "Tee ή"}, {Name: "order.express", Value: "Yes"}}
+	if !reflect.DeepEqual(fd.Fields, want) {
+		t.Errorf("want %+v, got %+v\n", want, fd.Fields)
+	}
+
+	if len(fd.Annotations) != 1 || fd.Annotations[0].ID != "box" || fd.Annotations[0].PageNr != 2 {
+		t.Errorf("want annotation box on page 2, got %+v\n", fd.Annotations)
+	}
+
+	if _, err := ReadFDF(strings.NewReader("%PDF-1.7")); err == nil {
+		t.Errorf("want error for missing FDF header\n")
+	}
+}
+
+func TestExportImportFormData(t *testing.T) {
+
+	xRefTable := formDemoXRef(t)
+
+	// The page count gets set during validation.
+	xRefTable.PageCount = 1
+
+	fd, err := ExportFormData(xRefTable)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Push buttons carry no value.
+	for _, f := range fd.Fields {
+		if f.Name == "Submit" || f.Name == "Reset" {
+			t.Errorf("unexpected field %s\n", f.Name)
+		}
+	}
+
+	fd.Fields = []Field{{Name: "inputField", Value: "Imported"}, {Name: "Languages", Values: []string{"de"}}}
+	fd.Annotations = []Annotation{{ID: "imported", Type: "Text", PageNr: 1, Rect: []float64{10, 10, 30, 30}}}
+
+	// Importing twice replaces the annotation of the same name.
+	for i := 0; i < 2; i++ {
+		if err = ImportFormData(xRefTable, fd); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if f := fieldByName(t, xRefTable, "inputField"); f.Value != "Imported" {
+		t.Errorf("inputField: got %q\n", f.Value)
+	}
+
+	if f := fieldByName(t, xRefTable, "Languages"); f.Value != "de" {
+		t.Errorf("Languages: got %q\n", f.Value)
+	}
+
+	annots, err := Annotations(xRefTable, IntSet{1: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n := 0
+	for _, a := range annots {
+		if a.ID == "imported" {
+			n++
+		}
+	}
+
+	if n != 1 {
+		t.Errorf("want 1 imported annotation, got %d\n", n)
+	}
+}
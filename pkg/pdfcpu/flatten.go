@@ -0,0 +1,407 @@
+/*
+Copyright 2018 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pdfcpu
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/hhrutter/pdfcpu/pkg/log"
+	"github.com/hhrutter/pdfcpu/pkg/types"
+	"github.com/pkg/errors"
+)
+
+// Annotation flags hiding an annotation.
+const (
+	annotHidden = 1 << 1
+	annotNoView = 1 << 5
+)
+
+// normalAppearance returns the normal appearance stream of an annotation
+// taking into account the appearance state, or nil if there is none.
+func (xRefTable *XRefTable) normalAppearance(d *Dict) (*IndirectRef, *StreamDict, error) {
+
+	o, found := d.Find("AP")
+	if !found {
+		return nil, nil, nil
+	}
+
+	ap, err := xRefTable.DereferenceDict(o)
+	if err != nil || ap == nil {
+		return nil, nil, err
+	}
+
+	o, found = ap.Find("N")
+	if !found {
+		return nil, nil, nil
+	}
+
+	o1, err := xRefTable.Dereference(o)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Appearance subdictionaries map appearance states to streams.
+	if states, ok := o1.(Dict); ok {
+		as := d.NameEntry("AS")
+		if as == nil {
+			return nil, nil, nil
+		}
+		if o, found = states.Find(*as); !found {
+			return nil, nil, nil
+		}
+	}
+
+	indRef, ok := o.(IndirectRef)
+	if !ok {
+		return nil, nil, nil
+	}
+
+	sd, err := xRefTable.DereferenceStreamDict(indRef)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &indRef, sd, nil
+}
+
+// appearanceMatrix returns the matrix mapping the appearance bounding box transformed by its form matrix
+// onto the annotation rectangle, see 12.5.5 Appearance Streams.
+func (xRefTable *XRefTable) appearanceMatrix(d *Dict, sd *StreamDict) (*[6]float64, error) {
+
+	r := d.ArrayEntry("Rect")
+	bbox := sd.ArrayEntry("BBox")
+	if r == nil || len(*r) != 4 || bbox == nil || len(*bbox) != 4 {
+		return nil, nil
+	}
+
+	m := [6]float64{1, 0, 0, 1, 0, 0}
+	if a := sd.ArrayEntry("Matrix"); a != nil && len(*a) == 6 {
+		for i, o := range *a {
+			m[i] = xRefTable.DereferenceNumber(o)
+		}
+	}
+
+	rect, bb := rect(xRefTable, *r), rect(xRefTable, *bbox)
+
+	// Bounding box of the transformed appearance bounding box.
+	t := types.NewRectangle(math.MaxFloat64, math.MaxFloat64, -math.MaxFloat64, -math.MaxFloat64)
+	for _, p := range []types.Point{bb.LL, bb.UR, {X: bb.LL.X, Y: bb.UR.Y}, {X: bb.UR.X, Y: bb.LL.Y}} {
+		x := m[0]*p.X + m[2]*p.Y + m[4]
+		y := m[1]*p.X + m[3]*p.Y + m[5]
+		t.LL.X, t.LL.Y = math.Min(t.LL.X, x), math.Min(t.LL.Y, y)
+		t.UR.X, t.UR.Y = math.Max(t.UR.X, x), math.Max(t.UR.Y, y)
+	}
+
+	if t.Width() == 0 || t.Height() == 0 {
+		return nil, nil
+	}
+
+	sx, sy := rect.Width()/t.Width(), rect.Height()/t.Height()
+
+	return &[6]float64{sx, 0, 0, sy, rect.LL.X - sx*t.LL.X, rect.LL.Y - sy*t.LL.Y}, nil
+}
+
+// flattenResources returns a copy of the page resources the page dict gets to own
+// so that resources shared with other pages remain untouched.
+func (xRefTable *XRefTable) flattenResources(pageDict *Dict, resources *Dict) (Dict, error) {
+
+	resDict := NewDict()
+	xObjects := NewDict()
+
+	if resources != nil {
+
+		for k, v := range *resources {
+			resDict[k] = v
+		}
+
+		if o, found := resources.Find("XObject"); found {
+			d, err := xRefTable.DereferenceDict(o)
+			if err != nil {
+				return nil, err
+			}
+			if d != nil {
+				for k, v := range *d {
+					xObjects[k] = v
+				}
+			}
+		}
+	}
+
+	resDict.Update("XObject", xObjects)
+	pageDict.Update("Resources", resDict)
+
+	return xObjects, nil
+}
+
+// flattenPage draws the normal appearances of the annotations of a page into the page content
+// and removes them along with their popups and replies.
+// Form field widgets always get removed, annotations without appearance except popups are kept.
+// It returns the object numbers of the removed widgets.
+func (xRefTable *XRefTable) flattenPage(pageNr int) (IntSet, error) {
+
+	pageDict, inhPAttrs, err := xRefTable.PageDict(pageNr)
+	if err != nil {
+		return nil, err
+	}
+
+	if pageDict == nil {
+		return nil, errors.Errorf("flattenPage: missing page %d", pageNr)
+	}
+
+	widgets := IntSet{}
+
+	var (
+		b        bytes.Buffer
+		xObjects Dict
+	)
+
+	flatten := func(d *Dict, objNr int) (bool, error) {
+
+		widget := false
+		if st := d.Subtype(); st != nil {
+			if *st == "Popup" {
+				// Popups go along with their parent annotation.
+				return false, nil
+			}
+			widget = *st == "Widget"
+		}
+
+		if widget && objNr > 0 {
+			widgets[objNr] = true
+		}
+
+		indRef, sd, err := xRefTable.normalAppearance(d)
+		if err != nil {
+			return false, err
+		}
+
+		if sd == nil {
+			return widget, nil
+		}
+
+		if f := d.IntEntry("F"); f != nil && *f&(annotHidden|annotNoView) > 0 {
+			return true, nil
+		}
+
+		m, err := xRefTable.appearanceMatrix(d, sd)
+		if err != nil || m == nil {
+			return true, err
+		}
+
+		if xObjects == nil {
+			if xObjects, err = xRefTable.flattenResources(pageDict, inhPAttrs.resources); err != nil {
+				return false, err
+			}
+		}
+
+		var id string
+		for i := 0; ; i++ {
+			id = "Fm" + strconv.Itoa(i)
+			if _, found := xObjects.Find(id); !found {
+				break
+			}
+		}
+
+		// Appearance streams are form XObjects.
+		sd.Insert("Type", Name("XObject"))
+		sd.Insert("Subtype", Name("Form"))
+		xObjects.Insert(id, *indRef)
+
+		fmt.Fprintf(&b, "q %.5f %.5f %.5f %.5f %.5f %.5f cm /%s Do Q\n", m[0], m[1], m[2], m[3], m[4], m[5], id)
+
+		return true, nil
+	}
+
+	c, err := xRefTable.removePageAnnotations(pageDict, flatten)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Debug.Printf("flattenPage: page %d: flattened %d annotations\n", pageNr, c)
+
+	if b.Len() == 0 {
+		return widgets, nil
+	}
+
+	// Draw the annotations on top of the page content in the default graphics state.
+	if _, found := pageDict.Find("Contents"); !found {
+		indRef, err := contentStream(xRefTable, b.String())
+		if err != nil {
+			return nil, err
+		}
+		pageDict.Insert("Contents", *indRef)
+		return widgets, nil
+	}
+
+	return widgets, wrapPageContent(xRefTable, pageDict, "q\n", "\nQ\n"+b.String())
+}
+
+// pruneFields removes the fields whose widgets got removed from a field array
+// and adds the object numbers of removed fields to removed.
+func (xRefTable *XRefTable) pruneFields(a Array, removed IntSet) (Array, error) {
+
+	var arr Array
+
+	for _, o := range a {
+
+		objNr := 0
+		if indRef, ok := o.(IndirectRef); ok {
+			objNr = indRef.ObjectNumber.Value()
+		}
+
+		if removed[objNr] {
+			continue
+		}
+
+		d, err := xRefTable.DereferenceDict(o)
+		if err != nil {
+			return nil, err
+		}
+
+		if d == nil {
+			continue
+		}
+
+		if o, found := d.Find("Kids"); found {
+
+			kids, err := xRefTable.DereferenceArray(o)
+			if err != nil {
+				return nil, err
+			}
+
+			if kids != nil && len(*kids) > 0 {
+
+				k, err := xRefTable.pruneFields(*kids, removed)
+				if err != nil {
+					return nil, err
+				}
+
+				if len(k) == 0 {
+					if objNr > 0 {
+						removed[objNr] = true
+					}
+					continue
+				}
+
+				d.Update("Kids", k)
+			}
+		}
+
+		arr = append(arr, o)
+	}
+
+	return arr, nil
+}
+
+// removeFormFields removes the fields of removed widgets from the AcroForm
+// and the AcroForm itself once there are no fields left.
+func (xRefTable *XRefTable) removeFormFields(widgets IntSet) error {
+
+	if len(widgets) == 0 {
+		return nil
+	}
+
+	rootDict, err := xRefTable.Catalog()
+	if err != nil {
+		return err
+	}
+
+	o, found := rootDict.Find("AcroForm")
+	if !found {
+		return nil
+	}
+
+	form, err := xRefTable.DereferenceDict(o)
+	if err != nil || form == nil {
+		return err
+	}
+
+	var fields Array
+	if o, found = form.Find("Fields"); found {
+		a, err := xRefTable.DereferenceArray(o)
+		if err != nil {
+			return err
+		}
+		if a != nil {
+			if fields, err = xRefTable.pruneFields(*a, widgets); err != nil {
+				return err
+			}
+		}
+	}
+
+	if len(fields) == 0 {
+		rootDict.Delete("AcroForm")
+		return nil
+	}
+
+	form.Update("Fields", fields)
+
+	// The calculation order refers to fields.
+	if o, found = form.Find("CO"); found {
+		a, err := xRefTable.DereferenceArray(o)
+		if err != nil {
+			return err
+		}
+		if a != nil {
+			var co Array
+			for _, o := range *a {
+				if indRef, ok := o.(IndirectRef); ok && widgets[indRef.ObjectNumber.Value()] {
+					continue
+				}
+				co = append(co, o)
+			}
+			form.Update("CO", co)
+		}
+	}
+
+	// An XFA form would no longer match the remaining fields.
+	form.Delete("XFA")
+
+	return nil
+}
+
+// FlattenAnnotations draws the annotations of the selected pages into the page content and removes them.
+// Form fields whose widgets got removed are removed from the AcroForm.
+func FlattenAnnotations(xRefTable *XRefTable, selectedPages IntSet) error {
+
+	log.Debug.Println("FlattenAnnotations begin")
+
+	widgets := IntSet{}
+
+	for _, pageNr := range selectedPageNumbers(selectedPages) {
+
+		w, err := xRefTable.flattenPage(pageNr)
+		if err != nil {
+			return err
+		}
+
+		for objNr := range w {
+			widgets[objNr] = true
+		}
+	}
+
+	if err := xRefTable.removeFormFields(widgets); err != nil {
+		return err
+	}
+
+	log.Debug.Println("FlattenAnnotations end")
+
+	return nil
+}
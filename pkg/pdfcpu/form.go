@@ -0,0 +1,669 @@
+/*
+Copyright 2018 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pdfcpu
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/hhrutter/pdfcpu/pkg/log"
+	"github.com/pkg/errors"
+)
+
+// Field represents a terminal field of an AcroForm.
+type Field struct {
+	Name    string   `json:"name"` // the fully qualified field name.
+	Type    string   `json:"type,omitempty"`
+	Value   string   `json:"value,omitempty"`
+	Values  []string `json:"values,omitempty"`  // ListBox: multiple selection.
+	Options []string `json:"options,omitempty"` // CheckBox, RadioButton: on states, ComboBox, ListBox: export values.
+	Flags   []string `json:"flags,omitempty"`
+	PageNr  int      `json:"page,omitempty"` // the page of the first widget.
+}
+
+// field flags (bit positions)
+const (
+	fieldReadOnly    = 1
+	fieldRadio       = 16
+	fieldPushbutton  = 17
+	fieldCombo       = 18
+	fieldEdit        = 19
+	fieldMultiSelect = 22
+)
+
+// The field flags worth listing by field type, see 12.7.3.1 and 12.7.4.
+var fieldFlags = []struct {
+	bit  int
+	ft   string // all field types if empty.
+	name string
+}{
+	{1, "", "ReadOnly"},
+	{2, "", "Required"},
+	{3, "", "NoExport"},
+	{13, "Tx", "Multiline"},
+	{14, "Tx", "Password"},
+	{21, "Tx", "FileSelect"},
+	{23, "Tx", "DoNotSpellCheck"},
+	{24, "Tx", "DoNotScroll"},
+	{25, "Tx", "Comb"},
+	{26, "Tx", "RichText"},
+	{15, "Btn", "NoToggleToOff"},
+	{26, "Btn", "RadiosInUnison"},
+	{19, "Ch", "Edit"},
+	{20, "Ch", "Sort"},
+	{22, "Ch", "MultiSelect"},
+	{23, "Ch", "DoNotSpellCheck"},
+	{27, "Ch", "CommitOnSelChange"},
+}
+
+func flagSet(ff, bit int) bool {
+	return ff&(1<<uint(bit-1)) > 0
+}
+
+func (f Field) String() string {
+
+	s := fmt.Sprintf("%s (%s)", f.Name, f.Type)
+
+	if len(f.Values) > 0 {
+		s += fmt.Sprintf(" = %q", f.Values)
+	} else if f.Value != "" {
+		s += fmt.Sprintf(" = %q", f.Value)
+	}
+
+	if len(f.Options) > 0 {
+		s += fmt.Sprintf(" options=%q", f.Options)
+	}
+
+	if len(f.Flags) > 0 {
+		s += " flags=" + strings.Join(f.Flags, ",")
+	}
+
+	if f.PageNr > 0 {
+		s += fmt.Sprintf(" page=%d", f.PageNr)
+	}
+
+	return s
+}
+
+// formField is a terminal field along with its widgets.
+type formField struct {
+	Field
+	d       *Dict
+	ft      string
+	ff      int
+	widgets []*Dict
+}
+
+// acroForm returns the AcroForm dict or nil if there is none.
+func (xRefTable *XRefTable) acroForm() (*Dict, error) {
+
+	rootDict, err := xRefTable.Catalog()
+	if err != nil {
+		return nil, err
+	}
+
+	o, found := rootDict.Find("AcroForm")
+	if !found {
+		return nil, nil
+	}
+
+	return xRefTable.DereferenceDict(o)
+}
+
+// appearanceStates returns the appearance states of a widget except Off.
+func (xRefTable *XRefTable) appearanceStates(w *Dict) ([]string, error) {
+
+	o, found := w.Find("AP")
+	if !found {
+		return nil, nil
+	}
+
+	ap, err := xRefTable.DereferenceDict(o)
+	if err != nil || ap == nil {
+		return nil, err
+	}
+
+	o, found = ap.Find("N")
+	if !found {
+		return nil, nil
+	}
+
+	d, err := xRefTable.DereferenceDict(o)
+	if err != nil || d == nil {
+		// A single appearance stream.
+		return nil, nil
+	}
+
+	var ss []string
+	for k := range *d {
+		if k != "Off" {
+			ss = append(ss, k)
+		}
+	}
+
+	sort.Strings(ss)
+
+	return ss, nil
+}
+
+// choiceOptions returns the export values and display texts of the options of a choice field.
+func (xRefTable *XRefTable) choiceOptions(d *Dict) ([]string, []string, error) {
+
+	o, found := d.Find("Opt")
+	if !found {
+		return nil, nil, nil
+	}
+
+	a, err := xRefTable.DereferenceArray(o)
+	if err != nil || a == nil {
+		return nil, nil, err
+	}
+
+	var exports, displays []string
+
+	for _, o := range *a {
+
+		o, err := xRefTable.Dereference(o)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		display := o
+
+		// [export value, display text]
+		if arr, ok := o.(Array); ok {
+			if len(arr) == 0 {
+				return nil, nil, errors.New("choiceOptions: corrupt Opt")
+			}
+			o, display = arr[0], arr[len(arr)-1]
+		}
+
+		s, err := xRefTable.propertyString(o)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		s1, err := xRefTable.propertyString(display)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		exports, displays = append(exports, s), append(displays, s1)
+	}
+
+	return exports, displays, nil
+}
+
+func fieldType(ft string, ff int) string {
+
+	switch ft {
+
+	case "Tx":
+		return "Text"
+
+	case "Btn":
+		if flagSet(ff, fieldPushbutton) {
+			return "PushButton"
+		}
+		if flagSet(ff, fieldRadio) {
+			return "RadioButton"
+		}
+		return "CheckBox"
+
+	case "Ch":
+		if flagSet(ff, fieldCombo) {
+			return "ComboBox"
+		}
+		return "ListBox"
+
+	case "Sig":
+		return "Signature"
+
+	}
+
+	return ft
+}
+
+// fieldValue sets the current value of f as well as its options.
+func (xRefTable *XRefTable) fieldValue(f *formField, v Object) error {
+
+	v, err := xRefTable.Dereference(v)
+	if err != nil {
+		return err
+	}
+
+	switch f.Type {
+
+	case "CheckBox", "RadioButton":
+		for _, w := range f.widgets {
+			ss, err := xRefTable.appearanceStates(w)
+			if err != nil {
+				return err
+			}
+			for _, s := range ss {
+				if !MemberOf(s, f.Options) {
+					f.Options = append(f.Options, s)
+				}
+			}
+		}
+		f.Value = "Off"
+		if v, ok := v.(Name); ok {
+			f.Value = v.Value()
+		}
+		return nil
+
+	case "ComboBox", "ListBox":
+		if f.Options, _, err = xRefTable.choiceOptions(f.d); err != nil {
+			return err
+		}
+		if a, ok := v.(Array); ok {
+			for _, o := range a {
+				s, err := xRefTable.propertyString(o)
+				if err != nil {
+					return err
+				}
+				f.Values = append(f.Values, s)
+			}
+			return nil
+		}
+
+	case "PushButton", "Signature":
+		return nil
+
+	}
+
+	if _, ok := v.(StreamDict); ok {
+		// Rich text values are not supported.
+		return nil
+	}
+
+	f.Value, err = xRefTable.propertyString(v)
+
+	return err
+}
+
+// collectFields adds the terminal fields of the field tree rooted at o to ff.
+// FT, Ff and V are inheritable field attributes.
+func (xRefTable *XRefTable) collectFields(o Object, prefix, ft string, flags int, v Object, pageNrs map[int]int, visited IntSet, ff *[]*formField) error {
+
+	if indRef, ok := o.(IndirectRef); ok {
+		if visited[indRef.ObjectNumber.Value()] {
+			return nil
+		}
+		visited[indRef.ObjectNumber.Value()] = true
+	}
+
+	d, err := xRefTable.DereferenceDict(o)
+	if err != nil || d == nil {
+		return err
+	}
+
+	name := prefix
+	if o, found := d.Find("T"); found {
+		t, err := xRefTable.DereferenceText(o)
+		if err != nil {
+			return err
+		}
+		if name != "" {
+			name += "."
+		}
+		name += t
+	}
+
+	if n := d.NameEntry("FT"); n != nil {
+		ft = *n
+	}
+
+	if i := d.IntEntry("Ff"); i != nil {
+		flags = *i
+	}
+
+	if o, found := d.Find("V"); found {
+		v = o
+	}
+
+	var widgets []*Dict
+
+	if st := d.Subtype(); st != nil && *st == "Widget" {
+		widgets = append(widgets, d)
+	}
+
+	fieldKids := false
+
+	if o, found := d.Find("Kids"); found {
+
+		kids, err := xRefTable.DereferenceArray(o)
+		if err != nil {
+			return err
+		}
+
+		if kids != nil {
+			for _, o := range *kids {
+
+				kid, err := xRefTable.DereferenceDict(o)
+				if err != nil {
+					return err
+				}
+
+				if kid == nil {
+					continue
+				}
+
+				// Kids are either fields or widgets.
+				if _, found := kid.Find("T"); !found {
+					widgets = append(widgets, kid)
+					continue
+				}
+
+				fieldKids = true
+				if err = xRefTable.collectFields(o, name, ft, flags, v, pageNrs, visited, ff); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	if fieldKids {
+		return nil
+	}
+
+	f := &formField{Field: Field{Name: name, Type: fieldType(ft, flags)}, d: d, ft: ft, ff: flags, widgets: widgets}
+
+	for _, flag := range fieldFlags {
+		if (flag.ft == "" || flag.ft == ft) && flagSet(flags, flag.bit) {
+			f.Flags = append(f.Flags, flag.name)
+		}
+	}
+
+	for _, w := range widgets {
+		if p := w.IndirectRefEntry("P"); p != nil {
+			f.PageNr = pageNrs[p.ObjectNumber.Value()]
+			break
+		}
+	}
+
+	if err = xRefTable.fieldValue(f, v); err != nil {
+		return err
+	}
+
+	*ff = append(*ff, f)
+
+	return nil
+}
+
+// formFields returns the terminal fields of the AcroForm.
+func (xRefTable *XRefTable) formFields() ([]*formField, error) {
+
+	form, err := xRefTable.acroForm()
+	if err != nil || form == nil {
+		return nil, err
+	}
+
+	o, found := form.Find("Fields")
+	if !found {
+		return nil, nil
+	}
+
+	fields, err := xRefTable.DereferenceArray(o)
+	if err != nil || fields == nil {
+		return nil, err
+	}
+
+	pageNrs, err := xRefTable.pageNumbers()
+	if err != nil {
+		return nil, err
+	}
+
+	var ff []*formField
+
+	visited := IntSet{}
+
+	for _, o := range *fields {
+		if err = xRefTable.collectFields(o, "", "", 0, nil, pageNrs, visited, &ff); err != nil {
+			return nil, err
+		}
+	}
+
+	return ff, nil
+}
+
+// FormFields returns the terminal fields of the AcroForm.
+func FormFields(xRefTable *XRefTable) ([]Field, error) {
+
+	ff, err := xRefTable.formFields()
+	if err != nil {
+		return nil, err
+	}
+
+	fields := []Field{}
+	for _, f := range ff {
+		fields = append(fields, f.Field)
+	}
+
+	return fields, nil
+}
+
+// ListFormFields returns a list of the terminal fields of the AcroForm.
+func ListFormFields(xRefTable *XRefTable) ([]string, error) {
+
+	log.Debug.Println("ListFormFields begin")
+
+	fields, err := FormFields(xRefTable)
+	if err != nil {
+		return nil, err
+	}
+
+	var list []string
+	for _, f := range fields {
+		list = append(list, f.String())
+	}
+
+	log.Debug.Println("ListFormFields end")
+
+	return list, nil
+}
+
+// fillTextField sets the value of a text field.
+func fillTextField(f *formField, value string) error {
+
+	if value == "" {
+		f.d.Delete("V")
+		return nil
+	}
+
+	if i := f.d.IntEntry("MaxLen"); i != nil && utf8.RuneCountInString(value) > *i {
+		return errors.Errorf("field %s: value exceeds %d characters", f.Name, *i)
+	}
+
+	o, err := textObject(value)
+	if err != nil {
+		return err
+	}
+
+	f.d.Update("V", o)
+
+	return nil
+}
+
+// fillButtonField selects the state value of a check box or radio group in all its widgets.
+func (xRefTable *XRefTable) fillButtonField(f *formField, value string) error {
+
+	if value == "" {
+		value = "Off"
+	}
+
+	if value != "Off" && !MemberOf(value, f.Options) {
+		return errors.Errorf("field %s: invalid value %q, use one of Off, %s", f.Name, value, strings.Join(f.Options, ", "))
+	}
+
+	f.d.Update("V", Name(value))
+
+	for _, w := range f.widgets {
+
+		ss, err := xRefTable.appearanceStates(w)
+		if err != nil {
+			return err
+		}
+
+		as := "Off"
+		if MemberOf(value, ss) {
+			as = value
+		}
+
+		w.Update("AS", Name(as))
+	}
+
+	return nil
+}
+
+// fillChoiceField sets the selected values of a combo or list box.
+func fillChoiceField(f *formField, values []string) error {
+
+	if len(values) > 1 && !flagSet(f.ff, fieldMultiSelect) {
+		return errors.Errorf("field %s: multiple selection not allowed", f.Name)
+	}
+
+	edit := f.Type == "ComboBox" && flagSet(f.ff, fieldEdit)
+
+	var (
+		arr Array
+		ii  []int
+	)
+
+	for _, v := range values {
+
+		i := -1
+		for j, opt := range f.Options {
+			if opt == v {
+				i = j
+				break
+			}
+		}
+
+		if i < 0 && !edit {
+			return errors.Errorf("field %s: invalid value %q, use one of %s", f.Name, v, strings.Join(f.Options, ", "))
+		}
+
+		if i >= 0 {
+			ii = append(ii, i)
+		}
+
+		o, err := textObject(v)
+		if err != nil {
+			return err
+		}
+
+		arr = append(arr, o)
+	}
+
+	switch len(arr) {
+	case 0:
+		f.d.Delete("V")
+	case 1:
+		f.d.Update("V", arr[0])
+	default:
+		f.d.Update("V", arr)
+	}
+
+	// The selected option indices are needed for multiple selection.
+	f.d.Delete("I")
+	if len(ii) > 0 && f.Type == "ListBox" {
+		sort.Ints(ii)
+		f.d.Insert("I", NewIntegerArray(ii...))
+	}
+
+	return nil
+}
+
+// FillForm sets the values of the fields of the AcroForm identified by their fully qualified names.
+// The normal appearances of changed text and choice fields get regenerated.
+func FillForm(xRefTable *XRefTable, fields []Field) error {
+
+	log.Debug.Println("FillForm begin")
+
+	form, err := xRefTable.acroForm()
+	if err != nil {
+		return err
+	}
+
+	if form == nil {
+		return errors.New("FillForm: missing form")
+	}
+
+	ff, err := xRefTable.formFields()
+	if err != nil {
+		return err
+	}
+
+	m := map[string]*formField{}
+	for _, f := range ff {
+		m[f.Name] = f
+	}
+
+	ac := &appearanceContext{form: form, fonts: map[string]IndirectRef{}}
+
+	for _, fv := range fields {
+
+		f, ok := m[fv.Name]
+		if !ok {
+			return errors.Errorf("FillForm: unknown field %q", fv.Name)
+		}
+
+		if flagSet(f.ff, fieldReadOnly) {
+			return errors.Errorf("FillForm: field %s is read only", f.Name)
+		}
+
+		if len(fv.Values) > 0 && f.Type != "ListBox" && f.Type != "ComboBox" {
+			return errors.Errorf("FillForm: field %s takes a single value", f.Name)
+		}
+
+		switch f.Type {
+
+		case "Text":
+			if err = fillTextField(f, fv.Value); err == nil {
+				err = xRefTable.generateAppearance(ac, f, []string{fv.Value})
+			}
+
+		case "CheckBox", "RadioButton":
+			err = xRefTable.fillButtonField(f, fv.Value)
+
+		case "ComboBox", "ListBox":
+			values := fv.Values
+			if len(values) == 0 && fv.Value != "" {
+				values = []string{fv.Value}
+			}
+			if err = fillChoiceField(f, values); err == nil {
+				err = xRefTable.generateAppearance(ac, f, values)
+			}
+
+		default:
+			err = errors.Errorf("FillForm: unable to fill %s field %s", f.Type, f.Name)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		log.Debug.Printf("FillForm: %s\n", fv.Name)
+	}
+
+	log.Debug.Println("FillForm end")
+
+	return nil
+}
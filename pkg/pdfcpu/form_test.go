@@ -0,0 +1,265 @@
+/*
+Copyright 2018 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pdfcpu
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// formDemoXRef returns the AcroForm demo extended by a combo box and a multiple selection list box.
+func formDemoXRef(t *testing.T) *XRefTable {
+
+	t.Helper()
+
+	xRefTable, err := CreateAcroFormDemoXRef()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	form, err := xRefTable.acroForm()
+	if err != nil || form == nil {
+		t.Fatalf("missing form: %v\n", err)
+	}
+
+	fields := form.ArrayEntry("Fields")
+
+	for _, d := range []Dict{
+		{
+			"FT":      Name("Ch"),
+			"Ff":      Integer(1<<(fieldCombo-1) | 1<<(fieldEdit-1)),
+			"T":       StringLiteral("Country"),
+			"Opt":     Array{StringLiteral("AT"), StringLiteral("CH"), StringLiteral("DE")},
+			"V":       StringLiteral("CH"),
+			"Subtype": Name("Widget"),
+			"Rect":    NewRectangle(100, 100, 200, 120),
+			"DA":      StringLiteral("/Helvetica 0 Tf 0 0 1 rg"),
+			"Q":       Integer(1),
+		},
+		{
+			"FT":      Name("Ch"),
+			"Ff":      Integer(1 << (fieldMultiSelect - 1)),
+			"T":       StringLiteral("Languages"),
+			"Opt":     Array{Array{StringLiteral("de"), StringLiteral("German")}, Array{StringLiteral("en"), StringLiteral("English")}},
+			"Subtype": Name("Widget"),
+			"Rect":    NewRectangle(100, 200, 200, 250),
+			"DA":      StringLiteral("/Helvetica 10 Tf 0 g"),
+		},
+	} {
+		indRef, err := xRefTable.IndRefForNewObject(d)
+		if err != nil {
+			t.Fatal(err)
+		}
+		*fields = append(*fields, *indRef)
+	}
+
+	form.Update("Fields", *fields)
+
+	return xRefTable
+}
+
+func fieldByName(t *testing.T, xRefTable *XRefTable, name string) Field {
+
+	t.Helper()
+
+	fields, err := FormFields(xRefTable)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, f := range fields {
+		if f.Name == name {
+			return f
+		}
+	}
+
+	t.Fatalf("missing field %s\n", name)
+
+	return Field{}
+}
+
+func TestFormFields(t *testing.T) {
+
+	xRefTable := formDemoXRef(t)
+
+	for _, tt := range []struct {
+		name string
+		want Field
+	}{
+		{"inputField", Field{Name: "inputField", Type: "Text", Value: "Default value"}},
+		{"CheckBox", Field{Name: "CheckBox", Type: "CheckBox", Value: "Yes", Options: []string{"Yes"}}},
+		{"Country", Field{Name: "Country", Type: "ComboBox", Value: "CH", Options: []string{"AT", "CH", "DE"}, Flags: []string{"Edit"}}},
+		{"Languages", Field{Name: "Languages", Type: "ListBox", Options: []string{"de", "en"}, Flags: []string{"MultiSelect"}}},
+		{"Submit", Field{Name: "Submit", Type: "PushButton"}},
+	} {
+		if got := fieldByName(t, xRefTable, tt.name); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: want %+v, got %+v\n", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestFillForm(t *testing.T) {
+
+	xRefTable := formDemoXRef(t)
+
+	err := FillForm(xRefTable, []Field{
+		{Name: "inputField", Value: "Grüße"},
+		{Name: "CheckBox", Value: "Off"},
+		{Name: "Country", Value: "Liechtenstein"},
+		{Name: "Languages", Values: []string{"en", "de"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if f := fieldByName(t, xRefTable, "inputField"); f.Value != "Grüße" {
+		t.Errorf("inputField: got %q\n", f.Value)
+	}
+
+	if f := fieldByName(t, xRefTable, "CheckBox"); f.Value != "Off" {
+		t.Errorf("CheckBox: got %q\n", f.Value)
+	}
+
+	if f := fieldByName(t, xRefTable, "Country"); f.Value != "Liechtenstein" {
+		t.Errorf("Country: got %q\n", f.Value)
+	}
+
+	if f := fieldByName(t, xRefTable, "Languages"); !reflect.DeepEqual(f.Values, []string{"en", "de"}) {
+		t.Errorf("Languages: got %v\n", f.Values)
+	}
+
+	ff, err := xRefTable.formFields()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, f := range ff {
+		switch f.Name {
+		case "CheckBox":
+			if as := f.widgets[0].NameEntry("AS"); as == nil || *as != "Off" {
+				t.Errorf("CheckBox: want appearance state Off, got %v\n", as)
+			}
+		case "Languages":
+			if i := f.d.ArrayEntry("I"); i == nil || i.String() != "[0 1]" {
+				t.Errorf("Languages: want selected indices [0 1], got %v\n", i)
+			}
+		}
+	}
+
+	for _, f := range []Field{
+		{Name: "unknown", Value: "x"},
+		{Name: "CheckBox", Value: "No"},
+		{Name: "Country", Values: []string{"AT", "DE"}},
+		{Name: "Languages", Value: "fr"},
+		{Name: "Submit", Value: "x"},
+	} {
+		if err := FillForm(xRefTable, []Field{f}); err == nil {
+			t.Errorf("want error for %+v\n", f)
+		}
+	}
+}
+
+// appearanceContent returns the content of the normal appearance of the first widget of a field.
+func appearanceContent(t *testing.T, xRefTable *XRefTable, name string) string {
+
+	t.Helper()
+
+	ff, err := xRefTable.formFields()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, f := range ff {
+		if f.Name == name {
+			_, sd, err := xRefTable.normalAppearance(f.widgets[0])
+			if err != nil || sd == nil {
+				t.Fatalf("%s: missing appearance: %v\n", name, err)
+			}
+			return string(sd.Content)
+		}
+	}
+
+	t.Fatalf("missing field %s\n", name)
+
+	return ""
+}
+
+func TestFillFormAppearance(t *testing.T) {
+
+	xRefTable := formDemoXRef(t)
+
+	err := FillForm(xRefTable, []Field{
+		{Name: "inputField", Value: "Grüße"},
+		{Name: "Country", Value: "Liechtenstein"},
+		{Name: "Languages", Values: []string{"en"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		name string
+		want []string
+	}{
+		// WinAnsiEncoding: ü = 0xFC, ß = 0xDF
+		{"inputField", []string{"/Tx BMC", "0 g /F0 12.00 Tf", "(Gr\xfc\xdfe) Tj"}},
+		{"Country", []string{"0 0 1 rg /F0", "(Liechtenstein) Tj"}},
+		{"Languages", []string{"0.600 0.757 0.855 rg", "(German) Tj", "(English) Tj"}},
+	} {
+		s := appearanceContent(t, xRefTable, tt.name)
+		for _, want := range tt.want {
+			if !strings.Contains(s, want) {
+				t.Errorf("%s: missing %q in appearance:\n%s\n", tt.name, want, s)
+			}
+		}
+	}
+
+	// Only the selected option is highlighted.
+	if s := appearanceContent(t, xRefTable, "Languages"); strings.Count(s, " re f") != 1 {
+		t.Errorf("Languages: want one highlighted option:\n%s\n", s)
+	}
+}
+
+func TestParseDefaultAppearance(t *testing.T) {
+
+	for _, tt := range []struct {
+		s    string
+		want defaultAppearance
+	}{
+		{"", defaultAppearance{fontID: "Helv", color: "0 g"}},
+		{"/Helv 12 Tf 0 g", defaultAppearance{fontID: "Helv", fontSize: 12, color: "0 g"}},
+		{"0.5 0 0 rg /TiRo 0 Tf", defaultAppearance{fontID: "TiRo", color: "0.5 0 0 rg"}},
+		{"/Cour 9.5 Tf 0 0 0 1 k", defaultAppearance{fontID: "Cour", fontSize: 9.5, color: "0 0 0 1 k"}},
+	} {
+		if got := parseDefaultAppearance(tt.s); got != tt.want {
+			t.Errorf("%q: want %+v, got %+v\n", tt.s, tt.want, got)
+		}
+	}
+}
+
+func TestWrapText(t *testing.T) {
+
+	l := textLayout{fontName: "Courier", fontSize: 10, w: 64} // 10 chars per line.
+
+	got := l.wrap("one two three four\nsupercalifragilistic")
+	want := []string{"one two", "three four", "supercalif", "ragilistic"}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want %q, got %q\n", want, got)
+	}
+}
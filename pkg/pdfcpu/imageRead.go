@@ -17,13 +17,18 @@ limitations under the License.
 package pdfcpu
 
 import (
+	"bytes"
 	"image"
 	"image/color"
+	"image/jpeg"
 	"image/png"
+	"io"
+	"io/ioutil"
 	"os"
 
 	"github.com/hhrutter/pdfcpu/pkg/filter"
 	"github.com/hhrutter/pdfcpu/tiff"
+	"github.com/pkg/errors"
 )
 
 func createSMaskObject(xRefTable *XRefTable, buf []byte, w, h int) (*IndirectRef, error) {
@@ -292,3 +297,130 @@ func ReadTIFFFile(xRefTable *XRefTable, fileName string) (*StreamDict, error) {
 
 	return imgToImageDict(xRefTable, img)
 }
+
+// adobeJPEG returns true for JPEG data carrying an Adobe APP14 marker segment.
+func adobeJPEG(buf []byte) bool {
+
+	// Skip SOI and walk the marker segments preceding the scan data.
+	for i := 2; i+4 <= len(buf); {
+
+		if buf[i] != 0xFF {
+			return false
+		}
+
+		marker := buf[i+1]
+		if marker == 0xFF {
+			// Fill byte.
+			i++
+			continue
+		}
+
+		if marker == 0xDA || marker == 0xD9 {
+			// Start of scan or end of image.
+			return false
+		}
+
+		l := int(buf[i+2])<<8 | int(buf[i+3])
+
+		if marker == 0xEE && l >= 7 && i+9 <= len(buf) && string(buf[i+4:i+9]) == "Adobe" {
+			return true
+		}
+
+		i += 2 + l
+	}
+
+	return false
+}
+
+// jpegImageDict generates a PDF image object embedding JPEG data as is using the DCTDecode filter.
+func jpegImageDict(buf []byte) (*StreamDict, error) {
+
+	c, err := jpeg.DecodeConfig(bytes.NewReader(buf))
+	if err != nil {
+		return nil, err
+	}
+
+	var cs string
+
+	switch c.ColorModel {
+
+	case color.GrayModel:
+		cs = DeviceGrayCS
+
+	case color.YCbCrModel:
+		cs = DeviceRGBCS
+
+	case color.CMYKModel:
+		cs = DeviceCMYKCS
+
+	default:
+		return nil, ErrUnsupportedColorSpace
+	}
+
+	sd := &StreamDict{
+		Dict: Dict(
+			map[string]Object{
+				"Type":             Name("XObject"),
+				"Subtype":          Name("Image"),
+				"Width":            Integer(c.Width),
+				"Height":           Integer(c.Height),
+				"BitsPerComponent": Integer(8),
+				"ColorSpace":       Name(cs),
+				"Filter":           Name(filter.DCT),
+				"Length":           Integer(len(buf)),
+			},
+		),
+		Raw:            buf,
+		FilterPipeline: []PDFFilter{{Name: filter.DCT, DecodeParms: nil}}}
+
+	// Adobe applications write CMYK JPEGs with inverted components.
+	if cs == DeviceCMYKCS && adobeJPEG(buf) {
+		sd.Insert("Decode", NewNumberArray(1, 0, 1, 0, 1, 0, 1, 0))
+	}
+
+	streamLength := int64(len(buf))
+	sd.StreamLength = &streamLength
+
+	return sd, nil
+}
+
+// ReadJPEGFile generates a PDF image object for a JPEG file without re-encoding the image data.
+func ReadJPEGFile(fileName string) (*StreamDict, error) {
+
+	buf, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+
+	return jpegImageDict(buf)
+}
+
+// ReadImage generates a PDF image object for JPEG, PNG or TIFF data read from r.
+// JPEG data gets embedded as is.
+func ReadImage(xRefTable *XRefTable, r io.Reader) (*StreamDict, error) {
+
+	buf, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	_, format, err := image.DecodeConfig(bytes.NewReader(buf))
+	if err != nil {
+		return nil, err
+	}
+
+	if format == "jpeg" {
+		return jpegImageDict(buf)
+	}
+
+	if format != "png" && format != "tiff" {
+		return nil, errors.Errorf("ReadImage: unsupported image format: %s", format)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(buf))
+	if err != nil {
+		return nil, err
+	}
+
+	return imgToImageDict(xRefTable, img)
+}
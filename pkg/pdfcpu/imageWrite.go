@@ -21,8 +21,7 @@ import (
 	"image"
 	"image/color"
 	"image/png"
-	"io/ioutil"
-	"os"
+	"io"
 
 	"github.com/hhrutter/pdfcpu/pkg/filter"
 	"github.com/hhrutter/pdfcpu/pkg/log"
@@ -250,46 +249,48 @@ func softMask(xRefTable *XRefTable, d *StreamDict, w, h, objNr int) ([]byte, err
 	return sm, nil
 }
 
-func writeImgToJPG(filename string, sd *StreamDict) (string, error) {
+func writeImgToJPG(create FileCreator, filename string, sd *StreamDict) (string, error) {
 
 	filename += ".jpg"
 	//fmt.Printf("writing %s\n", filename)
 
 	// TODO WriteJPG(fileName, img)
 
-	return filename, ioutil.WriteFile(filename, sd.Raw, os.ModePerm)
+	return filename, WriteOutputFile(create, filename, func(w io.Writer) error {
+		_, err := w.Write(sd.Raw)
+		return err
+	})
 }
 
-func writeImgToJPX(filename string, sd *StreamDict) (string, error) {
+func writeImgToJPX(create FileCreator, filename string, sd *StreamDict) (string, error) {
 
 	filename += ".jpx"
 	//fmt.Printf("writing %s\n", filename)
 
 	// TODO WriteJPX(fileName, img)
 
-	return filename, ioutil.WriteFile(filename, sd.Raw, os.ModePerm)
+	return filename, WriteOutputFile(create, filename, func(w io.Writer) error {
+		_, err := w.Write(sd.Raw)
+		return err
+	})
 }
 
-func writeImgToTIFF(filename string, img *image.CMYK) (string, error) {
+func writeImgToTIFF(create FileCreator, filename string, img *image.CMYK) (string, error) {
 
 	filename += ".tif"
 	fmt.Printf("writing %s\n", filename)
 
-	f, err := os.Create(filename)
-	if err != nil {
-		return "", err
-	}
-	defer f.Close()
-
 	// TODO softmask handling.
-	err = tiff.Encode(f, img, nil)
+	err := WriteOutputFile(create, filename, func(w io.Writer) error {
+		return tiff.Encode(w, img, nil)
+	})
 
 	fmt.Println("tif written")
 
 	return filename, err
 }
 
-func writeDeviceCMYKToTIFF(filename string, im *PDFImage) (string, error) {
+func writeDeviceCMYKToTIFF(create FileCreator, filename string, im *PDFImage) (string, error) {
 
 	b := im.sd.Content
 
@@ -308,25 +309,21 @@ func writeDeviceCMYKToTIFF(filename string, im *PDFImage) (string, error) {
 		}
 	}
 
-	return writeImgToTIFF(filename, img)
+	return writeImgToTIFF(create, filename, img)
 }
 
-func writeImgToPNG(filename string, img image.Image) (string, error) {
+func writeImgToPNG(create FileCreator, filename string, img image.Image) (string, error) {
 
 	filename += ".png"
 
-	f, err := os.Create(filename)
-	if err != nil {
-		return "", err
-	}
-	defer f.Close()
-
 	//fmt.Println("png written")
 
-	return filename, png.Encode(f, img)
+	return filename, WriteOutputFile(create, filename, func(w io.Writer) error {
+		return png.Encode(w, img)
+	})
 }
 
-func writeDeviceGrayToPNG(filename string, im *PDFImage) (string, error) {
+func writeDeviceGrayToPNG(create FileCreator, filename string, im *PDFImage) (string, error) {
 
 	b := im.sd.Content
 
@@ -357,10 +354,10 @@ func writeDeviceGrayToPNG(filename string, im *PDFImage) (string, error) {
 		}
 	}
 
-	return writeImgToPNG(filename, img)
+	return writeImgToPNG(create, filename, img)
 }
 
-func writeDeviceRGBToPNG(filename string, im *PDFImage) (string, error) {
+func writeDeviceRGBToPNG(create FileCreator, filename string, im *PDFImage) (string, error) {
 
 	b := im.sd.Content
 
@@ -387,7 +384,7 @@ func writeDeviceRGBToPNG(filename string, im *PDFImage) (string, error) {
 		}
 	}
 
-	return writeImgToPNG(filename, img)
+	return writeImgToPNG(create, filename, img)
 }
 
 func ensureDeviceRGBCS(xRefTable *XRefTable, o Object) bool {
@@ -405,7 +402,7 @@ func ensureDeviceRGBCS(xRefTable *XRefTable, o Object) bool {
 	return false
 }
 
-func writeCalRGBToPNG(filename string, im *PDFImage) (string, error) {
+func writeCalRGBToPNG(create FileCreator, filename string, im *PDFImage) (string, error) {
 
 	b := im.sd.Content
 
@@ -428,10 +425,10 @@ func writeCalRGBToPNG(filename string, im *PDFImage) (string, error) {
 			i += 3
 		}
 	}
-	return writeImgToPNG(filename, img)
+	return writeImgToPNG(create, filename, img)
 }
 
-func writeICCBased(xRefTable *XRefTable, filename string, im *PDFImage, cs Array) (string, error) {
+func writeICCBased(xRefTable *XRefTable, create FileCreator, filename string, im *PDFImage, cs Array) (string, error) {
 
 	//  Any ICC profile >= ICC.1:2004:10 is sufficient for any PDF version <= 1.7
 	//  If the embedded ICC profile version is newer than the one used by the Reader, substitute with Alternate color space.
@@ -462,21 +459,21 @@ func writeICCBased(xRefTable *XRefTable, filename string, im *PDFImage, cs Array
 	switch n {
 	case 1:
 		// Gray
-		return writeDeviceGrayToPNG(filename, im)
+		return writeDeviceGrayToPNG(create, filename, im)
 
 	case 3:
 		// RGB
-		return writeDeviceRGBToPNG(filename, im)
+		return writeDeviceRGBToPNG(create, filename, im)
 
 	case 4:
 		// CMYK
-		return writeDeviceCMYKToTIFF(filename, im)
+		return writeDeviceCMYKToTIFF(create, filename, im)
 	}
 
 	return "", nil
 }
 
-func writeIndexedRGBToPNG(filename string, im *PDFImage, lookup []byte) (string, error) {
+func writeIndexedRGBToPNG(create FileCreator, filename string, im *PDFImage, lookup []byte) (string, error) {
 
 	b := im.sd.Content
 
@@ -504,10 +501,10 @@ func writeIndexedRGBToPNG(filename string, im *PDFImage, lookup []byte) (string,
 		}
 	}
 
-	return writeImgToPNG(filename, img)
+	return writeImgToPNG(create, filename, img)
 }
 
-func writeIndexedCMYKToTIFF(filename string, im *PDFImage, lookup []byte) (string, error) {
+func writeIndexedCMYKToTIFF(create FileCreator, filename string, im *PDFImage, lookup []byte) (string, error) {
 
 	b := im.sd.Content
 
@@ -531,10 +528,10 @@ func writeIndexedCMYKToTIFF(filename string, im *PDFImage, lookup []byte) (strin
 		}
 	}
 
-	return writeImgToTIFF(filename, img)
+	return writeImgToTIFF(create, filename, img)
 }
 
-func writeIndexedNameCS(filename string, im *PDFImage, cs Name, maxInd int, lookup []byte) (string, error) {
+func writeIndexedNameCS(create FileCreator, filename string, im *PDFImage, cs Name, maxInd int, lookup []byte) (string, error) {
 
 	switch cs {
 
@@ -544,7 +541,7 @@ func writeIndexedNameCS(filename string, im *PDFImage, cs Name, maxInd int, look
 			return "", errors.Errorf("writeIndexedNameCS: objNr=%d, corrupt DeviceRGB lookup table\n", im.objNr)
 		}
 
-		return writeIndexedRGBToPNG(filename, im, lookup)
+		return writeIndexedRGBToPNG(create, filename, im, lookup)
 
 	case DeviceCMYKCS:
 
@@ -552,7 +549,7 @@ func writeIndexedNameCS(filename string, im *PDFImage, cs Name, maxInd int, look
 			return "", errors.Errorf("writeIndexedNameCS: objNr=%d, corrupt DeviceCMYK lookup table\n", im.objNr)
 		}
 
-		return writeIndexedCMYKToTIFF(filename, im, lookup)
+		return writeIndexedCMYKToTIFF(create, filename, im, lookup)
 	}
 
 	log.Info.Printf("writeIndexedNameCS: objNr=%d, unsupported base colorspace %s\n", im.objNr, cs.String())
@@ -560,7 +557,7 @@ func writeIndexedNameCS(filename string, im *PDFImage, cs Name, maxInd int, look
 	return "", ErrUnsupportedColorSpace
 }
 
-func writeIndexedArrayCS(xRefTable *XRefTable, filename string, im *PDFImage, csa Array, maxInd int, lookup []byte) (string, error) {
+func writeIndexedArrayCS(xRefTable *XRefTable, create FileCreator, filename string, im *PDFImage, csa Array, maxInd int, lookup []byte) (string, error) {
 
 	b := im.sd.Content
 
@@ -600,16 +597,16 @@ func writeIndexedArrayCS(xRefTable *XRefTable, filename string, im *PDFImage, cs
 					i++
 				}
 			}
-			return writeImgToPNG(filename, img)
+			return writeImgToPNG(create, filename, img)
 
 		case 3:
 			// RGB
-			return writeIndexedRGBToPNG(filename, im, lookup)
+			return writeIndexedRGBToPNG(create, filename, im, lookup)
 
 		case 4:
 			// CMYK
 			log.Debug.Printf("writeIndexedArrayCS: CMYK objNr=%d w=%d h=%d bpc=%d buflen=%d\n", im.objNr, im.w, im.h, im.bpc, len(b))
-			return writeIndexedCMYKToTIFF(filename, im, lookup)
+			return writeIndexedCMYKToTIFF(create, filename, im, lookup)
 		}
 	}
 
@@ -618,7 +615,7 @@ func writeIndexedArrayCS(xRefTable *XRefTable, filename string, im *PDFImage, cs
 	return "", ErrUnsupportedColorSpace
 }
 
-func writeIndexed(xRefTable *XRefTable, filename string, im *PDFImage, cs Array) (string, error) {
+func writeIndexed(xRefTable *XRefTable, create FileCreator, filename string, im *PDFImage, cs Array) (string, error) {
 
 	// Identify the base color space.
 	baseCS, _ := xRefTable.Dereference(cs[1])
@@ -650,16 +647,16 @@ func writeIndexed(xRefTable *XRefTable, filename string, im *PDFImage, cs Array)
 
 	switch cs := baseCS.(type) {
 	case Name:
-		return writeIndexedNameCS(filename, im, cs, maxInd.Value(), lookup)
+		return writeIndexedNameCS(create, filename, im, cs, maxInd.Value(), lookup)
 
 	case Array:
-		return writeIndexedArrayCS(xRefTable, filename, im, cs, maxInd.Value(), lookup)
+		return writeIndexedArrayCS(xRefTable, create, filename, im, cs, maxInd.Value(), lookup)
 	}
 
 	return "", nil
 }
 
-func writeFlateEncodedImage(xRefTable *XRefTable, filename string, sd *StreamDict, objNr int) (string, error) {
+func writeFlateEncodedImage(xRefTable *XRefTable, create FileCreator, filename string, sd *StreamDict, objNr int) (string, error) {
 
 	pdfImage, err := pdfImage(xRefTable, sd, objNr)
 	if err != nil {
@@ -679,13 +676,13 @@ func writeFlateEncodedImage(xRefTable *XRefTable, filename string, sd *StreamDic
 		switch cs {
 
 		case DeviceGrayCS:
-			fn, err = writeDeviceGrayToPNG(filename, pdfImage)
+			fn, err = writeDeviceGrayToPNG(create, filename, pdfImage)
 
 		case DeviceRGBCS:
-			fn, err = writeDeviceRGBToPNG(filename, pdfImage)
+			fn, err = writeDeviceRGBToPNG(create, filename, pdfImage)
 
 		case DeviceCMYKCS:
-			fn, err = writeDeviceCMYKToTIFF(filename, pdfImage)
+			fn, err = writeDeviceCMYKToTIFF(create, filename, pdfImage)
 
 		default:
 			log.Info.Printf("writeFlateEncodedImage: objNr=%d, unsupported name colorspace %s\n", objNr, cs.String())
@@ -698,13 +695,13 @@ func writeFlateEncodedImage(xRefTable *XRefTable, filename string, sd *StreamDic
 		switch csn {
 
 		case CalRGBCS:
-			fn, err = writeCalRGBToPNG(filename, pdfImage)
+			fn, err = writeCalRGBToPNG(create, filename, pdfImage)
 
 		case ICCBasedCS:
-			fn, err = writeICCBased(xRefTable, filename, pdfImage, cs)
+			fn, err = writeICCBased(xRefTable, create, filename, pdfImage, cs)
 
 		case IndexedCS:
-			fn, err = writeIndexed(xRefTable, filename, pdfImage, cs)
+			fn, err = writeIndexed(xRefTable, create, filename, pdfImage, cs)
 
 		default:
 			log.Info.Printf("writeFlateEncodedImage: objNr=%d, unsupported array colorspace %s\n", objNr, csn)
@@ -719,12 +716,18 @@ func writeFlateEncodedImage(xRefTable *XRefTable, filename string, sd *StreamDic
 
 // WriteImage writes a PDF image object to disk.
 func WriteImage(xRefTable *XRefTable, filename string, sd *StreamDict, objNr int) (string, error) {
+	return WriteImageTo(xRefTable, createFile, filename, sd, objNr)
+}
+
+// WriteImageTo writes a PDF image object to the file create returns for filename extended by the image type.
+// It returns the resulting file name.
+func WriteImageTo(xRefTable *XRefTable, create FileCreator, filename string, sd *StreamDict, objNr int) (string, error) {
 
 	switch sd.FilterPipeline[0].Name {
 
 	case filter.Flate, filter.CCITTFax:
 		// If color space is CMYK then write .tif else write .png
-		fn, err := writeFlateEncodedImage(xRefTable, filename, sd, objNr)
+		fn, err := writeFlateEncodedImage(xRefTable, create, filename, sd, objNr)
 		if err != nil {
 			if err == ErrUnsupportedColorSpace {
 				log.Info.Printf("Image obj#%d uses an unsupported color space. Please see the logfile for details.\n", objNr)
@@ -734,10 +737,10 @@ func WriteImage(xRefTable *XRefTable, filename string, sd *StreamDict, objNr int
 		return fn, err
 
 	case filter.DCT:
-		return writeImgToJPG(filename, sd)
+		return writeImgToJPG(create, filename, sd)
 
 	case filter.JPX:
-		return writeImgToJPX(filename, sd)
+		return writeImgToJPX(create, filename, sd)
 
 	}
 
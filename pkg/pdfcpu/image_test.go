@@ -17,6 +17,7 @@ limitations under the License.
 package pdfcpu
 
 import (
+	"bytes"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -415,3 +416,28 @@ func TestReadTIFFWritePNG(t *testing.T) {
 	}
 
 }
+
+func TestAdobeJPEG(t *testing.T) {
+
+	soi := []byte{0xFF, 0xD8}
+	app0 := []byte{0xFF, 0xE0, 0x00, 0x07, 'J', 'F', 'I', 'F', 0x00}
+	app14 := []byte{0xFF, 0xEE, 0x00, 0x0E, 'A', 'd', 'o', 'b', 'e', 0x00, 0x64, 0x00, 0x00, 0x00, 0x00, 0x02}
+	sos := []byte{0xFF, 0xDA, 0x00, 0x02}
+
+	join := func(bb ...[]byte) []byte { return bytes.Join(bb, nil) }
+
+	for _, tt := range []struct {
+		name string
+		buf  []byte
+		want bool
+	}{
+		{"JFIF", join(soi, app0, sos), false},
+		{"Adobe", join(soi, app0, app14, sos), true},
+		{"Adobe after scan", join(soi, app0, sos, app14), false},
+		{"truncated", join(soi, app14[:6]), false},
+	} {
+		if got := adobeJPEG(tt.buf); got != tt.want {
+			t.Errorf("%s: want %t, got %t\n", tt.name, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,357 @@
+/*
+Copyright 2018 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pdfcpu
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/hhrutter/pdfcpu/pkg/filter"
+	"github.com/hhrutter/pdfcpu/pkg/log"
+	"github.com/hhrutter/pdfcpu/pkg/types"
+	"github.com/pkg/errors"
+)
+
+// image positions on a page
+const (
+	posCenter = iota
+	posTopLeft
+	posTopCenter
+	posTopRight
+	posLeft
+	posRight
+	posBottomLeft
+	posBottomCenter
+	posBottomRight
+)
+
+var imagePositions = map[string]int{
+	"c":  posCenter,
+	"tl": posTopLeft,
+	"tc": posTopCenter,
+	"tr": posTopRight,
+	"l":  posLeft,
+	"r":  posRight,
+	"bl": posBottomLeft,
+	"bc": posBottomCenter,
+	"br": posBottomRight,
+}
+
+// Import represents the command details for the command "ImportImages".
+type Import struct {
+	paperSize   string    // name of the paper size, empty for pages sized by the image.
+	paperDim    types.Dim // paper dimensions in portrait orientation.
+	orientation int       // paper orientation: auto, portrait or landscape.
+	dpi         int       // image resolution used for pages sized by the image.
+	margin      float64   // space around the image in points.
+	pos         int       // position of the image within the margins.
+}
+
+func (imp Import) String() string {
+
+	s := "image size"
+	if imp.paperSize != "" {
+		s = imp.paperSize
+	}
+
+	return fmt.Sprintf("page size=%s, dpi=%d, margin=%.0f, pos=%d", s, imp.dpi, imp.margin, imp.pos)
+}
+
+// DefaultImport returns the default configuration for importing images:
+// Each page takes the size of its image at 72 dpi.
+func DefaultImport() *Import {
+	return &Import{dpi: 72, pos: posCenter}
+}
+
+func parseImportError() error {
+	return errors.New("Invalid import description string. Please consult pdfcpu help import!\n")
+}
+
+func parseImportPaperSize(v string, imp *Import) error {
+
+	dim, orientation, err := parsePaperSize(v)
+	if err != nil {
+		return err
+	}
+
+	imp.paperSize, imp.paperDim, imp.orientation = v, dim, orientation
+
+	return nil
+}
+
+func parseDPI(v string) (int, error) {
+
+	dpi, err := strconv.Atoi(v)
+	if err != nil || dpi <= 0 {
+		return 0, errors.New("dpi must be a positive integer")
+	}
+
+	return dpi, nil
+}
+
+func parseImagePosition(v string) (int, error) {
+
+	pos, ok := imagePositions[v]
+	if !ok {
+		return 0, errors.New("Valid positions: c, tl, tc, tr, l, r, bl, bc, br")
+	}
+
+	return pos, nil
+}
+
+// ParseImportDetails parses an ImportImages command string into an internal structure.
+func ParseImportDetails(s string) (*Import, error) {
+
+	imp := DefaultImport()
+
+	if s == "" {
+		return imp, nil
+	}
+
+	var err error
+
+	for _, s := range strings.Split(s, ",") {
+
+		ss1 := strings.Split(s, ":")
+		if len(ss1) != 2 {
+			return nil, parseImportError()
+		}
+
+		k := strings.TrimSpace(ss1[0])
+		v := strings.TrimSpace(ss1[1])
+
+		switch k {
+		case "f": // paper size
+			err = parseImportPaperSize(v, imp)
+
+		case "d": // dpi
+			imp.dpi, err = parseDPI(v)
+
+		case "m": // margin
+			imp.margin, err = parseMargin(v)
+
+		case "p": // position
+			imp.pos, err = parseImagePosition(v)
+
+		default:
+			err = parseImportError()
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return imp, nil
+}
+
+// CreateContext creates a Context for a new PDF file without any pages.
+func CreateContext(config *Configuration) (*Context, error) {
+
+	if config == nil {
+		config = NewDefaultConfiguration()
+	}
+
+	xRefTable, err := createXRefTableWithRootDict()
+	if err != nil {
+		return nil, err
+	}
+
+	xRefTable.ValidationMode = config.ValidationMode
+
+	rootDict, err := xRefTable.Catalog()
+	if err != nil {
+		return nil, err
+	}
+
+	pagesDict := Dict(
+		map[string]Object{
+			"Type":  Name("Pages"),
+			"Count": Integer(0),
+			"Kids":  Array{},
+		},
+	)
+
+	indRef, err := xRefTable.IndRefForNewObject(pagesDict)
+	if err != nil {
+		return nil, err
+	}
+
+	rootDict.Insert("Pages", *indRef)
+
+	return &Context{
+		Configuration: config,
+		XRefTable:     xRefTable,
+		Read:          newReadContext(nil, 0),
+		Optimize:      newOptimizationContext(),
+		Write:         NewWriteContext(config.Eol),
+	}, nil
+}
+
+// imagePage returns the page dimensions and the region covered by an image of w x h pixels.
+func (imp *Import) imagePage(w, h int) (types.Dim, types.Rectangle) {
+
+	m := imp.margin
+
+	if imp.paperSize == "" {
+		// The page takes the size of the image at the configured resolution.
+		iw := float64(w) * 72 / float64(imp.dpi)
+		ih := float64(h) * 72 / float64(imp.dpi)
+		dim := types.Dim{Width: iw + 2*m, Height: ih + 2*m}
+		return dim, types.Rectangle{LL: types.Point{X: m, Y: m}, UR: types.Point{X: m + iw, Y: m + ih}}
+	}
+
+	dim := imp.paperDim
+
+	landscape := imp.orientation == orientationLandscape ||
+		imp.orientation == orientationAuto && w > h
+
+	if landscape {
+		dim.Width, dim.Height = dim.Height, dim.Width
+	}
+
+	// Scale the image to fit within the margins keeping its aspect ratio.
+	aw, ah := dim.Width-2*m, dim.Height-2*m
+	if aw <= 0 || ah <= 0 {
+		aw, ah = dim.Width, dim.Height
+		m = 0
+	}
+
+	s := aw / float64(w)
+	if ah/float64(h) < s {
+		s = ah / float64(h)
+	}
+
+	iw, ih := float64(w)*s, float64(h)*s
+
+	// Horizontal alignment.
+	x := m + (aw-iw)/2
+	switch imp.pos {
+	case posTopLeft, posLeft, posBottomLeft:
+		x = m
+	case posTopRight, posRight, posBottomRight:
+		x = m + aw - iw
+	}
+
+	// Vertical alignment.
+	y := m + (ah-ih)/2
+	switch imp.pos {
+	case posTopLeft, posTopCenter, posTopRight:
+		y = m + ah - ih
+	case posBottomLeft, posBottomCenter, posBottomRight:
+		y = m
+	}
+
+	return dim, types.Rectangle{LL: types.Point{X: x, Y: y}, UR: types.Point{X: x + iw, Y: y + ih}}
+}
+
+// createImagePage creates a page displaying the image object imgIndRef of w x h pixels.
+func createImagePage(xRefTable *XRefTable, parent IndirectRef, imgIndRef IndirectRef, w, h int, imp *Import) (*IndirectRef, error) {
+
+	dim, r := imp.imagePage(w, h)
+
+	sd := &StreamDict{
+		Dict:           NewDict(),
+		Content:        []byte(fmt.Sprintf("q %.2f 0 0 %.2f %.2f %.2f cm /Im0 Do Q", r.Width(), r.Height(), r.LL.X, r.LL.Y)),
+		FilterPipeline: []PDFFilter{{Name: filter.Flate, DecodeParms: nil}},
+	}
+	sd.InsertName("Filter", filter.Flate)
+
+	if err := encodeStream(sd); err != nil {
+		return nil, err
+	}
+
+	contents, err := xRefTable.IndRefForNewObject(*sd)
+	if err != nil {
+		return nil, err
+	}
+
+	d := NewDict()
+	d.InsertName("Type", "Page")
+	d.Insert("Parent", parent)
+	d.Insert("MediaBox", NewRectangle(0, 0, dim.Width, dim.Height))
+	d.Insert("Resources", Dict(map[string]Object{"XObject": Dict(map[string]Object{"Im0": imgIndRef})}))
+	d.Insert("Contents", *contents)
+
+	return xRefTable.IndRefForNewObject(d)
+}
+
+// ImportImages appends a page for each image read from imgs to the page tree.
+func ImportImages(xRefTable *XRefTable, imgs []io.Reader, imp *Import) error {
+
+	log.Debug.Printf("ImportImages begin: %s\n", imp)
+
+	pagesIndRef, err := xRefTable.Pages()
+	if err != nil {
+		return err
+	}
+
+	if pagesIndRef == nil {
+		return errors.New("ImportImages: missing page tree")
+	}
+
+	pagesDict, err := xRefTable.DereferenceDict(*pagesIndRef)
+	if err != nil {
+		return err
+	}
+
+	if pagesDict == nil {
+		return errors.New("ImportImages: corrupt page tree")
+	}
+
+	for _, r := range imgs {
+
+		sd, err := ReadImage(xRefTable, r)
+		if err != nil {
+			return err
+		}
+
+		w := sd.IntEntry("Width")
+		h := sd.IntEntry("Height")
+		if w == nil || h == nil || *w <= 0 || *h <= 0 {
+			return errors.New("ImportImages: corrupt image dimensions")
+		}
+
+		imgIndRef, err := xRefTable.IndRefForNewObject(*sd)
+		if err != nil {
+			return err
+		}
+
+		indRef, err := createImagePage(xRefTable, *pagesIndRef, *imgIndRef, *w, *h, imp)
+		if err != nil {
+			return err
+		}
+
+		kids := pagesDict.ArrayEntry("Kids")
+		if kids == nil {
+			return errors.New("ImportImages: corrupt page tree root")
+		}
+
+		err = xRefTable.insertKid(*pagesIndRef, len(*kids), *indRef, 1)
+		if err != nil {
+			return err
+		}
+
+		xRefTable.PageCount++
+	}
+
+	log.Debug.Println("ImportImages end")
+
+	return nil
+}
@@ -17,10 +17,14 @@ limitations under the License.
 package pdfcpu
 
 import (
+	"encoding/json"
+	"fmt"
 	"strings"
 	"time"
 
 	"github.com/hhrutter/pdfcpu/pkg/log"
+	"github.com/hhrutter/pdfcpu/pkg/types"
+	"github.com/pkg/errors"
 )
 
 func csvSafeString(s string) string {
@@ -104,6 +108,11 @@ func ensureInfoDict(ctx *Context) error {
 		d.InsertString("CreationDate", now)
 		d.InsertString("ModDate", now)
 
+		// Keep the document info dict equivalent to existing XMP metadata.
+		if err := seedInfoDict(ctx.XRefTable, d); err != nil {
+			return err
+		}
+
 		indRef, err := ctx.IndRefForNewObject(d)
 		if err != nil {
 			return err
@@ -111,7 +120,7 @@ func ensureInfoDict(ctx *Context) error {
 
 		ctx.Info = indRef
 
-		return nil
+		return syncMetadata(ctx.XRefTable)
 	}
 
 	dict, err := ctx.DereferenceDict(*ctx.Info)
@@ -128,7 +137,7 @@ func ensureInfoDict(ctx *Context) error {
 	dict.Update("ModDate", StringLiteral(now))
 	dict.Update("Producer", StringLiteral(PDFCPULongVersion))
 
-	return nil
+	return syncMetadata(ctx.XRefTable)
 }
 
 // Write the document info object for this PDF file.
@@ -161,3 +170,355 @@ func writeDocumentInfoDict(ctx *Context) error {
 
 	return nil
 }
+
+// PageInfo represents the boundaries and rotation of a page.
+type PageInfo struct {
+	Number   int              `json:"number"`
+	MediaBox *types.Rectangle `json:"mediaBox"`
+	CropBox  *types.Rectangle `json:"cropBox,omitempty"`
+	BleedBox *types.Rectangle `json:"bleedBox,omitempty"`
+	TrimBox  *types.Rectangle `json:"trimBox,omitempty"`
+	ArtBox   *types.Rectangle `json:"artBox,omitempty"`
+	Rotate   int              `json:"rotate"`
+}
+
+// boxArray returns r as [llx lly urx ury] or nil for a missing box.
+func boxArray(r *types.Rectangle) []float64 {
+
+	if r == nil {
+		return nil
+	}
+
+	return []float64{r.LL.X, r.LL.Y, r.UR.X, r.UR.Y}
+}
+
+// MarshalJSON encodes the page boundaries as [llx lly urx ury] like annotation rectangles.
+func (p PageInfo) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Number   int       `json:"number"`
+		MediaBox []float64 `json:"mediaBox"`
+		CropBox  []float64 `json:"cropBox,omitempty"`
+		BleedBox []float64 `json:"bleedBox,omitempty"`
+		TrimBox  []float64 `json:"trimBox,omitempty"`
+		ArtBox   []float64 `json:"artBox,omitempty"`
+		Rotate   int       `json:"rotate"`
+	}{
+		Number:   p.Number,
+		MediaBox: boxArray(p.MediaBox),
+		CropBox:  boxArray(p.CropBox),
+		BleedBox: boxArray(p.BleedBox),
+		TrimBox:  boxArray(p.TrimBox),
+		ArtBox:   boxArray(p.ArtBox),
+		Rotate:   p.Rotate,
+	})
+}
+
+// EncryptionInfo represents the encryption in use.
+type EncryptionInfo struct {
+	Algorithm string `json:"algorithm"` // AES or RC4
+	KeyLength int    `json:"keyLength"` // in bits
+	V         int    `json:"v"`         // Algorithm as in the encrypt dict
+	R         int    `json:"r"`         // Revision of the standard security handler
+}
+
+// PDFInfo represents the essential facts of a PDF file.
+type PDFInfo struct {
+	FileName     string          `json:"fileName,omitempty"`
+	Version      string          `json:"version"`
+	PageCount    int             `json:"pageCount"`
+	Pages        []PageInfo      `json:"pages"`
+	Title        string          `json:"title,omitempty"`
+	Author       string          `json:"author,omitempty"`
+	Subject      string          `json:"subject,omitempty"`
+	Keywords     string          `json:"keywords,omitempty"`
+	Creator      string          `json:"creator,omitempty"`
+	Producer     string          `json:"producer,omitempty"`
+	CreationDate string          `json:"creationDate,omitempty"`
+	ModDate      string          `json:"modificationDate,omitempty"`
+	Tagged       bool            `json:"tagged"`
+	Linearized   bool            `json:"linearized"`
+	Encrypted    bool            `json:"encrypted"`
+	Encryption   *EncryptionInfo `json:"encryption,omitempty"`
+	Permissions  []string        `json:"permissions"`
+	Attachments  int             `json:"attachments"`
+	Form         bool            `json:"form"`
+}
+
+func boxRect(xRefTable *XRefTable, a *Array) (*types.Rectangle, error) {
+
+	if a == nil {
+		return nil, nil
+	}
+
+	if len(*a) != 4 {
+		return nil, errors.Errorf("boxRect: corrupt rectangle %s", a)
+	}
+
+	r := rect(xRefTable, *a)
+
+	// Normalize to lower left and upper right corner.
+	if r.LL.X > r.UR.X {
+		r.LL.X, r.UR.X = r.UR.X, r.LL.X
+	}
+	if r.LL.Y > r.UR.Y {
+		r.LL.Y, r.UR.Y = r.UR.Y, r.LL.Y
+	}
+
+	return &r, nil
+}
+
+func pageBox(xRefTable *XRefTable, pageDict *Dict, key string) (*types.Rectangle, error) {
+
+	o, found := pageDict.Find(key)
+	if !found {
+		return nil, nil
+	}
+
+	a, err := xRefTable.DereferenceArray(o)
+	if err != nil {
+		return nil, err
+	}
+
+	return boxRect(xRefTable, a)
+}
+
+func pageInfo(xRefTable *XRefTable, pageDict *Dict, pAttrs InheritedPageAttrs, pageNr int) (*PageInfo, error) {
+
+	mediaBox, err := boxRect(xRefTable, pAttrs.mediaBox)
+	if err != nil {
+		return nil, err
+	}
+
+	cropBox, err := boxRect(xRefTable, pAttrs.cropBox)
+	if err != nil {
+		return nil, err
+	}
+
+	p := PageInfo{Number: pageNr, MediaBox: mediaBox, CropBox: cropBox, Rotate: int(pAttrs.rotate)}
+
+	// Bleed, trim and art box are not inheritable.
+	for _, b := range []struct {
+		key string
+		r   **types.Rectangle
+	}{
+		{"BleedBox", &p.BleedBox},
+		{"TrimBox", &p.TrimBox},
+		{"ArtBox", &p.ArtBox},
+	} {
+		if *b.r, err = pageBox(xRefTable, pageDict, b.key); err != nil {
+			return nil, err
+		}
+	}
+
+	return &p, nil
+}
+
+// pageInfos collects page infos walking the page tree in document order.
+func pageInfos(xRefTable *XRefTable, indRef IndirectRef, pAttrs InheritedPageAttrs, pages *[]PageInfo) error {
+
+	dict, err := xRefTable.DereferenceDict(indRef)
+	if err != nil || dict == nil {
+		return err
+	}
+
+	// pAttrs is a copy and takes effect for this subtree only.
+	err = xRefTable.checkInheritedPageAttrs(dict, &pAttrs)
+	if err != nil {
+		return err
+	}
+
+	kids := dict.ArrayEntry("Kids")
+	if kids == nil {
+		p, err := pageInfo(xRefTable, dict, pAttrs, len(*pages)+1)
+		if err != nil {
+			return err
+		}
+		*pages = append(*pages, *p)
+		return nil
+	}
+
+	for _, o := range *kids {
+
+		if o == nil {
+			continue
+		}
+
+		indRef, ok := o.(IndirectRef)
+		if !ok {
+			return errors.Errorf("pageInfos: corrupt page node dict")
+		}
+
+		err = pageInfos(xRefTable, indRef, pAttrs, pages)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (info *PDFInfo) setInfoDictEntries(xRefTable *XRefTable) error {
+
+	if xRefTable.Info == nil {
+		return nil
+	}
+
+	dict, err := xRefTable.DereferenceDict(*xRefTable.Info)
+	if err != nil || dict == nil {
+		return err
+	}
+
+	for _, e := range []struct {
+		key string
+		s   *string
+	}{
+		{"Title", &info.Title},
+		{"Author", &info.Author},
+		{"Subject", &info.Subject},
+		{"Keywords", &info.Keywords},
+		{"Creator", &info.Creator},
+		{"Producer", &info.Producer},
+		{"CreationDate", &info.CreationDate},
+		{"ModDate", &info.ModDate},
+	} {
+		o, found := dict.Find(e.key)
+		if !found {
+			continue
+		}
+		if *e.s, err = xRefTable.DereferenceText(o); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// DocumentInfo returns the essential facts of a PDF file for a validated Context.
+func DocumentInfo(ctx *Context) (*PDFInfo, error) {
+
+	info := &PDFInfo{
+		FileName:    ctx.Read.FileName,
+		Version:     ctx.VersionString(),
+		PageCount:   ctx.PageCount,
+		Tagged:      ctx.Tagged,
+		Linearized:  ctx.Read.Linearized,
+		Encrypted:   ctx.E != nil,
+		Permissions: Permissions(ctx),
+	}
+
+	if ctx.E != nil {
+		alg := "RC4"
+		if ctx.AES4Streams {
+			alg = "AES"
+		}
+		info.Encryption = &EncryptionInfo{Algorithm: alg, KeyLength: ctx.E.L, V: ctx.E.V, R: ctx.E.R}
+	}
+
+	err := info.setInfoDictEntries(ctx.XRefTable)
+	if err != nil {
+		return nil, err
+	}
+
+	root, err := ctx.Pages()
+	if err != nil {
+		return nil, err
+	}
+
+	err = pageInfos(ctx.XRefTable, *root, InheritedPageAttrs{}, &info.Pages)
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := AttachList(ctx.XRefTable)
+	if err != nil {
+		return nil, err
+	}
+	info.Attachments = len(list)
+
+	rootDict, err := ctx.Catalog()
+	if err != nil {
+		return nil, err
+	}
+	_, info.Form = rootDict.Find("AcroForm")
+
+	return info, nil
+}
+
+func boxString(r *types.Rectangle) string {
+
+	// Relaxed validation tolerates pages without media box.
+	if r == nil {
+		return "missing"
+	}
+
+	return fmt.Sprintf("[%.2f %.2f %.2f %.2f] %.2f x %.2f", r.LL.X, r.LL.Y, r.UR.X, r.UR.Y, r.Width(), r.Height())
+}
+
+// Lines returns a human readable representation of info.
+func (info *PDFInfo) Lines() []string {
+
+	var list []string
+
+	add := func(format string, a ...interface{}) {
+		list = append(list, fmt.Sprintf(format, a...))
+	}
+
+	if info.FileName != "" {
+		add("%20s: %s", "File name", info.FileName)
+	}
+	add("%20s: %s", "PDF version", info.Version)
+	add("%20s: %d", "Page count", info.PageCount)
+
+	for _, p := range info.Pages {
+		add("%20s: %s rotate=%d", fmt.Sprintf("Page %d MediaBox", p.Number), boxString(p.MediaBox), p.Rotate)
+		for _, b := range []struct {
+			name string
+			r    *types.Rectangle
+		}{
+			{"CropBox", p.CropBox},
+			{"BleedBox", p.BleedBox},
+			{"TrimBox", p.TrimBox},
+			{"ArtBox", p.ArtBox},
+		} {
+			if b.r != nil {
+				add("%20s: %s", b.name, boxString(b.r))
+			}
+		}
+	}
+
+	for _, e := range []struct{ k, v string }{
+		{"Title", info.Title},
+		{"Author", info.Author},
+		{"Subject", info.Subject},
+		{"Keywords", info.Keywords},
+		{"Creator", info.Creator},
+		{"Producer", info.Producer},
+		{"Creation date", info.CreationDate},
+		{"Modification date", info.ModDate},
+	} {
+		if e.v != "" {
+			add("%20s: %s", e.k, e.v)
+		}
+	}
+
+	add("%20s: %t", "Tagged", info.Tagged)
+	add("%20s: %t", "Linearized", info.Linearized)
+	add("%20s: %t", "Encrypted", info.Encrypted)
+
+	if e := info.Encryption; e != nil {
+		add("%20s: %s %d bit (V=%d R=%d)", "Encryption", e.Algorithm, e.KeyLength, e.V, e.R)
+	}
+
+	for i, s := range info.Permissions {
+		k := ""
+		if i == 0 {
+			k = "Permissions"
+		}
+		add("%20s: %s", k, s)
+	}
+
+	add("%20s: %d", "Attachments", info.Attachments)
+	add("%20s: %t", "Form", info.Form)
+
+	return list
+}
@@ -0,0 +1,240 @@
+/*
+Copyright 2018 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pdfcpu
+
+import (
+	"container/list"
+	"math"
+	"sort"
+
+	"github.com/hhrutter/pdfcpu/pkg/log"
+	"github.com/pkg/errors"
+)
+
+// Lazy loading parses objects on first access instead of reading in the whole file upfront.
+//
+// Parsed objects stay in memory once loaded.
+// Stream content is kept in a cache bounded by Configuration.StreamCacheSize.
+// The least recently used content gets released and reloaded from the file when needed again.
+// Stream content that has been replaced after loading is never released.
+//
+// Validation parses every object, so lazy loading bounds the memory held by stream content
+// but not the memory held by the object graph.
+// Lazily loaded Contexts need to be closed in order to release the underlying file.
+
+// cachedStream represents stream content loaded from the file.
+type cachedStream struct {
+	objNr int
+	raw   []byte // The content as loaded, used to detect replaced content.
+}
+
+// objectCache loads objects on demand and keeps track of loaded stream content.
+type objectCache struct {
+	ctx     *Context
+	maxSize int64                 // Max size of stream content in memory.
+	size    int64                 // Current size of stream content in memory.
+	lru     *list.List            // Stream content, least recently used first.
+	streams map[int]*list.Element // Stream content by object number.
+}
+
+func newObjectCache(ctx *Context, maxSize int64) *objectCache {
+	return &objectCache{
+		ctx:     ctx,
+		maxSize: maxSize,
+		lru:     list.New(),
+		streams: map[int]*list.Element{},
+	}
+}
+
+// recordFingerprint saves the original state of an object just loaded from the file for incremental updates.
+func (c *objectCache) recordFingerprint(objNr int, o Object) {
+
+	fps := c.ctx.Read.fingerprints
+	if fps == nil {
+		return
+	}
+
+	if _, found := fps[objNr]; found {
+		return
+	}
+
+	if fp, ok := objectFingerprint(o); ok {
+		fps[objNr] = fp
+	}
+}
+
+// object returns the object of entry including any stream content and loads it if necessary.
+func (c *objectCache) object(objNr int, entry *XRefTableEntry) (Object, error) {
+
+	if entry.Compressed {
+		err := decompressXRefTableEntry(c.ctx.XRefTable, objNr, entry)
+		if err != nil {
+			return nil, err
+		}
+		c.recordFingerprint(objNr, entry.Object)
+		return entry.Object, nil
+	}
+
+	if entry.Object == nil {
+
+		if entry.Offset == nil || *entry.Offset == 0 {
+			return nil, nil
+		}
+
+		log.Debug.Printf("objectCache: loading object %d\n", objNr)
+
+		o, err := ParseObject(c.ctx, *entry.Offset, objNr, *entry.Generation)
+		if err != nil {
+			return nil, errors.Wrapf(err, "objectCache: problem dereferencing object %d", objNr)
+		}
+
+		entry.Object = o
+
+		if _, ok := o.(StreamDict); !ok {
+			c.recordFingerprint(objNr, o)
+			return o, nil
+		}
+	}
+
+	sd, ok := entry.Object.(StreamDict)
+	if !ok {
+		return entry.Object, nil
+	}
+
+	if sd.Raw != nil {
+		c.touch(objNr)
+		return sd, nil
+	}
+
+	log.Debug.Printf("objectCache: loading stream content of object %d\n", objNr)
+
+	if _, err := loadEncodedStreamContent(c.ctx, &sd); err != nil {
+		return nil, errors.Wrapf(err, "objectCache: problem dereferencing stream %d", objNr)
+	}
+
+	err := saveDecodedStreamContent(c.ctx, &sd, objNr, *entry.Generation, c.ctx.DecodeAllStreams)
+	if err != nil {
+		return nil, err
+	}
+
+	entry.Object = sd
+	c.recordFingerprint(objNr, sd)
+	c.add(objNr, sd.Raw)
+
+	return sd, nil
+}
+
+// touch marks the stream content of object #objNr as most recently used.
+func (c *objectCache) touch(objNr int) {
+	if e, found := c.streams[objNr]; found {
+		c.lru.MoveToBack(e)
+	}
+}
+
+// add registers freshly loaded stream content and releases least recently used content if the cache is full.
+func (c *objectCache) add(objNr int, raw []byte) {
+
+	if len(raw) == 0 {
+		return
+	}
+
+	c.streams[objNr] = c.lru.PushBack(&cachedStream{objNr: objNr, raw: raw})
+	c.size += int64(len(raw))
+
+	// Always keep the content just loaded.
+	for c.size > c.maxSize && c.lru.Len() > 1 {
+		e := c.lru.Front()
+		cs := e.Value.(*cachedStream)
+		c.lru.Remove(e)
+		delete(c.streams, cs.objNr)
+		c.size -= int64(len(cs.raw))
+		c.release(cs)
+	}
+}
+
+// release frees the stream content of a cached stream unless it has been replaced in the meantime.
+func (c *objectCache) release(cs *cachedStream) {
+
+	entry, found := c.ctx.Find(cs.objNr)
+	if !found || entry.Free {
+		return
+	}
+
+	sd, ok := entry.Object.(StreamDict)
+	if !ok || len(sd.Raw) != len(cs.raw) || &sd.Raw[0] != &cs.raw[0] {
+		return
+	}
+
+	log.Debug.Printf("objectCache: releasing stream content of object %d\n", cs.objNr)
+
+	sd.Raw = nil
+	sd.Content = nil
+	entry.Object = sd
+}
+
+// loadAll loads all objects including stream content into memory and turns off lazy loading.
+// This is needed before the underlying file gets overwritten or the object numbers get changed.
+func (c *objectCache) loadAll() error {
+
+	c.maxSize = math.MaxInt64
+
+	var keys []int
+	for k := range c.ctx.Table {
+		keys = append(keys, k)
+	}
+	sort.Ints(keys)
+
+	for _, objNr := range keys {
+		entry := c.ctx.Table[objNr]
+		if entry.Free {
+			continue
+		}
+		if _, err := c.object(objNr, entry); err != nil {
+			return err
+		}
+	}
+
+	c.ctx.cache = nil
+
+	return nil
+}
+
+// ensureLoaded loads all objects of a lazily loaded Context into memory.
+func ensureLoaded(ctx *Context) error {
+
+	if ctx.cache == nil {
+		return nil
+	}
+
+	return ctx.cache.loadAll()
+}
+
+// Close releases the stream cache and the file kept open by ReadPDFFile for lazy loading.
+// Objects not loaded so far are not available anymore.
+func (ctx *Context) Close() error {
+
+	ctx.cache = nil
+
+	if ctx.Read == nil || ctx.Read.file == nil {
+		return nil
+	}
+
+	f := ctx.Read.file
+	ctx.Read.file = nil
+
+	return f.Close()
+}
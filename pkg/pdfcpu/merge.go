@@ -293,14 +293,30 @@ func mergeDuplicateObjNumberIntSets(ctxSource, ctxDest *Context) {
 }
 
 // MergeXRefTables merges Context ctxSource into ctxDest by appending its page tree.
-func MergeXRefTables(ctxSource, ctxDest *Context) (err error) {
+// The outlines, named destinations, form fields and page labels of ctxSource are merged too.
+func MergeXRefTables(ctxSource, ctxDest *Context) error {
+	return mergeXRefTables(ctxSource, ctxDest, func() error {
+		return appendSourcePageTreeToDestPageTree(ctxSource, ctxDest)
+	}, true)
+}
+
+// mergeXRefTables merges Context ctxSource into ctxDest using linkPageTrees for attaching the source page tree.
+// If withCatalog is true the document level structures of the source catalog get merged as well.
+func mergeXRefTables(ctxSource, ctxDest *Context, linkPageTrees func() error, withCatalog bool) (err error) {
+
+	// Renumbering requires all source objects in memory.
+	err = ensureLoaded(ctxSource)
+	if err != nil {
+		return err
+	}
 
 	// Sweep over ctxSource cross ref table and ensure valid object numbers in ctxDest's space.
 	patchSourceObjectNumbers(ctxSource, ctxDest)
 
-	// Append ctxSource pageTree to ctxDest pageTree.
-	log.Debug.Println("appendSourcePageTreeToDestPageTree")
-	err = appendSourcePageTreeToDestPageTree(ctxSource, ctxDest)
+	pageCountDest := ctxDest.PageCount
+
+	// Link ctxSource pageTree into ctxDest pageTree.
+	err = linkPageTrees()
 	if err != nil {
 		return err
 	}
@@ -309,6 +325,13 @@ func MergeXRefTables(ctxSource, ctxDest *Context) (err error) {
 	log.Debug.Println("appendSourceObjectsToDest")
 	appendSourceObjectsToDest(ctxSource, ctxDest)
 
+	if withCatalog {
+		err = mergeCatalogs(ctxSource, ctxDest, pageCountDest)
+		if err != nil {
+			return err
+		}
+	}
+
 	// Mark source's root object as free.
 	err = ctxDest.DeleteObject(int(ctxSource.Root.ObjectNumber))
 	if err != nil {
@@ -332,3 +355,48 @@ func MergeXRefTables(ctxSource, ctxDest *Context) (err error) {
 
 	return nil
 }
+
+// zippedPageNumbers returns the page order for interleaving n1 pages with the n2 pages following them.
+func zippedPageNumbers(n1, n2 int, reverse bool) []int {
+
+	pageNrs := make([]int, 0, n1+n2)
+
+	for i := 1; i <= n1 || i <= n2; i++ {
+		if i <= n1 {
+			pageNrs = append(pageNrs, i)
+		}
+		if i <= n2 {
+			j := n1 + i
+			if reverse {
+				j = n1 + n2 + 1 - i
+			}
+			pageNrs = append(pageNrs, j)
+		}
+	}
+
+	return pageNrs
+}
+
+// ZipXRefTables merges Context ctxSource into ctxDest interleaving their pages starting with the first page of ctxDest.
+// If reverse is true the pages of ctxSource are taken back to front like the back sides of a scanned stack of paper.
+// The remaining pages of the longer document are appended.
+// Outlines, named destinations and form fields get merged like for MergeXRefTables.
+// Page labels get removed since they do not fit the interleaved pages.
+func ZipXRefTables(ctxSource, ctxDest *Context, reverse bool) error {
+
+	n1, n2 := ctxDest.PageCount, ctxSource.PageCount
+
+	err := MergeXRefTables(ctxSource, ctxDest)
+	if err != nil {
+		return err
+	}
+
+	rootDict, err := ctxDest.Catalog()
+	if err != nil {
+		return err
+	}
+
+	rootDict.Delete("PageLabels")
+
+	return ctxDest.reorderPages(zippedPageNumbers(n1, n2, reverse))
+}
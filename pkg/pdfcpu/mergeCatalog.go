@@ -0,0 +1,749 @@
+/*
+Copyright 2018 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pdfcpu
+
+import (
+	"fmt"
+
+	"github.com/hhrutter/pdfcpu/pkg/log"
+	"github.com/pkg/errors"
+)
+
+// The functions in this file merge the document level structures of a source catalog into the dest catalog.
+// They run after the source objects have been appended to ctxDest and therefore resolve everything using the dest xRefTable.
+
+// uniqueName returns k extended by the smallest numeric suffix not taken yet.
+func uniqueName(k string, taken func(string) bool) string {
+	for i := 2; ; i++ {
+		s := fmt.Sprintf("%s_%d", k, i)
+		if !taken(s) {
+			return s
+		}
+	}
+}
+
+// textObject returns a text string object for s, UTF-16BE encoded if s is not plain ASCII.
+// Text strings are string literals so that they get encrypted along with all other string literals of an encrypted document.
+func textObject(s string) (Object, error) {
+
+	for _, r := range s {
+		if r > 0x7E {
+			s = EncodeUTF16String(s)
+			break
+		}
+	}
+
+	s1, err := Escape(s)
+	if err != nil {
+		return nil, err
+	}
+
+	return StringLiteral(*s1), nil
+}
+
+// nameTreeKey returns the key of a name tree entry the way the validator caches it.
+func nameTreeKey(o Object) (string, error) {
+
+	switch k := o.(type) {
+	case StringLiteral:
+		return k.Value(), nil
+	case HexLiteral:
+		return k.Value(), nil
+	}
+
+	return "", errors.Errorf("nameTreeKey: corrupt key: %v", o)
+}
+
+// nameTreeEntries calls f for each entry of the name tree node o.
+func (xRefTable *XRefTable) nameTreeEntries(o Object, f func(k string, v Object) error) error {
+
+	dict, err := xRefTable.DereferenceDict(o)
+	if err != nil || dict == nil {
+		return err
+	}
+
+	if o, found := dict.Find("Kids"); found {
+
+		kids, err := xRefTable.DereferenceArray(o)
+		if err != nil || kids == nil {
+			return err
+		}
+
+		for _, kid := range *kids {
+			err = xRefTable.nameTreeEntries(kid, f)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	o, found := dict.Find("Names")
+	if !found {
+		return nil
+	}
+
+	names, err := xRefTable.DereferenceArray(o)
+	if err != nil || names == nil {
+		return err
+	}
+
+	for i := 0; i+1 < len(*names); i += 2 {
+
+		o, err := xRefTable.Dereference((*names)[i])
+		if err != nil {
+			return err
+		}
+
+		k, err := nameTreeKey(o)
+		if err != nil {
+			return err
+		}
+
+		err = f(k, (*names)[i+1])
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// destRenames records the named destinations of the source that had to be renamed because of collisions.
+type destRenames struct {
+	strings map[string]string // name tree keys of "Dests"
+	names   map[string]string // keys of the PDF 1.1 root entry "Dests"
+}
+
+// mergeNameTrees adds all entries of the source name trees to the corresponding dest name trees.
+func mergeNameTrees(xRefTable *XRefTable, srcRootDict *Dict, renames *destRenames) error {
+
+	o, found := srcRootDict.Find("Names")
+	if !found {
+		return nil
+	}
+
+	srcNamesDict, err := xRefTable.DereferenceDict(o)
+	if err != nil || srcNamesDict == nil {
+		return err
+	}
+
+	for name, o := range *srcNamesDict {
+
+		if xRefTable.Names[name] == nil {
+			err = xRefTable.LocateNameTree(name, true)
+			if err != nil {
+				return err
+			}
+		}
+
+		tree := xRefTable.Names[name]
+
+		taken := func(k string) bool {
+			_, found := tree.Value(k)
+			return found
+		}
+
+		err = xRefTable.nameTreeEntries(o, func(k string, v Object) error {
+
+			if taken(k) {
+				k1 := uniqueName(k, taken)
+				log.Debug.Printf("mergeNameTrees: %s: renaming %s to %s\n", name, k, k1)
+				if name == "Dests" {
+					renames.strings[k] = k1
+				}
+				k = k1
+			}
+
+			// Never add an existing key, this would free the object graph of its value.
+			return tree.Add(xRefTable, k, v)
+		})
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return xRefTable.BindNameTrees()
+}
+
+// mergeDests adds the PDF 1.1 named destinations of the source to the dest root entry "Dests".
+func mergeDests(xRefTable *XRefTable, srcRootDict, destRootDict *Dict, renames *destRenames) error {
+
+	o, found := srcRootDict.Find("Dests")
+	if !found {
+		return nil
+	}
+
+	o1, found := destRootDict.Find("Dests")
+	if !found {
+		destRootDict.Insert("Dests", o)
+		return nil
+	}
+
+	srcDests, err := xRefTable.DereferenceDict(o)
+	if err != nil || srcDests == nil {
+		return err
+	}
+
+	destDests, err := xRefTable.DereferenceDict(o1)
+	if err != nil || destDests == nil {
+		return err
+	}
+
+	taken := func(k string) bool {
+		_, found := destDests.Find(k)
+		return found
+	}
+
+	for k, v := range *srcDests {
+		if taken(k) {
+			k1 := uniqueName(k, taken)
+			log.Debug.Printf("mergeDests: renaming %s to %s\n", k, k1)
+			renames.names[k] = k1
+			k = k1
+		}
+		destDests.Insert(k, v)
+	}
+
+	return nil
+}
+
+// renamedDestination returns the replacement for a named destination or nil if it was not renamed.
+func (renames destRenames) renamedDestination(o Object) Object {
+
+	switch o := o.(type) {
+
+	case Name:
+		if k, ok := renames.names[o.Value()]; ok {
+			return Name(k)
+		}
+
+	case StringLiteral:
+		if k, ok := renames.strings[o.Value()]; ok {
+			return StringLiteral(k)
+		}
+
+	case HexLiteral:
+		if k, ok := renames.strings[o.Value()]; ok {
+			return StringLiteral(k)
+		}
+
+	}
+
+	return nil
+}
+
+// renameDestinations replaces references to renamed named destinations within the direct object o.
+func (renames destRenames) renameDestinations(o Object) {
+
+	switch o := o.(type) {
+
+	case Dict:
+		renames.renameDestinationsInDict(o)
+
+	case StreamDict:
+		renames.renameDestinationsInDict(o.Dict)
+
+	case Array:
+		for _, v := range o {
+			renames.renameDestinations(v)
+		}
+
+	}
+}
+
+func (renames destRenames) renameDestinationsInDict(dict Dict) {
+
+	// Outline items and link annotations use "Dest", GoTo actions use "D".
+	goTo := false
+	if s := dict.NameEntry("S"); s != nil && *s == "GoTo" {
+		goTo = true
+	}
+
+	for k, v := range dict {
+
+		if k == "Dest" || (goTo && k == "D") {
+			if v1 := renames.renamedDestination(v); v1 != nil {
+				dict.Update(k, v1)
+				continue
+			}
+		}
+
+		renames.renameDestinations(v)
+	}
+}
+
+// mergeOutlines appends the top level outline items of the source to the dest outlines.
+func mergeOutlines(xRefTable *XRefTable, srcRootDict, destRootDict *Dict) error {
+
+	o, found := srcRootDict.Find("Outlines")
+	if !found {
+		return nil
+	}
+
+	srcOutlines, err := xRefTable.DereferenceDict(o)
+	if err != nil || srcOutlines == nil {
+		return err
+	}
+
+	first := srcOutlines.IndirectRefEntry("First")
+	last := srcOutlines.IndirectRefEntry("Last")
+	if first == nil || last == nil {
+		return nil
+	}
+
+	indRef := destRootDict.IndirectRefEntry("Outlines")
+	if indRef == nil {
+		destRootDict.Update("Outlines", o)
+		return nil
+	}
+
+	destOutlines, err := xRefTable.DereferenceDict(*indRef)
+	if err != nil {
+		return err
+	}
+
+	if destOutlines == nil {
+		return errors.New("mergeOutlines: corrupt outline dict")
+	}
+
+	// Reparent the top level source items.
+	var dict *Dict
+	visited := IntSet{}
+
+	for ir := first; ir != nil; ir = dict.IndirectRefEntry("Next") {
+
+		if visited[ir.ObjectNumber.Value()] {
+			return errors.Errorf("mergeOutlines: circular outline item list at obj#%d", ir.ObjectNumber)
+		}
+		visited[ir.ObjectNumber.Value()] = true
+
+		dict, err = xRefTable.DereferenceDict(*ir)
+		if err != nil {
+			return err
+		}
+
+		if dict == nil {
+			return errors.Errorf("mergeOutlines: corrupt outline item obj#%d", ir.ObjectNumber)
+		}
+
+		dict.Update("Parent", *indRef)
+	}
+
+	destLast := destOutlines.IndirectRefEntry("Last")
+
+	if destLast == nil {
+		destOutlines.Update("First", *first)
+	} else {
+
+		lastDict, err := xRefTable.DereferenceDict(*destLast)
+		if err != nil {
+			return err
+		}
+
+		firstDict, err := xRefTable.DereferenceDict(*first)
+		if err != nil {
+			return err
+		}
+
+		if lastDict == nil || firstDict == nil {
+			return errors.New("mergeOutlines: corrupt outline item")
+		}
+
+		lastDict.Update("Next", *first)
+		firstDict.Update("Prev", *destLast)
+	}
+
+	destOutlines.Update("Last", *last)
+
+	// Count holds the number of visible outline items.
+	if c := srcOutlines.IntEntry("Count"); c != nil && *c > 0 {
+		n := *c
+		if c := destOutlines.IntEntry("Count"); c != nil && *c > 0 {
+			n += *c
+		}
+		destOutlines.Update("Count", Integer(n))
+	}
+
+	return nil
+}
+
+// fieldNames returns the names of the top level fields in fields.
+func fieldNames(xRefTable *XRefTable, fields Array) (map[string]bool, error) {
+
+	m := map[string]bool{}
+
+	for _, o := range fields {
+
+		dict, err := xRefTable.DereferenceDict(o)
+		if err != nil {
+			return nil, err
+		}
+
+		if dict == nil {
+			continue
+		}
+
+		o, found := dict.Find("T")
+		if !found {
+			continue
+		}
+
+		t, err := xRefTable.DereferenceText(o)
+		if err != nil {
+			return nil, err
+		}
+
+		m[t] = true
+	}
+
+	return m, nil
+}
+
+// mergeFields appends the top level fields of the source form to fields, renaming fields whose names are taken.
+// Variable text fields inherit the default appearance of the source form if it differs from the dest form.
+func mergeFields(xRefTable *XRefTable, srcFields, fields Array, da Object) (Array, error) {
+
+	names, err := fieldNames(xRefTable, fields)
+	if err != nil {
+		return nil, err
+	}
+
+	taken := func(k string) bool { return names[k] }
+
+	for _, o := range srcFields {
+
+		dict, err := xRefTable.DereferenceDict(o)
+		if err != nil {
+			return nil, err
+		}
+
+		if dict == nil {
+			continue
+		}
+
+		if o, found := dict.Find("T"); found {
+
+			t, err := xRefTable.DereferenceText(o)
+			if err != nil {
+				return nil, err
+			}
+
+			if names[t] {
+				t1 := uniqueName(t, taken)
+				log.Debug.Printf("mergeFields: renaming field %s to %s\n", t, t1)
+				o, err := textObject(t1)
+				if err != nil {
+					return nil, err
+				}
+				dict.Update("T", o)
+				t = t1
+			}
+
+			names[t] = true
+		}
+
+		if da != nil {
+			if _, found := dict.Find("DA"); !found {
+				if ft := dict.NameEntry("FT"); ft == nil || *ft == "Tx" || *ft == "Ch" {
+					dict.Insert("DA", da)
+				}
+			}
+		}
+
+		fields = append(fields, o)
+	}
+
+	return fields, nil
+}
+
+// mergeDefaultResources adds the source form resources missing in the dest form resources.
+func mergeDefaultResources(xRefTable *XRefTable, srcForm, destForm *Dict) error {
+
+	o, found := srcForm.Find("DR")
+	if !found {
+		return nil
+	}
+
+	o1, found := destForm.Find("DR")
+	if !found {
+		destForm.Insert("DR", o)
+		return nil
+	}
+
+	srcDR, err := xRefTable.DereferenceDict(o)
+	if err != nil || srcDR == nil {
+		return err
+	}
+
+	destDR, err := xRefTable.DereferenceDict(o1)
+	if err != nil || destDR == nil {
+		return err
+	}
+
+	for k, v := range *srcDR {
+
+		o, found := destDR.Find(k)
+		if !found {
+			destDR.Insert(k, v)
+			continue
+		}
+
+		srcRes, err := xRefTable.DereferenceDict(v)
+		if err != nil {
+			return err
+		}
+
+		destRes, err := xRefTable.DereferenceDict(o)
+		if err != nil {
+			return err
+		}
+
+		if srcRes == nil || destRes == nil {
+			continue
+		}
+
+		// Insert does not overwrite resources already in use by the dest form.
+		for name, res := range *srcRes {
+			destRes.Insert(name, res)
+		}
+	}
+
+	return nil
+}
+
+// mergeAcroForms adds the fields of the source form to the dest form.
+func mergeAcroForms(xRefTable *XRefTable, srcRootDict, destRootDict *Dict) error {
+
+	o, found := srcRootDict.Find("AcroForm")
+	if !found {
+		return nil
+	}
+
+	o1, found := destRootDict.Find("AcroForm")
+	if !found {
+		destRootDict.Insert("AcroForm", o)
+		return nil
+	}
+
+	srcForm, err := xRefTable.DereferenceDict(o)
+	if err != nil || srcForm == nil {
+		return err
+	}
+
+	destForm, err := xRefTable.DereferenceDict(o1)
+	if err != nil || destForm == nil {
+		return err
+	}
+
+	var srcFields, fields Array
+
+	if o, found := srcForm.Find("Fields"); found {
+		arr, err := xRefTable.DereferenceArray(o)
+		if err != nil {
+			return err
+		}
+		if arr != nil {
+			srcFields = *arr
+		}
+	}
+
+	if o, found := destForm.Find("Fields"); found {
+		arr, err := xRefTable.DereferenceArray(o)
+		if err != nil {
+			return err
+		}
+		if arr != nil {
+			fields = append(fields, *arr...)
+		}
+	}
+
+	srcDA, _ := srcForm.Find("DA")
+	destDA, found := destForm.Find("DA")
+
+	var da Object
+	if !found {
+		if srcDA != nil {
+			destForm.Insert("DA", srcDA)
+		}
+	} else if srcDA != nil && srcDA.PDFString() != destDA.PDFString() {
+		da = srcDA
+	}
+
+	fields, err = mergeFields(xRefTable, srcFields, fields, da)
+	if err != nil {
+		return err
+	}
+
+	destForm.Update("Fields", fields)
+
+	// An XFA form describes the dest fields only.
+	destForm.Delete("XFA")
+
+	if b := srcForm.BooleanEntry("NeedAppearances"); b != nil && *b {
+		destForm.Update("NeedAppearances", Boolean(true))
+	}
+
+	if f := srcForm.IntEntry("SigFlags"); f != nil {
+		flags := *f
+		if f := destForm.IntEntry("SigFlags"); f != nil {
+			flags |= *f
+		}
+		destForm.Update("SigFlags", Integer(flags))
+	}
+
+	if o, found := srcForm.Find("CO"); found {
+
+		srcCO, err := xRefTable.DereferenceArray(o)
+		if err != nil {
+			return err
+		}
+
+		var co Array
+		if o, found := destForm.Find("CO"); found {
+			arr, err := xRefTable.DereferenceArray(o)
+			if err != nil {
+				return err
+			}
+			if arr != nil {
+				co = append(co, *arr...)
+			}
+		}
+
+		if srcCO != nil {
+			destForm.Update("CO", append(co, *srcCO...))
+		}
+	}
+
+	return mergeDefaultResources(xRefTable, srcForm, destForm)
+}
+
+// mergePageLabels appends the page labels of the source shifted by the dest page count prior to merging.
+// Unlabeled pages keep their page numbers.
+func mergePageLabels(xRefTable *XRefTable, srcRootDict, destRootDict *Dict, pageCountDest int) error {
+
+	srcLabels, err := pageLabels(xRefTable, srcRootDict)
+	if err != nil {
+		return err
+	}
+
+	labels, err := pageLabels(xRefTable, destRootDict)
+	if err != nil {
+		return err
+	}
+
+	if len(srcLabels) == 0 && len(labels) == 0 {
+		return nil
+	}
+
+	if len(labels) == 0 {
+		labels = []pageLabel{{0, Dict{"S": Name("D")}}}
+	}
+
+	if len(srcLabels) == 0 {
+		srcLabels = []pageLabel{{0, Dict{"S": Name("D"), "St": Integer(pageCountDest + 1)}}}
+	}
+
+	var entries []numberEntry
+
+	for _, l := range labels {
+		if l.pageIndex < pageCountDest {
+			entries = append(entries, numberEntry{l.pageIndex, l.dict})
+		}
+	}
+
+	for _, l := range srcLabels {
+		entries = append(entries, numberEntry{pageCountDest + l.pageIndex, l.dict})
+	}
+
+	indRef, err := xRefTable.createNumberTree(entries)
+	if err != nil {
+		return err
+	}
+
+	destRootDict.Update("PageLabels", *indRef)
+
+	return nil
+}
+
+// mergeCatalogs merges outlines, named destinations, forms and page labels of the source into ctxDest.
+func mergeCatalogs(ctxSource, ctxDest *Context, pageCountDest int) error {
+
+	log.Debug.Println("mergeCatalogs begin")
+
+	xRefTable := ctxDest.XRefTable
+
+	srcRootDict, err := xRefTable.DereferenceDict(*ctxSource.Root)
+	if err != nil {
+		return err
+	}
+
+	destRootDict, err := xRefTable.Catalog()
+	if err != nil {
+		return err
+	}
+
+	if srcRootDict == nil || destRootDict == nil {
+		return errors.New("mergeCatalogs: missing root dict")
+	}
+
+	renames := destRenames{strings: map[string]string{}, names: map[string]string{}}
+
+	err = mergeNameTrees(xRefTable, srcRootDict, &renames)
+	if err != nil {
+		return err
+	}
+
+	err = mergeDests(xRefTable, srcRootDict, destRootDict, &renames)
+	if err != nil {
+		return err
+	}
+
+	if len(renames.strings) > 0 || len(renames.names) > 0 {
+		for objNr, entry := range ctxSource.Table {
+			if objNr == 0 || entry.Free || entry.Object == nil {
+				continue
+			}
+			renames.renameDestinations(entry.Object)
+		}
+	}
+
+	err = mergeOutlines(xRefTable, srcRootDict, destRootDict)
+	if err != nil {
+		return err
+	}
+
+	err = mergeAcroForms(xRefTable, srcRootDict, destRootDict)
+	if err != nil {
+		return err
+	}
+
+	err = mergePageLabels(xRefTable, srcRootDict, destRootDict, pageCountDest)
+	if err != nil {
+		return err
+	}
+
+	// The logical structure and optional content are not merged.
+	destRootDict.Delete("StructTreeRoot")
+	destRootDict.Delete("OCProperties")
+
+	log.Debug.Println("mergeCatalogs end")
+
+	return nil
+}
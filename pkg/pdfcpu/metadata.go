@@ -0,0 +1,287 @@
+/*
+Copyright 2018 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pdfcpu
+
+import (
+	"strings"
+
+	"github.com/hhrutter/pdfcpu/pkg/log"
+	"github.com/hhrutter/pdfcpu/pkg/xmp"
+	"github.com/pkg/errors"
+)
+
+func isDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// metadataDate converts a PDF date string into an XMP date.
+func metadataDate(s string) string {
+
+	s = strings.TrimPrefix(s, "D:")
+
+	if len(s) < 4 || !isDigits(s[:4]) {
+		return ""
+	}
+
+	d, s := s[:4], s[4:]
+
+	// month, day, hour, minute, second
+	seps := []string{"-", "-", "T", ":", ":"}
+
+	i := 0
+	for ; i < len(seps) && len(s) >= 2 && isDigits(s[:2]); i++ {
+		d += seps[i] + s[:2]
+		s = s[2:]
+	}
+
+	if i < 3 {
+		return d
+	}
+
+	// XMP requires minutes along with the hour.
+	if i == 3 {
+		d += ":00"
+	}
+
+	// Time zone: Z, +HH'mm' or -HH'mm'
+	switch {
+
+	case strings.HasPrefix(s, "Z"):
+		d += "Z"
+
+	case len(s) >= 3 && (s[0] == '+' || s[0] == '-') && isDigits(s[1:3]):
+		tz := s[:3]
+		s = strings.TrimPrefix(s[3:], "'")
+		if len(s) >= 2 && isDigits(s[:2]) {
+			tz += ":" + s[:2]
+		} else {
+			tz += ":00"
+		}
+		d += tz
+	}
+
+	return d
+}
+
+// Metadata returns the XMP metadata of the document or nil if there is none.
+func Metadata(xRefTable *XRefTable) (*xmp.Metadata, error) {
+
+	rootDict, err := xRefTable.Catalog()
+	if err != nil {
+		return nil, err
+	}
+
+	o, found := rootDict.Find("Metadata")
+	if !found {
+		return nil, nil
+	}
+
+	sd, err := xRefTable.DereferenceStreamDict(o)
+	if err != nil || sd == nil {
+		return nil, err
+	}
+
+	if err = decodeStream(sd); err != nil {
+		return nil, err
+	}
+
+	return xmp.Parse(sd.Content)
+}
+
+// writeMetadata replaces the catalog metadata stream.
+func writeMetadata(xRefTable *XRefTable, md *xmp.Metadata) error {
+
+	rootDict, err := xRefTable.Catalog()
+	if err != nil {
+		return err
+	}
+
+	// PDF/A does not allow filters for metadata streams.
+	sd := &StreamDict{Dict: NewDict(), Content: md.Bytes()}
+	sd.InsertName("Type", "Metadata")
+	sd.InsertName("Subtype", "XML")
+
+	if err = encodeStream(sd); err != nil {
+		return err
+	}
+
+	indRef, err := xRefTable.IndRefForNewObject(*sd)
+	if err != nil {
+		return err
+	}
+
+	rootDict.Update("Metadata", *indRef)
+
+	return nil
+}
+
+// metadataProperties returns the document info dict entries corresponding to md.
+func metadataProperties(md *xmp.Metadata) map[string]string {
+	return map[string]string{
+		"Title":    md.DC.Title,
+		"Author":   strings.Join(md.DC.Creator, ", "),
+		"Subject":  md.DC.Description,
+		"Keywords": md.PDF.Keywords,
+		"Creator":  md.XMP.CreatorTool,
+	}
+}
+
+// SetMetadata sets the XMP metadata of the document and updates the document info dict accordingly.
+func SetMetadata(xRefTable *XRefTable, md *xmp.Metadata) error {
+
+	log.Debug.Println("SetMetadata begin")
+
+	if md == nil {
+		return errors.New("SetMetadata: missing metadata")
+	}
+
+	d, err := xRefTable.infoDict(true)
+	if err != nil {
+		return err
+	}
+
+	for k, v := range metadataProperties(md) {
+
+		if v == "" {
+			d.Delete(k)
+			continue
+		}
+
+		o, err := textObject(v)
+		if err != nil {
+			return err
+		}
+
+		d.Update(k, o)
+	}
+
+	d.Delete("Trapped")
+	if md.PDF.Trapped != "" {
+		if !MemberOf(md.PDF.Trapped, []string{"True", "False", "Unknown"}) {
+			return errors.New("Trapped needs to be one of True, False, Unknown")
+		}
+		d.Insert("Trapped", Name(md.PDF.Trapped))
+	}
+
+	if err = writeMetadata(xRefTable, md); err != nil {
+		return err
+	}
+
+	log.Debug.Println("SetMetadata end")
+
+	return nil
+}
+
+// seedInfoDict adds the entries corresponding to existing XMP metadata to a new document info dict.
+func seedInfoDict(xRefTable *XRefTable, d Dict) error {
+
+	md, err := Metadata(xRefTable)
+	if err != nil {
+		log.Info.Printf("seedInfoDict: skipping metadata: %v\n", err)
+		return nil
+	}
+
+	if md == nil {
+		return nil
+	}
+
+	for k, v := range metadataProperties(md) {
+
+		if v == "" {
+			continue
+		}
+
+		o, err := textObject(v)
+		if err != nil {
+			return err
+		}
+
+		d.Insert(k, o)
+	}
+
+	if MemberOf(md.PDF.Trapped, []string{"True", "False", "Unknown"}) {
+		d.Insert("Trapped", Name(md.PDF.Trapped))
+	}
+
+	return nil
+}
+
+// infoMetadata sets the XMP properties corresponding to document info dict entries.
+var infoMetadata = map[string]func(md *xmp.Metadata, v string){
+	"Title":   func(md *xmp.Metadata, v string) { md.DC.Title = v },
+	"Subject": func(md *xmp.Metadata, v string) { md.DC.Description = v },
+	"Author": func(md *xmp.Metadata, v string) {
+		// Keep multiple creators as set by SetMetadata.
+		if v != strings.Join(md.DC.Creator, ", ") {
+			md.DC.Creator = nil
+			if v != "" {
+				md.DC.Creator = []string{v}
+			}
+		}
+	},
+	"Creator":      func(md *xmp.Metadata, v string) { md.XMP.CreatorTool = v },
+	"CreationDate": func(md *xmp.Metadata, v string) { md.XMP.CreateDate = metadataDate(v) },
+	"ModDate": func(md *xmp.Metadata, v string) {
+		md.XMP.ModifyDate = metadataDate(v)
+		md.XMP.MetadataDate = md.XMP.ModifyDate
+	},
+	"Producer": func(md *xmp.Metadata, v string) { md.PDF.Producer = v },
+	"Keywords": func(md *xmp.Metadata, v string) { md.PDF.Keywords = v },
+	"Trapped":  func(md *xmp.Metadata, v string) { md.PDF.Trapped = v },
+}
+
+// updateMetadata updates existing XMP metadata with the document info dict entries returned by properties.
+// XMP properties without corresponding entry are left untouched.
+func updateMetadata(xRefTable *XRefTable, properties func() (map[string]string, error)) error {
+
+	md, err := Metadata(xRefTable)
+	if err != nil {
+		// Leave metadata we are unable to parse untouched.
+		log.Info.Printf("updateMetadata: skipping metadata: %v\n", err)
+		return nil
+	}
+
+	// Only look at the document info dict if there is metadata to sync
+	// since its strings may already be encrypted by a previous write of this context.
+	if md == nil {
+		return nil
+	}
+
+	m, err := properties()
+	if err != nil {
+		return err
+	}
+
+	for k, v := range m {
+		if f, ok := infoMetadata[k]; ok {
+			f(md, v)
+		}
+	}
+
+	return writeMetadata(xRefTable, md)
+}
+
+// syncMetadata updates existing XMP metadata with the document info dict
+// since PDF/A requires both to be equivalent.
+func syncMetadata(xRefTable *XRefTable) error {
+	return updateMetadata(xRefTable, func() (map[string]string, error) { return Properties(xRefTable) })
+}
@@ -0,0 +1,181 @@
+/*
+Copyright 2018 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pdfcpu
+
+import (
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// maxNumberTreeEntries is the maximum number of entries of a number tree leaf
+// and the maximum number of kids of an intermediate number tree node.
+const maxNumberTreeEntries = 64
+
+// numberEntry is a key value pair of a number tree.
+type numberEntry struct {
+	k int
+	v Object
+}
+
+// numberTreeNode is a written number tree node along with its key range.
+type numberTreeNode struct {
+	indRef     IndirectRef
+	kmin, kmax int
+}
+
+// numberTreeEntries calls f for each entry of the number tree node o.
+func (xRefTable *XRefTable) numberTreeEntries(o Object, f func(k int, v Object)) error {
+
+	dict, err := xRefTable.DereferenceDict(o)
+	if err != nil || dict == nil {
+		return err
+	}
+
+	if o, found := dict.Find("Kids"); found {
+
+		kids, err := xRefTable.DereferenceArray(o)
+		if err != nil || kids == nil {
+			return err
+		}
+
+		for _, kid := range *kids {
+			err = xRefTable.numberTreeEntries(kid, f)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	o, found := dict.Find("Nums")
+	if !found {
+		return nil
+	}
+
+	nums, err := xRefTable.DereferenceArray(o)
+	if err != nil || nums == nil {
+		return err
+	}
+
+	for i := 0; i+1 < len(*nums); i += 2 {
+
+		o, err := xRefTable.Dereference((*nums)[i])
+		if err != nil {
+			return err
+		}
+
+		k, ok := o.(Integer)
+		if !ok {
+			return errors.Errorf("numberTreeEntries: corrupt key: %v", o)
+		}
+
+		f(k.Value(), (*nums)[i+1])
+	}
+
+	return nil
+}
+
+// numberTreeNodes writes a level of intermediate nodes with up to maxNumberTreeEntries kids each.
+func (xRefTable *XRefTable) numberTreeNodes(nodes []numberTreeNode) ([]numberTreeNode, error) {
+
+	var parents []numberTreeNode
+
+	for i := 0; i < len(nodes); i += maxNumberTreeEntries {
+
+		j := i + maxNumberTreeEntries
+		if j > len(nodes) {
+			j = len(nodes)
+		}
+
+		kids := Array{}
+		for _, n := range nodes[i:j] {
+			kids = append(kids, n.indRef)
+		}
+
+		kmin, kmax := nodes[i].kmin, nodes[j-1].kmax
+
+		indRef, err := xRefTable.IndRefForNewObject(Dict{"Kids": kids, "Limits": Array{Integer(kmin), Integer(kmax)}})
+		if err != nil {
+			return nil, err
+		}
+
+		parents = append(parents, numberTreeNode{*indRef, kmin, kmax})
+	}
+
+	return parents, nil
+}
+
+// createNumberTree writes a number tree holding entries and returns a reference to its root node.
+// Small trees consist of a single root node holding all entries.
+// Otherwise the entries get distributed over leaf nodes of up to maxNumberTreeEntries entries each.
+func (xRefTable *XRefTable) createNumberTree(entries []numberEntry) (*IndirectRef, error) {
+
+	if len(entries) == 0 {
+		return nil, errors.New("createNumberTree: missing entries")
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].k < entries[j].k })
+
+	nums := func(entries []numberEntry) Array {
+		arr := Array{}
+		for _, e := range entries {
+			arr = append(arr, Integer(e.k), e.v)
+		}
+		return arr
+	}
+
+	if len(entries) <= maxNumberTreeEntries {
+		return xRefTable.IndRefForNewObject(Dict{"Nums": nums(entries)})
+	}
+
+	var nodes []numberTreeNode
+
+	for i := 0; i < len(entries); i += maxNumberTreeEntries {
+
+		j := i + maxNumberTreeEntries
+		if j > len(entries) {
+			j = len(entries)
+		}
+
+		kmin, kmax := entries[i].k, entries[j-1].k
+
+		indRef, err := xRefTable.IndRefForNewObject(Dict{"Nums": nums(entries[i:j]), "Limits": Array{Integer(kmin), Integer(kmax)}})
+		if err != nil {
+			return nil, err
+		}
+
+		nodes = append(nodes, numberTreeNode{*indRef, kmin, kmax})
+	}
+
+	// Add intermediate levels until the root is able to hold all kids.
+	for len(nodes) > maxNumberTreeEntries {
+		var err error
+		if nodes, err = xRefTable.numberTreeNodes(nodes); err != nil {
+			return nil, err
+		}
+	}
+
+	// The root node has no limits.
+	kids := Array{}
+	for _, n := range nodes {
+		kids = append(kids, n.indRef)
+	}
+
+	return xRefTable.IndRefForNewObject(Dict{"Kids": kids})
+}
@@ -0,0 +1,67 @@
+/*
+Copyright 2018 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pdfcpu
+
+import (
+	"testing"
+)
+
+func TestNumberTree(t *testing.T) {
+
+	for _, n := range []int{1, maxNumberTreeEntries, maxNumberTreeEntries + 1, maxNumberTreeEntries*maxNumberTreeEntries + 1} {
+
+		xRefTable, err := createXRefTableWithRootDict()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var entries []numberEntry
+		for i := n - 1; i >= 0; i-- {
+			entries = append(entries, numberEntry{2 * i, Integer(i)})
+		}
+
+		indRef, err := xRefTable.createNumberTree(entries)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		root, err := xRefTable.DereferenceDict(*indRef)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, found := root.Find("Limits"); found {
+			t.Fatalf("%d entries: root node must not have limits\n", n)
+		}
+
+		// Entries need to be in ascending key order.
+		i := 0
+		err = xRefTable.numberTreeEntries(*indRef, func(k int, v Object) {
+			if k != 2*i || v.(Integer).Value() != i {
+				t.Fatalf("%d entries: want entry %d %d, got %d %v\n", n, 2*i, i, k, v)
+			}
+			i++
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if i != n {
+			t.Fatalf("%d entries: want %d entries, got %d\n", n, n, i)
+		}
+	}
+}
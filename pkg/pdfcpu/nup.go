@@ -0,0 +1,717 @@
+/*
+Copyright 2018 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pdfcpu
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hhrutter/pdfcpu/pkg/filter"
+	"github.com/hhrutter/pdfcpu/pkg/log"
+	"github.com/hhrutter/pdfcpu/pkg/types"
+	"github.com/pkg/errors"
+)
+
+// Paper sizes in portrait orientation in points.
+var paperSizes = map[string]types.Dim{
+	"A3":      {Width: 842, Height: 1191},
+	"A4":      {Width: 595, Height: 842},
+	"A5":      {Width: 420, Height: 595},
+	"Letter":  {Width: 612, Height: 792},
+	"Legal":   {Width: 612, Height: 1008},
+	"Tabloid": {Width: 792, Height: 1224},
+}
+
+// paper orientation
+const (
+	orientationAuto = iota
+	orientationPortrait
+	orientationLandscape
+)
+
+// order of pages on a sheet
+const (
+	orderRightDown = iota // left to right, then top to bottom
+	orderDownRight        // top to bottom, then left to right
+	orderLeftDown         // right to left, then top to bottom
+	orderDownLeft         // top to bottom, then right to left
+)
+
+// Supported numbers of pages per sheet and their grids as columns x rows in portrait orientation.
+var nUpGrids = map[int]struct{ cols, rows int }{
+	2:  {1, 2},
+	3:  {1, 3},
+	4:  {2, 2},
+	6:  {2, 3},
+	8:  {2, 4},
+	9:  {3, 3},
+	12: {3, 4},
+	16: {4, 4},
+}
+
+// NUp represents the command details for the command "NUp".
+type NUp struct {
+	n           int       // pages per sheet.
+	paperSize   string    // name of the paper size.
+	paperDim    types.Dim // paper dimensions in portrait orientation.
+	orientation int       // paper orientation: auto, portrait or landscape.
+	order       int       // order of pages on a sheet.
+	margin      float64   // space around each page in points.
+	border      bool      // draw a border line around each page.
+}
+
+func (nup NUp) String() string {
+	return fmt.Sprintf("%d-up on %s, order=%d, margin=%.0f, border=%t", nup.n, nup.paperSize, nup.order, nup.margin, nup.border)
+}
+
+// ParseNUpValue returns the number of pages per sheet for s.
+func ParseNUpValue(s string) (int, error) {
+
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, errors.Errorf("n must be one of 2, 3, 4, 6, 8, 9, 12, 16: %s", s)
+	}
+
+	if _, ok := nUpGrids[n]; !ok {
+		return 0, errors.Errorf("n must be one of 2, 3, 4, 6, 8, 9, 12, 16: %d", n)
+	}
+
+	return n, nil
+}
+
+// DefaultNUp returns the default configuration for placing n pages on each A4 sheet.
+func DefaultNUp(n int) (*NUp, error) {
+
+	if _, ok := nUpGrids[n]; !ok {
+		return nil, errors.Errorf("n must be one of 2, 3, 4, 6, 8, 9, 12, 16: %d", n)
+	}
+
+	return &NUp{
+		n:         n,
+		paperSize: "A4",
+		paperDim:  paperSizes["A4"],
+	}, nil
+}
+
+func parseNUpError() error {
+	return errors.New("Invalid nup description string. Please consult pdfcpu help nup!\n")
+}
+
+// parsePaperSize returns the dimensions in portrait orientation and the orientation for a paper size name
+// optionally followed by P or L for forcing portrait or landscape orientation.
+func parsePaperSize(v string) (types.Dim, int, error) {
+
+	if dim, ok := paperSizes[v]; ok {
+		return dim, orientationAuto, nil
+	}
+
+	name := v
+	orientation := orientationAuto
+
+	switch {
+	case strings.HasSuffix(v, "P"):
+		name, orientation = v[:len(v)-1], orientationPortrait
+	case strings.HasSuffix(v, "L"):
+		name, orientation = v[:len(v)-1], orientationLandscape
+	}
+
+	dim, ok := paperSizes[name]
+	if !ok {
+		var ss []string
+		for k := range paperSizes {
+			ss = append(ss, k)
+		}
+		sort.Strings(ss)
+		return dim, orientation, errors.Errorf("%s is unsupported, try one of %s.\n", v, strings.Join(ss, ", "))
+	}
+
+	return dim, orientation, nil
+}
+
+func parseNUpPaperSize(v string, nup *NUp) error {
+
+	dim, orientation, err := parsePaperSize(v)
+	if err != nil {
+		return err
+	}
+
+	nup.paperSize, nup.paperDim, nup.orientation = v, dim, orientation
+
+	return nil
+}
+
+func parseNUpOrder(v string, nup *NUp) error {
+
+	switch v {
+	case "rd":
+		nup.order = orderRightDown
+	case "dr":
+		nup.order = orderDownRight
+	case "ld":
+		nup.order = orderLeftDown
+	case "dl":
+		nup.order = orderDownLeft
+	default:
+		return errors.New("Valid orders: rd, dr, ld, dl")
+	}
+
+	return nil
+}
+
+func parseMargin(v string) (float64, error) {
+
+	m, err := strconv.ParseFloat(v, 64)
+	if err != nil || m < 0 {
+		return 0, errors.New("margin must be a positive number of points")
+	}
+
+	return m, nil
+}
+
+func parseBorder(v string) (bool, error) {
+
+	switch strings.ToLower(v) {
+	case "on", "true":
+		return true, nil
+	case "off", "false":
+		return false, nil
+	}
+
+	return false, errors.New("Valid borders: on, off")
+}
+
+// ParseNUpDetails parses a NUp command string into an internal structure.
+func ParseNUpDetails(s string, n int) (*NUp, error) {
+
+	nup, err := DefaultNUp(n)
+	if err != nil {
+		return nil, err
+	}
+
+	if s == "" {
+		return nup, nil
+	}
+
+	for _, s := range strings.Split(s, ",") {
+
+		ss1 := strings.Split(s, ":")
+		if len(ss1) != 2 {
+			return nil, parseNUpError()
+		}
+
+		k := strings.TrimSpace(ss1[0])
+		v := strings.TrimSpace(ss1[1])
+
+		switch k {
+		case "f": // paper size
+			err = parseNUpPaperSize(v, nup)
+
+		case "o": // order of pages
+			err = parseNUpOrder(v, nup)
+
+		case "m": // margin
+			nup.margin, err = parseMargin(v)
+
+		case "b": // border
+			nup.border, err = parseBorder(v)
+
+		default:
+			err = parseNUpError()
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err = nup.validateMargin(); err != nil {
+		return nil, err
+	}
+
+	return nup, nil
+}
+
+// validateMargin ensures the margins leave room for the pages in each cell of the sheet orientations in question.
+func (nup *NUp) validateMargin() error {
+
+	for _, landscape := range []bool{false, true} {
+
+		if landscape && nup.orientation == orientationPortrait || !landscape && nup.orientation == orientationLandscape {
+			continue
+		}
+
+		cols, rows := nup.grid(landscape)
+		dim := nup.sheetDim(landscape)
+
+		if 2*nup.margin >= math.Min(dim.Width/float64(cols), dim.Height/float64(rows)) {
+			return errors.Errorf("margin %.0f too large for %d-up on %s", nup.margin, nup.n, nup.paperSize)
+		}
+	}
+
+	return nil
+}
+
+// pageContent returns the decoded content of a page with multiple content streams joined.
+func pageContent(xRefTable *XRefTable, pageDict *Dict) ([]byte, error) {
+
+	o, found := pageDict.Find("Contents")
+	if !found {
+		return nil, nil
+	}
+
+	o, err := xRefTable.Dereference(o)
+	if err != nil || o == nil {
+		return nil, err
+	}
+
+	var arr Array
+
+	switch o := o.(type) {
+	case StreamDict:
+		arr = Array{o}
+	case Array:
+		arr = o
+	default:
+		return nil, errors.Errorf("pageContent: corrupt page \"Contents\"")
+	}
+
+	var b bytes.Buffer
+
+	for _, o := range arr {
+
+		sd, err := xRefTable.DereferenceStreamDict(o)
+		if err != nil {
+			return nil, err
+		}
+
+		if sd == nil {
+			continue
+		}
+
+		// Decode a copy leaving the stream dict untouched.
+		sdCopy := *sd
+		if err = decodeStream(&sdCopy); err != nil {
+			return nil, err
+		}
+
+		b.Write(sdCopy.Content)
+
+		// Content streams may only be split at token boundaries.
+		b.WriteString("\n")
+	}
+
+	return b.Bytes(), nil
+}
+
+// pageForm represents a page turned into a form XObject.
+type pageForm struct {
+	indRef *IndirectRef
+	bb     types.Rectangle // visible region of the page.
+	rot    int             // page rotation in effect.
+}
+
+// visibleRegion returns the page dict of page pageNr along with its inherited attributes and its crop box or media box.
+func visibleRegion(xRefTable *XRefTable, pageNr int) (*Dict, *InheritedPageAttrs, types.Rectangle, error) {
+
+	d, inhPAttrs, err := xRefTable.PageDict(pageNr)
+	if err != nil {
+		return nil, nil, types.Rectangle{}, err
+	}
+
+	if d == nil {
+		return nil, nil, types.Rectangle{}, errors.Errorf("visibleRegion: unknown page %d", pageNr)
+	}
+
+	box := inhPAttrs.mediaBox
+	if inhPAttrs.cropBox != nil {
+		box = inhPAttrs.cropBox
+	}
+	if box == nil {
+		return nil, nil, types.Rectangle{}, errors.Errorf("visibleRegion: missing media box for page %d", pageNr)
+	}
+
+	return d, inhPAttrs, rect(xRefTable, *box), nil
+}
+
+// createPageForm creates a form XObject rendering the visible region of page pageNr.
+func createPageForm(xRefTable *XRefTable, pageNr int) (*pageForm, error) {
+
+	d, inhPAttrs, bb, err := visibleRegion(xRefTable, pageNr)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := pageContent(xRefTable, d)
+	if err != nil {
+		return nil, err
+	}
+
+	sd := &StreamDict{
+		Dict: Dict(
+			map[string]Object{
+				"Type":    Name("XObject"),
+				"Subtype": Name("Form"),
+				"BBox":    NewRectangle(bb.LL.X, bb.LL.Y, bb.UR.X, bb.UR.Y),
+				"Matrix":  NewIntegerArray(1, 0, 0, 1, 0, 0),
+				"Filter":  Name(filter.Flate),
+			},
+		),
+		Content:        content,
+		FilterPipeline: []PDFFilter{{Name: filter.Flate, DecodeParms: nil}},
+	}
+
+	if inhPAttrs.resources != nil {
+		sd.Insert("Resources", *inhPAttrs.resources)
+	}
+
+	if err = encodeStream(sd); err != nil {
+		return nil, err
+	}
+
+	indRef, err := xRefTable.IndRefForNewObject(*sd)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pageForm{indRef: indRef, bb: bb, rot: normalizedRotation(int(inhPAttrs.rotate))}, nil
+}
+
+// transformMatrix returns the matrix fitting the visible region of a page rotated by rot degrees clockwise into cell.
+func transformMatrix(bb types.Rectangle, rot int, cell types.Rectangle) matrix {
+
+	w, h := bb.Width(), bb.Height()
+
+	// 1) Translate the visible region to the origin.
+	m1 := identMatrix
+	m1[2][0] = -bb.LL.X
+	m1[2][1] = -bb.LL.Y
+
+	// 2) Rotate clockwise and move back into the first quadrant.
+	m2 := identMatrix
+	switch rot {
+	case 90:
+		m2[0][0], m2[0][1], m2[1][0], m2[1][1] = 0, -1, 1, 0
+		m2[2][1] = w
+		w, h = h, w
+	case 180:
+		m2[0][0], m2[1][1] = -1, -1
+		m2[2][0], m2[2][1] = w, h
+	case 270:
+		m2[0][0], m2[0][1], m2[1][0], m2[1][1] = 0, 1, -1, 0
+		m2[2][0] = h
+		w, h = h, w
+	}
+
+	// 3) Scale to fit and center within the cell.
+	s := cell.Width() / w
+	if sy := cell.Height() / h; sy < s {
+		s = sy
+	}
+
+	m3 := identMatrix
+	m3[0][0], m3[1][1] = s, s
+	m3[2][0] = cell.LL.X + (cell.Width()-s*w)/2
+	m3[2][1] = cell.LL.Y + (cell.Height()-s*h)/2
+
+	return m1.multiply(m2).multiply(m3)
+}
+
+// grid returns the columns and rows of a sheet for the paper orientation chosen.
+func (nup *NUp) grid(landscape bool) (cols, rows int) {
+
+	g := nUpGrids[nup.n]
+	if landscape {
+		return g.rows, g.cols
+	}
+
+	return g.cols, g.rows
+}
+
+// sheetDim returns the dimensions of a sheet for the paper orientation chosen.
+func (nup *NUp) sheetDim(landscape bool) types.Dim {
+
+	if landscape {
+		return types.Dim{Width: nup.paperDim.Height, Height: nup.paperDim.Width}
+	}
+
+	return nup.paperDim
+}
+
+// cell returns the region on a sheet for the i-th page.
+func (nup *NUp) cell(i int, landscape bool) types.Rectangle {
+
+	cols, rows := nup.grid(landscape)
+	dim := nup.sheetDim(landscape)
+
+	var col, row int
+
+	switch nup.order {
+	case orderRightDown:
+		col, row = i%cols, i/cols
+	case orderDownRight:
+		col, row = i/rows, i%rows
+	case orderLeftDown:
+		col, row = cols-1-i%cols, i/cols
+	case orderDownLeft:
+		col, row = cols-1-i/rows, i%rows
+	}
+
+	w := dim.Width / float64(cols)
+	h := dim.Height / float64(rows)
+
+	llx := float64(col) * w
+	lly := dim.Height - float64(row+1)*h
+
+	return types.NewRectangle(llx+nup.margin, lly+nup.margin, llx+w-nup.margin, lly+h-nup.margin)
+}
+
+// landscape returns true if the sheets need landscape orientation for best use of the paper
+// for pages with visible region bb rotated by rot degrees.
+func (nup *NUp) landscape(bb types.Rectangle, rot int) bool {
+
+	switch nup.orientation {
+	case orientationPortrait:
+		return false
+	case orientationLandscape:
+		return true
+	}
+
+	scale := func(landscape bool) float64 {
+		m := transformMatrix(bb, rot, nup.cell(0, landscape))
+		return m[0][0]*m[0][0] + m[0][1]*m[0][1]
+	}
+
+	return scale(true) > scale(false)
+}
+
+// sheetContent returns the content stream of a sheet rendering forms into cells.
+func sheetContent(forms []*pageForm, cells []types.Rectangle, border bool) []byte {
+
+	var b bytes.Buffer
+
+	for i, f := range forms {
+
+		cell := cells[i]
+
+		if border {
+			fmt.Fprintf(&b, "q 0 G 1 w %.2f %.2f %.2f %.2f re S Q ",
+				cell.LL.X, cell.LL.Y, cell.Width(), cell.Height())
+		}
+
+		if f == nil {
+			continue
+		}
+
+		m := transformMatrix(f.bb, f.rot, cell)
+		fmt.Fprintf(&b, "q %.5f %.5f %.5f %.5f %.5f %.5f cm /Fm%d Do Q ",
+			m[0][0], m[0][1], m[1][0], m[1][1], m[2][0], m[2][1], i)
+	}
+
+	return b.Bytes()
+}
+
+// createSheet creates a page rendering forms into cells.
+func createSheet(xRefTable *XRefTable, parent IndirectRef, dim types.Dim, forms []*pageForm, cells []types.Rectangle, border bool) (*IndirectRef, error) {
+
+	xObjects := NewDict()
+	for i, f := range forms {
+		if f != nil {
+			xObjects.Insert(fmt.Sprintf("Fm%d", i), *f.indRef)
+		}
+	}
+
+	sd := &StreamDict{
+		Dict:           NewDict(),
+		Content:        sheetContent(forms, cells, border),
+		FilterPipeline: []PDFFilter{{Name: filter.Flate, DecodeParms: nil}},
+	}
+	sd.InsertName("Filter", filter.Flate)
+
+	if err := encodeStream(sd); err != nil {
+		return nil, err
+	}
+
+	contents, err := xRefTable.IndRefForNewObject(*sd)
+	if err != nil {
+		return nil, err
+	}
+
+	d := NewDict()
+	d.InsertName("Type", "Page")
+	d.Insert("Parent", parent)
+	d.Insert("MediaBox", NewRectangle(0, 0, dim.Width, dim.Height))
+	d.Insert("Resources", Dict(map[string]Object{"XObject": xObjects}))
+	d.Insert("Contents", *contents)
+
+	return xRefTable.IndRefForNewObject(d)
+}
+
+// removePageReferences removes document level structures referring to pages that got replaced.
+func removePageReferences(xRefTable *XRefTable) error {
+
+	rootDict, err := xRefTable.Catalog()
+	if err != nil {
+		return err
+	}
+
+	for _, k := range []string{"Outlines", "Dests", "OpenAction", "PageLabels", "StructTreeRoot", "AcroForm"} {
+		rootDict.Delete(k)
+	}
+
+	if _, found := rootDict.Find("Names"); !found {
+		return nil
+	}
+
+	namesDict, err := xRefTable.NamesDict()
+	if err != nil || namesDict == nil {
+		return err
+	}
+
+	if _, found := namesDict.Find("Dests"); !found {
+		return nil
+	}
+
+	// Unlink the destinations only, their object graph leads back into the page tree.
+	delete(xRefTable.Names, "Dests")
+	namesDict.Delete("Dests")
+
+	if namesDict.Len() == 0 {
+		rootDict.Delete("Names")
+	}
+
+	return nil
+}
+
+// sheet represents the pages placed on one side of an output page.
+type sheet struct {
+	pageNrs []int             // page numbers per cell, 0 leaves a cell empty.
+	cells   []types.Rectangle // regions on the sheet.
+}
+
+// replacePageTree replaces the page tree by a flat page tree holding one page per sheet.
+func replacePageTree(xRefTable *XRefTable, sheets []sheet, dim types.Dim, border bool) error {
+
+	forms := map[int]*pageForm{}
+
+	for _, s := range sheets {
+		for _, pageNr := range s.pageNrs {
+			if pageNr == 0 || forms[pageNr] != nil {
+				continue
+			}
+			f, err := createPageForm(xRefTable, pageNr)
+			if err != nil {
+				return err
+			}
+			forms[pageNr] = f
+		}
+	}
+
+	pagesDict := Dict(
+		map[string]Object{
+			"Type":  Name("Pages"),
+			"Count": Integer(len(sheets)),
+		},
+	)
+
+	pagesIndRef, err := xRefTable.IndRefForNewObject(pagesDict)
+	if err != nil {
+		return err
+	}
+
+	kids := Array{}
+
+	for _, s := range sheets {
+
+		ff := make([]*pageForm, len(s.pageNrs))
+		for i, pageNr := range s.pageNrs {
+			ff[i] = forms[pageNr]
+		}
+
+		indRef, err := createSheet(xRefTable, *pagesIndRef, dim, ff, s.cells, border)
+		if err != nil {
+			return err
+		}
+
+		kids = append(kids, *indRef)
+	}
+
+	pagesDict.Insert("Kids", kids)
+
+	err = removePageReferences(xRefTable)
+	if err != nil {
+		return err
+	}
+
+	rootDict, err := xRefTable.Catalog()
+	if err != nil {
+		return err
+	}
+
+	rootDict.Update("Pages", *pagesIndRef)
+
+	xRefTable.PageCount = len(sheets)
+
+	return nil
+}
+
+// NUpPages replaces the page tree by pages of the configured paper size each holding up to n of the selected pages.
+func NUpPages(xRefTable *XRefTable, selectedPages IntSet, nup *NUp) error {
+
+	log.Debug.Printf("NUpPages begin: %s\n", nup)
+
+	pageNrs := selectedPageNumbers(selectedPages)
+
+	if len(pageNrs) == 0 {
+		return errors.New("NUpPages: no pages selected")
+	}
+
+	// The first page decides on the paper orientation.
+	_, inhPAttrs, bb, err := visibleRegion(xRefTable, pageNrs[0])
+	if err != nil {
+		return err
+	}
+
+	landscape := nup.landscape(bb, normalizedRotation(int(inhPAttrs.rotate)))
+
+	cells := make([]types.Rectangle, nup.n)
+	for i := range cells {
+		cells[i] = nup.cell(i, landscape)
+	}
+
+	var sheets []sheet
+
+	for i := 0; i < len(pageNrs); i += nup.n {
+		j := i + nup.n
+		if j > len(pageNrs) {
+			j = len(pageNrs)
+		}
+		sheets = append(sheets, sheet{pageNrs: pageNrs[i:j], cells: cells})
+	}
+
+	err = replacePageTree(xRefTable, sheets, nup.sheetDim(landscape), nup.border)
+	if err != nil {
+		return err
+	}
+
+	log.Debug.Println("NUpPages end")
+
+	return nil
+}
@@ -45,6 +45,11 @@ func identifyPageContent(xRefTable *XRefTable, pageDict *Dict, pageNumber, pageO
 			return errors.Errorf("identifyPageContent: obj#:%d illegal indRef for Contents\n", pageObjNumber)
 		}
 
+		// Ensure a lazily loaded object is available.
+		if _, err := xRefTable.Dereference(indRef); err != nil {
+			return err
+		}
+
 		contentStreamDict, ok := entry.Object.(StreamDict)
 		if ok {
 			contentStreamDict.IsPageContent = true
@@ -74,6 +79,11 @@ func identifyPageContent(xRefTable *XRefTable, pageDict *Dict, pageNumber, pageO
 			return errors.Errorf("identifyPageContent: obj#:%d illegal indRef for Contents\n", pageObjNumber)
 		}
 
+		// Ensure a lazily loaded object is available.
+		if _, err := xRefTable.Dereference(indRef); err != nil {
+			return err
+		}
+
 		contentStreamDict, ok := entry.Object.(StreamDict)
 		if !ok {
 			return errors.Errorf("identifyPageContent: obj#:%d page content entry is no stream dict\n", pageObjNumber)
@@ -0,0 +1,475 @@
+/*
+Copyright 2018 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pdfcpu
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hhrutter/pdfcpu/pkg/log"
+	"github.com/pkg/errors"
+)
+
+// page label numbering styles, see 12.4.2 Page Labels
+var labelStyles = map[string]string{
+	"D": "decimal",
+	"R": "Roman uppercase",
+	"r": "roman lowercase",
+	"A": "letters uppercase",
+	"a": "letters lowercase",
+}
+
+// PageLabel represents the command details for the command "AddPageLabels".
+type PageLabel struct {
+	style  string // numbering style D, R, r, A, a or empty for labels without a numeric portion.
+	prefix string // label prefix.
+	start  int    // value of the numeric portion for the first page of a page range.
+}
+
+func (pl PageLabel) String() string {
+
+	style := "none"
+	if pl.style != "" {
+		style = labelStyles[pl.style]
+	}
+
+	return fmt.Sprintf("style=%s, prefix=%q, start=%d", style, pl.prefix, pl.start)
+}
+
+// DefaultPageLabel returns the default page label: decimal numbering starting with 1.
+func DefaultPageLabel() *PageLabel {
+	return &PageLabel{style: "D", start: 1}
+}
+
+func parsePageLabelError() error {
+	return errors.New("Invalid page label description string. Please consult pdfcpu help pagelabels!\n")
+}
+
+func parseLabelStyle(v string) (string, error) {
+
+	if v == "none" {
+		return "", nil
+	}
+
+	if _, ok := labelStyles[v]; !ok {
+		return "", errors.New("Valid page label styles: D, R, r, A, a, none")
+	}
+
+	return v, nil
+}
+
+func parseLabelStart(v string) (int, error) {
+
+	i, err := strconv.Atoi(v)
+	if err != nil || i < 1 {
+		return 0, errors.New("page label start must be a positive integer")
+	}
+
+	return i, nil
+}
+
+// ParsePageLabelDetails parses an AddPageLabels command string into an internal structure.
+func ParsePageLabelDetails(s string) (*PageLabel, error) {
+
+	pl := DefaultPageLabel()
+
+	if s == "" {
+		return pl, nil
+	}
+
+	var err error
+
+	for _, s := range strings.Split(s, ",") {
+
+		ss1 := strings.SplitN(s, ":", 2)
+		if len(ss1) != 2 {
+			return nil, parsePageLabelError()
+		}
+
+		k := strings.TrimSpace(ss1[0])
+		v := strings.TrimSpace(ss1[1])
+
+		switch k {
+		case "s": // numbering style
+			pl.style, err = parseLabelStyle(v)
+
+		case "p": // prefix
+			pl.prefix = v
+
+		case "st": // start value
+			pl.start, err = parseLabelStart(v)
+
+		default:
+			err = parsePageLabelError()
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return pl, nil
+}
+
+func romanNumeral(n int) string {
+
+	values := []int{1000, 900, 500, 400, 100, 90, 50, 40, 10, 9, 5, 4, 1}
+	numerals := []string{"M", "CM", "D", "CD", "C", "XC", "L", "XL", "X", "IX", "V", "IV", "I"}
+
+	var sb strings.Builder
+
+	for i, v := range values {
+		for n >= v {
+			sb.WriteString(numerals[i])
+			n -= v
+		}
+	}
+
+	return sb.String()
+}
+
+// letters returns A to Z for the first 26 numbers, AA to ZZ for the next 26 and so on.
+func letters(n int) string {
+	return strings.Repeat(string('A'+rune((n-1)%26)), (n-1)/26+1)
+}
+
+// label returns the label of the page at offset i of a page range.
+func (pl PageLabel) label(i int) string {
+
+	n := pl.start + i
+
+	var s string
+
+	switch pl.style {
+	case "D":
+		s = strconv.Itoa(n)
+	case "R":
+		s = romanNumeral(n)
+	case "r":
+		s = strings.ToLower(romanNumeral(n))
+	case "A":
+		s = letters(n)
+	case "a":
+		s = strings.ToLower(letters(n))
+	}
+
+	return pl.prefix + s
+}
+
+// dict returns the page label dict for pl.
+func (pl PageLabel) dict() (Dict, error) {
+
+	d := NewDict()
+
+	if pl.style != "" {
+		d.InsertName("S", pl.style)
+	}
+
+	if pl.prefix != "" {
+		t, err := textObject(pl.prefix)
+		if err != nil {
+			return nil, err
+		}
+		d.Insert("P", t)
+	}
+
+	if pl.start != 1 {
+		d.Insert("St", Integer(pl.start))
+	}
+
+	return d, nil
+}
+
+// pageLabelForDict returns the page label represented by the page label dict o.
+func pageLabelForDict(xRefTable *XRefTable, o Object) (*PageLabel, error) {
+
+	d, err := xRefTable.DereferenceDict(o)
+	if err != nil {
+		return nil, err
+	}
+
+	if d == nil {
+		return nil, errors.New("pageLabelForDict: corrupt page label dict")
+	}
+
+	pl := &PageLabel{start: 1}
+
+	if o, found := d.Find("S"); found {
+		n, err := xRefTable.DereferenceName(o, V10, nil)
+		if err != nil {
+			return nil, err
+		}
+		pl.style = n.Value()
+	}
+
+	if o, found := d.Find("P"); found {
+		if pl.prefix, err = xRefTable.DereferenceText(o); err != nil {
+			return nil, err
+		}
+	}
+
+	if o, found := d.Find("St"); found {
+		i, err := xRefTable.DereferenceInteger(o)
+		if err != nil {
+			return nil, err
+		}
+		if i != nil {
+			pl.start = i.Value()
+		}
+	}
+
+	return pl, nil
+}
+
+// pageLabel is a page label dict keyed by the index of the first page of its range.
+type pageLabel struct {
+	pageIndex int
+	dict      Object
+}
+
+// pageLabels returns the page label ranges of the document in number tree order.
+func pageLabels(xRefTable *XRefTable, rootDict *Dict) ([]pageLabel, error) {
+
+	o, found := rootDict.Find("PageLabels")
+	if !found {
+		return nil, nil
+	}
+
+	var labels []pageLabel
+
+	err := xRefTable.numberTreeEntries(o, func(k int, v Object) {
+		labels = append(labels, pageLabel{k, v})
+	})
+
+	return labels, err
+}
+
+// ListPageLabels returns a list of all page label ranges.
+func ListPageLabels(xRefTable *XRefTable) ([]string, error) {
+
+	log.Debug.Println("ListPageLabels begin")
+
+	rootDict, err := xRefTable.Catalog()
+	if err != nil {
+		return nil, err
+	}
+
+	labels, err := pageLabels(xRefTable, rootDict)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(labels, func(i, j int) bool { return labels[i].pageIndex < labels[j].pageIndex })
+
+	var list []string
+
+	for i, l := range labels {
+
+		from := l.pageIndex + 1
+		if from > xRefTable.PageCount {
+			break
+		}
+
+		thru := xRefTable.PageCount
+		if i+1 < len(labels) && labels[i+1].pageIndex < thru {
+			thru = labels[i+1].pageIndex
+		}
+
+		pl, err := pageLabelForDict(xRefTable, l.dict)
+		if err != nil {
+			return nil, err
+		}
+
+		if from == thru {
+			list = append(list, fmt.Sprintf("page %d: %s -> %q", from, pl, pl.label(0)))
+			continue
+		}
+
+		list = append(list, fmt.Sprintf("pages %d-%d: %s -> %q ... %q", from, thru, pl, pl.label(0), pl.label(thru-from)))
+	}
+
+	log.Debug.Println("ListPageLabels end")
+
+	return list, nil
+}
+
+// writePageLabels replaces the page labels of the document by the page label dicts m keyed by page index.
+func writePageLabels(xRefTable *XRefTable, rootDict *Dict, m map[int]Object) error {
+
+	if len(m) == 0 {
+		rootDict.Delete("PageLabels")
+		return nil
+	}
+
+	// The number tree needs to cover the first page.
+	if _, ok := m[0]; !ok {
+		d, err := DefaultPageLabel().dict()
+		if err != nil {
+			return err
+		}
+		m[0] = d
+	}
+
+	var entries []numberEntry
+	for k, v := range m {
+		entries = append(entries, numberEntry{k, v})
+	}
+
+	indRef, err := xRefTable.createNumberTree(entries)
+	if err != nil {
+		return err
+	}
+
+	rootDict.Update("PageLabels", *indRef)
+
+	return nil
+}
+
+// pageLabelDicts returns the page label dicts of the document keyed by page index.
+func pageLabelDicts(xRefTable *XRefTable, rootDict *Dict) (map[int]Object, error) {
+
+	labels, err := pageLabels(xRefTable, rootDict)
+	if err != nil {
+		return nil, err
+	}
+
+	m := map[int]Object{}
+	for _, l := range labels {
+		m[l.pageIndex] = l.dict
+	}
+
+	return m, nil
+}
+
+// AddPageLabels starts a page label range described by pl for each run of consecutive selected pages.
+// Pages following a run keep their labels.
+func AddPageLabels(xRefTable *XRefTable, selectedPages IntSet, pl *PageLabel) error {
+
+	log.Debug.Printf("AddPageLabels begin: %s\n", pl)
+
+	rootDict, err := xRefTable.Catalog()
+	if err != nil {
+		return err
+	}
+
+	m, err := pageLabelDicts(xRefTable, rootDict)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := m[0]; !ok {
+		if m[0], err = DefaultPageLabel().dict(); err != nil {
+			return err
+		}
+	}
+
+	d, err := pl.dict()
+	if err != nil {
+		return err
+	}
+
+	pageNrs := selectedPageNumbers(selectedPages)
+
+	for i := 0; i < len(pageNrs); {
+
+		// Locate the run of consecutive pages starting at page pageNrs[i].
+		j := i
+		for j+1 < len(pageNrs) && pageNrs[j+1] == pageNrs[j]+1 {
+			j++
+		}
+
+		from, thru := pageNrs[i]-1, pageNrs[j]-1
+		i = j + 1
+
+		next := thru + 1
+
+		if _, ok := m[next]; !ok && next < xRefTable.PageCount {
+
+			// Continue the labeling of the range the following page belongs to.
+			k := 0
+			for k1 := range m {
+				if k1 < next && k1 > k {
+					k = k1
+				}
+			}
+
+			pl1, err := pageLabelForDict(xRefTable, m[k])
+			if err != nil {
+				return err
+			}
+
+			pl1.start += next - k
+
+			if m[next], err = pl1.dict(); err != nil {
+				return err
+			}
+		}
+
+		for k := range m {
+			if k >= from && k <= thru {
+				delete(m, k)
+			}
+		}
+
+		m[from] = d
+	}
+
+	if err = writePageLabels(xRefTable, rootDict, m); err != nil {
+		return err
+	}
+
+	log.Debug.Println("AddPageLabels end")
+
+	return nil
+}
+
+// RemovePageLabels removes all page label ranges starting at selected pages.
+// The pages of a removed range continue the labeling of the preceding range.
+func RemovePageLabels(xRefTable *XRefTable, selectedPages IntSet) error {
+
+	log.Debug.Println("RemovePageLabels begin")
+
+	rootDict, err := xRefTable.Catalog()
+	if err != nil {
+		return err
+	}
+
+	if _, found := rootDict.Find("PageLabels"); !found {
+		return nil
+	}
+
+	m, err := pageLabelDicts(xRefTable, rootDict)
+	if err != nil {
+		return err
+	}
+
+	for pageNr, v := range selectedPages {
+		if v {
+			delete(m, pageNr-1)
+		}
+	}
+
+	if err = writePageLabels(xRefTable, rootDict, m); err != nil {
+		return err
+	}
+
+	log.Debug.Println("RemovePageLabels end")
+
+	return nil
+}
@@ -0,0 +1,754 @@
+/*
+Copyright 2018 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pdfcpu
+
+import (
+	"sort"
+
+	"github.com/hhrutter/pdfcpu/pkg/log"
+	"github.com/pkg/errors"
+)
+
+// locatePage returns the indirect references of page pageNr and of the page tree node holding it.
+func (xRefTable *XRefTable) locatePage(root IndirectRef, p *int, pageNr int) (pageIndRef, parentIndRef *IndirectRef, err error) {
+
+	dict, err := xRefTable.DereferenceDict(root)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pageCount := dict.IntEntry("Count")
+	if pageCount != nil && *p+*pageCount < pageNr {
+		// Skip sub pagetree.
+		*p += *pageCount
+		return nil, nil, nil
+	}
+
+	kids := dict.ArrayEntry("Kids")
+	if kids == nil {
+		return nil, nil, errors.Errorf("locatePage: corrupt page tree node: obj#%d", root.ObjectNumber)
+	}
+
+	for _, o := range *kids {
+
+		if o == nil {
+			continue
+		}
+
+		indRef, ok := o.(IndirectRef)
+		if !ok {
+			return nil, nil, errors.New("locatePage: corrupt page node dict")
+		}
+
+		pageNodeDict, err := xRefTable.DereferenceDict(indRef)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if pageNodeDict == nil || pageNodeDict.Type() == nil {
+			return nil, nil, errors.New("locatePage: corrupt page node dict")
+		}
+
+		switch *pageNodeDict.Type() {
+
+		case "Pages":
+			pageIndRef, parentIndRef, err = xRefTable.locatePage(indRef, p, pageNr)
+			if err != nil || pageIndRef != nil {
+				return pageIndRef, parentIndRef, err
+			}
+
+		case "Page":
+			*p++
+			if *p == pageNr {
+				return &indRef, &root, nil
+			}
+
+		}
+	}
+
+	return nil, nil, nil
+}
+
+// PageIndRef returns the indirect reference of page pageNr and of its parent page tree node.
+func (xRefTable *XRefTable) PageIndRef(pageNr int) (pageIndRef, parentIndRef *IndirectRef, err error) {
+
+	root, err := xRefTable.Pages()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	p := 0
+
+	pageIndRef, parentIndRef, err = xRefTable.locatePage(*root, &p, pageNr)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if pageIndRef == nil {
+		return nil, nil, errors.Errorf("PageIndRef: unknown page %d", pageNr)
+	}
+
+	return pageIndRef, parentIndRef, nil
+}
+
+// kidIndex returns the position of indRef in kids.
+func kidIndex(kids Array, indRef IndirectRef) int {
+
+	for i, o := range kids {
+		if ir, ok := o.(IndirectRef); ok && ir.ObjectNumber == indRef.ObjectNumber {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// updatePageCounts adds delta to the page count of a page tree node and all its ancestors.
+func (xRefTable *XRefTable) updatePageCounts(indRef IndirectRef, delta int) error {
+
+	for {
+
+		dict, err := xRefTable.DereferenceDict(indRef)
+		if err != nil {
+			return err
+		}
+
+		c := dict.IntEntry("Count")
+		if c == nil {
+			return errors.Errorf("updatePageCounts: missing \"Count\" in page tree node obj#%d", indRef.ObjectNumber)
+		}
+
+		dict.Update("Count", Integer(*c+delta))
+
+		parent := dict.IndirectRefEntry("Parent")
+		if parent == nil {
+			return nil
+		}
+
+		indRef = *parent
+	}
+}
+
+// insertKid inserts kid into the Kids of page tree node parent at position i and updates the page counts.
+func (xRefTable *XRefTable) insertKid(parent IndirectRef, i int, kid IndirectRef, pageCount int) error {
+
+	dict, err := xRefTable.DereferenceDict(parent)
+	if err != nil {
+		return err
+	}
+
+	kids := dict.ArrayEntry("Kids")
+	if kids == nil {
+		return errors.Errorf("insertKid: corrupt \"Kids\" entry in page tree node obj#%d", parent.ObjectNumber)
+	}
+
+	arr := append(Array{}, (*kids)[:i]...)
+	arr = append(arr, kid)
+	arr = append(arr, (*kids)[i:]...)
+	dict.Update("Kids", arr)
+
+	return xRefTable.updatePageCounts(parent, pageCount)
+}
+
+// removeKid removes kid from the Kids of page tree node parent and updates the page counts.
+// Page tree nodes left without kids get removed as well except for the page tree root.
+func (xRefTable *XRefTable) removeKid(parent, kid IndirectRef, pageCount int) error {
+
+	dict, err := xRefTable.DereferenceDict(parent)
+	if err != nil {
+		return err
+	}
+
+	kids := dict.ArrayEntry("Kids")
+	if kids == nil {
+		return errors.Errorf("removeKid: corrupt \"Kids\" entry in page tree node obj#%d", parent.ObjectNumber)
+	}
+
+	i := kidIndex(*kids, kid)
+	if i < 0 {
+		return errors.Errorf("removeKid: obj#%d is no kid of page tree node obj#%d", kid.ObjectNumber, parent.ObjectNumber)
+	}
+
+	arr := append(Array{}, (*kids)[:i]...)
+	arr = append(arr, (*kids)[i+1:]...)
+	dict.Update("Kids", arr)
+
+	grandParent := dict.IndirectRefEntry("Parent")
+
+	if len(arr) > 0 || grandParent == nil {
+		return xRefTable.updatePageCounts(parent, -pageCount)
+	}
+
+	return xRefTable.removeKid(*grandParent, parent, pageCount)
+}
+
+// selectedPageNumbers returns the selected page numbers in ascending order.
+func selectedPageNumbers(selectedPages IntSet) []int {
+
+	var pageNrs []int
+	for pageNr, v := range selectedPages {
+		if v {
+			pageNrs = append(pageNrs, pageNr)
+		}
+	}
+
+	sort.Ints(pageNrs)
+
+	return pageNrs
+}
+
+// sortedPageNumbers returns the selected page numbers in descending order.
+// Processing pages back to front keeps the numbers of the pages yet to be processed stable.
+func sortedPageNumbers(selectedPages IntSet) []int {
+
+	pageNrs := selectedPageNumbers(selectedPages)
+
+	sort.Sort(sort.Reverse(sort.IntSlice(pageNrs)))
+
+	return pageNrs
+}
+
+// RemovePages removes all selected pages from the page tree
+// along with the outline items, links and named destinations leading to them.
+func RemovePages(xRefTable *XRefTable, selectedPages IntSet) error {
+
+	log.Debug.Println("RemovePages begin")
+
+	pageNrs := sortedPageNumbers(selectedPages)
+
+	if len(pageNrs) >= xRefTable.PageCount {
+		return errors.New("RemovePages: can't remove all pages")
+	}
+
+	removed := IntSet{} // object numbers of the removed page dicts.
+
+	for _, pageNr := range pageNrs {
+
+		pageIndRef, parentIndRef, err := xRefTable.PageIndRef(pageNr)
+		if err != nil {
+			return err
+		}
+
+		log.Debug.Printf("RemovePages: page %d obj#%d\n", pageNr, pageIndRef.ObjectNumber)
+
+		removed[pageIndRef.ObjectNumber.Value()] = true
+
+		err = xRefTable.removeKid(*parentIndRef, *pageIndRef, 1)
+		if err != nil {
+			return err
+		}
+
+		xRefTable.PageCount--
+	}
+
+	if err := xRefTable.pruneDestinations(removed); err != nil {
+		return err
+	}
+
+	log.Debug.Println("RemovePages end")
+
+	return nil
+}
+
+// removedPageDestination returns true if the explicit destination dest leads to a removed page.
+func removedPageDestination(dest Array, removed IntSet) bool {
+
+	if len(dest) == 0 {
+		return false
+	}
+
+	indRef, ok := dest[0].(IndirectRef)
+
+	return ok && removed[indRef.ObjectNumber.Value()]
+}
+
+// pruneLinks removes the link annotations leading to removed pages from all remaining pages.
+func (xRefTable *XRefTable) pruneLinks(removed IntSet) error {
+
+	for pageNr := 1; pageNr <= xRefTable.PageCount; pageNr++ {
+
+		pageDict, _, err := xRefTable.PageDict(pageNr)
+		if err != nil {
+			return err
+		}
+
+		if pageDict == nil {
+			return errors.Errorf("pruneLinks: missing page %d", pageNr)
+		}
+
+		_, err = xRefTable.removePageAnnotations(pageDict, func(d *Dict, objNr int) (bool, error) {
+			if st := d.Subtype(); st == nil || *st != "Link" {
+				return false, nil
+			}
+			// Link annotations use the same entries as outline items.
+			dest, err := xRefTable.outlineItemDestination(d)
+			return removedPageDestination(dest, removed), err
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// pruneNamedDestinations removes the named destinations leading to removed pages.
+func (xRefTable *XRefTable) pruneNamedDestinations(rootDict *Dict, removed IntSet) error {
+
+	// PDF 1.1 named destinations live in the root dict.
+	if o, found := rootDict.Find("Dests"); found {
+
+		destsDict, err := xRefTable.DereferenceDict(o)
+		if err != nil {
+			return err
+		}
+
+		if destsDict != nil {
+			for k, v := range *destsDict {
+				dest, err := xRefTable.explicitDestination(v)
+				if err != nil {
+					return err
+				}
+				if removedPageDestination(dest, removed) {
+					destsDict.Delete(k)
+				}
+			}
+		}
+	}
+
+	tree := xRefTable.Names["Dests"]
+	if tree == nil {
+		return nil
+	}
+
+	var keys []string
+
+	err := tree.Process(xRefTable, func(xRefTable *XRefTable, k string, v Object) error {
+		dest, err := xRefTable.explicitDestination(v)
+		if err != nil {
+			return err
+		}
+		if removedPageDestination(dest, removed) {
+			keys = append(keys, k)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, k := range keys {
+
+		empty, _, err := tree.Remove(xRefTable, k)
+		if err != nil {
+			return err
+		}
+
+		if empty {
+			delete(xRefTable.Names, "Dests")
+			return xRefTable.RemoveNameTree("Dests")
+		}
+	}
+
+	return nil
+}
+
+// pruneDestinations removes outline items, link annotations, named destinations and the open action leading to removed pages.
+// Removed pages are given by the object numbers of their page dicts.
+func (xRefTable *XRefTable) pruneDestinations(removed IntSet) error {
+
+	rootDict, err := xRefTable.Catalog()
+	if err != nil {
+		return err
+	}
+
+	if o, found := rootDict.Find("OpenAction"); found {
+		dest, err := xRefTable.explicitDestination(o)
+		if err != nil {
+			return err
+		}
+		if removedPageDestination(dest, removed) {
+			rootDict.Delete("OpenAction")
+		}
+	}
+
+	if err = xRefTable.pruneOutline(removed); err != nil {
+		return err
+	}
+
+	if err = xRefTable.pruneLinks(removed); err != nil {
+		return err
+	}
+
+	// Named destinations go last because outline items and links may refer to them.
+	return xRefTable.pruneNamedDestinations(rootDict, removed)
+}
+
+// blankPageDict returns a page dict for an empty page using mediaBox.
+func blankPageDict(parent IndirectRef, mediaBox Array) Dict {
+
+	d := NewDict()
+	d.InsertName("Type", "Page")
+	d.Insert("Parent", parent)
+	d.Insert("MediaBox", append(Array{}, mediaBox...))
+	d.Insert("Resources", NewDict())
+
+	return d
+}
+
+// InsertBlankPages inserts an empty page before or after each selected page.
+// Each blank page gets the media box of the page it is inserted next to.
+func InsertBlankPages(xRefTable *XRefTable, selectedPages IntSet, before bool) error {
+
+	log.Debug.Printf("InsertBlankPages begin: before=%t\n", before)
+
+	for _, pageNr := range sortedPageNumbers(selectedPages) {
+
+		_, inhPAttrs, err := xRefTable.PageDict(pageNr)
+		if err != nil {
+			return err
+		}
+
+		if inhPAttrs.mediaBox == nil {
+			return errors.Errorf("InsertBlankPages: missing media box for page %d", pageNr)
+		}
+
+		pageIndRef, parentIndRef, err := xRefTable.PageIndRef(pageNr)
+		if err != nil {
+			return err
+		}
+
+		indRef, err := xRefTable.IndRefForNewObject(blankPageDict(*parentIndRef, *inhPAttrs.mediaBox))
+		if err != nil {
+			return err
+		}
+
+		parentDict, err := xRefTable.DereferenceDict(*parentIndRef)
+		if err != nil {
+			return err
+		}
+
+		i := kidIndex(*parentDict.ArrayEntry("Kids"), *pageIndRef)
+		if !before {
+			i++
+		}
+
+		log.Debug.Printf("InsertBlankPages: page %d obj#%d\n", pageNr, indRef.ObjectNumber)
+
+		err = xRefTable.insertKid(*parentIndRef, i, *indRef, 1)
+		if err != nil {
+			return err
+		}
+
+		xRefTable.PageCount++
+	}
+
+	log.Debug.Println("InsertBlankPages end")
+
+	return nil
+}
+
+// insertSourcePageTreeIntoDestPageTree inserts the page tree of ctxSource before or after page pageNr of ctxDest.
+func insertSourcePageTreeIntoDestPageTree(ctxSource, ctxDest *Context, pageNr int, before bool) error {
+
+	log.Debug.Println("insertSourcePageTreeIntoDestPageTree begin")
+
+	indRefPageTreeRootDictSource, err := ctxSource.Pages()
+	if err != nil {
+		return err
+	}
+
+	pageTreeRootDictSource, err := ctxSource.XRefTable.DereferenceDict(*indRefPageTreeRootDictSource)
+	if err != nil {
+		return err
+	}
+
+	pageIndRef, parentIndRef, err := ctxDest.PageIndRef(pageNr)
+	if err != nil {
+		return err
+	}
+
+	parentDict, err := ctxDest.DereferenceDict(*parentIndRef)
+	if err != nil {
+		return err
+	}
+
+	i := kidIndex(*parentDict.ArrayEntry("Kids"), *pageIndRef)
+	if !before {
+		i++
+	}
+
+	pageTreeRootDictSource.Insert("Parent", *parentIndRef)
+
+	// The source page tree becomes a kid of the page tree node holding page pageNr.
+	err = ctxDest.insertKid(*parentIndRef, i, *indRefPageTreeRootDictSource, ctxSource.PageCount)
+	if err != nil {
+		return err
+	}
+
+	ctxDest.PageCount += ctxSource.PageCount
+
+	log.Debug.Println("insertSourcePageTreeIntoDestPageTree end")
+
+	return nil
+}
+
+// InsertXRefTable merges Context ctxSource into ctxDest by inserting its pages before or after page pageNr.
+func InsertXRefTable(ctxSource, ctxDest *Context, pageNr int, before bool) error {
+
+	if pageNr < 1 || pageNr > ctxDest.PageCount {
+		return errors.Errorf("InsertXRefTable: unknown page %d", pageNr)
+	}
+
+	return mergeXRefTables(ctxSource, ctxDest, func() error {
+		return insertSourcePageTreeIntoDestPageTree(ctxSource, ctxDest, pageNr, before)
+	}, false)
+}
+
+// InsertPages inserts blank pages or, if ctxSource is not nil, the pages of ctxSource before or after the selected pages of ctxDest.
+// The pages of ctxSource go to a single insert position.
+func InsertPages(ctxSource, ctxDest *Context, selectedPages IntSet, before bool) error {
+
+	if ctxSource == nil {
+		return InsertBlankPages(ctxDest.XRefTable, selectedPages, before)
+	}
+
+	pageNrs := selectedPageNumbers(selectedPages)
+
+	if len(pageNrs) != 1 {
+		return errors.New("insert pages: please select exactly one page as insert position")
+	}
+
+	if ctxDest.Version() < V15 {
+		v, _ := PDFVersion("1.5")
+		ctxDest.RootVersion = &v
+		log.Stats.Println("Ensure V1.5 for writing object & xref streams")
+	}
+
+	return InsertXRefTable(ctxSource, ctxDest, pageNrs[0], before)
+}
+
+// inheritableAttrs are the page attributes a page may inherit from its ancestors in the page tree.
+var inheritableAttrs = []string{"Resources", "MediaBox", "CropBox", "Rotate"}
+
+// resolveInheritedAttrs adds the attributes pageDict inherits from its ancestors to pageDict.
+// Indirect references are taken over as is so the page keeps sharing the objects involved.
+func (xRefTable *XRefTable) resolveInheritedAttrs(pageDict Dict) error {
+
+	parent := pageDict.IndirectRefEntry("Parent")
+
+	for parent != nil {
+
+		dict, err := xRefTable.DereferenceDict(*parent)
+		if err != nil {
+			return err
+		}
+
+		for _, k := range inheritableAttrs {
+			if o, found := dict.Find(k); found {
+				pageDict.Insert(k, o)
+			}
+		}
+
+		parent = dict.IndirectRefEntry("Parent")
+	}
+
+	return nil
+}
+
+// copyPageDict returns a shallow copy of pageDict sharing all indirectly referenced objects like resources and content.
+// Annotations belong to a single page and are left out.
+func copyPageDict(pageDict Dict) Dict {
+
+	d := NewDict()
+	for k, v := range pageDict {
+		d[k] = v
+	}
+
+	d.Delete("Annots")
+	d.Delete("StructParents")
+
+	return d
+}
+
+// reorderPages moves the pages into the order given by pageNrs, a permutation of all page numbers.
+// Unlike CollectPages the page objects stay put so all references to them remain valid.
+func (xRefTable *XRefTable) reorderPages(pageNrs []int) error {
+
+	if len(pageNrs) != xRefTable.PageCount {
+		return errors.Errorf("reorderPages: want %d pages, got %d", xRefTable.PageCount, len(pageNrs))
+	}
+
+	root, err := xRefTable.Pages()
+	if err != nil {
+		return err
+	}
+
+	// Locate all pages before rearranging the page tree.
+	pageIndRefs := make([]IndirectRef, len(pageNrs))
+	parentIndRefs := make([]IndirectRef, len(pageNrs))
+	located := IntSet{}
+
+	for i, pageNr := range pageNrs {
+
+		if pageNr < 1 || pageNr > xRefTable.PageCount || located[pageNr] {
+			return errors.Errorf("reorderPages: invalid page %d", pageNr)
+		}
+		located[pageNr] = true
+
+		pageIndRef, parentIndRef, err := xRefTable.PageIndRef(pageNr)
+		if err != nil {
+			return err
+		}
+
+		pageDict, err := xRefTable.DereferenceDict(*pageIndRef)
+		if err != nil {
+			return err
+		}
+
+		// The pages become kids of the page tree root.
+		if err = xRefTable.resolveInheritedAttrs(*pageDict); err != nil {
+			return err
+		}
+
+		pageIndRefs[i], parentIndRefs[i] = *pageIndRef, *parentIndRef
+	}
+
+	// The first i kids of the page tree root are the pages already in place.
+	for i, pageIndRef := range pageIndRefs {
+
+		if err = xRefTable.removeKid(parentIndRefs[i], pageIndRef, 1); err != nil {
+			return err
+		}
+
+		if err = xRefTable.insertKid(*root, i, pageIndRef, 1); err != nil {
+			return err
+		}
+
+		pageDict, err := xRefTable.DereferenceDict(pageIndRef)
+		if err != nil {
+			return err
+		}
+
+		pageDict.Update("Parent", *root)
+	}
+
+	return nil
+}
+
+// CollectPages replaces the page tree by a flat page tree holding the pages pageNrs in the given order.
+// A page may be given repeatedly, each repetition becomes a new page object sharing resources and content with the original.
+func CollectPages(xRefTable *XRefTable, pageNrs []int) error {
+
+	log.Debug.Printf("CollectPages begin: %v\n", pageNrs)
+
+	if len(pageNrs) == 0 {
+		return errors.New("CollectPages: no pages selected")
+	}
+
+	// Locate all pages involved before taking the page tree apart.
+	pages := map[int]IndirectRef{}
+
+	for _, pageNr := range pageNrs {
+
+		if _, found := pages[pageNr]; found {
+			continue
+		}
+
+		if pageNr < 1 || pageNr > xRefTable.PageCount {
+			return errors.Errorf("CollectPages: unknown page %d", pageNr)
+		}
+
+		pageIndRef, _, err := xRefTable.PageIndRef(pageNr)
+		if err != nil {
+			return err
+		}
+
+		pageDict, err := xRefTable.DereferenceDict(*pageIndRef)
+		if err != nil {
+			return err
+		}
+
+		err = xRefTable.resolveInheritedAttrs(*pageDict)
+		if err != nil {
+			return err
+		}
+
+		pages[pageNr] = *pageIndRef
+	}
+
+	pagesDict := Dict(
+		map[string]Object{
+			"Type":  Name("Pages"),
+			"Count": Integer(len(pageNrs)),
+		},
+	)
+
+	pagesIndRef, err := xRefTable.IndRefForNewObject(pagesDict)
+	if err != nil {
+		return err
+	}
+
+	kids := Array{}
+	used := IntSet{}
+
+	for _, pageNr := range pageNrs {
+
+		pageIndRef := pages[pageNr]
+
+		pageDict, err := xRefTable.DereferenceDict(pageIndRef)
+		if err != nil {
+			return err
+		}
+
+		if used[pageNr] {
+			d := copyPageDict(*pageDict)
+			d.Update("Parent", *pagesIndRef)
+			indRef, err := xRefTable.IndRefForNewObject(d)
+			if err != nil {
+				return err
+			}
+			log.Debug.Printf("CollectPages: page %d repeated as obj#%d\n", pageNr, indRef.ObjectNumber)
+			kids = append(kids, *indRef)
+			continue
+		}
+
+		pageDict.Update("Parent", *pagesIndRef)
+		kids = append(kids, pageIndRef)
+		used[pageNr] = true
+	}
+
+	pagesDict.Insert("Kids", kids)
+
+	err = removePageReferences(xRefTable)
+	if err != nil {
+		return err
+	}
+
+	rootDict, err := xRefTable.Catalog()
+	if err != nil {
+		return err
+	}
+
+	rootDict.Update("Pages", *pagesIndRef)
+
+	xRefTable.PageCount = len(pageNrs)
+
+	log.Debug.Println("CollectPages end")
+
+	return nil
+}
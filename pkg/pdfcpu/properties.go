@@ -0,0 +1,239 @@
+/*
+Copyright 2018 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pdfcpu
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hhrutter/pdfcpu/pkg/log"
+	"github.com/pkg/errors"
+)
+
+// Document info dict entries pdfcpu updates on every write, see ensureInfoDict.
+var reservedProperties = []string{"Producer", "CreationDate", "ModDate"}
+
+// ParseProperty parses a property string of the form "key = value".
+func ParseProperty(s string) (string, string, error) {
+
+	ss := strings.SplitN(s, "=", 2)
+	if len(ss) != 2 {
+		return "", "", errors.Errorf("invalid property %q, use key = value", s)
+	}
+
+	k := strings.TrimSpace(ss[0])
+	v := strings.TrimSpace(ss[1])
+
+	if err := validatePropertyKey(k); err != nil {
+		return "", "", err
+	}
+
+	return k, v, nil
+}
+
+// validatePropertyKey ensures k may be used as a document info dict key.
+func validatePropertyKey(k string) error {
+
+	if k == "" || strings.ContainsAny(k, " \t\r\n\f()<>[]{}/%#") {
+		return errors.Errorf("invalid property key %q", k)
+	}
+
+	if MemberOf(k, reservedProperties) {
+		return errors.Errorf("property %s is maintained by pdfcpu", k)
+	}
+
+	return nil
+}
+
+// infoDict returns the document info dict, creating one if necessary.
+func (xRefTable *XRefTable) infoDict(create bool) (*Dict, error) {
+
+	if xRefTable.Info == nil {
+
+		if !create {
+			return nil, nil
+		}
+
+		d := NewDict()
+
+		indRef, err := xRefTable.IndRefForNewObject(d)
+		if err != nil {
+			return nil, err
+		}
+
+		xRefTable.Info = indRef
+
+		return &d, nil
+	}
+
+	d, err := xRefTable.DereferenceDict(*xRefTable.Info)
+	if err != nil {
+		return nil, err
+	}
+
+	if d == nil {
+		return nil, errors.New("infoDict: corrupt document info dict")
+	}
+
+	return d, nil
+}
+
+// propertyString returns a string representation of the value of a document info dict entry.
+func (xRefTable *XRefTable) propertyString(o Object) (string, error) {
+
+	o, err := xRefTable.Dereference(o)
+	if err != nil {
+		return "", err
+	}
+
+	switch o := o.(type) {
+
+	case StringLiteral, HexLiteral:
+		return xRefTable.DereferenceText(o)
+
+	case Name:
+		return o.Value(), nil
+
+	case nil:
+		return "", nil
+
+	}
+
+	return o.String(), nil
+}
+
+// Properties returns the entries of the document info dict.
+func Properties(xRefTable *XRefTable) (map[string]string, error) {
+
+	d, err := xRefTable.infoDict(false)
+	if err != nil || d == nil {
+		return nil, err
+	}
+
+	m := map[string]string{}
+
+	for k, v := range *d {
+		if m[k], err = xRefTable.propertyString(v); err != nil {
+			return nil, err
+		}
+	}
+
+	return m, nil
+}
+
+// ListProperties returns a sorted list of the entries of the document info dict.
+func ListProperties(xRefTable *XRefTable) ([]string, error) {
+
+	log.Debug.Println("ListProperties begin")
+
+	m, err := Properties(xRefTable)
+	if err != nil {
+		return nil, err
+	}
+
+	var list []string
+
+	for k, v := range m {
+		list = append(list, fmt.Sprintf("%s = %s", k, v))
+	}
+
+	sort.Strings(list)
+
+	log.Debug.Println("ListProperties end")
+
+	return list, nil
+}
+
+// AddProperties sets entries of the document info dict.
+// Non ASCII values get encoded as UTF-16BE.
+func AddProperties(xRefTable *XRefTable, props map[string]string) error {
+
+	log.Debug.Println("AddProperties begin")
+
+	d, err := xRefTable.infoDict(true)
+	if err != nil {
+		return err
+	}
+
+	for k, v := range props {
+
+		if err = validatePropertyKey(k); err != nil {
+			return err
+		}
+
+		if k == "Trapped" {
+			if !MemberOf(v, []string{"True", "False", "Unknown"}) {
+				return errors.New("Trapped needs to be one of True, False, Unknown")
+			}
+			d.Update(k, Name(v))
+			continue
+		}
+
+		o, err := textObject(v)
+		if err != nil {
+			return err
+		}
+
+		d.Update(k, o)
+	}
+
+	log.Debug.Println("AddProperties end")
+
+	return nil
+}
+
+// RemoveProperties removes entries from the document info dict.
+// If keys is empty all entries not maintained by pdfcpu get removed.
+func RemoveProperties(xRefTable *XRefTable, keys []string) error {
+
+	log.Debug.Println("RemoveProperties begin")
+
+	d, err := xRefTable.infoDict(false)
+	if err != nil || d == nil {
+		return err
+	}
+
+	if len(keys) == 0 {
+		for k := range *d {
+			if !MemberOf(k, reservedProperties) {
+				keys = append(keys, k)
+			}
+		}
+	}
+
+	m := map[string]string{}
+
+	for _, k := range keys {
+
+		if err = validatePropertyKey(k); err != nil {
+			return err
+		}
+
+		d.Delete(k)
+		m[k] = ""
+	}
+
+	// Writing syncs only the remaining entries into existing XMP metadata.
+	if err = updateMetadata(xRefTable, func() (map[string]string, error) { return m, nil }); err != nil {
+		return err
+	}
+
+	log.Debug.Println("RemoveProperties end")
+
+	return nil
+}
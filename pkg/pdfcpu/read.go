@@ -36,6 +36,7 @@ const (
 )
 
 // ReadPDFFile reads in a PDFFile and generates a Context, an in-memory representation containing a cross reference table.
+// When lazy loading the file stays open for loading objects on demand until the Context gets closed.
 func ReadPDFFile(fileName string, config *Configuration) (*Context, error) {
 
 	log.Debug.Println("readPDFFile: begin")
@@ -45,15 +46,39 @@ func ReadPDFFile(fileName string, config *Configuration) (*Context, error) {
 		return nil, errors.Wrapf(err, "can't open %q", fileName)
 	}
 
-	defer func() {
+	ctx, err := Read(file, config)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	if ctx.LazyLoading {
+		ctx.Read.file = file
+	} else {
 		file.Close()
-	}()
+	}
+
+	ctx.Read.FileName = fileName
+
+	log.Debug.Println("readPDFFile: end")
 
-	ctx, err := NewContext(fileName, file, config)
+	return ctx, nil
+}
+
+// Read reads in a PDF from rs and generates a Context, an in-memory representation containing a cross reference table.
+func Read(rs io.ReadSeeker, config *Configuration) (*Context, error) {
+
+	log.Debug.Println("Read: begin")
+
+	ctx, err := NewContext(rs, config)
 	if err != nil {
 		return nil, err
 	}
 
+	if ctx.LazyLoading {
+		ctx.cache = newObjectCache(ctx, ctx.StreamCacheSize)
+	}
+
 	if ctx.Reader15 {
 		log.Info.Println("PDF Version 1.5 conforming reader")
 	} else {
@@ -73,7 +98,12 @@ func ReadPDFFile(fileName string, config *Configuration) (*Context, error) {
 		return nil, err
 	}
 
-	log.Debug.Println("readPDFFile: end")
+	// Remember the original state of all objects in order to detect modifications.
+	if ctx.Incremental {
+		recordFingerprints(ctx)
+	}
+
+	log.Debug.Println("Read: end")
 
 	return ctx, nil
 }
@@ -138,7 +168,7 @@ func newPositionedReader(rs io.ReadSeeker, offset *int64) (*bufio.Reader, error)
 
 // Get the file offset of the last XRefSection.
 // Go to end of file and search backwards for the first occurrence of startxref {offset} %%EOF
-func offsetLastXRefSection(ra io.ReaderAt, fileSize int64) (*int64, error) {
+func offsetLastXRefSection(rs io.ReadSeeker, fileSize int64) (*int64, error) {
 
 	var bufSize int64 = defaultBufSize
 
@@ -151,7 +181,11 @@ func offsetLastXRefSection(ra io.ReaderAt, fileSize int64) (*int64, error) {
 
 	log.Debug.Printf("offsetLastXRefSection at %d\n", off)
 
-	if _, err := ra.ReadAt(buf, off); err != nil {
+	if _, err := rs.Seek(off, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	if _, err := io.ReadFull(rs, buf); err != nil {
 		return nil, err
 	}
 
@@ -459,11 +493,13 @@ func extractXRefTableEntriesFromXRefStream(buf []byte, sd XRefStreamDict, ctx *C
 			log.Debug.Printf("extractXRefTableEntriesFromXRefStream: Object #%d is compressed at obj %5d[%d]\n", objectNumber, c2, c3)
 			objNumberRef := int(c2)
 			objIndex := int(c3)
+			g := 0
 
 			xRefTableEntry =
 				XRefTableEntry{
 					Free:            false,
 					Compressed:      true,
+					Generation:      &g,
 					ObjectStream:    &objNumberRef,
 					ObjectStreamInd: &objIndex}
 
@@ -600,7 +636,7 @@ func parseHybridXRefStream(offset *int64, ctx *Context) error {
 
 	log.Debug.Println("parseHybridXRefStream: begin")
 
-	rd, err := newPositionedReader(ctx.Read.File, offset)
+	rd, err := newPositionedReader(ctx.Read.RS, offset)
 	if err != nil {
 		return err
 	}
@@ -874,15 +910,19 @@ func parseXRefSection(s *bufio.Scanner, ctx *Context) (*int64, error) {
 // if present, shall be used instead of the version specified in the Header.
 // Save PDF Version from header to xRefTable.
 // The header version comes as the first line of the file.
-func headerVersion(ra io.ReaderAt) (*Version, error) {
+func headerVersion(rs io.ReadSeeker) (*Version, error) {
 
 	log.Debug.Println("headerVersion begin")
 
 	// Get first line of file which holds the version of this PDFFile.
 	// We call this the header version.
 
+	if _, err := rs.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
 	buf := make([]byte, 10)
-	if _, err := ra.ReadAt(buf, 0); err != nil {
+	if _, err := io.ReadFull(rs, buf); err != nil {
 		return nil, err
 	}
 
@@ -911,9 +951,9 @@ func buildXRefTableStartingAt(ctx *Context, offset *int64) error {
 
 	log.Debug.Println("buildXRefTableStartingAt: begin")
 
-	file := ctx.Read.File
+	rs := ctx.Read.RS
 
-	hv, err := headerVersion(file)
+	hv, err := headerVersion(rs)
 	if err != nil {
 		return err
 	}
@@ -922,7 +962,7 @@ func buildXRefTableStartingAt(ctx *Context, offset *int64) error {
 
 	for offset != nil {
 
-		rd, err := newPositionedReader(file, offset)
+		rd, err := newPositionedReader(rs, offset)
 		if err != nil {
 			return err
 		}
@@ -941,7 +981,7 @@ func buildXRefTableStartingAt(ctx *Context, offset *int64) error {
 
 			log.Debug.Println("buildXRefTableStartingAt: found xref stream")
 			ctx.Read.UsingXRefStreams = true
-			rd, err = newPositionedReader(file, offset)
+			rd, err = newPositionedReader(rs, offset)
 			if err != nil {
 				return err
 			}
@@ -974,11 +1014,13 @@ func readXRefTable(ctx *Context) (err error) {
 
 	log.Debug.Println("readXRefTable: begin")
 
-	offset, err := offsetLastXRefSection(ctx.Read.File, ctx.Read.FileSize)
+	offset, err := offsetLastXRefSection(ctx.Read.RS, ctx.Read.FileSize)
 	if err != nil {
 		return
 	}
 
+	ctx.Read.OffsetLastXRefSection = *offset
+
 	err = buildXRefTableStartingAt(ctx, offset)
 	if err == io.EOF {
 		return errors.Wrap(err, "readXRefTable: unexpected eof")
@@ -1309,7 +1351,7 @@ func dict(ctx *Context, Dict Dict, objNr, genNr, endInd, streamInd int) (d *Dict
 func object(ctx *Context, offset int64, objNr, genNr int) (o Object, endInd, streamInd int, streamOffset int64, err error) {
 
 	var rd io.Reader
-	rd, err = newPositionedReader(ctx.Read.File, &offset)
+	rd, err = newPositionedReader(ctx.Read.RS, &offset)
 	if err != nil {
 		return nil, 0, 0, 0, err
 	}
@@ -1436,6 +1478,12 @@ func dereferencedObject(ctx *Context, objectNumber int) (Object, error) {
 		return nil, errors.New("dereferencedObject: object not registered in xRefTable")
 	}
 
+	if ctx.cache != nil {
+		if _, err := ctx.cache.object(objectNumber, entry); err != nil {
+			return nil, err
+		}
+	}
+
 	if entry.Compressed {
 		decompressXRefTableEntry(ctx.XRefTable, objectNumber, entry)
 	}
@@ -1468,6 +1516,12 @@ func dereferencedDict(ctx *Context, objectNumber int) (*Dict, error) {
 		return nil, errors.New("dereferencedDict: object not registered in xRefTable")
 	}
 
+	if ctx.cache != nil {
+		if _, err := ctx.cache.object(objectNumber, entry); err != nil {
+			return nil, err
+		}
+	}
+
 	if entry.Compressed {
 		decompressXRefTableEntry(ctx.XRefTable, objectNumber, entry)
 	}
@@ -1570,7 +1624,7 @@ func loadEncodedStreamContent(ctx *Context, streamDict *StreamDict) ([]byte, err
 	}
 
 	newOffset := streamDict.StreamOffset
-	rd, err := newPositionedReader(ctx.Read.File, &newOffset)
+	rd, err := newPositionedReader(ctx.Read.RS, &newOffset)
 	if err != nil {
 		return nil, err
 	}
@@ -2048,9 +2102,12 @@ func dereferenceXRefTable(ctx *Context, config *Configuration) error {
 	}
 
 	// For each xRefTableEntry assign a Object either by parsing from file or pointing to a decompressed object.
-	err = dereferenceObjects(ctx)
-	if err != nil {
-		return err
+	// When lazy loading this happens on first access.
+	if ctx.cache == nil {
+		err = dereferenceObjects(ctx)
+		if err != nil {
+			return err
+		}
 	}
 
 	// Identify an optional Version entry in the root object/catalog.
@@ -0,0 +1,379 @@
+/*
+Copyright 2018 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pdfcpu
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/hhrutter/pdfcpu/pkg/log"
+	"github.com/hhrutter/pdfcpu/pkg/types"
+	"github.com/pkg/errors"
+)
+
+// Resize represents the command details for the command "Resize".
+type Resize struct {
+	scale       float64   // scale factor, 0 for fitting pages to paperSize.
+	paperSize   string    // name of the paper size.
+	paperDim    types.Dim // paper dimensions in portrait orientation.
+	orientation int       // paper orientation: auto, portrait or landscape.
+}
+
+func (r Resize) String() string {
+	if r.scale > 0 {
+		return fmt.Sprintf("scale=%.2f", r.scale)
+	}
+	return fmt.Sprintf("paper size=%s", r.paperSize)
+}
+
+func parseResizeError() error {
+	return errors.New("Invalid resize description string. Please consult pdfcpu help resize!\n")
+}
+
+func parseScaleFactor(v string) (float64, error) {
+
+	s, err := strconv.ParseFloat(v, 64)
+	if err != nil || s <= 0 {
+		return 0, errors.New("scale factor must be a positive number")
+	}
+
+	return s, nil
+}
+
+// ParseResizeDetails parses a Resize command string into an internal structure.
+func ParseResizeDetails(s string) (*Resize, error) {
+
+	ss := strings.Split(s, ",")
+	if len(ss) != 1 {
+		return nil, errors.New("resize: use either a paper size or a scale factor")
+	}
+
+	ss1 := strings.Split(ss[0], ":")
+	if len(ss1) != 2 {
+		return nil, parseResizeError()
+	}
+
+	k := strings.TrimSpace(ss1[0])
+	v := strings.TrimSpace(ss1[1])
+
+	r := &Resize{}
+
+	var err error
+
+	switch k {
+	case "f": // paper size
+		r.paperDim, r.orientation, err = parsePaperSize(v)
+		r.paperSize = v
+
+	case "s": // scale factor
+		r.scale, err = parseScaleFactor(v)
+
+	default:
+		err = parseResizeError()
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// resizeMatrix returns the new page dimensions in user space and the scaling matrix [s 0 0 s tx ty]
+// for the visible region bb of a page rotated by rot degrees.
+func (r *Resize) resizeMatrix(bb types.Rectangle, rot int) (types.Dim, [6]float64) {
+
+	w, h := bb.Width(), bb.Height()
+
+	if r.scale > 0 {
+		s := r.scale
+		return types.Dim{Width: s * w, Height: s * h}, [6]float64{s, 0, 0, s, -s * bb.LL.X, -s * bb.LL.Y}
+	}
+
+	// The paper orientation refers to the page as displayed.
+	rotated := rot == 90 || rot == 270
+
+	landscape := w > h
+	if rotated {
+		landscape = !landscape
+	}
+
+	switch r.orientation {
+	case orientationPortrait:
+		landscape = false
+	case orientationLandscape:
+		landscape = true
+	}
+
+	dim := r.paperDim
+	if landscape != rotated {
+		dim.Width, dim.Height = dim.Height, dim.Width
+	}
+
+	// Scale uniformly and center within the paper size leaving blank bars where the aspect ratios differ.
+	s := math.Min(dim.Width/w, dim.Height/h)
+	dx := (dim.Width - s*w) / 2
+	dy := (dim.Height - s*h) / 2
+
+	return dim, [6]float64{s, 0, 0, s, dx - s*bb.LL.X, dy - s*bb.LL.Y}
+}
+
+func transformPoint(m [6]float64, x, y float64) (float64, float64) {
+	return m[0]*x + m[4], m[3]*y + m[5]
+}
+
+// transformCoordinates applies m to an array of x y coordinate pairs.
+func transformCoordinates(xRefTable *XRefTable, a Array, m [6]float64) Array {
+
+	arr := make(Array, len(a))
+
+	for i := 0; i+1 < len(a); i += 2 {
+		x, y := transformPoint(m, xRefTable.DereferenceNumber(a[i]), xRefTable.DereferenceNumber(a[i+1]))
+		arr[i], arr[i+1] = Float(x), Float(y)
+	}
+
+	return arr
+}
+
+func transformRect(xRefTable *XRefTable, a Array, m [6]float64) Array {
+	r := rect(xRefTable, a)
+	llx, lly := transformPoint(m, r.LL.X, r.LL.Y)
+	urx, ury := transformPoint(m, r.UR.X, r.UR.Y)
+	return NewRectangle(llx, lly, urx, ury)
+}
+
+// transformAnnotation moves and scales an annotation along with the page content.
+func transformAnnotation(xRefTable *XRefTable, d *Dict, m [6]float64) error {
+
+	for _, k := range []string{"Rect", "QuadPoints", "L", "Vertices", "CL", "InkList"} {
+
+		o, found := d.Find(k)
+		if !found {
+			continue
+		}
+
+		a, err := xRefTable.DereferenceArray(o)
+		if err != nil {
+			return err
+		}
+
+		if a == nil {
+			continue
+		}
+
+		switch k {
+
+		case "Rect":
+			if len(*a) != 4 {
+				return errors.New("transformAnnotation: corrupt Rect")
+			}
+			d.Update(k, transformRect(xRefTable, *a, m))
+
+		case "InkList":
+			var inkList Array
+			for _, o := range *a {
+				path, err := xRefTable.DereferenceArray(o)
+				if err != nil {
+					return err
+				}
+				if path != nil {
+					inkList = append(inkList, transformCoordinates(xRefTable, *path, m))
+				}
+			}
+			d.Update(k, inkList)
+
+		default:
+			d.Update(k, transformCoordinates(xRefTable, *a, m))
+		}
+	}
+
+	return nil
+}
+
+// transformAnnotations moves and scales all annotations of a page along with the page content.
+func transformAnnotations(xRefTable *XRefTable, pageDict *Dict, m [6]float64, visited IntSet) error {
+
+	o, found := pageDict.Find("Annots")
+	if !found {
+		return nil
+	}
+
+	annots, err := xRefTable.DereferenceArray(o)
+	if err != nil || annots == nil {
+		return err
+	}
+
+	for _, o := range *annots {
+
+		if indRef, ok := o.(IndirectRef); ok {
+			if visited[indRef.ObjectNumber.Value()] {
+				continue
+			}
+			visited[indRef.ObjectNumber.Value()] = true
+		}
+
+		d, err := xRefTable.DereferenceDict(o)
+		if err != nil {
+			return err
+		}
+
+		if d == nil {
+			continue
+		}
+
+		if err = transformAnnotation(xRefTable, d, m); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func contentStream(xRefTable *XRefTable, s string) (*IndirectRef, error) {
+
+	sd := &StreamDict{Dict: NewDict(), Content: []byte(s)}
+
+	if err := encodeStream(sd); err != nil {
+		return nil, err
+	}
+
+	return xRefTable.IndRefForNewObject(*sd)
+}
+
+// wrapContent wraps the page content in a transformation by m clipped to bb leaving shared content streams untouched.
+func wrapContent(xRefTable *XRefTable, pageDict *Dict, m [6]float64, bb types.Rectangle) error {
+
+	// Content outside the visible region must not show up in the new media box.
+	pre := fmt.Sprintf("q %.5f %.5f %.5f %.5f %.5f %.5f cm\n%.5f %.5f %.5f %.5f re W n\n",
+		m[0], m[1], m[2], m[3], m[4], m[5], bb.LL.X, bb.LL.Y, bb.Width(), bb.Height())
+
+	return wrapPageContent(xRefTable, pageDict, pre, "\nQ")
+}
+
+// wrapPageContent surrounds the page content by pre and post leaving shared content streams untouched.
+func wrapPageContent(xRefTable *XRefTable, pageDict *Dict, pre, post string) error {
+
+	o, found := pageDict.Find("Contents")
+	if !found {
+		return nil
+	}
+
+	var contents Array
+
+	o1, err := xRefTable.Dereference(o)
+	if err != nil {
+		return err
+	}
+
+	switch o1 := o1.(type) {
+
+	case StreamDict:
+		contents = Array{o}
+
+	case Array:
+		contents = o1
+
+	default:
+		return errors.New("wrapPageContent: corrupt page content")
+	}
+
+	preIndRef, err := contentStream(xRefTable, pre)
+	if err != nil {
+		return err
+	}
+
+	postIndRef, err := contentStream(xRefTable, post)
+	if err != nil {
+		return err
+	}
+
+	arr := Array{*preIndRef}
+	arr = append(arr, contents...)
+	arr = append(arr, *postIndRef)
+
+	pageDict.Update("Contents", arr)
+
+	return nil
+}
+
+// resizePage scales the visible region of page pageNr and updates its boxes and annotations accordingly.
+func resizePage(xRefTable *XRefTable, pageNr int, r *Resize, visited IntSet) error {
+
+	d, inhPAttrs, bb, err := visibleRegion(xRefTable, pageNr)
+	if err != nil {
+		return err
+	}
+
+	if bb.Width() == 0 || bb.Height() == 0 {
+		return errors.Errorf("resizePage: page %d is empty", pageNr)
+	}
+
+	dim, m := r.resizeMatrix(bb, normalizedRotation(int(inhPAttrs.rotate)))
+
+	log.Debug.Printf("resizePage: page %d: %.2f x %.2f, matrix=%v\n", pageNr, dim.Width, dim.Height, m)
+
+	if err = wrapContent(xRefTable, d, m, bb); err != nil {
+		return err
+	}
+
+	if err = transformAnnotations(xRefTable, d, m, visited); err != nil {
+		return err
+	}
+
+	// Bleed, trim and art box are not inheritable.
+	for _, k := range boxNames[2:] {
+		a, err := pageBox(xRefTable, d, k)
+		if err != nil {
+			return err
+		}
+		if a != nil {
+			llx, lly := transformPoint(m, a.LL.X, a.LL.Y)
+			urx, ury := transformPoint(m, a.UR.X, a.UR.Y)
+			d.Update(k, NewRectangle(llx, lly, urx, ury))
+		}
+	}
+
+	d.Update("MediaBox", NewRectangle(0, 0, dim.Width, dim.Height))
+
+	// The crop box defaults to the media box.
+	d.Delete("CropBox")
+	if inhPAttrs.cropBox != nil {
+		d.Insert("CropBox", NewRectangle(0, 0, dim.Width, dim.Height))
+	}
+
+	return nil
+}
+
+// ResizePages scales the selected pages either by a scale factor or to fit a paper size.
+func ResizePages(xRefTable *XRefTable, selectedPages IntSet, r *Resize) error {
+
+	log.Debug.Printf("ResizePages begin: %s\n", r)
+
+	visited := IntSet{}
+
+	for _, pageNr := range selectedPageNumbers(selectedPages) {
+		if err := resizePage(xRefTable, pageNr, r, visited); err != nil {
+			return err
+		}
+	}
+
+	log.Debug.Println("ResizePages end")
+
+	return nil
+}
@@ -0,0 +1,92 @@
+/*
+Copyright 2018 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pdfcpu
+
+import (
+	"strconv"
+
+	"github.com/hhrutter/pdfcpu/pkg/log"
+	"github.com/pkg/errors"
+)
+
+// ParseRotation returns the rotation in degrees for s, which needs to be a multiple of 90.
+func ParseRotation(s string) (int, error) {
+
+	r, err := strconv.Atoi(s)
+	if err != nil || r%90 != 0 {
+		return 0, errors.Errorf("rotation must be a multiple of 90: %s", s)
+	}
+
+	return r, nil
+}
+
+// normalizedRotation maps r into the range 0, 90, 180, 270.
+func normalizedRotation(r int) int {
+
+	r %= 360
+	if r < 0 {
+		r += 360
+	}
+
+	return r
+}
+
+// rotatePage adds rotation to the rotation in effect for a page.
+// An inherited rotation gets overridden by a Rotate entry in the page dict.
+func rotatePage(xRefTable *XRefTable, pageNr, rotation int) error {
+
+	pageDict, inhPAttrs, err := xRefTable.PageDict(pageNr)
+	if err != nil {
+		return err
+	}
+
+	if pageDict == nil {
+		return errors.Errorf("rotatePage: unknown page %d", pageNr)
+	}
+
+	r := normalizedRotation(int(inhPAttrs.rotate) + rotation)
+
+	log.Debug.Printf("rotatePage: page %d rotate=%d\n", pageNr, r)
+
+	pageDict.Update("Rotate", Integer(r))
+
+	return nil
+}
+
+// RotatePages rotates all selected pages clockwise by rotation degrees, which needs to be a multiple of 90.
+func RotatePages(xRefTable *XRefTable, selectedPages IntSet, rotation int) error {
+
+	log.Debug.Printf("RotatePages begin: rotation=%d\n", rotation)
+
+	if rotation%90 != 0 {
+		return errors.Errorf("RotatePages: rotation must be a multiple of 90: %d", rotation)
+	}
+
+	for pageNr, v := range selectedPages {
+		if !v {
+			continue
+		}
+		err := rotatePage(xRefTable, pageNr, rotation)
+		if err != nil {
+			return err
+		}
+	}
+
+	log.Debug.Println("RotatePages end")
+
+	return nil
+}
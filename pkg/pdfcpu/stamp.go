@@ -922,7 +922,10 @@ func updatePageContentsForWM(xRefTable *XRefTable, obj Object, wm *Watermark, gs
 		}
 		generationNumber := indRef.GenerationNumber.Value()
 		entry, _ = xRefTable.FindTableEntry(objNr, generationNumber)
-		obj = entry.Object
+		var err error
+		if obj, err = xRefTable.Dereference(indRef); err != nil {
+			return err
+		}
 	}
 
 	switch o := obj.(type) {
@@ -960,8 +963,12 @@ func updatePageContentsForWM(xRefTable *XRefTable, obj Object, wm *Watermark, gs
 
 		generationNumber := indRef.GenerationNumber.Value()
 		entry, _ := xRefTable.FindTableEntry(objNr, generationNumber)
-		sd, _ := (entry.Object).(StreamDict)
-		err := patchContentForWM(&sd, gsID, xoID, wm)
+		o1, err := xRefTable.Dereference(indRef)
+		if err != nil {
+			return err
+		}
+		sd, _ := o1.(StreamDict)
+		err = patchContentForWM(&sd, gsID, xoID, wm)
 		if err != nil {
 			return err
 		}
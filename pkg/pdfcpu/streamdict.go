@@ -190,5 +190,9 @@ func NewXRefStreamDict(ctx *Context) *XRefStreamDict {
 		streamDict.Insert("Encrypt", *ctx.Encrypt)
 	}
 
+	if ctx.Incremental {
+		streamDict.Insert("Prev", Integer(ctx.Read.OffsetLastXRefSection))
+	}
+
 	return &XRefStreamDict{StreamDict: streamDict}
 }
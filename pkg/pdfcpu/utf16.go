@@ -142,6 +142,18 @@ func DecodeUTF16String(s string) (string, error) {
 	return decodeUTF16String([]byte(s))
 }
 
+// EncodeUTF16String encodes s as UTF16BE including the byte order mark.
+func EncodeUTF16String(s string) string {
+
+	b := []byte{0xFE, 0xFF}
+
+	for _, u := range utf16.Encode([]rune(s)) {
+		b = append(b, byte(u>>8), byte(u))
+	}
+
+	return string(b)
+}
+
 // StringLiteralToString returns the best possible string rep for a string literal.
 func StringLiteralToString(s string) (string, error) {
 
@@ -21,7 +21,9 @@ import (
 	"bytes"
 	"encoding/hex"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
 	"sort"
 	"strings"
 
@@ -40,19 +42,29 @@ func prepareContextForWriting(ctx *Context) error {
 }
 
 // WritePDFFile generates a PDF file for the cross reference table contained in Context.
-func WritePDFFile(ctx *Context) error {
+func WritePDFFile(ctx *Context) (err error) {
 
 	fileName := ctx.Write.DirName + ctx.Write.FileName
 
 	log.Info.Printf("writing to %s\n", fileName)
 
+	if ctx.Incremental {
+		return writeIncrementalPDFFile(ctx, fileName)
+	}
+
+	// Overwriting a lazily loaded file requires all objects in memory.
+	if ctx.cache != nil && filepath.Clean(fileName) == filepath.Clean(ctx.Read.FileName) {
+		err = ensureLoaded(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
 	file, err := os.Create(fileName)
 	if err != nil {
 		return errors.Wrapf(err, "can't create %s\n%s", fileName, err)
 	}
 
-	ctx.Write.Writer = bufio.NewWriter(file)
-
 	defer func() {
 
 		// The underlying bufio.Writer has already been flushed.
@@ -68,7 +80,55 @@ func WritePDFFile(ctx *Context) error {
 
 	}()
 
-	err = prepareContextForWriting(ctx)
+	return Write(ctx, file)
+}
+
+// FileCreator creates an output file of a command producing several files.
+// Writers implementing io.Closer get closed once written.
+type FileCreator func(fileName string) (io.Writer, error)
+
+func createFile(fileName string) (io.Writer, error) {
+	return os.Create(fileName)
+}
+
+// DirFileCreator returns a FileCreator for files in dirName.
+func DirFileCreator(dirName string) FileCreator {
+	return func(fileName string) (io.Writer, error) {
+		return os.Create(filepath.Join(dirName, fileName))
+	}
+}
+
+// WriteOutputFile writes an output file created by create.
+func WriteOutputFile(create FileCreator, fileName string, write func(w io.Writer) error) (err error) {
+
+	w, err := create(fileName)
+	if err != nil {
+		return err
+	}
+
+	if c, ok := w.(io.Closer); ok {
+		defer func() {
+			// Processing error takes precedence.
+			if err1 := c.Close(); err == nil {
+				err = err1
+			}
+		}()
+	}
+
+	return write(w)
+}
+
+// Write generates a PDF for the cross reference table contained in Context and writes it to w.
+func Write(ctx *Context, w io.Writer) error {
+
+	if ctx.Incremental {
+		return writeIncrementalUpdate(ctx, w)
+	}
+
+	cw := &countingWriter{w: w}
+	ctx.Write.Writer = bufio.NewWriter(cw)
+
+	err := prepareContextForWriting(ctx)
 	if err != nil {
 		return err
 	}
@@ -131,7 +191,7 @@ func WritePDFFile(ctx *Context) error {
 		return err
 	}
 
-	err = setFileSizeOfWrittenFile(ctx.Write, file)
+	err = setFileSizeOfWrittenFile(ctx.Write, cw)
 	if err != nil {
 		return err
 	}
@@ -219,10 +279,19 @@ func writePages(ctx *Context, rootDict *Dict) error {
 	// Manipulate page tree as needed for splitting, trimming or page extraction.
 	if ctx.Write.ExtractPages != nil && len(ctx.Write.ExtractPages) > 0 {
 		p := 0
-		_, err := trimPagesDict(ctx, indRef, &p)
+		var trimmed []pageNodeState
+		_, err := trimPagesDict(ctx, indRef, &p, &trimmed)
 		if err != nil {
 			return err
 		}
+
+		// Restore the page tree so the same Context may be written again for another set of pages.
+		defer func() {
+			for _, s := range trimmed {
+				s.dict.Update("Kids", s.kids)
+				s.dict.Update("Count", s.count)
+			}
+		}()
 	}
 
 	// Embed all page tree objects into objects stream.
@@ -390,6 +459,10 @@ func writeTrailerDict(ctx *Context) error {
 		dict.Insert("ID", *xRefTable.ID)
 	}
 
+	if ctx.Incremental {
+		dict.Insert("Prev", Integer(ctx.Read.OffsetLastXRefSection))
+	}
+
 	_, err = w.WriteString(dict.PDFString())
 	if err != nil {
 		return err
@@ -1004,21 +1077,28 @@ func writeXRef(ctx *Context) error {
 	return writeXRefTable(ctx)
 }
 
-func setFileSizeOfWrittenFile(w *WriteContext, f *os.File) error {
+// countingWriter keeps track of the number of bytes written to w.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
 
-	// Get file info for file just written but flush first to get correct file size.
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, err
+}
 
-	err := w.Flush()
-	if err != nil {
-		return err
-	}
+func setFileSizeOfWrittenFile(w *WriteContext, cw *countingWriter) error {
 
-	fileInfo, err := f.Stat()
+	// Flush first to get correct file size.
+
+	err := w.Flush()
 	if err != nil {
 		return err
 	}
 
-	w.FileSize = fileInfo.Size()
+	w.FileSize = cw.n
 
 	return nil
 }
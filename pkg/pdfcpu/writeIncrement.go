@@ -0,0 +1,349 @@
+/*
+Copyright 2018 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pdfcpu
+
+import (
+	"bufio"
+	"crypto/md5"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/hhrutter/pdfcpu/pkg/log"
+	"github.com/pkg/errors"
+)
+
+// See 7.5.6 Incremental Updates
+
+// fingerprint represents the state of an object.
+type fingerprint [md5.Size]byte
+
+// objectFingerprint returns the fingerprint of an object.
+// Object streams and xref streams are never rewritten and have no fingerprint.
+func objectFingerprint(o Object) (fingerprint, bool) {
+
+	switch o := o.(type) {
+
+	case ObjectStreamDict, XRefStreamDict:
+		return fingerprint{}, false
+
+	case StreamDict:
+		h := md5.New()
+		h.Write([]byte(o.Dict.PDFString()))
+		h.Write(o.Raw)
+		var fp fingerprint
+		copy(fp[:], h.Sum(nil))
+		return fp, true
+
+	case nil:
+		return md5.Sum([]byte("null")), true
+
+	}
+
+	return md5.Sum([]byte(o.PDFString())), true
+}
+
+// recordFingerprints saves the fingerprints of all objects in use.
+func recordFingerprints(ctx *Context) {
+
+	ctx.Read.fingerprints = map[int]fingerprint{}
+
+	for i, entry := range ctx.Table {
+
+		if entry.Free || entry.Compressed {
+			continue
+		}
+
+		// When lazy loading objects get fingerprinted on first access.
+		if entry.Object == nil && ctx.cache != nil {
+			continue
+		}
+
+		if fp, ok := objectFingerprint(entry.Object); ok {
+			ctx.Read.fingerprints[i] = fp
+		}
+	}
+}
+
+// modified returns true if object #objNr is new or has been modified since reading.
+func modified(ctx *Context, objNr int, o Object) bool {
+
+	fp, ok := objectFingerprint(o)
+	if !ok {
+		return false
+	}
+
+	fpOrig, found := ctx.Read.fingerprints[objNr]
+
+	return !found || fp != fpOrig
+}
+
+func checkIncrementalUpdate(ctx *Context) error {
+
+	if ctx.Read == nil || ctx.Read.fingerprints == nil {
+		return errors.New("incremental update: missing original state, enable Incremental before reading")
+	}
+
+	switch ctx.Mode {
+	case ENCRYPT, DECRYPT, CHANGEUPW, CHANGEOPW, ADDPERMISSIONS:
+		return errors.New("incremental update: not supported for changing the encryption")
+	}
+
+	if ctx.Write.ReducedFeatureSet() {
+		return errors.Errorf("incremental update: not supported for %s", ctx.Write.Command)
+	}
+
+	return nil
+}
+
+func writeModifiedObject(ctx *Context, objNr, genNr int, o Object) error {
+
+	switch obj := o.(type) {
+
+	case nil:
+		return writePDFNullObject(ctx, objNr, genNr)
+
+	case Dict:
+		return writeDictObject(ctx, objNr, genNr, obj)
+
+	case StreamDict:
+		if ctx.EncKey != nil {
+			_, err := encryptDeepObject(obj, objNr, genNr, ctx.EncKey, ctx.AES4Strings)
+			if err != nil {
+				return err
+			}
+		}
+		return writeStreamDictObject(ctx, objNr, genNr, obj)
+
+	case Array:
+		return writeArrayObject(ctx, objNr, genNr, obj)
+
+	case Integer:
+		return writeIntegerObject(ctx, objNr, genNr, obj)
+
+	case Float:
+		return writeFloatObject(ctx, objNr, genNr, obj)
+
+	case StringLiteral:
+		return writeStringLiteralObject(ctx, objNr, genNr, obj)
+
+	case HexLiteral:
+		return writeHexLiteralObject(ctx, objNr, genNr, obj)
+
+	case Boolean:
+		return writeBooleanObject(ctx, objNr, genNr, obj)
+
+	case Name:
+		return writeNameObject(ctx, objNr, genNr, obj)
+
+	}
+
+	return errors.Errorf("writeModifiedObject: undefined PDF object #%d %T\n", objNr, o)
+}
+
+// writeModifiedObjects writes all new and modified objects.
+func writeModifiedObjects(ctx *Context) error {
+
+	var keys []int
+	for i := range ctx.Table {
+		keys = append(keys, i)
+	}
+	sort.Ints(keys)
+
+	for _, objNr := range keys {
+
+		entry := ctx.Table[objNr]
+
+		if entry.Free || entry.Compressed || ctx.Write.HasWriteOffset(objNr) {
+			continue
+		}
+
+		o := entry.Object
+
+		if ctx.cache != nil {
+
+			if o == nil {
+				// Never loaded and therefore unmodified.
+				continue
+			}
+
+			// Reload released stream content.
+			var err error
+			if o, err = ctx.cache.object(objNr, entry); err != nil {
+				return err
+			}
+		}
+
+		if !modified(ctx, objNr, o) {
+			continue
+		}
+
+		log.Debug.Printf("writeModifiedObjects: obj #%d\n", objNr)
+
+		err := writeModifiedObject(ctx, objNr, *entry.Generation, o)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeIncrement appends all new and modified objects followed by a cross reference section
+// pointing back to the original cross reference section to w.
+func writeIncrement(ctx *Context, w io.Writer) error {
+
+	err := checkIncrementalUpdate(ctx)
+	if err != nil {
+		return err
+	}
+
+	cw := &countingWriter{w: w}
+	ctx.Write.Writer = bufio.NewWriter(cw)
+	ctx.Write.Offset = ctx.Read.FileSize
+
+	err = prepareContextForWriting(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Since we support PDF Collections (since V1.7) for file attachments
+	// we need to declare V1.7 via the catalog for the update.
+	if ctx.Version() < V17 {
+		rootDict, err := ctx.Catalog()
+		if err != nil {
+			return err
+		}
+		v := V17
+		rootDict.Update("Version", Name(v.String()))
+		ctx.RootVersion = &v
+	}
+
+	// Ensure corresponding and accurate name tree object graphs.
+	err = ctx.BindNameTrees()
+	if err != nil {
+		return err
+	}
+
+	// Separate the update from the original %%EOF.
+	err = ctx.Write.WriteEol()
+	if err != nil {
+		return err
+	}
+	ctx.Write.Offset += int64(len(ctx.Write.Eol))
+
+	err = writeModifiedObjects(ctx)
+	if err != nil {
+		return err
+	}
+
+	// The update has to use the cross reference format of the original file.
+	if ctx.Read.UsingXRefStreams {
+		err = writeXRefStream(ctx)
+	} else {
+		err = writeXRefTable(ctx)
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = writeTrailer(ctx.Write)
+	if err != nil {
+		return err
+	}
+
+	err = setFileSizeOfWrittenFile(ctx.Write, cw)
+	if err != nil {
+		return err
+	}
+
+	ctx.Write.FileSize += ctx.Read.FileSize
+
+	return nil
+}
+
+// writeIncrementalUpdate writes the original PDF followed by an incremental update to w.
+func writeIncrementalUpdate(ctx *Context, w io.Writer) error {
+
+	if ctx.Read == nil || ctx.Read.RS == nil {
+		return errors.New("incremental update: original PDF not available")
+	}
+
+	if _, err := ctx.Read.RS.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	if _, err := io.CopyN(w, ctx.Read.RS, ctx.Read.FileSize); err != nil {
+		return err
+	}
+
+	return writeIncrement(ctx, w)
+}
+
+// writeIncrementalPDFFile appends an incremental update to the original PDF file
+// or writes a copy of the original file including the update to fileName.
+func writeIncrementalPDFFile(ctx *Context, fileName string) (err error) {
+
+	if ctx.Read.FileName == "" {
+		return errors.New("incremental update: original PDF file not available")
+	}
+
+	var file *os.File
+
+	defer func() {
+		if file == nil {
+			return
+		}
+		// Processing error takes precedence.
+		if err != nil {
+			file.Close()
+			return
+		}
+		err = file.Close()
+	}()
+
+	if filepath.Clean(fileName) == filepath.Clean(ctx.Read.FileName) {
+
+		// Update in place.
+		file, err = os.OpenFile(fileName, os.O_WRONLY|os.O_APPEND, 0)
+		if err != nil {
+			return errors.Wrapf(err, "can't open %s", fileName)
+		}
+
+		return writeIncrement(ctx, file)
+	}
+
+	// When lazy loading the original file is still open.
+	if ctx.cache == nil {
+
+		orig, err := os.Open(ctx.Read.FileName)
+		if err != nil {
+			return errors.Wrapf(err, "can't open %q", ctx.Read.FileName)
+		}
+		defer orig.Close()
+
+		ctx.Read.RS = orig
+	}
+
+	file, err = os.Create(fileName)
+	if err != nil {
+		return errors.Wrapf(err, "can't create %s", fileName)
+	}
+
+	return writeIncrementalUpdate(ctx, file)
+}
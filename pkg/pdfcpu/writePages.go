@@ -367,7 +367,13 @@ func writePagesDict(ctx *Context, indRef *IndirectRef, pageCount int) error {
 	return nil
 }
 
-func trimPagesDict(ctx *Context, indRef *IndirectRef, pageCount *int) (count int, err error) {
+// pageNodeState holds the original "Kids" and "Count" of a trimmed page tree node.
+type pageNodeState struct {
+	dict        Dict
+	kids, count Object
+}
+
+func trimPagesDict(ctx *Context, indRef *IndirectRef, pageCount *int, trimmed *[]pageNodeState) (count int, err error) {
 
 	xRefTable := ctx.XRefTable
 	objNumber := int(indRef.ObjectNumber)
@@ -416,7 +422,7 @@ func trimPagesDict(ctx *Context, indRef *IndirectRef, pageCount *int) (count int
 
 		case "Pages":
 			// Recurse over pagetree
-			trimmedCount, err := trimPagesDict(ctx, indRef, pageCount)
+			trimmedCount, err := trimPagesDict(ctx, indRef, pageCount, trimmed)
 			if err != nil {
 				return 0, err
 			}
@@ -440,6 +446,8 @@ func trimPagesDict(ctx *Context, indRef *IndirectRef, pageCount *int) (count int
 
 	}
 
+	*trimmed = append(*trimmed, pageNodeState{dict: dict, kids: *kidsArray, count: c})
+
 	log.Debug.Printf("trimPagesDict end: This page node is trimmed to %d pages\n", count)
 	dict.Update("Count", Integer(count))
 
@@ -0,0 +1,352 @@
+/*
+Copyright 2018 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pdfcpu
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+type xfdfFile struct {
+	Href string `xml:"href,attr"`
+}
+
+type xfdfField struct {
+	Name   string      `xml:"name,attr"`
+	Values []string    `xml:"value"`
+	Fields []xfdfField `xml:"field"`
+}
+
+// xfdfAnnot represents an annotation element named after the lower case annotation subtype.
+type xfdfAnnot struct {
+	XMLName       xml.Name
+	Page          int      `xml:"page,attr"` // zero based page index.
+	Rect          string   `xml:"rect,attr"`
+	Name          string   `xml:"name,attr,omitempty"`
+	Title         string   `xml:"title,attr,omitempty"`
+	Color         string   `xml:"color,attr,omitempty"`
+	InteriorColor string   `xml:"interior-color,attr,omitempty"`
+	Icon          string   `xml:"icon,attr,omitempty"`
+	Open          string   `xml:"open,attr,omitempty"`
+	Start         string   `xml:"start,attr,omitempty"`  // Line
+	End           string   `xml:"end,attr,omitempty"`    // Line
+	Coords        string   `xml:"coords,attr,omitempty"` // text markup annotations
+	Contents      string   `xml:"contents,omitempty"`
+	Vertices      string   `xml:"vertices,omitempty"`        // Polygon, PolyLine
+	InkList       []string `xml:"inklist>gesture,omitempty"` // Ink
+}
+
+type xfdfAnnots struct {
+	Annots []xfdfAnnot `xml:",any"`
+}
+
+// xfdf represents an XFDF file, the XML representation of FDF as specified by ISO 19444-1.
+type xfdf struct {
+	XMLName xml.Name    `xml:"http://ns.adobe.com/xfdf/ xfdf"`
+	F       *xfdfFile   `xml:"f"`
+	Fields  []xfdfField `xml:"fields>field"`
+	Annots  *xfdfAnnots `xml:"annots"`
+}
+
+// xfdfNumbers returns the numbers of f as comma separated list.
+func xfdfNumbers(f []float64) string {
+
+	ss := make([]string, len(f))
+	for i, n := range f {
+		ss[i] = strconv.FormatFloat(n, 'f', -1, 64)
+	}
+
+	return strings.Join(ss, ",")
+}
+
+// xfdfPoints returns the coordinates of f as list of points separated by semicolons.
+func xfdfPoints(f []float64) string {
+
+	var ss []string
+	for i := 0; i+1 < len(f); i += 2 {
+		ss = append(ss, xfdfNumbers(f[i:i+2]))
+	}
+
+	return strings.Join(ss, ";")
+}
+
+// parseXFDFNumbers parses a list of numbers separated by commas or semicolons.
+func parseXFDFNumbers(s string) ([]float64, error) {
+
+	var f []float64
+
+	for _, s := range strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ';' || r == ' ' }) {
+		n, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return nil, errors.Errorf("invalid number %q", s)
+		}
+		f = append(f, n)
+	}
+
+	return f, nil
+}
+
+// xfdfColor returns the RGB color c as #RRGGBB.
+func xfdfColor(c []float64) string {
+
+	if len(c) != 3 {
+		return ""
+	}
+
+	return fmt.Sprintf("#%02X%02X%02X", int(c[0]*255+0.5), int(c[1]*255+0.5), int(c[2]*255+0.5))
+}
+
+// parseXFDFColor parses a color given as #RRGGBB.
+func parseXFDFColor(s string) ([]float64, error) {
+
+	if s == "" {
+		return nil, nil
+	}
+
+	if len(s) != 7 || s[0] != '#' {
+		return nil, errors.Errorf("invalid color %q", s)
+	}
+
+	var c []float64
+
+	for i := 1; i < 7; i += 2 {
+		n, err := strconv.ParseUint(s[i:i+2], 16, 8)
+		if err != nil {
+			return nil, errors.Errorf("invalid color %q", s)
+		}
+		c = append(c, float64(n)/255)
+	}
+
+	return c, nil
+}
+
+func xfdfFields(nodes []*fieldNode) []xfdfField {
+
+	var ff []xfdfField
+
+	for _, n := range nodes {
+		f := xfdfField{Name: n.name, Fields: xfdfFields(n.kids)}
+		if n.field != nil {
+			f.Values = fieldValues(n.field)
+		}
+		ff = append(ff, f)
+	}
+
+	return ff
+}
+
+func newXFDFAnnot(a Annotation) (*xfdfAnnot, error) {
+
+	if len(a.Rect) != 4 {
+		return nil, errors.Errorf("%s annotation: rect needs 4 coordinates", a.Type)
+	}
+
+	x := &xfdfAnnot{
+		XMLName:       xml.Name{Local: strings.ToLower(a.Type)},
+		Page:          a.PageNr - 1,
+		Rect:          xfdfNumbers(a.Rect),
+		Name:          a.ID,
+		Title:         a.Author,
+		Color:         xfdfColor(a.Color),
+		InteriorColor: xfdfColor(a.InteriorColor),
+		Icon:          a.Icon,
+		Contents:      a.Contents,
+	}
+
+	if a.Open {
+		x.Open = "yes"
+	}
+
+	switch a.Type {
+
+	case "Line":
+		if len(a.Points) == 4 {
+			x.Start, x.End = xfdfNumbers(a.Points[:2]), xfdfNumbers(a.Points[2:])
+		}
+
+	case "Polygon", "PolyLine":
+		x.Vertices = xfdfPoints(a.Points)
+
+	case "Highlight", "Underline", "Squiggly", "StrikeOut":
+		x.Coords = xfdfNumbers(a.Points)
+
+	case "Ink":
+		for _, path := range a.InkList {
+			x.InkList = append(x.InkList, xfdfPoints(path))
+		}
+	}
+
+	return x, nil
+}
+
+// WriteXFDF writes fd as XFDF file to w.
+func WriteXFDF(w io.Writer, fd *FormData) error {
+
+	x := xfdf{Fields: xfdfFields(fieldTree(fd.Fields))}
+
+	if fd.File != "" {
+		x.F = &xfdfFile{Href: fd.File}
+	}
+
+	if len(fd.Annotations) > 0 {
+		x.Annots = &xfdfAnnots{}
+		for _, a := range fd.Annotations {
+			xa, err := newXFDFAnnot(a)
+			if err != nil {
+				return err
+			}
+			x.Annots.Annots = append(x.Annots.Annots, *xa)
+		}
+	}
+
+	bb, err := xml.MarshalIndent(x, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "%s%s\n", xml.Header, bb)
+
+	return err
+}
+
+// parseXFDFFields adds the fields holding values to ff.
+func parseXFDFFields(xx []xfdfField, prefix string, ff *[]Field) {
+
+	for _, x := range xx {
+
+		name := x.Name
+		if prefix != "" {
+			name = prefix + "." + name
+		}
+
+		if x.Values != nil {
+			*ff = append(*ff, newField(name, x.Values))
+		}
+
+		parseXFDFFields(x.Fields, name, ff)
+	}
+}
+
+// xfdfAnnotationType returns the annotation type for an XFDF annotation element.
+func xfdfAnnotationType(element string) (string, error) {
+
+	for t := range annotationTypes {
+		if strings.ToLower(t) == element {
+			return t, nil
+		}
+	}
+
+	return "", errors.Errorf("unsupported annotation %q", element)
+}
+
+func parseXFDFAnnot(x xfdfAnnot) (*Annotation, error) {
+
+	t, err := xfdfAnnotationType(x.XMLName.Local)
+	if err != nil {
+		return nil, err
+	}
+
+	a := &Annotation{
+		Type:     t,
+		PageNr:   x.Page + 1,
+		ID:       x.Name,
+		Author:   x.Title,
+		Icon:     x.Icon,
+		Open:     x.Open == "yes",
+		Contents: x.Contents,
+	}
+
+	if a.Rect, err = parseXFDFNumbers(x.Rect); err != nil {
+		return nil, err
+	}
+
+	if a.Color, err = parseXFDFColor(x.Color); err != nil {
+		return nil, err
+	}
+
+	if a.InteriorColor, err = parseXFDFColor(x.InteriorColor); err != nil {
+		return nil, err
+	}
+
+	switch t {
+
+	case "Line":
+		if a.Points, err = parseXFDFNumbers(x.Start + "," + x.End); err != nil {
+			return nil, err
+		}
+
+	case "Polygon", "PolyLine":
+		if a.Points, err = parseXFDFNumbers(x.Vertices); err != nil {
+			return nil, err
+		}
+
+	case "Highlight", "Underline", "Squiggly", "StrikeOut":
+		if a.Points, err = parseXFDFNumbers(x.Coords); err != nil {
+			return nil, err
+		}
+
+	case "Ink":
+		for _, s := range x.InkList {
+			path, err := parseXFDFNumbers(s)
+			if err != nil {
+				return nil, err
+			}
+			a.InkList = append(a.InkList, path)
+		}
+	}
+
+	return a, nil
+}
+
+// ReadXFDF reads form data from an XFDF file.
+func ReadXFDF(r io.Reader) (*FormData, error) {
+
+	var x xfdf
+
+	if err := xml.NewDecoder(r).Decode(&x); err != nil {
+		return nil, errors.Wrap(err, "ReadXFDF")
+	}
+
+	fd := &FormData{}
+
+	if x.F != nil {
+		fd.File = x.F.Href
+	}
+
+	parseXFDFFields(x.Fields, "", &fd.Fields)
+
+	if x.Annots != nil {
+		for _, xa := range x.Annots.Annots {
+			// Popups go along with their parent annotation.
+			if xa.XMLName.Local == "popup" {
+				continue
+			}
+			a, err := parseXFDFAnnot(xa)
+			if err != nil {
+				return nil, errors.Wrap(err, "ReadXFDF")
+			}
+			fd.Annotations = append(fd.Annotations, *a)
+		}
+	}
+
+	return fd, nil
+}
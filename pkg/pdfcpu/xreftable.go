@@ -117,6 +117,8 @@ type XRefTable struct {
 	ValidationMode int  // see Configuration
 
 	Optimized bool
+
+	cache *objectCache // Loads objects on demand, lazy loading only.
 }
 
 // NewXRefTable creates a new XRefTable.
@@ -218,6 +220,10 @@ func (xRefTable *XRefTable) FindObject(objNumber int) (Object, error) {
 		return nil, errors.Errorf("FindObject: obj#%d not registered in xRefTable", objNumber)
 	}
 
+	if xRefTable.cache != nil && !entry.Free {
+		return xRefTable.cache.object(objNumber, entry)
+	}
+
 	return entry.Object, nil
 }
 
@@ -707,6 +713,10 @@ func (xRefTable *XRefTable) indRefToObject(indObjRef *IndirectRef) (Object, erro
 		return nil, nil
 	}
 
+	if xRefTable.cache != nil {
+		return xRefTable.cache.object(objectNumber, entry)
+	}
+
 	if entry.Object == nil {
 		return nil, nil
 	}
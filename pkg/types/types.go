@@ -52,3 +52,13 @@ func (r Rectangle) String() string {
 func NewRectangle(llx, lly, urx, ury float64) Rectangle {
 	return Rectangle{LL: Point{llx, lly}, UR: Point{urx, ury}}
 }
+
+// Dim represents the dimensions of a rectangular region in userspace.
+type Dim struct {
+	Width, Height float64
+}
+
+// Landscape returns true if d is wider than high.
+func (d Dim) Landscape() bool {
+	return d.Width > d.Height
+}
@@ -0,0 +1,516 @@
+/*
+Copyright 2018 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package xmp provides parsing and writing of XMP metadata packets.
+package xmp
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Namespaces supported by this package.
+const (
+	NSRDF    = "http://www.w3.org/1999/02/22-rdf-syntax-ns#"
+	NSDC     = "http://purl.org/dc/elements/1.1/"
+	NSXMP    = "http://ns.adobe.com/xap/1.0/"
+	NSPDF    = "http://ns.adobe.com/pdf/1.3/"
+	NSPDFAID = "http://www.aiim.org/pdfa/ns/id/"
+	NSXMPMM  = "http://ns.adobe.com/xap/1.0/mm/"
+
+	nsXML = "http://www.w3.org/XML/1998/namespace"
+	nsX   = "adobe:ns:meta/"
+)
+
+// The prefixes used for writing the supported namespaces.
+var prefixes = map[string]string{
+	NSDC:     "dc",
+	NSXMP:    "xmp",
+	NSPDF:    "pdf",
+	NSPDFAID: "pdfaid",
+	NSXMPMM:  "xmpMM",
+}
+
+// DublinCore represents the Dublin Core properties of a document.
+type DublinCore struct {
+	Title            string            // dc:title, default language.
+	TitleLangs       map[string]string // dc:title in other languages keyed by language tag.
+	Creator          []string          // dc:creator
+	Description      string            // dc:description, default language.
+	DescriptionLangs map[string]string // dc:description in other languages keyed by language tag.
+	Subject          []string          // dc:subject
+	Format           string            // dc:format
+}
+
+// Basic represents the XMP basic properties of a document.
+type Basic struct {
+	CreateDate   string // xmp:CreateDate
+	ModifyDate   string // xmp:ModifyDate
+	MetadataDate string // xmp:MetadataDate
+	CreatorTool  string // xmp:CreatorTool
+}
+
+// PDF represents the Adobe PDF properties of a document.
+type PDF struct {
+	Producer   string // pdf:Producer
+	Keywords   string // pdf:Keywords
+	PDFVersion string // pdf:PDFVersion
+	Trapped    string // pdf:Trapped
+}
+
+// PDFAID represents the PDF/A identification of a document.
+type PDFAID struct {
+	Part        int    // pdfaid:part, 0 for no PDF/A claim.
+	Conformance string // pdfaid:conformance
+}
+
+// MediaManagement represents the XMP media management properties of a document.
+type MediaManagement struct {
+	DocumentID string // xmpMM:DocumentID
+	InstanceID string // xmpMM:InstanceID
+}
+
+// Metadata represents the content of an XMP packet.
+type Metadata struct {
+	DC     DublinCore
+	XMP    Basic
+	PDF    PDF
+	PDFAID PDFAID
+	XMPMM  MediaManagement
+
+	namespaces map[string]string // prefix -> namespace declarations of a parsed packet.
+	other      []string          // properties not covered above as found in a parsed packet.
+}
+
+// DateString returns an XMP representation of t.
+func DateString(t time.Time) string {
+	return t.Format("2006-01-02T15:04:05-07:00")
+}
+
+type parser struct {
+	b  []byte // the packet.
+	d  *xml.Decoder
+	md *Metadata
+}
+
+// The supported properties along with their setters.
+var setters = map[xml.Name]func(md *Metadata, vv []string){
+	{Space: NSDC, Local: "title"}:       func(md *Metadata, vv []string) { md.DC.Title = vv[0] },
+	{Space: NSDC, Local: "creator"}:     func(md *Metadata, vv []string) { md.DC.Creator = vv },
+	{Space: NSDC, Local: "description"}: func(md *Metadata, vv []string) { md.DC.Description = vv[0] },
+	{Space: NSDC, Local: "subject"}:     func(md *Metadata, vv []string) { md.DC.Subject = vv },
+	{Space: NSDC, Local: "format"}:      func(md *Metadata, vv []string) { md.DC.Format = vv[0] },
+
+	{Space: NSXMP, Local: "CreateDate"}:   func(md *Metadata, vv []string) { md.XMP.CreateDate = vv[0] },
+	{Space: NSXMP, Local: "ModifyDate"}:   func(md *Metadata, vv []string) { md.XMP.ModifyDate = vv[0] },
+	{Space: NSXMP, Local: "MetadataDate"}: func(md *Metadata, vv []string) { md.XMP.MetadataDate = vv[0] },
+	{Space: NSXMP, Local: "CreatorTool"}:  func(md *Metadata, vv []string) { md.XMP.CreatorTool = vv[0] },
+
+	{Space: NSPDF, Local: "Producer"}:   func(md *Metadata, vv []string) { md.PDF.Producer = vv[0] },
+	{Space: NSPDF, Local: "Keywords"}:   func(md *Metadata, vv []string) { md.PDF.Keywords = vv[0] },
+	{Space: NSPDF, Local: "PDFVersion"}: func(md *Metadata, vv []string) { md.PDF.PDFVersion = vv[0] },
+	{Space: NSPDF, Local: "Trapped"}:    func(md *Metadata, vv []string) { md.PDF.Trapped = vv[0] },
+
+	{Space: NSPDFAID, Local: "part"}:        func(md *Metadata, vv []string) { md.PDFAID.Part, _ = strconv.Atoi(vv[0]) },
+	{Space: NSPDFAID, Local: "conformance"}: func(md *Metadata, vv []string) { md.PDFAID.Conformance = vv[0] },
+
+	{Space: NSXMPMM, Local: "DocumentID"}: func(md *Metadata, vv []string) { md.XMPMM.DocumentID = vv[0] },
+	{Space: NSXMPMM, Local: "InstanceID"}: func(md *Metadata, vv []string) { md.XMPMM.InstanceID = vv[0] },
+}
+
+// The setters for the language alternatives of the supported language alternative properties.
+var langSetters = map[xml.Name]func(md *Metadata, langs map[string]string){
+	{Space: NSDC, Local: "title"}:       func(md *Metadata, langs map[string]string) { md.DC.TitleLangs = langs },
+	{Space: NSDC, Local: "description"}: func(md *Metadata, langs map[string]string) { md.DC.DescriptionLangs = langs },
+}
+
+// Parse parses an XMP packet.
+// Properties not covered by Metadata are preserved for writing.
+func Parse(b []byte) (*Metadata, error) {
+
+	p := &parser{b: b, d: xml.NewDecoder(bytes.NewReader(b)), md: &Metadata{namespaces: map[string]string{}}}
+
+	var found bool
+
+	for {
+
+		t, err := p.d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "xmp: corrupt packet")
+		}
+
+		se, ok := t.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		p.declare(se)
+
+		if se.Name.Space == NSRDF && se.Name.Local == "Description" {
+			found = true
+			if err = p.description(se); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if !found {
+		return nil, errors.New("xmp: missing rdf:Description")
+	}
+
+	return p.md, nil
+}
+
+// declare records the namespace declarations of se.
+func (p *parser) declare(se xml.StartElement) {
+	for _, a := range se.Attr {
+		if a.Name.Space == "xmlns" {
+			p.md.namespaces[a.Name.Local] = a.Value
+		}
+	}
+}
+
+func (p *parser) prefix(ns string) string {
+
+	for k, v := range p.md.namespaces {
+		if v == ns {
+			return k
+		}
+	}
+
+	// Go's decoder does not report undeclared prefixes.
+	prefix := fmt.Sprintf("ns%d", len(p.md.namespaces))
+	p.md.namespaces[prefix] = ns
+
+	return prefix
+}
+
+// description parses the properties of an rdf:Description element.
+func (p *parser) description(se xml.StartElement) error {
+
+	// Simple properties may be written as attributes.
+	for _, a := range se.Attr {
+
+		if a.Name.Space == "xmlns" || a.Name.Space == "" || a.Name.Space == NSRDF || a.Name.Space == nsXML {
+			continue
+		}
+
+		if f, ok := setters[a.Name]; ok {
+			f(p.md, []string{a.Value})
+			continue
+		}
+
+		prefix := p.prefix(a.Name.Space)
+
+		var buf bytes.Buffer
+		xml.EscapeText(&buf, []byte(a.Value))
+		p.md.other = append(p.md.other, fmt.Sprintf("<%s:%s>%s</%s:%s>", prefix, a.Name.Local, buf.String(), prefix, a.Name.Local))
+	}
+
+	for {
+
+		off := p.d.InputOffset()
+
+		t, err := p.d.Token()
+		if err != nil {
+			return errors.Wrap(err, "xmp: corrupt rdf:Description")
+		}
+
+		switch t := t.(type) {
+
+		case xml.StartElement:
+
+			p.declare(t)
+
+			if f, ok := setters[t.Name]; ok {
+				vv, langs, err := p.values()
+				if err != nil {
+					return err
+				}
+				if len(vv) > 0 {
+					f(p.md, vv)
+				}
+				if f, ok := langSetters[t.Name]; ok && len(langs) > 0 {
+					f(p.md, langs)
+				}
+				continue
+			}
+
+			if err = p.d.Skip(); err != nil {
+				return errors.Wrap(err, "xmp: corrupt rdf:Description")
+			}
+
+			p.md.other = append(p.md.other, string(p.b[off:p.d.InputOffset()]))
+
+		case xml.EndElement:
+			return nil
+		}
+	}
+}
+
+// values returns the values of a simple property or the items of an array property.
+// The default language item of an alternative array comes first,
+// langs holds the items of an alternative array tagged with any other language.
+func (p *parser) values() (vv []string, langs map[string]string, err error) {
+
+	var (
+		text  string
+		item  *string
+		lang  string
+		depth int
+	)
+
+	for {
+
+		t, err := p.d.Token()
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "xmp: corrupt property")
+		}
+
+		switch t := t.(type) {
+
+		case xml.StartElement:
+			depth++
+			p.declare(t)
+			if t.Name.Space == NSRDF && t.Name.Local == "li" {
+				item, lang = new(string), ""
+				for _, a := range t.Attr {
+					if a.Name.Space == nsXML && a.Name.Local == "lang" {
+						lang = a.Value
+					}
+				}
+			}
+
+		case xml.CharData:
+			if item != nil {
+				*item += string(t)
+			} else if depth == 0 {
+				text += string(t)
+			}
+
+		case xml.EndElement:
+			if depth == 0 {
+				if vv == nil {
+					if text = strings.TrimSpace(text); text != "" {
+						vv = []string{text}
+					}
+				}
+				return vv, langs, nil
+			}
+			depth--
+			if t.Name.Space == NSRDF && t.Name.Local == "li" && item != nil {
+				switch lang {
+				case "x-default":
+					vv = append([]string{*item}, vv...)
+				case "":
+					vv = append(vv, *item)
+				default:
+					vv = append(vv, *item)
+					if langs == nil {
+						langs = map[string]string{}
+					}
+					langs[lang] = *item
+				}
+				item = nil
+			}
+		}
+	}
+}
+
+type writer struct {
+	bytes.Buffer
+}
+
+func (w *writer) text(s string) {
+	xml.EscapeText(w, []byte(s))
+}
+
+func (w *writer) simple(prefix, name, v string) {
+	if v == "" {
+		return
+	}
+	fmt.Fprintf(w, "   <%s:%s>", prefix, name)
+	w.text(v)
+	fmt.Fprintf(w, "</%s:%s>\n", prefix, name)
+}
+
+func (w *writer) array(prefix, name, kind string, vv []string) {
+
+	if len(vv) == 0 {
+		return
+	}
+
+	fmt.Fprintf(w, "   <%s:%s>\n    <rdf:%s>\n", prefix, name, kind)
+
+	for _, v := range vv {
+		w.WriteString("     <rdf:li>")
+		w.text(v)
+		w.WriteString("</rdf:li>\n")
+	}
+
+	fmt.Fprintf(w, "    </rdf:%s>\n   </%s:%s>\n", kind, prefix, name)
+}
+
+// alt writes a language alternative with the default language item first.
+func (w *writer) alt(prefix, name, v string, langs map[string]string) {
+
+	if v == "" && len(langs) == 0 {
+		return
+	}
+
+	fmt.Fprintf(w, "   <%s:%s>\n    <rdf:Alt>\n", prefix, name)
+
+	item := func(lang, v string) {
+		w.WriteString(`     <rdf:li xml:lang="`)
+		w.text(lang)
+		w.WriteString(`">`)
+		w.text(v)
+		w.WriteString("</rdf:li>\n")
+	}
+
+	if v != "" {
+		item("x-default", v)
+	}
+
+	var keys []string
+	for k := range langs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		item(k, langs[k])
+	}
+
+	fmt.Fprintf(w, "    </rdf:Alt>\n   </%s:%s>\n", prefix, name)
+}
+
+func (w *writer) description(ns string, f func(prefix string)) {
+
+	prefix := prefixes[ns]
+
+	start := w.Len()
+	fmt.Fprintf(w, "  <rdf:Description rdf:about=\"\" xmlns:%s=\"%s\">\n", prefix, ns)
+	n := w.Len()
+
+	f(prefix)
+
+	if w.Len() == n {
+		// No properties for this namespace.
+		w.Truncate(start)
+		return
+	}
+
+	w.WriteString("  </rdf:Description>\n")
+}
+
+// Bytes returns an XMP packet for md suitable for a PDF metadata stream.
+func (md Metadata) Bytes() []byte {
+
+	w := &writer{}
+
+	w.WriteString("<?xpacket begin=\"\ufeff\" id=\"W5M0MpCehiHzreSzNTczkc9d\"?>\n")
+	fmt.Fprintf(w, "<x:xmpmeta xmlns:x=\"%s\">\n", nsX)
+	fmt.Fprintf(w, " <rdf:RDF xmlns:rdf=\"%s\">\n", NSRDF)
+
+	w.description(NSDC, func(prefix string) {
+		w.alt(prefix, "title", md.DC.Title, md.DC.TitleLangs)
+		w.array(prefix, "creator", "Seq", md.DC.Creator)
+		w.alt(prefix, "description", md.DC.Description, md.DC.DescriptionLangs)
+		w.array(prefix, "subject", "Bag", md.DC.Subject)
+		w.simple(prefix, "format", md.DC.Format)
+	})
+
+	w.description(NSXMP, func(prefix string) {
+		w.simple(prefix, "CreateDate", md.XMP.CreateDate)
+		w.simple(prefix, "ModifyDate", md.XMP.ModifyDate)
+		w.simple(prefix, "MetadataDate", md.XMP.MetadataDate)
+		w.simple(prefix, "CreatorTool", md.XMP.CreatorTool)
+	})
+
+	w.description(NSPDF, func(prefix string) {
+		w.simple(prefix, "Producer", md.PDF.Producer)
+		w.simple(prefix, "Keywords", md.PDF.Keywords)
+		w.simple(prefix, "PDFVersion", md.PDF.PDFVersion)
+		w.simple(prefix, "Trapped", md.PDF.Trapped)
+	})
+
+	w.description(NSPDFAID, func(prefix string) {
+		if md.PDFAID.Part > 0 {
+			w.simple(prefix, "part", strconv.Itoa(md.PDFAID.Part))
+		}
+		w.simple(prefix, "conformance", md.PDFAID.Conformance)
+	})
+
+	w.description(NSXMPMM, func(prefix string) {
+		w.simple(prefix, "DocumentID", md.XMPMM.DocumentID)
+		w.simple(prefix, "InstanceID", md.XMPMM.InstanceID)
+	})
+
+	md.writeOther(w)
+
+	w.WriteString(" </rdf:RDF>\n</x:xmpmeta>\n")
+
+	// Leave room for in place updates by other applications.
+	for i := 0; i < 20; i++ {
+		w.WriteString(strings.Repeat(" ", 99) + "\n")
+	}
+
+	w.WriteString(`<?xpacket end="w"?>`)
+
+	return w.Bytes()
+}
+
+// writeOther writes the preserved properties of a parsed packet.
+func (md Metadata) writeOther(w *writer) {
+
+	if len(md.other) == 0 {
+		return
+	}
+
+	var keys []string
+	for k := range md.namespaces {
+		if k != "rdf" && k != "x" && k != "" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	w.WriteString("  <rdf:Description rdf:about=\"\"")
+	for _, k := range keys {
+		fmt.Fprintf(w, " xmlns:%s=\"", k)
+		w.text(md.namespaces[k])
+		w.WriteString("\"")
+	}
+	w.WriteString(">\n")
+
+	for _, s := range md.other {
+		fmt.Fprintf(w, "   %s\n", s)
+	}
+
+	w.WriteString("  </rdf:Description>\n")
+}
@@ -0,0 +1,119 @@
+/*
+Copyright 2018 The pdfcpu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package xmp
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const packet = `<?xpacket begin="" id="W5M0MpCehiHzreSzNTczkc9d"?>
+<x:xmpmeta xmlns:x="adobe:ns:meta/">
+ <rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#">
+  <rdf:Description rdf:about="" xmlns:xmp="http://ns.adobe.com/xap/1.0/" xmlns:pdf="http://ns.adobe.com/pdf/1.3/"
+    xmp:CreateDate="2018-12-01T10:00:00+01:00" pdf:Producer="pdfcpu" xmp:Label="draft">
+   <xmp:CreatorTool>Writer &amp; Co</xmp:CreatorTool>
+  </rdf:Description>
+  <rdf:Description rdf:about="" xmlns:dc="http://purl.org/dc/elements/1.1/">
+   <dc:title>
+    <rdf:Alt>
+     <rdf:li xml:lang="de">Titel</rdf:li>
+     <rdf:li xml:lang="x-default">Title</rdf:li>
+    </rdf:Alt>
+   </dc:title>
+   <dc:creator><rdf:Seq><rdf:li>Jane</rdf:li><rdf:li>John</rdf:li></rdf:Seq></dc:creator>
+   <dc:subject><rdf:Bag><rdf:li>a</rdf:li><rdf:li>b</rdf:li></rdf:Bag></dc:subject>
+  </rdf:Description>
+  <rdf:Description rdf:about="" xmlns:pdfaid="http://www.aiim.org/pdfa/ns/id/" xmlns:my="http://example.com/my/">
+   <pdfaid:part>2</pdfaid:part>
+   <pdfaid:conformance>B</pdfaid:conformance>
+   <my:DocNr>4711</my:DocNr>
+  </rdf:Description>
+ </rdf:RDF>
+</x:xmpmeta>
+<?xpacket end="w"?>`
+
+func TestParse(t *testing.T) {
+
+	md, err := Parse([]byte(packet))
+	if err != nil {
+		t.Fatalf("Parse: %v\n", err)
+	}
+
+	if md.DC.Title != "Title" {
+		t.Errorf("title: want Title, got %q\n", md.DC.Title)
+	}
+
+	if !reflect.DeepEqual(md.DC.TitleLangs, map[string]string{"de": "Titel"}) {
+		t.Errorf("title langs: got %v\n", md.DC.TitleLangs)
+	}
+
+	if !reflect.DeepEqual(md.DC.Creator, []string{"Jane", "John"}) {
+		t.Errorf("creator: got %v\n", md.DC.Creator)
+	}
+
+	if !reflect.DeepEqual(md.DC.Subject, []string{"a", "b"}) {
+		t.Errorf("subject: got %v\n", md.DC.Subject)
+	}
+
+	if md.XMP.CreateDate != "2018-12-01T10:00:00+01:00" || md.XMP.CreatorTool != "Writer & Co" {
+		t.Errorf("xmp: got %+v\n", md.XMP)
+	}
+
+	if md.PDF.Producer != "pdfcpu" {
+		t.Errorf("producer: got %q\n", md.PDF.Producer)
+	}
+
+	if md.PDFAID.Part != 2 || md.PDFAID.Conformance != "B" {
+		t.Errorf("pdfaid: got %+v\n", md.PDFAID)
+	}
+
+	if _, err = Parse([]byte("<x:xmpmeta xmlns:x=\"adobe:ns:meta/\"/>")); err == nil {
+		t.Errorf("Parse: want error for missing rdf:Description\n")
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+
+	md, err := Parse([]byte(packet))
+	if err != nil {
+		t.Fatalf("Parse: %v\n", err)
+	}
+
+	b := md.Bytes()
+
+	// Unknown properties and title languages survive.
+	for _, s := range []string{`<rdf:li xml:lang="de">Titel</rdf:li>`, "<my:DocNr>4711</my:DocNr>", "<xmp:Label>draft</xmp:Label>", `xmlns:my="http://example.com/my/"`} {
+		if !strings.Contains(string(b), s) {
+			t.Errorf("Bytes: missing %s in\n%s\n", s, b)
+		}
+	}
+
+	md1, err := Parse(b)
+	if err != nil {
+		t.Fatalf("Parse: %v\n%s\n", err, b)
+	}
+
+	if !reflect.DeepEqual(md.DC, md1.DC) || md.XMP != md1.XMP || md.PDF != md1.PDF || md.PDFAID != md1.PDFAID || md.XMPMM != md1.XMPMM {
+		t.Errorf("round trip: want %+v, got %+v\n", md, md1)
+	}
+
+	if !reflect.DeepEqual(md.other, md1.other) {
+		t.Errorf("round trip: want %v, got %v\n", md.other, md1.other)
+	}
+}